@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
@@ -46,6 +47,18 @@ type BenchmarkStatus = struct {
 
 	// Will be true when benchmark has finished and data is ready.
 	DataReady bool `json:"data_ready"`
+
+	// Clients contains the liveness of warp clients in distributed benchmarks.
+	Clients []ClientStatus `json:"clients,omitempty"`
+}
+
+// ClientStatus contains the liveness of a warp client.
+type ClientStatus struct {
+	Host string `json:"host"`
+	// State is "connected", "lost" or "disconnected".
+	State string `json:"state"`
+	// LastSeen is the last time the client responded.
+	LastSeen time.Time `json:"last_seen,omitempty"`
 }
 
 // Operations contains raw benchmark operations.
@@ -70,6 +83,10 @@ type Server struct {
 
 	ops     bench.Operations
 	aggrDur time.Duration
+	live    *LiveStats
+	slo     *SLOStatus
+
+	annotations bench.Annotations
 
 	// lock for Server
 	mu sync.Mutex
@@ -85,6 +102,28 @@ func (s *Server) OperationsReady(ops bench.Operations, filename, cmdLine string)
 	s.mu.Unlock()
 }
 
+// SetLive can be used to update the live stats of a running benchmark.
+func (s *Server) SetLive(l LiveStats) {
+	l = l.Clone()
+	s.mu.Lock()
+	s.live = &l
+	s.mu.Unlock()
+}
+
+// SetSLO can be used to update the latest SLO evaluation.
+func (s *Server) SetSLO(slo SLOStatus) {
+	s.mu.Lock()
+	s.slo = &slo
+	s.mu.Unlock()
+}
+
+// SetClients updates the liveness of warp clients.
+func (s *Server) SetClients(c []ClientStatus) {
+	s.mu.Lock()
+	s.status.Clients = c
+	s.mu.Unlock()
+}
+
 // SetLnLoggers can be used to set upstream loggers.
 // When logging to the servers these will be called.
 func (s *Server) SetLnLoggers(info, err func(data ...interface{})) {
@@ -133,6 +172,59 @@ func (s *Server) Errorln(data ...interface{}) {
 	s.status.Error = strings.TrimSpace(fmt.Sprintln(data...))
 }
 
+// Annotate adds an annotation at the current time.
+func (s *Server) Annotate(text string) {
+	s.mu.Lock()
+	s.annotations = append(s.annotations, bench.Annotation{Time: time.Now(), Text: text})
+	s.mu.Unlock()
+}
+
+// Annotations returns the annotations added since the server was started.
+func (s *Server) Annotations() bench.Annotations {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(bench.Annotations(nil), s.annotations...)
+}
+
+// handleAnnotate handles POST `/v1/annotate` requests with the annotation text as body.
+// GET requests return the current annotations.
+func (s *Server) handleAnnotate(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		b, err := json.MarshalIndent(s.Annotations(), "", "  ")
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.Write(b)
+	case http.MethodPost:
+		b, err := io.ReadAll(io.LimitReader(req.Body, 4<<10))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		text := strings.TrimSpace(string(b))
+		if text == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("empty annotation"))
+			return
+		}
+		s.Annotate(text)
+		s.mu.Lock()
+		infoln := s.infoln
+		s.mu.Unlock()
+		if infoln != nil {
+			infoln("Annotation:", text)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
 // handleStatus handles GET `/v1/status` requests.
 func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
@@ -151,6 +243,53 @@ func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
 	w.Write(b)
 }
 
+// handleLive handles GET `/v1/live` requests.
+// If no benchmark data has been received "No Content" status will be returned.
+func (s *Server) handleLive(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	live := s.live
+	s.mu.Unlock()
+	if live == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	b, err := json.MarshalIndent(live, "", "  ")
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write(b)
+}
+
+// handleSLO handles GET `/v1/slo` requests.
+func (s *Server) handleSLO(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	slo := s.slo
+	s.mu.Unlock()
+	if slo == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	b, err := json.MarshalIndent(slo, "", "  ")
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write(b)
+}
+
 // handleAggregated handles GET `/v1/aggregated` requests with optional "segment" parameter.
 func (s *Server) handleAggregated(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
@@ -270,6 +409,9 @@ func NewBenchmarkMonitor(listenAddr string) *Server {
 	mux.HandleFunc("/v1/stop", s.handleStop)
 	mux.HandleFunc("/v1/status", s.handleStatus)
 	mux.HandleFunc("/v1/aggregated", s.handleAggregated)
+	mux.HandleFunc("/v1/live", s.handleLive)
+	mux.HandleFunc("/v1/slo", s.handleSLO)
+	mux.HandleFunc("/v1/annotate", s.handleAnnotate)
 	mux.HandleFunc("/v1/operations/json", s.handleDownloadJSON)
 	mux.HandleFunc("/v1/operations", s.handleDownloadZst)
 
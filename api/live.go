@@ -0,0 +1,141 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package api
+
+import (
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// LiveStats contains running totals of a benchmark while it is executing.
+type LiveStats struct {
+	// Updated is the time the stats were last updated.
+	Updated time.Time `json:"updated"`
+
+	// Clients is the number of warp clients that contributed.
+	Clients int `json:"clients"`
+
+	// Operations contains stats per operation type.
+	Operations map[string]LiveOperation `json:"operations"`
+}
+
+// LiveOperation contains running totals for a single operation type.
+type LiveOperation struct {
+	Requests    int64     `json:"requests"`
+	Errors      int64     `json:"errors"`
+	Bytes       int64     `json:"bytes"`
+	Objects     int64     `json:"objects"`
+	ReqDurNanos int64     `json:"request_duration_ns"`
+	FirstStart  time.Time `json:"first_start"`
+	LastEnd     time.Time `json:"last_end"`
+
+	// ValidationErrors is the number of errors that failed validation.
+	// These are included in Errors.
+	ValidationErrors int64 `json:"validation_errors,omitempty"`
+}
+
+// Add an operation to the stats.
+func (l *LiveStats) Add(op bench.Operation) {
+	if l.Operations == nil {
+		l.Operations = make(map[string]LiveOperation, 4)
+	}
+	if l.Clients == 0 {
+		l.Clients = 1
+	}
+	o := l.Operations[op.OpType]
+	o.Requests++
+	if op.Err != "" {
+		o.Errors++
+		if op.ValidationErr() {
+			o.ValidationErrors++
+		}
+	} else {
+		o.Bytes += op.Size
+		o.Objects += int64(op.ObjPerOp)
+		o.ReqDurNanos += int64(op.End.Sub(op.Start))
+	}
+	if o.FirstStart.IsZero() || op.Start.Before(o.FirstStart) {
+		o.FirstStart = op.Start
+	}
+	if op.End.After(o.LastEnd) {
+		o.LastEnd = op.End
+	}
+	l.Operations[op.OpType] = o
+	l.Updated = time.Now()
+}
+
+// Merge other stats into l.
+func (l *LiveStats) Merge(other LiveStats) {
+	if l.Operations == nil {
+		l.Operations = make(map[string]LiveOperation, len(other.Operations))
+	}
+	l.Clients += other.Clients
+	for typ, src := range other.Operations {
+		dst, ok := l.Operations[typ]
+		if !ok {
+			l.Operations[typ] = src
+			continue
+		}
+		dst.Requests += src.Requests
+		dst.Errors += src.Errors
+		dst.ValidationErrors += src.ValidationErrors
+		dst.Bytes += src.Bytes
+		dst.Objects += src.Objects
+		dst.ReqDurNanos += src.ReqDurNanos
+		if src.FirstStart.Before(dst.FirstStart) {
+			dst.FirstStart = src.FirstStart
+		}
+		if src.LastEnd.After(dst.LastEnd) {
+			dst.LastEnd = src.LastEnd
+		}
+		l.Operations[typ] = dst
+	}
+	if other.Updated.After(l.Updated) {
+		l.Updated = other.Updated
+	}
+}
+
+// Total returns the stats of all operation types combined.
+func (l LiveStats) Total() LiveOperation {
+	var t LiveOperation
+	for _, o := range l.Operations {
+		t.Requests += o.Requests
+		t.Errors += o.Errors
+		t.ValidationErrors += o.ValidationErrors
+		t.Bytes += o.Bytes
+		t.Objects += o.Objects
+		t.ReqDurNanos += o.ReqDurNanos
+		if t.FirstStart.IsZero() || o.FirstStart.Before(t.FirstStart) {
+			t.FirstStart = o.FirstStart
+		}
+		if o.LastEnd.After(t.LastEnd) {
+			t.LastEnd = o.LastEnd
+		}
+	}
+	return t
+}
+
+// Clone returns a deep copy of the stats.
+func (l LiveStats) Clone() LiveStats {
+	dst := l
+	dst.Operations = make(map[string]LiveOperation, len(l.Operations))
+	for k, v := range l.Operations {
+		dst.Operations[k] = v
+	}
+	return dst
+}
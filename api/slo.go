@@ -0,0 +1,48 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package api
+
+import (
+	"time"
+)
+
+// SLOStatus contains the result of evaluating service level objectives
+// over a rolling window of operations.
+type SLOStatus struct {
+	// Time of the evaluation.
+	Time time.Time `json:"time"`
+
+	// WindowNanos is the length of the evaluated window.
+	WindowNanos int64 `json:"window_ns"`
+
+	// Requests and errors within the window.
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+
+	// Availability is the percentage of successful requests.
+	Availability       float64 `json:"availability"`
+	AvailabilityTarget float64 `json:"availability_target"`
+
+	// P99Nanos is the 99th percentile request time of successful requests.
+	P99Nanos       int64 `json:"p99_ns"`
+	P99TargetNanos int64 `json:"p99_target_ns"`
+
+	// Breached is true if any objective was not met.
+	// Breaches contains a description of each.
+	Breached bool     `json:"breached"`
+	Breaches []string `json:"breaches,omitempty"`
+}
@@ -0,0 +1,39 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var accessFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "access-dist",
+		Value: "uniform",
+		Usage: "Distribution of objects read. Can be 'uniform', 'sequential' or 'zipf:exponent', for example 'zipf:1.1'",
+	},
+}
+
+// accessDist returns the access distribution specified in the context.
+func accessDist(ctx *cli.Context) bench.AccessDist {
+	a, err := bench.ParseAccessDist(ctx.String("access-dist"))
+	fatalIf(probe.NewError(err), "Invalid --access-dist")
+	return a
+}
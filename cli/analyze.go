@@ -25,11 +25,13 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
@@ -49,6 +51,30 @@ var analyzeFlags = []cli.Flag{
 		Value: "",
 		Usage: "Output aggregated data as to file",
 	},
+	cli.StringFlag{
+		Name:  "analyze.out.format",
+		Value: "csv",
+		Usage: "Format of --analyze.out data. Can be 'csv' or 'json'",
+	},
+	cli.StringFlag{
+		Name:  "analyze.html",
+		Value: "",
+		Usage: "Write aggregated results as a standalone HTML report with charts to this file",
+	},
+	cli.BoolFlag{
+		Name:  "analyze.heatmap",
+		Usage: "Print a latency heatmap (time x latency) for each operation type",
+	},
+	cli.StringFlag{
+		Name:  "analyze.heatmap.out",
+		Value: "",
+		Usage: "Write latency heatmap matrix to this file. Uses JSON if the file ends with '.json', otherwise CSV",
+	},
+	cli.IntFlag{
+		Name:  "analyze.heatmap.buckets",
+		Value: 16,
+		Usage: "Number of latency buckets in heatmap",
+	},
 	cli.StringFlag{
 		Name:  "analyze.op",
 		Value: "",
@@ -81,6 +107,10 @@ var analyzeFlags = []cli.Flag{
 		Name:  "analyze.v",
 		Usage: "Display additional analysis data.",
 	},
+	cli.BoolFlag{
+		Name:  "report.raw",
+		Usage: "Print analysis as unformatted numbers in columns and key=value lines.",
+	},
 	cli.StringFlag{
 		Name:  serverFlagName,
 		Usage: "When running benchmarks open a webserver to fetch results remotely, eg: localhost:7762",
@@ -114,34 +144,32 @@ func mainAnalyze(ctx *cli.Context) error {
 	if len(args) == 0 {
 		console.Fatal("No benchmark data file supplied")
 	}
-	if len(args) > 1 {
-		console.Fatal("Only one benchmark file can be given")
-	}
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
 	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
 	defer monitor.Done()
 	log := console.Printf
 	if globalQuiet {
 		log = nil
 	}
-	for _, arg := range args {
-		var input io.Reader
-		if arg == "-" {
-			input = os.Stdin
-		} else {
-			f, err := os.Open(arg)
-			fatalIf(probe.NewError(err), "Unable to open input file")
-			defer f.Close()
-			input = f
+	if len(args) > 1 {
+		// Merge all files into one analysis.
+		ops, meta := mergeBenchData(ctx, args, true, log)
+		if len(ops) == 0 {
+			console.Fatal("Benchmark files contain no data")
 		}
-		err := zstdDec.Reset(input)
-		fatalIf(probe.NewError(err), "Unable to read input")
-		ops, err := bench.OperationsFromCSV(zstdDec, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		ops.SortByStartTime()
+		printAnalysis(ctx, ops, &meta)
+		ops, _ = ops.SplitAnnotations()
+		monitor.OperationsReady(ops, "merged", commandLine(ctx))
+		return nil
+	}
+	for _, arg := range args {
+		ops, meta, err := readBenchData(ctx, arg, true, log)
 		fatalIf(probe.NewError(err), "Unable to parse input")
 
-		printAnalysis(ctx, ops)
-		monitor.OperationsReady(ops, strings.TrimSuffix(filepath.Base(arg), ".csv.zst"), commandLine(ctx))
+		printAnalysis(ctx, ops, &meta)
+		ops, _ = ops.SplitAnnotations()
+		name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(arg), ".csv.zst"), ".parquet")
+		monitor.OperationsReady(ops, name, commandLine(ctx))
 	}
 	return nil
 }
@@ -184,7 +212,8 @@ func printMixedOpAnalysis(ctx *cli.Context, aggr aggregate.Aggregated, details b
 
 		if ops.Errors > 0 {
 			console.SetColor("Print", color.New(color.FgHiRed))
-			console.Println("Errors:", ops.Errors)
+			console.Println("Errors:", errorsString(ops))
+			printErrorCategories(ops, details)
 			if details {
 				for _, err := range ops.FirstErrors {
 					console.Println(err)
@@ -192,6 +221,7 @@ func printMixedOpAnalysis(ctx *cli.Context, aggr aggregate.Aggregated, details b
 			}
 			console.SetColor("Print", color.New(color.FgWhite))
 		}
+		printRetries(ops, details)
 		eps := ops.ThroughputByHost
 		if len(eps) == 1 || !details {
 			console.Println(" * Throughput:", ops.Throughput.StringDetails(details))
@@ -234,7 +264,10 @@ func printMixedOpAnalysis(ctx *cli.Context, aggr aggregate.Aggregated, details b
 	}
 }
 
-func printAnalysis(ctx *cli.Context, o bench.Operations) {
+// printAnalysis prints the analysis of the operations.
+// meta is included if the operations were loaded from a file.
+func printAnalysis(ctx *cli.Context, o bench.Operations, meta *bench.Metadata) {
+	o, notes := o.SplitAnnotations()
 	details := ctx.Bool("analyze.v")
 	var wrSegs io.Writer
 	prefiltered := false
@@ -255,7 +288,7 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 			hosts := o.Endpoints()
 			console.Println("Host not found, valid hosts are:")
 			for _, h := range hosts {
-				console.Println("\t* %s", h)
+				console.Printf("\t* %s\n", h)
 			}
 			return
 		}
@@ -267,6 +300,13 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		prefiltered = prefiltered || o.IsMixed()
 		o = o.FilterByOp(wantOp)
 	}
+	for _, op := range o {
+		// Not all threads are running when concurrency changes.
+		if op.Concurrency > 0 || op.Phase != "" {
+			prefiltered = true
+			break
+		}
+	}
 	durFn := func(total time.Duration) time.Duration {
 		if total <= 0 {
 			return 0
@@ -284,6 +324,92 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		}
 	}
 
+	if fn := ctx.String("analyze.heatmap.out"); fn != "" {
+		if err := writeHeatmaps(ctx, fn, o); err != nil {
+			printError("Unable to write heatmap:", err)
+		} else if fn != "-" {
+			defer console.Println("Latency heatmap written to", fn)
+		}
+	}
+	if !globalJSON && !ctx.Bool("report.raw") {
+		if ctx.Bool("analyze.heatmap") {
+			defer printHeatmaps(ctx, o)
+		}
+		defer printAnnotations(ctx, o, notes)
+		defer printCryptoAnalysis(o)
+		if details {
+			defer printGenAnalysis(o)
+		}
+		defer printSelectAnalysis(o)
+		defer printListAnalysis(o)
+		defer printSlowRequests(o)
+		defer printRemoteAnalysis(o)
+		defer printConcurrencyAnalysis(o)
+		defer printPhaseAnalysis(o)
+	}
+	if meta != nil && !meta.IsEmpty() {
+		aggr.Metadata = meta
+		if !globalJSON {
+			printMetadata(*meta)
+		}
+	}
+	if fn := ctx.String("analyze.html"); fn != "" {
+		if err := writeHTMLReport(fn, aggr); err != nil {
+			printError("Unable to write HTML report:", err)
+		} else {
+			defer console.Println("HTML report written to", fn)
+		}
+	}
+	printAggregated(ctx, aggr, details)
+}
+
+// printMetadata prints how the benchmark data was created.
+func printMetadata(meta bench.Metadata) {
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("Benchmark data:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	if meta.Command != "" {
+		console.Println(" * Command:", meta.Command)
+	}
+	if meta.Version != "" {
+		console.Println(" * Warp version:", meta.Version)
+	}
+	keys := make([]string, 0, len(meta.Values))
+	for k := range meta.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		console.Printf(" * %s: %s\n", k, meta.Values[k])
+	}
+}
+
+// printHDRAnalysis writes and prints the analysis of histogram collected operations.
+func printHDRAnalysis(ctx *cli.Context, hdr *bench.HDRStats, fn string) {
+	aggr := aggregate.HDR(hdr, aggregate.Options{
+		DurFunc: func(total time.Duration) time.Duration {
+			if total <= 0 {
+				return 0
+			}
+			return analysisDur(ctx, total)
+		},
+	})
+	b, err := json.MarshalIndent(aggr, "", "  ")
+	fatalIf(probe.NewError(err), "Unable to marshal data.")
+	err = os.WriteFile(fn, b, 0o666)
+	if err != nil {
+		printError("Unable to write aggregated data:", err)
+	} else if !globalJSON {
+		console.Printf("Aggregated data written to %q\n", fn)
+	}
+	printAggregated(ctx, aggr, ctx.Bool("analyze.v"))
+	if n := hdr.Saturated(); n > 0 {
+		printError(fmt.Sprintf("%d samples exceeded the histogram range. Percentiles including them are capped.", n))
+	}
+}
+
+// printAggregated prints aggregated benchmark data.
+func printAggregated(ctx *cli.Context, aggr aggregate.Aggregated, details bool) {
 	if globalJSON {
 		b, err := json.MarshalIndent(aggr, "", "  ")
 		fatalIf(probe.NewError(err), "Unable to marshal data.")
@@ -294,6 +420,11 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		return
 	}
 
+	if ctx.Bool("report.raw") {
+		printRawAnalysis(aggr)
+		return
+	}
+
 	if aggr.Mixed {
 		printMixedOpAnalysis(ctx, aggr, details)
 		return
@@ -332,7 +463,8 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		}
 		if ops.Errors > 0 {
 			console.SetColor("Print", color.New(color.FgHiRed))
-			console.Println("Errors:", ops.Errors)
+			console.Println("Errors:", errorsString(ops))
+			printErrorCategories(ops, details)
 			if details {
 				console.SetColor("Print", color.New(color.FgWhite))
 				console.Println("First Errors:")
@@ -342,6 +474,8 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 				console.Println("")
 			}
 		}
+		console.SetColor("Print", color.New(color.FgWhite))
+		printRetries(ops, details)
 
 		if ops.Skipped {
 			console.SetColor("Print", color.New(color.FgHiWhite))
@@ -356,6 +490,9 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		}
 		console.SetColor("Print", color.New(color.FgWhite))
 		console.Println("* Average:", ops.Throughput.StringDetails(details))
+		if ops.Confidence != nil {
+			console.Println("* 95% CI:", ops.Confidence.StringThroughput())
+		}
 
 		if eps := ops.ThroughputByHost; len(eps) > 1 {
 			console.SetColor("Print", color.New(color.FgHiWhite))
@@ -400,6 +537,577 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 	}
 }
 
+// printConcurrencyAnalysis prints throughput and latency for each concurrency level,
+// if the concurrency changed during the benchmark.
+func printConcurrencyAnalysis(o bench.Operations) {
+	printGroupedAnalysis(o, "Concurrency",
+		func(op bench.Operation) string {
+			if op.Concurrency == 0 {
+				return ""
+			}
+			return strconv.FormatUint(uint64(op.Concurrency), 10)
+		},
+		func(levels []string) {
+			sort.Slice(levels, func(i, j int) bool {
+				a, _ := strconv.Atoi(levels[i])
+				b, _ := strconv.Atoi(levels[j])
+				return a < b
+			})
+		},
+		func(_ string, ops bench.Operations) time.Duration {
+			return ops.Duration()
+		})
+}
+
+// printPhaseAnalysis prints throughput and latency for each scheduled phase,
+// if phases were used during the benchmark.
+func printPhaseAnalysis(o bench.Operations) {
+	active := phaseDurations(o)
+	printGroupedAnalysis(o, "Phase",
+		func(op bench.Operation) string {
+			return op.Phase
+		},
+		nil,
+		func(name string, _ bench.Operations) time.Duration {
+			return active[name]
+		})
+}
+
+// printGroupedAnalysis prints throughput and latency for each operation type
+// in each group returned by group. Operations in the empty group are ignored.
+// Groups are printed in the order they are first seen, unless sortGroups is set.
+// duration returns the time the operations of one type in a group were active.
+func printGroupedAnalysis(o bench.Operations, label string, group func(op bench.Operation) string, sortGroups func(groups []string), duration func(group string, ops bench.Operations) time.Duration) {
+	byGroup := make(map[string]bench.Operations)
+	var groups []string
+	for _, op := range o {
+		g := group(op)
+		if g == "" {
+			continue
+		}
+		if _, ok := byGroup[g]; !ok {
+			groups = append(groups, g)
+		}
+		byGroup[g] = append(byGroup[g], op)
+	}
+	if len(groups) == 0 {
+		return
+	}
+	if sortGroups != nil {
+		sortGroups(groups)
+	}
+
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Throughput by " + strings.ToLower(label) + ":")
+	console.SetColor("Print", color.New(color.FgWhite))
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, label+"\tOp\tRequests\tErrors\tThroughput\tObj/s\tAvg\t50%\t90%\t")
+	for _, g := range groups {
+		ops := byGroup[g]
+		for _, typ := range ops.OpTypes() {
+			ops := ops.FilterByOp(typ)
+			ok := ops.FilterSuccessful()
+			var bytes int64
+			var objs int
+			for _, op := range ok {
+				bytes += op.Size
+				objs += op.ObjPerOp
+			}
+			tp, objsPS := "-", "-"
+			if dur := duration(g, ops); dur > 0 {
+				if bytes > 0 {
+					tp = bench.Throughput(float64(bytes) / dur.Seconds()).String()
+				}
+				objsPS = fmt.Sprintf("%.2f", float64(objs)/dur.Seconds())
+			}
+			avg, p50, p90 := "-", "-", "-"
+			if len(ok) > 0 {
+				avg = ok.AvgDuration().Round(time.Microsecond).String()
+				ok.SortByDuration()
+				p50 = ok.Median(0.5).Duration().Round(time.Microsecond).String()
+				p90 = ok.Median(0.9).Duration().Round(time.Microsecond).String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n", g, typ, len(ops), len(ops)-len(ok), tp, objsPS, avg, p50, p90)
+		}
+	}
+	tw.Flush()
+	console.Print(sb.String())
+}
+
+// phaseDurations returns the total time spent in each phase.
+// Since phases repeat, the time of each consecutive run of operations in the same phase is added.
+func phaseDurations(o bench.Operations) map[string]time.Duration {
+	sorted := make(bench.Operations, 0, len(o))
+	for _, op := range o {
+		if op.Phase != "" {
+			sorted = append(sorted, op)
+		}
+	}
+	sorted.SortByStartTime()
+	res := make(map[string]time.Duration)
+	var runStart, runEnd time.Time
+	for i, op := range sorted {
+		if i == 0 || op.Phase != sorted[i-1].Phase {
+			runStart, runEnd = op.Start, op.End
+		}
+		if op.End.After(runEnd) {
+			runEnd = op.End
+		}
+		if i == len(sorted)-1 || sorted[i+1].Phase != op.Phase {
+			res[op.Phase] += runEnd.Sub(runStart)
+		}
+	}
+	return res
+}
+
+// printAnnotations prints each annotation with the throughput of the analysis segment
+// before, containing and after the annotation for each operation type.
+func printAnnotations(ctx *cli.Context, o bench.Operations, notes bench.Annotations) {
+	if len(notes) == 0 || len(o) == 0 {
+		return
+	}
+	start, end := o.TimeRange()
+	segDur := analysisDur(ctx, end.Sub(start))
+	if segDur <= 0 {
+		return
+	}
+	types := o.OpTypes()
+	segsByType := make(map[string]bench.Segments, len(types))
+	for _, typ := range types {
+		segs := o.FilterByOp(typ).Segment(bench.SegmentOptions{
+			From:           start,
+			PerSegDuration: segDur,
+			AllThreads:     false,
+		})
+		segs.SortByTime()
+		segsByType[typ] = segs
+	}
+	speed := func(segs bench.Segments, i int) string {
+		if i < 0 || i >= len(segs) {
+			return "-"
+		}
+		mib, _, objs := segs[i].SpeedPerSec()
+		if mib > 0 {
+			return fmt.Sprintf("%.2f MiB/s, %.2f obj/s", mib, objs)
+		}
+		return fmt.Sprintf("%.2f obj/s", objs)
+	}
+
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Printf("Annotations (segments of %v):\n", segDur)
+	for _, n := range notes {
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		offset := n.Time.Sub(start).Round(time.Millisecond)
+		console.Printf(" * %v (%s): %s\n", offset, n.Time.Format("15:04:05 MST"), n.Text)
+		console.SetColor("Print", color.New(color.FgWhite))
+		if n.Time.Before(start) || n.Time.After(end) {
+			console.Println("   Outside benchmark.")
+			continue
+		}
+		for _, typ := range types {
+			segs := segsByType[typ]
+			idx := -1
+			for i, seg := range segs {
+				if !n.Time.Before(seg.Start) && n.Time.Before(seg.EndsBefore) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				continue
+			}
+			console.Printf("   %s: before: %s -> during: %s -> after: %s\n", typ, speed(segs, idx-1), speed(segs, idx), speed(segs, idx+1))
+		}
+	}
+}
+
+// printCryptoAnalysis prints the time spent on client-side encryption,
+// if any operations were encrypted or decrypted on the client.
+func printCryptoAnalysis(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		var n int
+		var bytes int64
+		var crypto, total time.Duration
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.CryptoTime <= 0 {
+				continue
+			}
+			n++
+			bytes += op.Size
+			crypto += op.CryptoTime
+			total += op.End.Sub(op.Start)
+		}
+		if n == 0 {
+			continue
+		}
+		if tw == nil {
+			tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(tw, "Op\tRequests\tAvg crypto\tAvg total\tCrypto %\tCrypto throughput\t")
+		}
+		tp := "-"
+		if crypto > 0 {
+			tp = bench.Throughput(float64(bytes) / crypto.Seconds()).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%.1f%%\t%s\t\n", typ, n,
+			(crypto / time.Duration(n)).Round(time.Microsecond), (total / time.Duration(n)).Round(time.Microsecond),
+			100*float64(crypto)/float64(total), tp)
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Client-side encryption:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// printGenAnalysis prints the time spent generating uploaded data,
+// so it can be seen if the client is limiting upload speed.
+func printGenAnalysis(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		var n int
+		var bytes int64
+		var gen, total time.Duration
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.GenTime <= 0 {
+				continue
+			}
+			n++
+			bytes += op.Size
+			gen += op.GenTime
+			total += op.End.Sub(op.Start)
+		}
+		if n == 0 {
+			continue
+		}
+		if tw == nil {
+			tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(tw, "Op\tRequests\tAvg generation\tAvg network\tGeneration %\tGenerator throughput\t")
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%.1f%%\t%s\t\n", typ, n,
+			(gen / time.Duration(n)).Round(time.Microsecond), ((total - gen) / time.Duration(n)).Round(time.Microsecond),
+			100*float64(gen)/float64(total), bench.Throughput(float64(bytes)/gen.Seconds()))
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Client generation overhead:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// printErrorCategories prints errors by category.
+// If details is set and more than one host has errors, errors are also printed for each host.
+func printErrorCategories(ops aggregate.Operation, details bool) {
+	for _, c := range ops.ErrorCategories {
+		name := c.Category
+		if c.Status != 0 {
+			name = fmt.Sprintf("%s (%d)", name, c.Status)
+		}
+		console.Printf(" * %s: %d, %.02f/s\n", name, c.Count, c.PerSec)
+		if !details || len(c.ByHost) <= 1 {
+			continue
+		}
+		for _, host := range stringKeysSorted(c.ByHost) {
+			console.Printf("   - %s: %d\n", host, c.ByHost[host])
+		}
+	}
+}
+
+// printRetries prints retry statistics, if any requests were retried.
+// If details is set, final status codes are printed as well.
+func printRetries(ops aggregate.Operation, details bool) {
+	r := ops.Retries
+	if r == nil || (r.Retries == 0 && !details) {
+		return
+	}
+	console.Printf(" * Retries: %d, %.03fx request amplification. %d succeeded first try, %d succeeded after retry, %d failed after retry.\n",
+		r.Retries, r.Amplification, r.FirstTry, r.RetriedOK, r.RetriedFailed)
+	if !details {
+		return
+	}
+	statuses := make([]int, 0, len(r.Statuses))
+	for status := range r.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	var sb strings.Builder
+	for i, status := range statuses {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		name := strconv.Itoa(status)
+		if status == 0 {
+			name = "no response"
+		}
+		fmt.Fprintf(&sb, "%s: %d", name, r.Statuses[status])
+	}
+	console.Println(" * Status codes:", sb.String())
+}
+
+// errorsString returns the number of errors,
+// split into infrastructure errors and validation failures if there are any of the latter.
+func errorsString(ops aggregate.Operation) string {
+	if ops.ValidationErrors == 0 {
+		return strconv.Itoa(ops.Errors)
+	}
+	return fmt.Sprintf("%d (%d infrastructure, %d validation)", ops.Errors, ops.Errors-ops.ValidationErrors, ops.ValidationErrors)
+}
+
+// printSelectAnalysis prints the bytes scanned and returned by S3 Select,
+// if any operations reported select statistics.
+func printSelectAnalysis(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		var n int
+		var scanned, returned int64
+		var total time.Duration
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.BytesScanned <= 0 {
+				continue
+			}
+			n++
+			scanned += op.BytesScanned
+			returned += op.BytesReturned
+			total += op.End.Sub(op.Start)
+		}
+		if n == 0 {
+			continue
+		}
+		if tw == nil {
+			tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(tw, "Op\tRequests\tAvg scanned\tAvg returned\tReturned %\tScan throughput\t")
+		}
+		tp := "-"
+		if total > 0 {
+			tp = bench.Throughput(float64(scanned) / total.Seconds()).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.2f%%\t%s\t\n", typ, n,
+			humanize.IBytes(uint64(scanned/int64(n))), humanize.IBytes(uint64(returned/int64(n))),
+			100*float64(returned)/float64(scanned), tp)
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("S3 Select efficiency:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// printListAnalysis prints the size of listing responses,
+// if any operations recorded them.
+func printListAnalysis(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		var n, objs, pages int
+		var wire int64
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.Pages <= 0 {
+				continue
+			}
+			n++
+			objs += op.ObjPerOp
+			pages += op.Pages
+			wire += op.WireBytes
+		}
+		if n == 0 {
+			continue
+		}
+		if tw == nil {
+			tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(tw, "Op\tRequests\tObjects\tPages\tAvg received\tBytes/object\tBytes/page\t")
+		}
+		perObj := "-"
+		if objs > 0 {
+			perObj = fmt.Sprintf("%.1f", float64(wire)/float64(objs))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%.1f\t\n", typ, n, objs, pages,
+			humanize.IBytes(uint64(wire/int64(n))), perObj, float64(wire)/float64(pages))
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Listing response size:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// slowRequestsShown is the number of slowest requests shown for each operation type.
+const slowRequestsShown = 5
+
+// printSlowRequests prints the slowest successful requests of each operation type
+// with the request ID returned by the server, if recorded.
+func printSlowRequests(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		var ops bench.Operations
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.RequestID != "" {
+				ops = append(ops, op)
+			}
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		if tw == nil {
+			tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "Op\tDuration\tEnded\tEndpoint\tRequest ID\tDeployment ID")
+		}
+		ops.SortByDuration()
+		for i := len(ops) - 1; i >= max(len(ops)-slowRequestsShown, 0); i-- {
+			op := ops[i]
+			fmt.Fprintf(tw, "%s\t%v\t%s\t%s\t%s\t%s\n", typ, op.Duration().Round(time.Microsecond),
+				op.End.Format("15:04:05.000"), op.Endpoint, op.RequestID, op.DeploymentID)
+		}
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Slowest requests:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// printRemoteAnalysis prints request times by server IP,
+// if the IP was recorded for operations.
+func printRemoteAnalysis(o bench.Operations) {
+	var tw *tabwriter.Writer
+	var sb strings.Builder
+	for _, typ := range o.OpTypes() {
+		byIP := make(map[string]bench.Operations)
+		for _, op := range o.FilterByOp(typ).FilterSuccessful() {
+			if op.Remote != "" {
+				byIP[op.Remote] = append(byIP[op.Remote], op)
+			}
+		}
+		for _, ip := range stringKeysSorted(byIP) {
+			ops := byIP[ip]
+			if tw == nil {
+				tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+				fmt.Fprintln(tw, "Op\tServer IP\tRequests\tAvg\t50%\t90%\t99%\t")
+			}
+			avg := ops.AvgDuration()
+			ops.SortByDuration()
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%v\t%v\t%v\t%v\t\n", typ, ip, len(ops), avg.Round(time.Microsecond),
+				ops.Median(0.5).Duration().Round(time.Microsecond), ops.Median(0.9).Duration().Round(time.Microsecond),
+				ops.Median(0.99).Duration().Round(time.Microsecond))
+		}
+	}
+	if tw == nil {
+		return
+	}
+	tw.Flush()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\n----------------------------------------")
+	console.Println("Request times by server IP:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Print(sb.String())
+}
+
+// printRawAnalysis prints the analysis with unformatted numbers.
+// Bytes are in bytes, durations in milliseconds.
+// Each operation type is printed as a table row and as a line of key=value pairs.
+func printRawAnalysis(aggr aggregate.Aggregated) {
+	keys := []string{
+		"op", "requests", "errors", "validation_errors", "concurrency", "hosts", "duration_ms", "obj_size",
+		"avg_bps", "avg_ops", "fastest_bps", "median_bps", "slowest_bps",
+		"fastest_ops", "median_ops", "slowest_ops",
+		"avg_ms", "p50_ms", "p90_ms", "p99_ms", "fastest_ms", "slowest_ms", "skipped",
+	}
+	rows := make([][]string, 0, len(aggr.Operations))
+	for _, ops := range aggr.Operations {
+		v := map[string]string{
+			"op":                ops.Type,
+			"requests":          strconv.Itoa(ops.N),
+			"errors":            strconv.Itoa(ops.Errors),
+			"validation_errors": strconv.Itoa(ops.ValidationErrors),
+			"concurrency":       strconv.Itoa(ops.Concurrency),
+			"hosts":             strconv.Itoa(ops.Hosts),
+			"duration_ms":       strconv.FormatInt(ops.EndTime.Sub(ops.StartTime).Milliseconds(), 10),
+			"skipped":           strconv.FormatBool(ops.Skipped),
+		}
+		f := func(f float64) string {
+			return strconv.FormatFloat(f, 'f', 2, 64)
+		}
+		if !ops.Skipped {
+			t := ops.Throughput
+			v["avg_bps"] = f(t.AverageBPS)
+			v["avg_ops"] = f(t.AverageOPS)
+			if seg := t.Segmented; seg != nil {
+				v["fastest_bps"] = f(seg.FastestBPS)
+				v["median_bps"] = f(seg.MedianBPS)
+				v["slowest_bps"] = f(seg.SlowestBPS)
+				v["fastest_ops"] = f(seg.FastestOPS)
+				v["median_ops"] = f(seg.MedianOPS)
+				v["slowest_ops"] = f(seg.SlowestOPS)
+			}
+		}
+		if r := ops.SingleSizedRequests; r != nil && !r.Skipped {
+			v["obj_size"] = strconv.FormatInt(r.ObjSize, 10)
+			v["avg_ms"] = strconv.Itoa(r.DurAvgMillis)
+			v["p50_ms"] = strconv.Itoa(r.DurMedianMillis)
+			v["p90_ms"] = strconv.Itoa(r.Dur90Millis)
+			v["p99_ms"] = strconv.Itoa(r.Dur99Millis)
+			v["fastest_ms"] = strconv.Itoa(r.FastestMillis)
+			v["slowest_ms"] = strconv.Itoa(r.SlowestMillis)
+		}
+		if r := ops.MultiSizedRequests; r != nil && !r.Skipped {
+			v["obj_size"] = strconv.FormatInt(r.AvgObjSize, 10)
+		}
+		row := make([]string, len(keys))
+		for i, k := range keys {
+			row[i] = v[k]
+			if row[i] == "" {
+				row[i] = "-"
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, strings.Join(keys, "\t")+"\t")
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	tw.Flush()
+	sb.WriteByte('\n')
+	for _, row := range rows {
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(k + "=" + row[i])
+		}
+		sb.WriteByte('\n')
+	}
+	console.Print(sb.String())
+}
+
 func writeSegs(ctx *cli.Context, wrSegs io.Writer, ops bench.Operations, allThreads, details bool) {
 	if wrSegs == nil {
 		return
@@ -417,7 +1125,8 @@ func writeSegs(ctx *cli.Context, wrSegs io.Writer, ops bench.Operations, allThre
 	}
 
 	segs.SortByTime()
-	err := segs.CSV(wrSegs, "")
+	segs.AddLatency(ops)
+	err := writeSegsFormat(ctx, wrSegs, segs, "")
 	errorIf(probe.NewError(err), "Error writing analysis")
 	start := segs[0].Start
 	wantSegs := len(segs)
@@ -452,12 +1161,21 @@ func writeSegs(ctx *cli.Context, wrSegs io.Writer, ops bench.Operations, allThre
 				segs.SortByObjsPerSec()
 			}
 			segs.SortByTime()
-			err := segs.CSV(wrSegs, ep)
+			segs.AddLatency(ops)
+			err := writeSegsFormat(ctx, wrSegs, segs, ep)
 			errorIf(probe.NewError(err), "Error writing analysis")
 		}
 	}
 }
 
+// writeSegsFormat writes segments in the format specified by --analyze.out.format.
+func writeSegsFormat(ctx *cli.Context, w io.Writer, segs bench.Segments, host string) error {
+	if ctx.String("analyze.out.format") == "json" {
+		return segs.JSON(w, host)
+	}
+	return segs.CSV(w, host)
+}
+
 func printRequestAnalysis(_ *cli.Context, ops aggregate.Operation, details bool) {
 	console.SetColor("Print", color.New(color.FgHiWhite))
 
@@ -482,6 +1200,11 @@ func printRequestAnalysis(_ *cli.Context, ops aggregate.Operation, details bool)
 			", Slowest: ", time.Duration(reqs.SlowestMillis)*time.Millisecond,
 			", StdDev: ", time.Duration(reqs.StdDev)*time.Millisecond,
 			"\n")
+		if ops.Confidence != nil {
+			if s := ops.Confidence.StringRequests(); s != "" {
+				console.Println(" * 95% CI:", s)
+			}
+		}
 
 		if reqs.FirstByte != nil {
 			console.Println(" * TTFB:", reqs.FirstByte)
@@ -644,6 +1367,16 @@ func checkAnalyze(ctx *cli.Context) {
 		err := errors.New("-analyze.dur cannot be 0")
 		fatal(probe.NewError(err), "Invalid -analyze.dur value")
 	}
+	switch ctx.String("analyze.out.format") {
+	case "", "csv", "json":
+	default:
+		err := errors.New("-analyze.out.format must be 'csv' or 'json'")
+		fatal(probe.NewError(err), "Invalid -analyze.out.format value")
+	}
+	if ctx.Int("analyze.heatmap.buckets") <= 0 {
+		err := errors.New("-analyze.heatmap.buckets must be at least 1")
+		fatal(probe.NewError(err), "Invalid -analyze.heatmap.buckets value")
+	}
 }
 
 // stringKeysSorted returns the keys as a sorted string slice.
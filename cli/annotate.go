@@ -0,0 +1,80 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var annotateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "run",
+		Usage: "Address of the running benchmark, as given to its --" + serverFlagName + " parameter, eg: localhost:7762",
+	},
+}
+
+var annotateCmd = cli.Command{
+	Name:   "annotate",
+	Usage:  "add an annotation to a running benchmark",
+	Action: mainAnnotate,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, annotateFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} --run=host:port [FLAGS] text
+  -> see https://github.com/minio/warp#annotations
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainAnnotate is the entry point for annotate command.
+func mainAnnotate(ctx *cli.Context) error {
+	run := ctx.String("run")
+	if run == "" {
+		console.Fatal("--run must be specified")
+	}
+	text := strings.TrimSpace(strings.Join(ctx.Args(), " "))
+	if text == "" {
+		console.Fatal("No annotation text supplied")
+	}
+	if !strings.Contains(run, "://") {
+		run = "http://" + run
+	}
+	cl := http.Client{Timeout: 10 * time.Second}
+	resp, err := cl.Post(strings.TrimSuffix(run, "/")+"/v1/annotate", "text/plain", strings.NewReader(text))
+	fatalIf(probe.NewError(err), "Unable to send annotation")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		fatalIf(probe.NewError(fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))), "Unable to send annotation")
+	}
+	console.Infoln("Annotation added.")
+	return nil
+}
@@ -18,17 +18,22 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/api"
 	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/generator"
 	"github.com/minio/websocket"
 )
 
@@ -46,13 +51,51 @@ type clientReply struct {
 	Time      time.Time `json:"time"`
 	StageInfo struct {
 		Custom   map[string]string `json:"custom,omitempty"`
+		Live     *api.LiveStats    `json:"live,omitempty"`
 		Progress float64           `json:"progress"`
 		Started  bool              `json:"started"`
 		Finished bool              `json:"finished"`
+		Ready    bool              `json:"ready,omitempty"`
 	} `json:"stage_info"`
-	Type clientReplyType  `json:"type"`
-	Err  string           `json:"err,omitempty"`
-	Ops  bench.Operations `json:"ops,omitempty"`
+	Type clientReplyType `json:"type"`
+	Err  string          `json:"err,omitempty"`
+	// Resumed is set when a reconnecting server resumes its session.
+	Resumed bool `json:"resumed,omitempty"`
+	// Queued is the position of the server in the session queue, while waiting.
+	Queued int `json:"queued,omitempty"`
+	// Info describes the client. Sent when the connection is confirmed.
+	Info *clientInfo `json:"info,omitempty"`
+
+	// OpsChunk contains operations as zstd compressed CSV.
+	// The chunk has index Chunk out of Chunks.
+	OpsChunk []byte `json:"ops_chunk,omitempty"`
+	Chunk    int    `json:"chunk,omitempty"`
+	Chunks   int    `json:"chunks,omitempty"`
+}
+
+// opsChunkSize is the number of operations sent in each chunk.
+const opsChunkSize = 100_000
+
+// encodeOpsChunk returns chunk n of ops as zstd compressed CSV and the number of chunks.
+func encodeOpsChunk(ops bench.Operations, n int) ([]byte, int, error) {
+	chunks := (len(ops) + opsChunkSize - 1) / opsChunkSize
+	if n < 0 || n >= chunks {
+		if n == 0 {
+			return nil, 0, nil
+		}
+		return nil, chunks, fmt.Errorf("chunk %d out of range, %d chunks", n, chunks)
+	}
+	var buf bytes.Buffer
+	err := ops[n*opsChunkSize:min((n+1)*opsChunkSize, len(ops))].CSV(&buf, "")
+	if err != nil {
+		return nil, chunks, err
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		return nil, chunks, err
+	}
+	defer enc.Close()
+	return enc.EncodeAll(buf.Bytes(), nil), chunks, nil
 }
 
 // executeBenchmark will execute the benchmark and return any error.
@@ -76,6 +119,13 @@ func (s serverRequest) executeBenchmark(ctx context.Context) (*clientBenchmark,
 			return nil, err
 		}
 	}
+	// Give each client its own sequence, unless it should be shared.
+	if seed := ctx2.Int64("seed"); seed != 0 && !ctx2.Bool("seed.shared") {
+		err := ctx2.Set("seed", strconv.FormatInt(generator.DeriveSeed(seed, uint64(s.ClientIdx)), 10))
+		if err != nil {
+			return nil, err
+		}
+	}
 	var cb clientBenchmark
 	cb.init(ctx)
 	cb.clientIdx = s.ClientIdx
@@ -104,8 +154,29 @@ func (s serverRequest) executeBenchmark(ctx context.Context) (*clientBenchmark,
 var (
 	connectedMu sync.Mutex
 	connected   serverInfo
+	// connectedGen is incremented for each accepted connection.
+	connectedGen uint64
+	// disconnectedAt is when the last connection was lost.
+	disconnectedAt time.Time
 )
 
+// clientSessionTimeout is how long a running benchmark is kept for
+// the server to reconnect before another server can take over.
+const clientSessionTimeout = 5 * time.Minute
+
+// benchmarkRunning returns whether a benchmark is running.
+func benchmarkRunning() bool {
+	activeBenchmarkMu.Lock()
+	ab := activeBenchmark
+	activeBenchmarkMu.Unlock()
+	if ab == nil {
+		return false
+	}
+	ab.Lock()
+	defer ab.Unlock()
+	return ab.stage != stageDone
+}
+
 // wsUpgrader performs websocket upgrades.
 var wsUpgrader = websocket.Upgrader{
 	CheckOrigin: func(_ *http.Request) bool {
@@ -131,42 +202,74 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 		console.Error("Error reading server info:", err.Error())
 		return
 	}
-	if err = s.validate(); err != nil {
+	if err = s.validate(clientAuth); err != nil {
+		console.Errorln("Rejected server connection:", err)
 		ws.WriteJSON(clientReply{Err: err.Error()})
 		return
 	}
 
 	s.connected = true
 	connectedMu.Lock()
-	if connected.ID == "" || !connected.connected {
-		// First connection or server disconnected.
-		connected = s
-	} else if connected.ID != s.ID {
-		err = errors.New("another server already connected")
+	resumed, abandoned, err := admitServer(s)
+	if err != nil && clientQueueTimeout > 0 {
+		console.Infoln("Queueing server", s.ID+":", err)
+		resumed, abandoned, err = waitForSession(s, r.RemoteAddr, func(pos int) error {
+			return ws.WriteJSON(clientReply{Time: time.Now(), Queued: pos})
+		})
+	}
+	var gen uint64
+	if err == nil {
+		gen = acceptServer(s, r.RemoteAddr)
 	}
 	connectedMu.Unlock()
 	if err != nil {
 		ws.WriteJSON(clientReply{Err: err.Error()})
 		return
 	}
+	if abandoned {
+		console.Infoln("Server did not reconnect. Stopping benchmark.")
+		activeBenchmarkMu.Lock()
+		if activeBenchmark != nil {
+			activeBenchmark.cancel()
+		}
+		activeBenchmarkMu.Unlock()
+	}
 
-	console.Infoln("Accepting connection from server:", s.ID)
+	if resumed {
+		console.Infoln("Resuming session with server:", s.ID)
+	} else {
+		console.Infoln("Accepting connection from server:", s.ID)
+	}
 	defer func() {
-		// When we return, reset connection info.
-		connectedMu.Lock()
-		connected.connected = false
-		connectedMu.Unlock()
+		releaseServer(gen)
 		ws.Close()
 	}()
 
 	// Confirm the connection
-	err = ws.WriteJSON(clientReply{Time: time.Now()})
+	err = ws.WriteJSON(clientReply{Time: time.Now(), Resumed: resumed, Info: localClientInfo()})
 	if err != nil {
 		console.Error("Writing response:", err)
 		return
 	}
+
+	// Heartbeats from the server extend the deadline.
+	readDeadline := func() time.Time {
+		if serverTimeout <= 0 {
+			return time.Time{}
+		}
+		return time.Now().Add(serverTimeout)
+	}
+	ws.SetPingHandler(func(data string) error {
+		ws.SetReadDeadline(readDeadline())
+		err := ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
 	for {
 		var req serverRequest
+		ws.SetReadDeadline(readDeadline())
 		err := ws.ReadJSON(&req)
 		if err != nil {
 			console.Error("Reading server message:", err.Error())
@@ -222,12 +325,18 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 				resp.Type = clientRespStatus
 				break
 			}
+			wait := time.Until(req.StartTime)
+			if req.MaxLag > 0 && -wait > req.MaxLag {
+				// Starting now would shift the measured window.
+				resp.Err = fmt.Sprintf("missed start time by %v", (-wait).Round(time.Millisecond))
+				console.Errorln("Not starting stage", req.Stage+":", resp.Err)
+				break
+			}
 			info.startRequested = true
 			ab.Lock()
 			ab.info[req.Stage] = info
 			ab.Unlock()
 
-			wait := time.Until(req.StartTime)
 			if wait < 0 {
 				wait = 0
 			}
@@ -237,6 +346,21 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 				close(info.start)
 			}()
 			resp.Type = clientRespStatus
+		case serverReqReadyStage:
+			activeBenchmarkMu.Lock()
+			ab := activeBenchmark
+			activeBenchmarkMu.Unlock()
+			if ab == nil {
+				resp.Err = "no benchmark running"
+				break
+			}
+			ready, err := ab.stageReady(req.Stage)
+			if err != nil {
+				resp.Err = err.Error()
+				break
+			}
+			resp.Type = clientRespStatus
+			resp.StageInfo.Ready = ready
 		case serverReqStageStatus:
 			activeBenchmarkMu.Lock()
 			ab := activeBenchmark
@@ -249,7 +373,9 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 			ab.Lock()
 			err := ab.err
 			stageInfo := ab.info
+			live := ab.live
 			ab.Unlock()
+			resp.StageInfo.Live = live.get()
 			if err != nil {
 				resp.Err = err.Error()
 				break
@@ -270,6 +396,26 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 				resp.StageInfo.Custom = info.custom
 			default:
 			}
+		case serverReqStopStage:
+			activeBenchmarkMu.Lock()
+			ab := activeBenchmark
+			activeBenchmarkMu.Unlock()
+			if ab == nil {
+				resp.Err = "no benchmark running"
+				break
+			}
+			if req.Stage != stageBenchmark {
+				resp.Err = "stage cannot be stopped"
+				break
+			}
+			resp.Type = clientRespStatus
+			ab.Lock()
+			stop := ab.stopBenchmark
+			ab.Unlock()
+			if stop != nil {
+				console.Infoln("Stopping stage", req.Stage)
+				stop()
+			}
 		case serverReqSendOps:
 			activeBenchmarkMu.Lock()
 			ab := activeBenchmark
@@ -280,8 +426,13 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 			}
 			resp.Type = clientRespOps
 			ab.Lock()
-			resp.Ops = ab.results
+			ops := ab.results
 			ab.Unlock()
+			resp.Chunk = req.Chunk
+			resp.OpsChunk, resp.Chunks, err = encodeOpsChunk(ops, req.Chunk)
+			if err != nil {
+				resp.Err = err.Error()
+			}
 		default:
 			resp.Err = "unknown command"
 		}
@@ -0,0 +1,151 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// benchDataExt returns the file extension of benchmark data for --benchdata.format.
+func benchDataExt(ctx *cli.Context) string {
+	if ctx.String("benchdata.format") == "parquet" {
+		return ".parquet"
+	}
+	return ".csv.zst"
+}
+
+// writeBenchData writes ops to w in the format selected by --benchdata.format.
+func writeBenchData(ctx *cli.Context, w io.Writer, ops bench.Operations) error {
+	if ctx.String("benchdata.format") == "parquet" {
+		return ops.Parquet(w, benchMetadata(ctx))
+	}
+	return writeCSVZstd(ctx, w, ops)
+}
+
+// benchDataCompressionFlag selects the compression level of CSV benchmark data.
+var benchDataCompressionFlag = cli.StringFlag{
+	Name:  "benchdata.compression",
+	Value: "auto",
+	Usage: "Compression of CSV benchmark data. Can be 'auto', 'fastest', 'default', 'better' or 'best'. 'auto' uses 'fastest' for big outputs",
+}
+
+// autoCompressionOps is the number of operations above which
+// 'auto' compression will use the fastest level.
+const autoCompressionOps = 10_000_000
+
+// benchDataLevel returns the compression level for n operations.
+func benchDataLevel(ctx *cli.Context, n int) (zstd.EncoderLevel, error) {
+	s := ctx.String("benchdata.compression")
+	switch s {
+	case "", "auto":
+		if n > autoCompressionOps {
+			return zstd.SpeedFastest, nil
+		}
+		return zstd.SpeedBetterCompression, nil
+	}
+	ok, level := zstd.EncoderLevelFromString(s)
+	if !ok {
+		return 0, fmt.Errorf("unknown compression %q. Must be 'auto', 'fastest', 'default', 'better' or 'best'", s)
+	}
+	return level, nil
+}
+
+// writeCSVZstd writes ops to w as zstd compressed CSV.
+// Chunks of operations are formatted and compressed concurrently as separate zstd frames.
+func writeCSVZstd(ctx *cli.Context, w io.Writer, ops bench.Operations) error {
+	level, err := benchDataLevel(ctx, len(ops))
+	if err != nil {
+		return err
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(0)))
+	if err != nil {
+		return err
+	}
+	defer enc.Close()
+	return ops.CSVParallel(w, benchMetadata(ctx).String(), func(b []byte) []byte {
+		return enc.EncodeAll(b, nil)
+	})
+}
+
+// benchMetadata returns the metadata written with benchmark data.
+// Values from --benchdata.meta take precedence over captured server information.
+func benchMetadata(ctx *cli.Context) bench.Metadata {
+	meta := bench.Metadata{
+		Command: commandLine(ctx),
+		Version: pkg.Version,
+	}
+	if len(serverMeta) > 0 {
+		meta.Values = maps.Clone(serverMeta)
+	}
+	for _, kv := range ctx.StringSlice("benchdata.meta") {
+		k, v, _ := strings.Cut(kv, "=")
+		if meta.Values == nil {
+			meta.Values = make(map[string]string)
+		}
+		meta.Values[k] = v
+	}
+	return meta
+}
+
+// readBenchData reads benchmark data from the file, or stdin if "-" is given.
+// Files with a .parquet extension are read as Parquet, otherwise zstd compressed CSV is expected.
+func readBenchData(ctx *cli.Context, fn string, analyzeOnly bool, log func(msg string, v ...interface{})) (bench.Operations, bench.Metadata, error) {
+	var input io.Reader = os.Stdin
+	if fn != "-" {
+		f, err := os.Open(fn)
+		if err != nil {
+			return nil, bench.Metadata{}, err
+		}
+		defer f.Close()
+		if strings.HasSuffix(fn, ".parquet") {
+			st, err := f.Stat()
+			if err != nil {
+				return nil, bench.Metadata{}, err
+			}
+			return bench.OperationsFromParquetMeta(f, st.Size(), analyzeOnly, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		}
+		input = f
+	}
+	br := bufio.NewReader(input)
+	if magic, _ := br.Peek(4); string(magic) == "PAR1" {
+		// Parquet requires random access.
+		b, err := io.ReadAll(br)
+		if err != nil {
+			return nil, bench.Metadata{}, err
+		}
+		return bench.OperationsFromParquetMeta(bytes.NewReader(b), int64(len(b)), analyzeOnly, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+	}
+	dec, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, bench.Metadata{}, err
+	}
+	defer dec.Close()
+	return bench.OperationsFromCSVMeta(dec, analyzeOnly, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+}
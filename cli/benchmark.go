@@ -24,13 +24,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/cheggaaa/pb"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/madmin-go/v3"
 	"github.com/minio/mc/pkg/probe"
@@ -45,6 +49,21 @@ var benchFlags = []cli.Flag{
 		Value: "",
 		Usage: "Output benchmark+profile data to this file. By default unique filename is generated.",
 	},
+	cli.StringFlag{
+		Name:  "benchdata.format",
+		Value: "csv",
+		Usage: "Benchmark data format. Can be 'csv' (zstd compressed) or 'parquet'.",
+	},
+	benchDataCompressionFlag,
+	cli.StringSliceFlag{
+		Name:  "benchdata.meta",
+		Usage: "Add key=value metadata to the benchmark data. Can be specified multiple times",
+	},
+	cli.StringFlag{
+		Name:  "collector",
+		Value: "ops",
+		Usage: "Operation collector. 'ops' keeps all operations, 'hdr' only keeps latency histograms and writes aggregated output.",
+	},
 	cli.StringFlag{
 		Name:  "serverprof",
 		Usage: "Run MinIO server profiling during benchmark; possible values are 'cpu', 'mem', 'block', 'mutex' and 'trace'.",
@@ -52,9 +71,13 @@ var benchFlags = []cli.Flag{
 	},
 	cli.DurationFlag{
 		Name:  "duration",
-		Usage: "Duration to run the benchmark. Use 's' and 'm' to specify seconds and minutes.",
+		Usage: "Duration to run the benchmark. Use 's' and 'm' to specify seconds and minutes. 0 runs until interrupted",
 		Value: 5 * time.Minute,
 	},
+	cli.DurationFlag{
+		Name:  "window",
+		Usage: "Write operations to a new file for each window of this duration, so memory use does not grow. 0 keeps all operations",
+	},
 	cli.BoolFlag{
 		Name:  "autoterm",
 		Usage: "Auto terminate when benchmark is considered stable.",
@@ -66,9 +89,24 @@ var benchFlags = []cli.Flag{
 	},
 	cli.Float64Flag{
 		Name:  "autoterm.pct",
-		Usage: "The percentage the last 6/25 time blocks must be within current speed to auto terminate.",
+		Usage: "The percentage the time blocks in the autoterm window must be within current value to auto terminate.",
 		Value: 7.5,
 	},
+	cli.IntFlag{
+		Name:  "autoterm.segments",
+		Usage: "Number of time blocks the benchmark is split into when checking for stability.",
+		Value: 25,
+	},
+	cli.IntFlag{
+		Name:  "autoterm.window",
+		Usage: "Number of final time blocks that must be stable to auto terminate.",
+		Value: 7,
+	},
+	cli.StringFlag{
+		Name:  "autoterm.metric",
+		Usage: "Metric that must be stable. Can be 'throughput', 'latency' or 'both'.",
+		Value: "throughput",
+	},
 	cli.BoolFlag{
 		Name:  "noclear",
 		Usage: "Do not clear bucket before or after running benchmarks. Use when running multiple clients.",
@@ -89,6 +127,157 @@ var benchFlags = []cli.Flag{
 		EnvVar: "",
 		Value:  "",
 	},
+	cli.StringFlag{
+		Name:   "warp-auth",
+		Usage:  "Shared secret sent to warp clients for authentication",
+		EnvVar: appNameUC + "_AUTH",
+	},
+	cli.BoolFlag{
+		Name:  "warp-client-tls",
+		Usage: "Connect to warp clients using TLS (wss://). Use --insecure to skip certificate verification",
+	},
+	cli.StringFlag{
+		Name:  "warp-client-ca",
+		Usage: "Additional CA certificates to trust for --warp-client-tls. File name or PEM data",
+	},
+	cli.DurationFlag{
+		Name:  "warp-client-heartbeat",
+		Usage: "Interval between heartbeats sent to warp clients. 0 disables heartbeats",
+		Value: 5 * time.Second,
+	},
+	cli.DurationFlag{
+		Name:  "warp-client-timeout",
+		Usage: "Mark warp clients as lost when they do not respond within this time. 0 waits forever",
+		Value: 30 * time.Second,
+	},
+	cli.DurationFlag{
+		Name:  "warp-client-max-lag",
+		Usage: "Exclude warp clients that receive the benchmark start later than this after the agreed start time",
+		Value: time.Second,
+	},
+	cli.IntFlag{
+		Name:  "prepare.retries",
+		Usage: "Number of times a failed upload is retried when preparing the benchmark",
+		Value: 3,
+	},
+	cli.StringFlag{
+		Name:  "prepare.tolerate-errors",
+		Usage: "Percentage of uploads that may fail when preparing before the benchmark is aborted, for example '1%'",
+		Value: "0%",
+	},
+	cli.BoolFlag{
+		Name:  "cleanup.verify",
+		Usage: "After cleanup, list the bucket and report any objects and incomplete uploads left",
+	},
+	cli.StringFlag{
+		Name:  "concurrency-ramp",
+		Usage: "Change concurrency during the benchmark. Comma separated concurrency:duration steps, for example '10:1m,50:5m,100:5m'. Overrides --concurrent and --duration",
+	},
+	cli.BoolFlag{
+		Name:  "autotune",
+		Usage: "Double concurrency in steps, starting at --concurrent, until throughput stops increasing and report the optimum. Overrides --duration",
+	},
+	cli.DurationFlag{
+		Name:  "autotune.step",
+		Usage: "Duration of each --autotune step",
+		Value: 30 * time.Second,
+	},
+	cli.IntFlag{
+		Name:  "autotune.max",
+		Usage: "Highest concurrency tried by --autotune",
+		Value: 512,
+	},
+	cli.Float64Flag{
+		Name:  "autotune.gain",
+		Usage: "Minimum throughput increase in percent for --autotune to try the next step",
+		Value: 5,
+	},
+	cli.Float64Flag{
+		Name:  "autotune.errors",
+		Usage: "Highest error rate in percent accepted by --autotune",
+		Value: 1,
+	},
+	cli.DurationFlag{
+		Name:  "autotune.latency",
+		Usage: "Highest 90th percentile request time accepted by --autotune. 0 disables",
+	},
+	cli.StringFlag{
+		Name:  "phases",
+		Usage: "Alternate load between phases. Comma separated name:duration[:load%] phases repeated until the benchmark ends, for example 'burst:2m,idle:5m'. Phases named 'idle' run no operations",
+	},
+	cli.DurationFlag{
+		Name:  "thread-stagger",
+		Usage: "Spread the start of threads evenly over this duration to avoid all threads starting at once",
+	},
+	cli.DurationFlag{
+		Name:  "thread-jitter",
+		Usage: "Add a random delay up to this duration before each operation on every thread",
+	},
+	cli.DurationFlag{
+		Name:  "autopause",
+		Usage: "Pause the benchmark when all operations have failed for this duration and resume when the target is healthy. 0 disables",
+	},
+	cli.DurationFlag{
+		Name:  "autopause.probe",
+		Usage: "Interval between probe operations while paused",
+		Value: 5 * time.Second,
+	},
+	cli.StringFlag{
+		Name:  "size-sweep",
+		Usage: "Run the benchmark once for each of these comma separated object sizes, for example '4KiB,1MiB,64MiB'",
+	},
+	cli.StringFlag{
+		Name:  "concurrency-sweep",
+		Usage: "Run the benchmark once for each of these comma separated concurrency values, for example '16,32,64'",
+	},
+	cli.StringFlag{
+		Name:  "encryption-sweep",
+		Usage: "Run the benchmark once for each of these comma separated bucket encryption modes, for example 'none,sse-s3,sse-kms'",
+	},
+	cli.StringFlag{
+		Name:  "buckets-sweep",
+		Usage: "Run the buckets benchmark once for each of these comma separated bucket counts, for example '1000,5000,10000'",
+	},
+	cli.DurationFlag{
+		Name:  "each",
+		Usage: "Duration of each sweep step. Defaults to --duration",
+	},
+	cli.StringFlag{
+		Name:  "fail-on",
+		Usage: "Exit with an error if operations failed. Can be 'any', 'infra' for infrastructure errors or 'validation' for validation failures",
+	},
+	cli.Int64Flag{
+		Name:  "seed",
+		Usage: "Seed for object names, sizes, data and operation order. Each client and thread derives its own sequence. 0 is random",
+	},
+	cli.BoolFlag{
+		Name:  "seed.shared",
+		Usage: "Use the same seed on all clients, so they operate on the same keys",
+	},
+}
+
+// setAutoTerm sets the auto termination parameters, if enabled.
+func setAutoTerm(ctx *cli.Context, c *bench.Common) {
+	if !ctx.Bool("autoterm") {
+		return
+	}
+	c.AutoTermDur = ctx.Duration("autoterm.dur")
+	c.AutoTermScale = ctx.Float64("autoterm.pct") / 100
+	c.AutoTermSegments = ctx.Int("autoterm.segments")
+	c.AutoTermWindow = ctx.Int("autoterm.window")
+	c.AutoTermMetric, _ = bench.ParseAutoTermMetric(ctx.String("autoterm.metric"))
+}
+
+// autoTermOptions returns the auto termination options set in the context.
+func autoTermOptions(ctx *cli.Context) bench.AutoTermOptions {
+	metric, _ := bench.ParseAutoTermMetric(ctx.String("autoterm.metric"))
+	return bench.AutoTermOptions{
+		Threshold: ctx.Float64("autoterm.pct") / 100,
+		Segments:  ctx.Int("autoterm.segments"),
+		Window:    ctx.Int("autoterm.window"),
+		MinDur:    ctx.Duration("autoterm.dur"),
+		Metric:    metric,
+	}
 }
 
 // runBench will run the supplied benchmark and save/print the analysis.
@@ -112,72 +301,27 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		return nil
 	}
 
+	if done, err := runSweep(ctx, b); done || err != nil {
+		return err
+	}
+
 	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
 	monitor.SetLnLoggers(printInfo, printError)
 	defer monitor.Done()
 
-	monitor.InfoLn("Preparing server.")
-	pgDone := make(chan struct{})
+	captureServerMeta(ctx)
 	c := b.GetCommon()
+	live := addLiveCollector(ctx, c, false)
 	c.Clear = !ctx.Bool("noclear")
-	if ctx.Bool("autoterm") {
-		// TODO: autoterm cannot be used when in client/server mode
-		c.AutoTermDur = ctx.Duration("autoterm.dur")
-		c.AutoTermScale = ctx.Float64("autoterm.pct") / 100
-	}
-	if !globalQuiet && !globalJSON {
-		c.PrepareProgress = make(chan float64, 1)
-		const pgScale = 10000
-		pg := newProgressBar(pgScale, pb.U_NO)
-		pg.ShowCounters = false
-		pg.ShowElapsedTime = false
-		pg.ShowSpeed = false
-		pg.ShowTimeLeft = false
-		pg.ShowFinalTime = true
-		go func() {
-			defer close(pgDone)
-			defer pg.Finish()
-			tick := time.NewTicker(time.Millisecond * 125)
-			defer tick.Stop()
-			pg.Set(-1)
-			pg.SetCaption("Preparing: ")
-			newVal := int64(-1)
-			for {
-				select {
-				case <-tick.C:
-					current := pg.Get()
-					if current != newVal {
-						pg.Set64(newVal)
-						pg.Update()
-					}
-					monitor.InfoQuietln(fmt.Sprintf("Preparation: %0.0f%% done...", float64(newVal)/float64(100)))
-				case pct, ok := <-c.PrepareProgress:
-					if !ok {
-						pg.Set64(pgScale)
-						if newVal > 0 {
-							pg.Update()
-						}
-						return
-					}
-					newVal = int64(pct * pgScale)
-				}
-			}
-		}()
-	} else {
-		close(pgDone)
-	}
-
-	err := b.Prepare(context.Background())
-	fatalIf(probe.NewError(err), "Error preparing server")
-	if c.PrepareProgress != nil {
-		close(c.PrepareProgress)
-		<-pgDone
-	}
-
-	if ap, ok := b.(AfterPreparer); ok {
-		err := ap.AfterPrepare(context.Background())
-		fatalIf(probe.NewError(err), "Error preparing server")
+	if s := sharedSetup(); s != nil {
+		// The bucket was set up by the first step, so only the data of this step is prepared.
+		c.BucketReady, c.Versioned = true, s.Versioned
 	}
+	setAutoTerm(ctx, c)
+	b = activeSweep.prepareStep(b, func(b bench.Benchmark) {
+		prepareBench(ctx, b, monitor)
+	})
+	c = b.GetCommon()
 
 	// Start after waiting a second or until we reached the start time.
 	tStart := time.Now().Add(time.Second * 3)
@@ -192,9 +336,20 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		}
 	}
 
-	benchDur := ctx.Duration("duration")
-	ctx2, cancel := context.WithDeadline(context.Background(), tStart.Add(benchDur))
+	benchDur := benchDuration(ctx, b.GetCommon())
+	var ctx2 context.Context
+	var cancel context.CancelFunc
+	if benchDur > 0 {
+		ctx2, cancel = context.WithDeadline(context.Background(), tStart.Add(benchDur))
+	} else {
+		// Run until interrupted.
+		ctx2, cancel = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	}
 	defer cancel()
+	if c.AutoTune != nil {
+		// Stop the benchmark when the optimum has been found.
+		c.AutoTune.Done = cancel
+	}
 	start := make(chan struct{})
 	go func() {
 		<-time.After(time.Until(tStart))
@@ -207,12 +362,37 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 	if fileName == "" {
 		fileName = fmt.Sprintf("%s-%s-%s-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"), cID)
 	}
+	if activeSweep != nil {
+		// Values may contain KMS key ids.
+		fileName += "-" + strings.NewReplacer(":", "-", "/", "-").Replace(activeSweep.current)
+	}
 
+	windows := startBenchWindows(ctx, ctx2, c, live, tStart, fileName, cID, monitor)
+	startStatsD(ctx, ctx2, live)
 	prof, err := startProfiling(ctx2, ctx)
 	fatalIf(probe.NewError(err), "Unable to start profile.")
 	monitor.InfoLn("Starting benchmark in ", time.Until(tStart).Round(time.Second), "...")
-	pgDone = make(chan struct{})
-	if !globalQuiet && !globalJSON {
+	if benchDur == 0 {
+		monitor.InfoLn("Running until interrupted...")
+	}
+	if live != nil {
+		go func() {
+			tick := time.NewTicker(time.Second)
+			defer tick.Stop()
+			for {
+				select {
+				case <-tick.C:
+					if l := live.get(); l != nil {
+						monitor.SetLive(*l)
+					}
+				case <-ctx2.Done():
+					return
+				}
+			}
+		}()
+	}
+	pgDone := make(chan struct{})
+	if !globalQuiet && !globalJSON && benchDur > 0 {
 		pg := newProgressBar(int64(benchDur), pb.U_DURATION)
 		go func() {
 			defer close(pgDone)
@@ -241,9 +421,16 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 	} else {
 		close(pgDone)
 	}
+	began := time.Now()
 	ops, _ := b.Start(ctx2, start)
 	cancel()
 	<-pgDone
+	var windowErrs, windowValidation int
+	if windows != nil {
+		// The remaining operations are written as the final window.
+		fileName = windows.finish()
+		windowErrs, windowValidation = windows.errs, windows.validation
+	}
 
 	// Previous context is canceled, create a new...
 	monitor.InfoLn("Saving benchmark data...")
@@ -251,33 +438,148 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 	ops.SortByStartTime()
 	ops.SetClientID(cID)
 	prof.stop(ctx2, ctx, fileName+".profiles.zip")
+	if hdr := c.Collector.Histograms(); hdr != nil {
+		monitor.OperationsReady(nil, fileName, commandLine(ctx))
+		printHDRAnalysis(ctx, hdr, fileName+".json")
+	} else {
+		annotated := ops.WithAnnotations(append(monitor.Annotations(), stsEvents.since(began)...))
 
-	if len(ops) > 0 {
-		f, err := os.Create(fileName + ".csv.zst")
-		if err != nil {
-			monitor.Errorln("Unable to write benchmark data:", err)
-		} else {
-			func() {
-				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
-				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
-
-				defer enc.Close()
-				err = ops.CSV(enc, commandLine(ctx))
-				fatalIf(probe.NewError(err), "Unable to write benchmark output")
+		if len(ops) > 0 {
+			f, err := os.Create(fileName + benchDataExt(ctx))
+			if err != nil {
+				monitor.Errorln("Unable to write benchmark data:", err)
+			} else {
+				func() {
+					defer f.Close()
+					err = writeBenchData(ctx, f, annotated)
+					fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				monitor.InfoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+".csv.zst"))
-			}()
+					monitor.InfoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+benchDataExt(ctx)))
+				}()
+			}
 		}
+		monitor.OperationsReady(ops, fileName, commandLine(ctx))
+		printAnalysis(ctx, annotated, nil)
+	}
+	printOutages(c.AutoPause)
+	printAutoTune(c.AutoTune)
+	errs, validation := opErrors(ops)
+	if hdr := c.Collector.Histograms(); hdr != nil {
+		errs, validation = hdr.ErrorCounts()
+	}
+	errs += windowErrs
+	validation += windowValidation
+	if activeSweep != nil {
+		activeSweep.add(ops)
+	}
+	failErr := checkFailOn(ctx, errs, validation)
+	if failErr == nil && activeSweep.keepPrepared() {
+		monitor.InfoLn("Keeping prepared data for the next step.")
+		return nil
 	}
-	monitor.OperationsReady(ops, fileName, commandLine(ctx))
-	printAnalysis(ctx, ops)
 	if !ctx.Bool("keep-data") && !ctx.Bool("noclear") {
 		monitor.InfoLn("Starting cleanup...")
 		b.Cleanup(context.Background())
+		verifyCleanup(ctx, c)
 	}
 	monitor.InfoLn("Cleanup Done.")
-	return nil
+	return failErr
+}
+
+// prepareBench prepares b and waits for it to complete.
+func prepareBench(ctx *cli.Context, b bench.Benchmark, monitor *api.Server) {
+	monitor.InfoLn("Preparing server.")
+	c := b.GetCommon()
+	pgDone := make(chan struct{})
+	if !globalQuiet && !globalJSON {
+		c.PrepareProgress = make(chan float64, 1)
+		const pgScale = 10000
+		pg := newProgressBar(pgScale, pb.U_NO)
+		pg.ShowCounters = false
+		pg.ShowElapsedTime = false
+		pg.ShowSpeed = false
+		pg.ShowTimeLeft = false
+		pg.ShowFinalTime = true
+		go func() {
+			defer close(pgDone)
+			defer pg.Finish()
+			tick := time.NewTicker(time.Millisecond * 125)
+			defer tick.Stop()
+			pg.Set(-1)
+			pg.SetCaption("Preparing: ")
+			newVal := int64(-1)
+			for {
+				select {
+				case <-tick.C:
+					current := pg.Get()
+					if current != newVal {
+						pg.Set64(newVal)
+						pg.Update()
+					}
+					monitor.InfoQuietln(fmt.Sprintf("Preparation: %0.0f%% done...", float64(newVal)/float64(100)))
+				case pct, ok := <-c.PrepareProgress:
+					if !ok {
+						pg.Set64(pgScale)
+						if newVal > 0 {
+							pg.Update()
+						}
+						return
+					}
+					newVal = int64(pct * pgScale)
+				}
+			}
+		}()
+	} else {
+		close(pgDone)
+	}
+
+	err := b.Prepare(context.Background())
+	fatalIf(probe.NewError(err), "Error preparing server")
+	if c.PrepareProgress != nil {
+		close(c.PrepareProgress)
+		<-pgDone
+	}
+	writeManifest(ctx, b)
+
+	if ap, ok := b.(AfterPreparer); ok {
+		err := ap.AfterPrepare(context.Background())
+		fatalIf(probe.NewError(err), "Error preparing server")
+	}
+}
+
+// opErrors returns the number of failed operations and how many of them failed validation.
+func opErrors(ops bench.Operations) (errs, validation int) {
+	for _, op := range ops {
+		if op.Err == "" {
+			continue
+		}
+		errs++
+		if op.ValidationErr() {
+			validation++
+		}
+	}
+	return errs, validation
+}
+
+// checkFailOn returns an error if failed operations match --fail-on.
+func checkFailOn(ctx *cli.Context, errs, validation int) error {
+	var n int
+	switch ctx.String("fail-on") {
+	case "":
+		return nil
+	case "any":
+		n = errs
+	case "infra":
+		n = errs - validation
+	case "validation":
+		n = validation
+	}
+	if n == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%d operations failed, --fail-on=%s", n, ctx.String("fail-on"))
+	printError(err)
+	return err
 }
 
 var (
@@ -292,7 +594,10 @@ type clientBenchmark struct {
 	info      map[benchmarkStage]stageInfo
 	stage     benchmarkStage
 	results   bench.Operations
+	live      *liveCollector
 	clientIdx int
+	// stopBenchmark will stop the benchmark stage.
+	stopBenchmark context.CancelFunc
 	sync.Mutex
 }
 
@@ -306,6 +611,7 @@ type stageInfo struct {
 func (c *clientBenchmark) init(ctx context.Context) {
 	c.results = nil
 	c.err = nil
+	c.stopBenchmark = nil
 	c.stage = stageNotStarted
 	c.info = make(map[benchmarkStage]stageInfo, len(benchmarkStages))
 	c.ctx, c.cancel = context.WithCancel(ctx)
@@ -354,6 +660,31 @@ func (c *clientBenchmark) stageDone(s benchmarkStage, err error, custom map[stri
 	c.Unlock()
 }
 
+// stageReady returns whether stage s can be started.
+// A stage is ready when it has not been started and the previous stage is done.
+func (c *clientBenchmark) stageReady(s benchmarkStage) (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	info, ok := c.info[s]
+	if !ok {
+		return false, errors.New("stage not found")
+	}
+	if info.startRequested {
+		return false, nil
+	}
+	if i := slices.Index(benchmarkStages, s); i > 0 {
+		select {
+		case <-c.info[benchmarkStages[i-1]].done:
+		default:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (c *clientBenchmark) setStage(s benchmarkStage) {
 	c.Lock()
 	c.stage = s
@@ -380,20 +711,28 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 		return err
 	}
 	common := b.GetCommon()
+	// Auto termination is not set on clients.
+	// It is decided by the server from the live stats of all clients.
+	// The warp server polls the live stats for progress and auto termination.
+	live := addLiveCollector(ctx, common, true)
 	cb.Lock()
+	cb.live = live
 	start := cb.info[stageBenchmark].start
 	ctx2, cancel := context.WithCancel(cb.ctx)
 	defer cancel()
+	cb.stopBenchmark = cancel
 	cb.Unlock()
+	startStatsD(ctx, ctx2, live)
 	err = b.Prepare(ctx2)
 
 	cb.stageDone(stagePrepare, err, common.Custom)
 	if err != nil {
 		return err
 	}
+	writeManifest(ctx, b)
 
 	// Start after waiting a second or until we reached the start time.
-	benchDur := ctx.Duration("duration")
+	benchDur := benchDuration(ctx, b.GetCommon())
 	go func() {
 		console.Infoln("Waiting")
 		// Wait for start signal
@@ -422,7 +761,9 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 		fileName = fmt.Sprintf("%s-%s-%s-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"), cID)
 	}
 
+	began := time.Now()
 	ops, err := b.Start(ctx2, start)
+	ops = ops.WithAnnotations(stsEvents.since(began))
 	cb.Lock()
 	cb.results = ops
 	cb.Unlock()
@@ -434,20 +775,16 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 	ops.SortByStartTime()
 
 	if len(ops) > 0 {
-		f, err := os.Create(fileName + ".csv.zst")
+		f, err := os.Create(fileName + benchDataExt(ctx))
 		if err != nil {
 			console.Error("Unable to write benchmark data:", err)
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
-				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
-
-				defer enc.Close()
-				err = ops.CSV(enc, commandLine(ctx))
+				err = writeBenchData(ctx, f, ops)
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				console.Infof("Benchmark data written to %q\n", fileName+".csv.zst")
+				console.Infof("Benchmark data written to %q\n", fileName+benchDataExt(ctx))
 			}()
 		}
 	}
@@ -526,6 +863,25 @@ func checkBenchmark(ctx *cli.Context) {
 
 	_, err := parseInfluxURL(ctx)
 	fatalIf(probe.NewError(err), "invalid influx config")
+	if s := ctx.String("statsd-server"); s != "" {
+		_, _, err := net.SplitHostPort(s)
+		fatalIf(probe.NewError(err), "invalid --statsd-server, must be host:port")
+	}
+	_, err = parseOTLPURL(ctx)
+	fatalIf(probe.NewError(err), "invalid otlp config")
+	if s := ctx.Float64("otlp.sample"); s <= 0 || s > 1 {
+		fatalIf(errDummy(), "--otlp.sample must be > 0 and <= 1")
+	}
+
+	parseChecksum(ctx)
+	checkBackend(ctx)
+	checkProvider(ctx)
+	checkSizeDist(ctx)
+	_, err = parseSSE(ctx)
+	fatalIf(probe.NewError(err), "Invalid server-side encryption options")
+	if ctx.String("sts-endpoint") == "" && (ctx.String("role-arn") != "" || ctx.String("web-identity-token-file") != "") {
+		fatal(errDummy(), "--role-arn and --web-identity-token-file require --sts-endpoint")
+	}
 
 	profs := strings.Split(ctx.String("serverprof"), ",")
 	for _, profilerType := range profs {
@@ -544,6 +900,55 @@ func checkBenchmark(ctx *cli.Context) {
 			fatalIf(errDummy(), "Profiler type %s unrecognized. Possible values are: %v.", profilerType, profilerTypes)
 		}
 	}
+	if ctx.String("warp-client") != "" && ctx.String("warp-auth") == "" {
+		fatalIf(errDummy(), "--warp-auth or %s_AUTH must be set to the secret of the warp clients", appNameUC)
+	}
+	if ctx.String("warp-client-ca") != "" && !ctx.Bool("warp-client-tls") {
+		fatalIf(errDummy(), "--warp-client-ca requires --warp-client-tls")
+	}
+	if ctx.String("warp-client") != "" && ctx.String("tls-client-key") != "" && !ctx.Bool("warp-client-tls") {
+		fatalIf(errDummy(), "--tls-client-key is sent to the warp clients and requires --warp-client-tls")
+	}
+	if ctx.Duration("warp-client-max-lag") < 0 {
+		fatalIf(errDummy(), "warp-client-max-lag cannot be negative")
+	}
+	if ctx.Duration("warp-client-heartbeat") < 0 || ctx.Duration("warp-client-timeout") < 0 {
+		fatalIf(errDummy(), "warp-client-heartbeat and warp-client-timeout cannot be negative")
+	}
+	if ctx.Duration("duration") < 0 {
+		fatalIf(errDummy(), "duration cannot be negative")
+	}
+	if ctx.Duration("duration") == 0 {
+		if ctx.String("warp-client") != "" {
+			fatalIf(errDummy(), "--duration=0 cannot be used with --warp-client")
+		}
+		if p, _ := getSweep(ctx); p != nil {
+			fatalIf(errDummy(), "--duration=0 cannot be used with sweeps")
+		}
+		if ctx.Command.Name == "worm" {
+			// Retention is set once for the entire run, so it would expire.
+			fatalIf(errDummy(), "--duration=0 cannot be used with worm")
+		}
+	}
+	if w := ctx.Duration("window"); w != 0 {
+		if w < time.Second {
+			fatalIf(errDummy(), "window must be at least 1s")
+		}
+		if ctx.Bool("autoterm") {
+			fatalIf(errDummy(), "--window cannot be used with --autoterm")
+		}
+		if ctx.String("warp-client") != "" {
+			fatalIf(errDummy(), "--window cannot be used with --warp-client")
+		}
+		if ctx.String("collector") == "hdr" {
+			fatalIf(errDummy(), "--window cannot be used with --collector=hdr")
+		}
+	}
+	switch ctx.String("fail-on") {
+	case "", "any", "infra", "validation":
+	default:
+		fatalIf(errDummy(), "fail-on must be 'any', 'infra' or 'validation'")
+	}
 	if st := ctx.String("syncstart"); st != "" {
 		t := parseLocalTime(st)
 		if t.Before(time.Now()) {
@@ -551,13 +956,177 @@ func checkBenchmark(ctx *cli.Context) {
 		}
 	}
 	if ctx.Bool("autoterm") {
-		// TODO: autoterm cannot be used when in client/server mode
 		if ctx.Duration("autoterm.dur") <= 0 {
 			fatalIf(errDummy(), "autoterm.dur cannot be zero or negative")
 		}
 		if ctx.Float64("autoterm.pct") <= 0 {
 			fatalIf(errDummy(), "autoterm.pct cannot be zero or negative")
 		}
+		if ctx.Int("autoterm.window") < 2 {
+			fatalIf(errDummy(), "autoterm.window must be at least 2")
+		}
+		if ctx.Int("autoterm.segments") <= ctx.Int("autoterm.window") {
+			fatalIf(errDummy(), "autoterm.segments must be bigger than autoterm.window")
+		}
+		_, err := bench.ParseAutoTermMetric(ctx.String("autoterm.metric"))
+		fatalIf(probe.NewError(err), "invalid autoterm.metric")
+	}
+	switch ctx.String("benchdata.format") {
+	case "csv", "parquet":
+	default:
+		fatalIf(errDummy(), "benchdata.format must be 'csv' or 'parquet'")
+	}
+	if _, err := benchDataLevel(ctx, 0); err != nil {
+		fatalIf(probe.NewError(err), "invalid benchdata.compression")
+	}
+	for _, kv := range ctx.StringSlice("benchdata.meta") {
+		k, _, ok := strings.Cut(kv, "=")
+		if !ok || k == "" || strings.ContainsAny(k, " \t\n") {
+			fatalIf(errDummy(), "benchdata.meta must be key=value with no spaces in key, got %q", kv)
+		}
+	}
+	switch ctx.String("collector") {
+	case "ops":
+	case "hdr":
+		if ctx.Bool("autoterm") {
+			fatalIf(errDummy(), "--collector=hdr cannot be used with --autoterm")
+		}
+		if ctx.String("warp-client") != "" {
+			fatalIf(errDummy(), "--collector=hdr cannot be used with --warp-client")
+		}
+		if p, _ := getSweep(ctx); p != nil {
+			fatalIf(errDummy(), "--collector=hdr cannot be used with sweeps")
+		}
+	default:
+		fatalIf(errDummy(), "collector must be 'ops' or 'hdr'")
+	}
+	if ctx.Int("prepare.retries") < 0 {
+		fatalIf(errDummy(), "prepare.retries cannot be negative")
+	}
+	parsePrepareTolerate(ctx)
+	if ctx.Duration("autopause") < 0 {
+		fatalIf(errDummy(), "autopause cannot be negative")
+	}
+	if ctx.Duration("autopause") > 0 && ctx.Duration("autopause.probe") <= 0 {
+		fatalIf(errDummy(), "autopause.probe must be positive")
+	}
+	switch ctx.String("http-version") {
+	case "1.1", "2":
+	default:
+		fatalIf(errDummy(), "http-version must be '1.1' or '2'")
+	}
+	for _, f := range []string{"conn.max-idle", "conn.max-idle-per-host", "conn.per-host"} {
+		if ctx.Int(f) < 0 {
+			fatalIf(errDummy(), "%s cannot be negative", f)
+		}
+	}
+	for _, f := range []string{"thread-stagger", "thread-jitter"} {
+		if ctx.Duration(f) < 0 {
+			fatalIf(errDummy(), "%s cannot be negative", f)
+		}
+	}
+	if r := ctx.String("concurrency-ramp"); r != "" {
+		_, err := bench.ParseRamp(r)
+		fatalIf(probe.NewError(err), "Invalid --concurrency-ramp")
+		if ctx.Bool("autoterm") {
+			fatalIf(errDummy(), "--concurrency-ramp cannot be used with --autoterm")
+		}
+		if ctx.String("concurrency-sweep") != "" {
+			fatalIf(errDummy(), "--concurrency-ramp cannot be used with --concurrency-sweep")
+		}
+	}
+	if ctx.Bool("autotune") {
+		for _, f := range []string{"concurrency-ramp", "phases", "concurrency-sweep", "warp-client"} {
+			if ctx.String(f) != "" {
+				fatalIf(errDummy(), "--autotune cannot be used with --%s", f)
+			}
+		}
+		if ctx.Bool("autoterm") {
+			fatalIf(errDummy(), "--autotune cannot be used with --autoterm")
+		}
+		if ctx.Duration("autotune.step") <= 0 {
+			fatalIf(errDummy(), "--autotune.step must be positive")
+		}
+		if ctx.Int("autotune.max") < ctx.Int("concurrent") {
+			fatalIf(errDummy(), "--autotune.max cannot be less than --concurrent")
+		}
+		if g := ctx.Float64("autotune.gain"); g < 0 {
+			fatalIf(errDummy(), "--autotune.gain cannot be negative")
+		}
+		if e := ctx.Float64("autotune.errors"); e < 0 || e > 100 {
+			fatalIf(errDummy(), "--autotune.errors must be between 0 and 100")
+		}
+	}
+	if p := ctx.String("phases"); p != "" {
+		_, err := bench.ParsePhases(p, ctx.Int("concurrent"))
+		fatalIf(probe.NewError(err), "Invalid --phases")
+		if ctx.Bool("autoterm") {
+			fatalIf(errDummy(), "--phases cannot be used with --autoterm")
+		}
+		if ctx.String("concurrency-ramp") != "" {
+			fatalIf(errDummy(), "--phases cannot be used with --concurrency-ramp")
+		}
+	}
+	checkSweep(ctx)
+}
+
+// benchDuration returns the duration of the benchmark.
+func benchDuration(ctx *cli.Context, c *bench.Common) time.Duration {
+	if c.Ramp != nil {
+		return c.Ramp.Duration()
+	}
+	if c.AutoTune != nil {
+		return c.AutoTune.MaxDuration()
+	}
+	return ctx.Duration("duration")
+}
+
+// printAutoTune prints the result of each tuning step and the optimum concurrency.
+func printAutoTune(a *bench.AutoTune) {
+	if a == nil || globalJSON {
+		return
+	}
+	steps, best, ok := a.Result()
+	if len(steps) == 0 {
+		return
+	}
+	console.Println("\n----------------------------------------")
+	console.Println("Concurrency tuning:")
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Concurrency\tRequests\tErrors\tThroughput\tObj/s\t90%\t\t")
+	for _, s := range steps {
+		tp := "-"
+		if s.BPS > 0 {
+			tp = bench.Throughput(s.BPS).String()
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%.2f\t%v\t%s\t\n", s.Concurrency, s.Requests, s.Errors, tp, s.OPS, s.P90.Round(time.Microsecond), s.Rejected)
+	}
+	tw.Flush()
+	console.Print(sb.String())
+	if !ok {
+		console.Errorln("No concurrency met the error rate and latency targets.")
+		return
+	}
+	console.Infof("Optimum concurrency: %d\n", best.Concurrency)
+}
+
+// printOutages prints the periods where the benchmark was paused.
+func printOutages(a *bench.AutoPause) {
+	if a == nil || globalJSON {
+		return
+	}
+	outages := a.Outages()
+	if len(outages) == 0 {
+		return
+	}
+	console.Printf("\nBenchmark was paused %d times while the target was unhealthy:\n", len(outages))
+	for _, o := range outages {
+		if o.End.IsZero() {
+			console.Printf(" * %s -> (not resumed)\n", o.Start.Format(time.DateTime))
+			continue
+		}
+		console.Printf(" * %s -> %s (%v)\n", o.Start.Format(time.DateTime), o.End.Format(time.TimeOnly), o.End.Sub(o.Start).Round(time.Second))
 	}
 }
 
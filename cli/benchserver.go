@@ -18,16 +18,23 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"maps"
+	"math/rand"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cheggaaa/pb"
 	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
@@ -36,15 +43,17 @@ import (
 	"github.com/minio/websocket"
 )
 
-const warpServerVersion = 1
+const warpServerVersion = 5
 
 type serverRequestOp string
 
 const (
 	serverReqDisconnect  serverRequestOp = "disconnect"
 	serverReqBenchmark   serverRequestOp = "benchmark"
+	serverReqReadyStage  serverRequestOp = "ready_stage"
 	serverReqStartStage  serverRequestOp = "start_stage"
 	serverReqStageStatus serverRequestOp = "stage_status"
+	serverReqStopStage   serverRequestOp = "stop_stage"
 	serverReqSendOps     serverRequestOp = "send_ops"
 )
 
@@ -62,10 +71,14 @@ type AfterPreparer interface {
 }
 
 // validate the serverinfo.
-func (s serverInfo) validate() error {
+// secret must match the secret sent by the server.
+func (s serverInfo) validate(secret string) error {
 	if s.ID == "" {
 		return errors.New("no server id sent")
 	}
+	if subtle.ConstantTimeCompare([]byte(s.Secret), []byte(secret)) != 1 {
+		return errors.New("warp server authentication failed, check --warp-auth")
+	}
 	if s.Version != warpServerVersion {
 		return errors.New("warp server and client version mismatch")
 	}
@@ -83,6 +96,10 @@ type serverRequest struct {
 	Operation serverRequestOp `json:"op"`
 	Stage     benchmarkStage  `json:"stage"`
 	ClientIdx int             `json:"client_idx"`
+	// Chunk is the chunk of operations requested by serverReqSendOps.
+	Chunk int `json:"chunk,omitempty"`
+	// MaxLag is the maximum time a stage may be started after StartTime.
+	MaxLag time.Duration `json:"max_lag,omitempty"`
 }
 
 // runServerBenchmark will run a benchmark server if requested.
@@ -92,7 +109,14 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 		return false, nil
 	}
 
-	conns := newConnections(parseHosts(ctx.String("warp-client"), false))
+	hosts, overrides, err := parseClientOverrides(parseHosts(ctx.String("warp-client"), false))
+	if err != nil {
+		return true, err
+	}
+	conns := newConnections(hosts, ctx.String("warp-auth"))
+	conns.scheme, conns.dialer = warpClientDialer(ctx)
+	conns.heartbeat = ctx.Duration("warp-client-heartbeat")
+	conns.timeout = ctx.Duration("warp-client-timeout")
 	if len(conns.hosts) == 0 {
 		return true, errors.New("no hosts")
 	}
@@ -104,18 +128,70 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	monitor.SetLnLoggers(printInfo, printError)
 	infoLn := monitor.InfoLn
 	errorLn := monitor.Errorln
+	captureServerMeta(ctx)
+
+	// Publish client liveness to the monitor.
+	statusDone := make(chan struct{})
+	defer close(statusDone)
+	go func() {
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			monitor.SetClients(conns.clientStatus())
+			select {
+			case <-statusDone:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	// Merge live stats from all clients and forward to the monitor.
+	var liveMu sync.Mutex
+	var liveMerged api.LiveStats
+	var autoTerm *clusterAutoTerm
+	liveByClient := make(map[int]api.LiveStats, len(conns.hosts))
+	conns.live = func(i int, s api.LiveStats) {
+		liveMu.Lock()
+		defer liveMu.Unlock()
+		liveByClient[i] = s
+		var merged api.LiveStats
+		for _, s := range liveByClient {
+			merged.Merge(s)
+		}
+		liveMerged = merged
+		if autoTerm != nil {
+			autoTerm.add(merged)
+		}
+		monitor.SetLive(merged)
+	}
+	getLive := func() api.LiveStats {
+		liveMu.Lock()
+		defer liveMu.Unlock()
+		return liveMerged
+	}
 
 	var allOps bench.Operations
 
 	// Serialize parameters
 	excludeFlags := map[string]struct{}{
-		"warp-client":        {},
-		"warp-client-server": {},
-		"serverprof":         {},
-		"autocompletion":     {},
-		"help":               {},
-		"syncstart":          {},
-		"analyze.out":        {},
+		"warp-client":           {},
+		"warp-auth":             {},
+		"warp-client-tls":       {},
+		"warp-client-ca":        {},
+		"warp-client-heartbeat": {},
+		"warp-client-timeout":   {},
+		"warp-client-server":    {},
+		"serverprof":            {},
+		"autocompletion":        {},
+		"help":                  {},
+		"syncstart":             {},
+		"analyze.out":           {},
+		"analyze.out.format":    {},
+	}
+	pemFlag := func(flag cli.Flag) (string, error) {
+		b, err := readPEM(ctx.String(flag.GetName()))
+		return string(b), err
 	}
 	transformFlags := map[string]func(flag cli.Flag) (string, error){
 		// Special handling for hosts, we read files and expand it.
@@ -129,6 +205,10 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 			// Rejoin
 			return strings.Join(hosts, ","), nil
 		},
+		// Send certificates as PEM data, so clients don't need the files.
+		"tls-client-cert": pemFlag,
+		"tls-client-key":  pemFlag,
+		"tls-ca":          pemFlag,
 	}
 
 	req := serverRequest{
@@ -157,9 +237,32 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	for k, v := range b.GetCommon().ExtraFlags {
 		req.Benchmark.Flags[k] = v
 	}
+	if _, ok := req.Benchmark.Flags["seed"]; !ok {
+		// Pick a seed, so the run can be reproduced.
+		seed := rand.Int63()
+		req.Benchmark.Flags["seed"] = strconv.FormatInt(seed, 10)
+		infoLn("Using --seed ", seed)
+	}
+
+	// Validate per-client overrides.
+	for i, o := range overrides {
+		for name := range o {
+			if !hasFlag(ctx.Command.Flags, name) {
+				return true, fmt.Errorf("client %s: flag %q is not a flag of %s", conns.hosts[i], name, ctx.Command.Name)
+			}
+		}
+	}
 
 	// Connect to hosts, send benchmark requests.
+	clientFlags := make([]map[string]string, len(conns.hosts))
 	for i := range conns.hosts {
+		req := req
+		if len(overrides[i]) > 0 {
+			req.Benchmark.Flags = maps.Clone(req.Benchmark.Flags)
+			maps.Copy(req.Benchmark.Flags, overrides[i])
+			infoLn("Client ", conns.hosts[i], " overrides: ", overrides[i])
+		}
+		clientFlags[i] = req.Benchmark.Flags
 		resp, err := conns.roundTrip(i, req)
 		fatalIf(probe.NewError(err), "Unable to send benchmark info to warp client")
 		if resp.Err != "" {
@@ -172,7 +275,7 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 
 	common := b.GetCommon()
 	_ = conns.startStageAll(stagePrepare, time.Now().Add(time.Second), true)
-	err := conns.waitForStage(stagePrepare, true, common)
+	err = conns.waitForStage(stagePrepare, true, common)
 	if err != nil {
 		fatalIf(probe.NewError(err), "Failed to prepare")
 	}
@@ -189,12 +292,23 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	if err != nil {
 		return true, err
 	}
-	err = conns.startStageAll(stageBenchmark, time.Now().Add(benchmarkWait), false)
+	if ctx.Bool("autoterm") {
+		// Stop all clients when the merged stats of all clients are stable.
+		at := newClusterAutoTerm(autoTermOptions(ctx), infoLn)
+		liveMu.Lock()
+		autoTerm = at
+		liveMu.Unlock()
+		conns.stable = at.stable
+	}
+	err = conns.startStageSynced(stageBenchmark, benchmarkWait, ctx.Duration("warp-client-max-lag"))
 	if err != nil {
-		errorLn("Failed to start all clients", err)
+		fatalIf(probe.NewError(err), "Failed to start benchmark")
 	}
 	infoLn("Running benchmark on all clients...")
+	pgDone := showClusterProgress(ctx, monitor, getLive)
 	err = conns.waitForStage(stageBenchmark, false, common)
+	conns.stable = nil
+	pgDone()
 	if err != nil {
 		errorLn("Failed to keep connection to all clients", err)
 	}
@@ -212,34 +326,40 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	case 1:
 		allOps = downloaded[0]
 	default:
-		threads := uint16(0)
+		threads := uint32(0)
 		for _, ops := range downloaded {
-			threads = ops.OffsetThreads(threads)
+			var err error
+			threads, err = ops.OffsetThreads(threads)
+			fatalIf(probe.NewError(err), "Unable to combine client results")
 			allOps = append(allOps, ops...)
 		}
 	}
 
+	allOps.SortByStartTime()
+	// Clients return their annotations with the operations.
+	allOps, notes := allOps.SplitAnnotations()
+	annotated := allOps.WithAnnotations(append(monitor.Annotations(), notes...))
 	if len(allOps) > 0 {
-		allOps.SortByStartTime()
-		f, err := os.Create(fileName + ".csv.zst")
+		f, err := os.Create(fileName + benchDataExt(ctx))
 		if err != nil {
 			errorLn("Unable to write benchmark data:", err)
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
-				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
-
-				defer enc.Close()
-				err = allOps.CSV(enc, commandLine(ctx))
+				err = writeBenchData(ctx, f, annotated)
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				infoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+".csv.zst"))
+				infoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+benchDataExt(ctx)))
 			}()
 		}
 	}
+	if err := newRunManifest(req, conns, clientFlags).write(fileName + ".manifest.json"); err != nil {
+		errorLn("Unable to write run manifest:", err)
+	} else {
+		infoLn(fmt.Sprintf("Run manifest written to %q\n", fileName+".manifest.json"))
+	}
 	monitor.OperationsReady(allOps, fileName, commandLine(ctx))
-	printAnalysis(ctx, allOps)
+	printAnalysis(ctx, annotated, nil)
 
 	err = conns.startStageAll(stageCleanup, time.Now(), false)
 	if err != nil {
@@ -249,33 +369,269 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	if err != nil {
 		errorLn("Failed to keep connection to all clients", err)
 	}
+	verifyCleanup(ctx, b.GetCommon())
 	infoLn("Cleanup done.\n")
 
-	return true, nil
+	errs, validation := opErrors(allOps)
+	return true, checkFailOn(ctx, errs, validation)
+}
+
+// clientOverrideFlags are the flags that can be overridden per client.
+// Other flags must be the same on all clients for the run to be coordinated
+// and cleaned up, or are files that are read by the server.
+var clientOverrideFlags = map[string]struct{}{
+	"concurrent":    {},
+	"obj.size":      {},
+	"obj.randsize":  {},
+	"obj.generator": {},
+	"part.size":     {},
+	"rps-limit":     {},
+}
+
+// parseClientOverrides splits per-client flag overrides from warp client hosts.
+// Overrides are specified as a query, for example "host:7761?concurrent=32&obj.size=1MiB".
+// Only flags in clientOverrideFlags can be overridden.
+func parseClientOverrides(hosts []string) ([]string, []map[string]string, error) {
+	overrides := make([]map[string]string, len(hosts))
+	dst := make([]string, len(hosts))
+	for i, host := range hosts {
+		host, query, ok := strings.Cut(host, "?")
+		dst[i] = host
+		if !ok {
+			continue
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("client %s: invalid overrides %q: %w", host, query, err)
+		}
+		overrides[i] = make(map[string]string, len(values))
+		for name, v := range values {
+			if _, ok := clientOverrideFlags[name]; !ok {
+				return nil, nil, fmt.Errorf("client %s: flag %q cannot be overridden per client", host, name)
+			}
+			if len(v) != 1 {
+				return nil, nil, fmt.Errorf("client %s: flag %q specified %d times", host, name, len(v))
+			}
+			overrides[i][name] = v[0]
+		}
+	}
+	return dst, overrides, nil
+}
+
+// hasFlag returns whether a flag with the given name exists.
+func hasFlag(flags []cli.Flag, name string) bool {
+	for _, flag := range flags {
+		if flag.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// showClusterProgress displays the progress of a distributed benchmark
+// with the cluster-wide throughput merged from the live stats of all clients.
+// The returned function stops the display.
+func showClusterProgress(ctx *cli.Context, monitor *api.Server, live func() api.LiveStats) (stop func()) {
+	benchDur := ctx.Duration("duration")
+	if globalQuiet || globalJSON || benchDur <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	pg := newProgressBar(int64(benchDur), pb.U_DURATION)
+	pg.SetCaption("Benchmarking:")
+	go func() {
+		defer close(stopped)
+		defer pg.Finish()
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+		var prev api.LiveOperation
+		prevT := time.Now()
+		for {
+			select {
+			case t := <-tick.C:
+				total := live().Total()
+				if total.FirstStart.IsZero() {
+					continue
+				}
+				if prev.FirstStart.IsZero() {
+					prevT = total.FirstStart
+				}
+				elapsed := min(t.Sub(total.FirstStart), benchDur)
+				secs := t.Sub(prevT).Seconds()
+				bps := bench.Throughput(float64(total.Bytes-prev.Bytes) / secs)
+				objs := float64(total.Objects-prev.Objects) / secs
+				prev, prevT = total, t
+				caption := fmt.Sprintf("%s, %.1f obj/s:", bps, objs)
+				if total.Bytes == 0 {
+					caption = fmt.Sprintf("%.1f obj/s:", objs)
+				}
+				pg.SetCaption(caption)
+				pg.Set64(int64(elapsed))
+				pg.Update()
+				monitor.InfoQuietln(fmt.Sprintf("Running benchmark: %0.0f%%, %s", 100*float64(elapsed)/float64(benchDur), strings.TrimSuffix(caption, ":")))
+			case <-done:
+				pg.Set64(int64(benchDur))
+				pg.Update()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
 }
 
 // connections keeps track of connections to clients.
 type connections struct {
 	info  func(data ...interface{})
 	errLn func(data ...interface{})
-	hosts []string
-	ws    []*websocket.Conn
-	si    serverInfo
+	// live is called with live stats received from client i, if set.
+	live func(i int, s api.LiveStats)
+	// stable is called after each status update from a client, if set.
+	// If it returns true the stage is stopped on the client.
+	stable func() bool
+	hosts  []string
+	ws     []*websocket.Conn
+	si     serverInfo
+	// scheme is "ws" or "wss" and dialer is used to connect to clients.
+	scheme string
+	dialer *websocket.Dialer
+	// heartbeat is the interval between pings sent to clients.
+	// A client that does not respond within timeout is considered lost.
+	heartbeat time.Duration
+	timeout   time.Duration
+
+	// mu protects the client liveness and information below.
+	mu       sync.Mutex
+	states   []string
+	lastSeen []time.Time
+	// infos and offsets are reported by clients and measured when connecting.
+	infos   []*clientInfo
+	offsets []time.Duration
+}
+
+// Client liveness states.
+const (
+	clientStateConnected    = "connected"
+	clientStateLost         = "lost"
+	clientStateDisconnected = "disconnected"
+)
+
+// warpClientDialer returns the websocket scheme and dialer used to connect to warp clients.
+// With --warp-client-tls clients are connected using TLS,
+// verified with the system CAs and --warp-client-ca, unless --insecure is set.
+func warpClientDialer(ctx *cli.Context) (string, *websocket.Dialer) {
+	if !ctx.Bool("warp-client-tls") {
+		return "ws", websocket.DefaultDialer
+	}
+	cfg := &tls.Config{
+		RootCAs:            mustGetSystemCertPool(),
+		InsecureSkipVerify: ctx.Bool("insecure"),
+		MinVersion:         tls.VersionTLS12,
+	}
+	if ca := ctx.String("warp-client-ca"); ca != "" {
+		caPEM, err := readPEM(ca)
+		fatalIf(probe.NewError(err), "Unable to read warp client CA certificates")
+		if !cfg.RootCAs.AppendCertsFromPEM(caPEM) {
+			fatal(errDummy(), "No CA certificates found in --warp-client-ca")
+		}
+	}
+	d := *websocket.DefaultDialer
+	d.TLSClientConfig = cfg
+	return "wss", &d
 }
 
 // newConnections creates connections (but does not connect) to clients.
-func newConnections(hosts []string) *connections {
+// secret is sent to clients to authenticate the server.
+func newConnections(hosts []string, secret string) *connections {
 	var c connections
 	c.si = serverInfo{
 		ID:      pRandASCII(20),
-		Secret:  "",
+		Secret:  secret,
 		Version: warpServerVersion,
 	}
 	c.hosts = hosts
 	c.ws = make([]*websocket.Conn, len(hosts))
+	c.scheme = "ws"
+	c.dialer = websocket.DefaultDialer
+	c.heartbeat = 5 * time.Second
+	c.timeout = 30 * time.Second
+	c.states = make([]string, len(hosts))
+	c.lastSeen = make([]time.Time, len(hosts))
+	c.infos = make([]*clientInfo, len(hosts))
+	c.offsets = make([]time.Duration, len(hosts))
+	for i := range c.states {
+		c.states[i] = clientStateDisconnected
+	}
 	return &c
 }
 
+// setState updates the liveness state of client i.
+func (c *connections) setState(i int, state string) {
+	c.mu.Lock()
+	c.states[i] = state
+	if state == clientStateConnected {
+		c.lastSeen[i] = time.Now()
+	}
+	c.mu.Unlock()
+}
+
+// seen records that client i responded.
+func (c *connections) seen(i int) {
+	c.mu.Lock()
+	c.lastSeen[i] = time.Now()
+	c.mu.Unlock()
+}
+
+// lost returns whether client i was lost during a stage.
+func (c *connections) lost(i int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.states[i] == clientStateLost
+}
+
+// clientStatus returns the liveness of all clients.
+func (c *connections) clientStatus() []api.ClientStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]api.ClientStatus, len(c.hosts))
+	for i, host := range c.hosts {
+		res[i] = api.ClientStatus{Host: host, State: c.states[i], LastSeen: c.lastSeen[i]}
+	}
+	return res
+}
+
+// startHeartbeat sends pings to client i on conn until the connection is closed.
+// Pongs received are recorded as the client being seen.
+func (c *connections) startHeartbeat(i int, conn *websocket.Conn) {
+	if c.heartbeat <= 0 {
+		return
+	}
+	conn.SetPongHandler(func(string) error {
+		c.seen(i)
+		return nil
+	})
+	go func() {
+		t := time.NewTicker(c.heartbeat)
+		defer t.Stop()
+		for range t.C {
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.heartbeat)); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// deadline returns the deadline for a request sent now.
+func (c *connections) deadline() time.Time {
+	if c.timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.timeout)
+}
+
 func (c *connections) errorF(format string, data ...interface{}) {
 	c.errLn(fmt.Sprintf(format, data...))
 }
@@ -287,6 +643,7 @@ func (c *connections) closeAll() {
 			conn.WriteJSON(serverRequest{Operation: serverReqDisconnect})
 			conn.Close()
 			c.ws[i] = nil
+			c.setState(i, clientStateDisconnected)
 		}
 	}
 }
@@ -306,9 +663,22 @@ func (c *connections) disconnect(i int) {
 		c.ws[i].WriteJSON(serverRequest{Operation: serverReqDisconnect})
 		c.ws[i].Close()
 		c.ws[i] = nil
+		c.setState(i, clientStateDisconnected)
 	}
 }
 
+// markLost closes the connection to a client that stopped responding.
+// Unlike disconnect the client is not asked to stop,
+// so results can be downloaded if it can be reached again.
+func (c *connections) markLost(i int) {
+	c.errorF("Client %v lost. Partial results will be downloaded if it reconnects.\n", c.hostName(i))
+	if c.ws[i] != nil {
+		c.ws[i].Close()
+		c.ws[i] = nil
+	}
+	c.setState(i, clientStateLost)
+}
+
 // roundTrip performs a roundtrip.
 func (c *connections) roundTrip(i int, req serverRequest) (*clientReply, error) {
 	conn := c.ws[i]
@@ -321,27 +691,50 @@ func (c *connections) roundTrip(i int, req serverRequest) (*clientReply, error)
 	for {
 		req.ClientIdx = i
 		conn := c.ws[i]
+		conn.SetWriteDeadline(c.deadline())
 		err := conn.WriteJSON(req)
 		if err != nil {
 			c.errLn(err)
-			if err := c.connect(i); err == nil {
+			if err := c.reconnect(i); err == nil {
 				continue
 			}
 			return nil, err
 		}
 		var resp clientReply
+		conn.SetReadDeadline(c.deadline())
 		err = conn.ReadJSON(&resp)
 		if err != nil {
 			c.errLn(err)
-			if err := c.connect(i); err == nil {
+			if err := c.reconnect(i); err == nil {
 				continue
 			}
 			return nil, err
 		}
+		c.seen(i)
 		return &resp, nil
 	}
 }
 
+// clientReconnectTimeout is how long the server will try to reconnect to a client.
+const clientReconnectTimeout = time.Minute
+
+// reconnect to a client after the connection was lost.
+// The client keeps the state of the benchmark, so the session is resumed.
+func (c *connections) reconnect(i int) error {
+	if c.ws[i] != nil {
+		c.ws[i].Close()
+		c.ws[i] = nil
+	}
+	deadline := time.Now().Add(clientReconnectTimeout)
+	for {
+		err := c.connect(i)
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
 // connect to a client.
 func (c *connections) connect(i int) error {
 	tries := 0
@@ -351,10 +744,10 @@ func (c *connections) connect(i int) error {
 			if !strings.Contains(host, ":") {
 				host += ":" + strconv.Itoa(warpServerDefaultPort)
 			}
-			u := url.URL{Scheme: "ws", Host: host, Path: "/ws"}
+			u := url.URL{Scheme: c.scheme, Host: host, Path: "/ws"}
 			c.info("Connecting to ", u.String())
 			var err error
-			c.ws[i], _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+			c.ws[i], _, err = c.dialer.Dial(u.String(), nil)
 			if err != nil {
 				return err
 			}
@@ -370,22 +763,49 @@ func (c *connections) connect(i int) error {
 			if err != nil {
 				return err
 			}
+			queued := false
+			for resp.Queued > 0 && resp.Err == "" {
+				// Wait for the session of another server to end.
+				c.info("Client ", host, ": Waiting for another server, queue position ", resp.Queued)
+				queued = true
+				resp = clientReply{}
+				err = c.ws[i].ReadJSON(&resp)
+				if err != nil {
+					return err
+				}
+			}
 			if resp.Err != "" {
 				return errors.New(resp.Err)
 			}
 
 			roundtrip := time.Since(sent)
+			if queued {
+				// The reply was not sent as a response to our request.
+				roundtrip = 0
+			}
 			// Add 50% of the roundtrip.
-			delta := time.Since(resp.Time.Add(roundtrip / 2))
+			offset := -time.Since(resp.Time.Add(roundtrip / 2))
+			c.mu.Lock()
+			c.offsets[i] = offset
+			if resp.Info != nil {
+				c.infos[i] = resp.Info
+			}
+			c.mu.Unlock()
+			delta := offset
 			if delta < 0 {
 				delta = -delta
 			}
 			if delta > time.Second {
 				return fmt.Errorf("host %v time delta too big (%v). Roundtrip took %v. Synchronize clock on client and retry", host, delta.Round(time.Millisecond), roundtrip.Round(time.Millisecond))
 			}
+			if resp.Resumed {
+				c.info("Client ", host, ": Session resumed")
+			}
 			return nil
 		}()
 		if err == nil {
+			c.setState(i, clientStateConnected)
+			c.startHeartbeat(i, c.ws[i])
 			return nil
 		}
 		if tries == 3 {
@@ -399,11 +819,13 @@ func (c *connections) connect(i int) error {
 }
 
 // startStage will start a stage at a specific time on a client.
-func (c *connections) startStage(i int, t time.Time, stage benchmarkStage) error {
+// If maxLag is > 0 the client will refuse to start the stage more than maxLag after t.
+func (c *connections) startStage(i int, t time.Time, stage benchmarkStage, maxLag time.Duration) error {
 	req := serverRequest{
 		Operation: serverReqStartStage,
 		Stage:     stage,
 		StartTime: t,
+		MaxLag:    maxLag,
 	}
 	resp, err := c.roundTrip(i, req)
 	if err != nil {
@@ -417,6 +839,80 @@ func (c *connections) startStage(i int, t time.Time, stage benchmarkStage) error
 	return nil
 }
 
+// stopStage will stop a running stage on a client.
+func (c *connections) stopStage(i int, stage benchmarkStage) error {
+	resp, err := c.roundTrip(i, serverRequest{Operation: serverReqStopStage, Stage: stage})
+	if err != nil {
+		return err
+	}
+	if resp.Err != "" {
+		return errors.New(resp.Err)
+	}
+	return nil
+}
+
+// readyStage returns whether a client is ready to start a stage.
+func (c *connections) readyStage(i int, stage benchmarkStage) error {
+	resp, err := c.roundTrip(i, serverRequest{Operation: serverReqReadyStage, Stage: stage})
+	if err != nil {
+		return err
+	}
+	if resp.Err != "" {
+		return errors.New(resp.Err)
+	}
+	if !resp.StageInfo.Ready {
+		return errors.New("client not ready")
+	}
+	return nil
+}
+
+// startStageSynced will start a stage on all connected clients in two steps.
+// First all clients must report they are ready, then the start time, wait from now, is sent to all clients.
+// Clients that are not ready or receive the start time more than maxLag late are excluded.
+// An error is returned if no clients remain.
+func (c *connections) startStageSynced(stage benchmarkStage, wait, maxLag time.Duration) error {
+	// each runs fn on all connected clients and excludes clients returning an error.
+	each := func(what string, fn func(i int) error) {
+		var wg sync.WaitGroup
+		for i, conn := range c.ws {
+			if conn == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := fn(i); err != nil {
+					c.errorF("Client %v excluded from stage %v, %s failed: %v\n", c.hostName(i), stage, what, err)
+					c.disconnect(i)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+	c.info("Waiting for clients to be ready for stage ", stage, "...")
+	each("ready check", func(i int) error {
+		return c.readyStage(i, stage)
+	})
+	startAt := time.Now().Add(wait)
+	c.info("Requesting stage ", stage, " start at ", startAt.Format("15:04:05.000"), "...")
+	each("start", func(i int) error {
+		return c.startStage(i, startAt, stage, maxLag)
+	})
+	var started int
+	for _, conn := range c.ws {
+		if conn != nil {
+			started++
+		}
+	}
+	if started == 0 {
+		return errors.New("no clients started")
+	}
+	if started < len(c.ws) {
+		c.errorF("Stage %v started on %d of %d clients\n", stage, started, len(c.ws))
+	}
+	return nil
+}
+
 // startStageAll will start a stage at a specific time on all connected clients.
 func (c *connections) startStageAll(stage benchmarkStage, startAt time.Time, failOnErr bool) error {
 	var wg sync.WaitGroup
@@ -431,7 +927,7 @@ func (c *connections) startStageAll(stage benchmarkStage, startAt time.Time, fai
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			err := c.startStage(i, startAt, stage)
+			err := c.startStage(i, startAt, stage, 0)
 			if err != nil {
 				if failOnErr {
 					fatalIf(probe.NewError(err), "Stage start failed.")
@@ -450,6 +946,54 @@ func (c *connections) startStageAll(stage benchmarkStage, startAt time.Time, fai
 	return gerr
 }
 
+// downloadClientOps will download operations from a client one chunk at a time.
+// Failed chunks are requested again up to 3 times, resuming the download.
+func (c *connections) downloadClientOps(i int) (bench.Operations, error) {
+	var ops bench.Operations
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	for chunk, chunks, tries := 0, 1, 0; chunk < chunks; {
+		ops2, n, err := func() (bench.Operations, int, error) {
+			resp, err := c.roundTrip(i, serverRequest{Operation: serverReqSendOps, Chunk: chunk})
+			if err != nil {
+				return nil, 0, err
+			}
+			if resp.Err != "" {
+				return nil, 0, errors.New(resp.Err)
+			}
+			if resp.Chunk != chunk {
+				return nil, 0, fmt.Errorf("received chunk %d, expected %d", resp.Chunk, chunk)
+			}
+			if resp.Chunks == 0 {
+				return nil, 0, nil
+			}
+			b, err := dec.DecodeAll(resp.OpsChunk, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			ops, err := bench.OperationsFromCSV(bytes.NewReader(b), false, 0, 0, nil)
+			return ops, resp.Chunks, err
+		}()
+		if err != nil {
+			if c.ws[i] == nil || tries == 3 {
+				return nil, err
+			}
+			tries++
+			c.errorF("Client %v chunk %d download error: %v, retrying...\n", c.hostName(i), chunk, err)
+			continue
+		}
+		ops = append(ops, ops2...)
+		chunk, chunks, tries = chunk+1, n, 0
+		if chunks > 1 {
+			c.info("Client ", c.hostName(i), ": Downloaded chunk ", chunk, "/", chunks, ".")
+		}
+	}
+	return ops, nil
+}
+
 // downloadOps will download operations from all connected clients.
 // If an error is encountered the result will be ignored.
 func (c *connections) downloadOps() []bench.Operations {
@@ -458,25 +1002,29 @@ func (c *connections) downloadOps() []bench.Operations {
 	c.info("Downloading operations...")
 	res := make([]bench.Operations, 0, len(c.ws))
 	for i, conn := range c.ws {
-		if conn == nil {
+		lost := c.lost(i)
+		if conn == nil && !lost {
 			continue
 		}
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			resp, err := c.roundTrip(i, serverRequest{Operation: serverReqSendOps})
-			if err != nil {
-				c.errorF("Client %v download returned error: %v\n", c.hostName(i), resp.Err)
-				return
+			if lost {
+				if err := c.recoverLost(i); err != nil {
+					c.errorF("Client %v could not be recovered: %v\n", c.hostName(i), err)
+					return
+				}
+				c.info("Client ", c.hostName(i), ": Recovered, downloading partial results.")
 			}
-			if resp.Err != "" {
-				c.errorF("Client %v returned error: %v\n", c.hostName(i), resp.Err)
+			ops, err := c.downloadClientOps(i)
+			if err != nil {
+				c.errorF("Client %v download returned error: %v\n", c.hostName(i), err)
 				return
 			}
 			c.info("Client ", c.hostName(i), ": Operations downloaded.")
 
 			mu.Lock()
-			res = append(res, resp.Ops)
+			res = append(res, ops)
 			mu.Unlock()
 		}(i)
 	}
@@ -484,7 +1032,34 @@ func (c *connections) downloadOps() []bench.Operations {
 	return res
 }
 
+// recoverLost reconnects to a lost client, stops the benchmark stage
+// and waits for the client to make its results available.
+func (c *connections) recoverLost(i int) error {
+	if err := c.connect(i); err != nil {
+		return err
+	}
+	if err := c.stopStage(i, stageBenchmark); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(clientReconnectTimeout)
+	for time.Now().Before(deadline) {
+		resp, err := c.roundTrip(i, serverRequest{Operation: serverReqStageStatus, Stage: stageBenchmark})
+		if err != nil {
+			return err
+		}
+		if resp.Err != "" {
+			return errors.New(resp.Err)
+		}
+		if resp.StageInfo.Finished {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return errors.New("timeout waiting for benchmark to stop")
+}
+
 // waitForStage will wait for stage completion on all clients.
+// Clients that stop responding are marked as lost.
 func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common *bench.Common) error {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -496,6 +1071,7 @@ func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
+			stopped := false
 			for {
 				req := serverRequest{
 					Operation: serverReqStageStatus,
@@ -503,7 +1079,7 @@ func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common
 				}
 				resp, err := c.roundTrip(i, req)
 				if err != nil {
-					c.disconnect(i)
+					c.markLost(i)
 					if failOnErr {
 						fatalIf(probe.NewError(err), "Stage failed.")
 					}
@@ -518,6 +1094,15 @@ func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common
 					c.errorF("Client %v returned error: %v\n", c.hostName(i), resp.Err)
 					return
 				}
+				if resp.StageInfo.Live != nil && c.live != nil {
+					c.live(i, *resp.StageInfo.Live)
+				}
+				if c.stable != nil && !stopped && !resp.StageInfo.Finished && c.stable() {
+					stopped = true
+					if err := c.stopStage(i, stage); err != nil {
+						c.errorF("Client %v stopping stage returned error: %v\n", c.hostName(i), err)
+					}
+				}
 				if resp.StageInfo.Finished {
 					// Merge custom
 					if len(resp.StageInfo.Custom) > 0 {
@@ -583,3 +1168,77 @@ func flagToJSON(ctx *cli.Context, flag cli.Flag) (string, error) {
 	}
 	return "", nil
 }
+
+// clusterAutoTerm decides auto termination from the live stats merged from all clients.
+type clusterAutoTerm struct {
+	opts    bench.AutoTermOptions
+	mu      sync.Mutex
+	samples map[string][]bench.AutoTermSample
+	stopped bool
+	infoLn  func(data ...interface{})
+}
+
+func newClusterAutoTerm(opts bench.AutoTermOptions, infoLn func(data ...interface{})) *clusterAutoTerm {
+	return &clusterAutoTerm{opts: opts, samples: make(map[string][]bench.AutoTermSample, 4), infoLn: infoLn}
+}
+
+// add a sample of the merged live stats.
+func (a *clusterAutoTerm) add(s api.LiveStats) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for op, o := range s.Operations {
+		sample := bench.AutoTermSample{
+			Time:     now,
+			Requests: o.Requests,
+			Bytes:    o.Bytes,
+			Objects:  o.Objects,
+			ReqDur:   time.Duration(o.ReqDurNanos),
+		}
+		samples := a.samples[op]
+		switch {
+		case len(samples) == 1 && samples[0].Requests == sample.Requests:
+			// Nothing has happened yet, start from here.
+			samples[0] = sample
+		case len(samples) > 1 && now.Sub(samples[len(samples)-2].Time) < 250*time.Millisecond:
+			// Clients report independently, only keep the latest update.
+			samples[len(samples)-1] = sample
+		default:
+			samples = append(samples, sample)
+		}
+		a.samples[op] = samples
+	}
+}
+
+// stable returns whether all active operation types are stable.
+func (a *clusterAutoTerm) stable() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopped {
+		return true
+	}
+	var desc []string
+	var window time.Duration
+	for _, op := range slices.Sorted(maps.Keys(a.samples)) {
+		samples := a.samples[op]
+		if len(samples) < 2 {
+			// Not active.
+			continue
+		}
+		d, dur, ok := a.opts.StableSamples(samples)
+		if !ok {
+			return false
+		}
+		if len(a.samples) > 1 {
+			d = op + " " + d
+		}
+		desc = append(desc, d)
+		window = max(window, dur)
+	}
+	if len(desc) == 0 {
+		return false
+	}
+	a.stopped = true
+	a.infoLn(fmt.Sprintf("All clients: %s within %f%% for %v. Terminating benchmark.", strings.Join(desc, ", "), a.opts.Threshold*100, window))
+	return true
+}
@@ -0,0 +1,61 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseClientOverrides(t *testing.T) {
+	hosts, overrides, err := parseClientOverrides([]string{
+		"client-1:7761",
+		"client-2:7761?concurrent=32",
+		"client-3:7761?concurrent=64&obj.size=1MiB",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHosts := []string{"client-1:7761", "client-2:7761", "client-3:7761"}
+	if !reflect.DeepEqual(hosts, wantHosts) {
+		t.Errorf("got hosts %v, want %v", hosts, wantHosts)
+	}
+	wantOverrides := []map[string]string{
+		nil,
+		{"concurrent": "32"},
+		{"concurrent": "64", "obj.size": "1MiB"},
+	}
+	if !reflect.DeepEqual(overrides, wantOverrides) {
+		t.Errorf("got overrides %v, want %v", overrides, wantOverrides)
+	}
+
+	for _, host := range []string{
+		"client:7761?duration=1m",
+		"client:7761?bucket=other",
+		"client:7761?prefix=x",
+		"client:7761?seed=1",
+		"client:7761?noclear=true",
+		"client:7761?tls-client-cert=cert.pem",
+		"client:7761?concurrent=1&concurrent=2",
+		"client:7761?concurrent=%zz",
+	} {
+		if _, _, err := parseClientOverrides([]string{host}); err == nil {
+			t.Errorf("%s: accepted", host)
+		}
+	}
+}
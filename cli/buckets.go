@@ -0,0 +1,116 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var bucketsFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "buckets",
+		Value: 1000,
+		Usage: "Number of buckets to prepare. Existing buckets are reused.",
+	},
+	cli.IntFlag{
+		Name:  "heads",
+		Value: 10,
+		Usage: "Number of HeadBucket requests done by each thread after each ListBuckets request",
+	},
+	cli.IntFlag{
+		Name:  "churn",
+		Value: 0,
+		Usage: "Number of MakeBucket/RemoveBucket pairs done by each thread after each ListBuckets request",
+	},
+	cli.IntFlag{
+		Name:  "policies",
+		Value: 0,
+		Usage: "Number of PutBucketPolicy/GetBucketPolicy pairs done by each thread after each ListBuckets request",
+	},
+	cli.IntFlag{
+		Name:  "tagging",
+		Value: 0,
+		Usage: "Number of PutBucketTagging/GetBucketTagging pairs done by each thread after each ListBuckets request",
+	},
+}
+
+var BucketsCombinedFlags = combineFlags(globalFlags, ioFlags, bucketsFlags, benchFlags, analyzeFlags)
+
+var bucketsCmd = cli.Command{
+	Name:   "buckets",
+	Usage:  "benchmark bucket operations with many buckets",
+	Action: mainBuckets,
+	Before: setGlobalsFromContext,
+	Flags:  BucketsCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#buckets
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainBuckets is the entry point for buckets command.
+func mainBuckets(ctx *cli.Context) error {
+	checkBucketsSyntax(ctx)
+	b := bench.Buckets{
+		Common:        getCommon(ctx, nil),
+		CreateBuckets: ctx.Int("buckets"),
+		HeadPerList:   ctx.Int("heads"),
+
+		ChurnPerList:   ctx.Int("churn"),
+		PolicyPerList:  ctx.Int("policies"),
+		TaggingPerList: ctx.Int("tagging"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkBucketsSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("buckets") < 1 {
+		console.Fatal("At least one bucket must be tested")
+	}
+	for _, flag := range []string{"heads", "churn", "policies", "tagging"} {
+		if ctx.Int(flag) < 0 {
+			console.Fatalf("--%s cannot be negative\n", flag)
+		}
+	}
+	// Bucket names can be at most 63 characters.
+	if name := fmt.Sprintf("%s-%d", ctx.String("bucket"), ctx.Int("buckets")-1); len(name) > 63 {
+		console.Fatalf("Bucket name %q is too long, use a shorter --bucket\n", name)
+	}
+	// Buckets created with --churn have a 16 character random suffix.
+	if name := ctx.String("bucket") + "-c0123456789abcdef"; ctx.Int("churn") > 0 && len(name) > 63 {
+		console.Fatalf("Bucket name %q is too long for --churn, use a shorter --bucket\n", name)
+	}
+	if ctx.Bool("cleanup.verify") {
+		console.Fatal("--cleanup.verify cannot be used with buckets")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
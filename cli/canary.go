@@ -0,0 +1,363 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/api"
+	"github.com/minio/warp/pkg/bench"
+	"golang.org/x/time/rate"
+)
+
+var canaryFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 100,
+		Usage: "Number of objects to upload.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "64KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.Float64Flag{
+		Name:  "get-distrib",
+		Usage: "The amount of GET operations.",
+		Value: 45,
+	},
+	cli.Float64Flag{
+		Name:  "stat-distrib",
+		Usage: "The amount of STAT operations.",
+		Value: 30,
+	},
+	cli.Float64Flag{
+		Name:  "put-distrib",
+		Usage: "The amount of PUT operations.",
+		Value: 15,
+	},
+	cli.Float64Flag{
+		Name:  "delete-distrib",
+		Usage: "The amount of DELETE operations. Must be same or lower than -put-distrib",
+		Value: 10,
+	},
+	cli.Float64Flag{
+		Name:  "rate",
+		Value: 10,
+		Usage: "Operations per second across all threads",
+	},
+	cli.DurationFlag{
+		Name:  "interval",
+		Value: time.Minute,
+		Usage: "Evaluate SLOs at this interval",
+	},
+	cli.DurationFlag{
+		Name:  "window",
+		Value: 10 * time.Minute,
+		Usage: "Evaluate SLOs over operations that ended within this window",
+	},
+	cli.StringFlag{
+		Name:  "slo.availability",
+		Value: "99.9%",
+		Usage: "Minimum percentage of successful operations",
+	},
+	cli.DurationFlag{
+		Name:  "slo.p99",
+		Value: time.Second,
+		Usage: "Maximum 99th percentile request time of successful operations",
+	},
+	cli.StringSliceFlag{
+		Name:  "webhook",
+		Usage: "POST the SLO status as JSON to this URL when an SLO is breached or recovers. Can be used multiple times",
+	},
+	cli.StringFlag{
+		Name:  serverFlagName,
+		Usage: "Open a webserver with live stats and SLO status, eg: localhost:7762",
+	},
+}
+
+var CanaryCombinedFlags = combineFlags(globalFlags, ioFlags, canaryFlags, genFlags)
+
+var canaryCmd = cli.Command{
+	Name:   "canary",
+	Usage:  "run a light mixed workload and evaluate SLOs until stopped",
+	Action: mainCanary,
+	Before: setGlobalsFromContext,
+	Flags:  CanaryCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#canary
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainCanary is the entry point for canary command.
+func mainCanary(ctx *cli.Context) error {
+	checkCanarySyntax(ctx)
+	sse := newSSE(ctx)
+	dist := bench.MixedDistribution{
+		Distribution: map[string]float64{
+			http.MethodGet:    ctx.Float64("get-distrib"),
+			"STAT":            ctx.Float64("stat-distrib"),
+			http.MethodPut:    ctx.Float64("put-distrib"),
+			http.MethodDelete: ctx.Float64("delete-distrib"),
+		},
+	}
+	err := dist.Generate(ctx.Int("objects") * 2)
+	fatalIf(probe.NewError(err), "Invalid distribution")
+	b := bench.Mixed{
+		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects: ctx.Int("objects"),
+		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
+		StatOpts: minio.StatObjectOptions{
+			ServerSideEncryption: sse,
+		},
+		Dist: &dist,
+	}
+	c := b.GetCommon()
+	c.Error = printError
+	c.Clear = true
+	c.DiscardOutput = true
+	c.RpsLimiter = rate.NewLimiter(rate.Limit(ctx.Float64("rate")), 1)
+
+	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
+	monitor.SetLnLoggers(printInfo, printError)
+	defer monitor.Done()
+
+	live, liveCh := newLiveCollector()
+	slo, sloCh := newSLOEvaluator(ctx)
+	c.ExtraOut = append(c.ExtraOut, liveCh, sloCh)
+
+	runCtx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	startStatsD(ctx, runCtx, live)
+
+	monitor.InfoLn("Preparing canary.")
+	fatalIf(probe.NewError(b.Prepare(runCtx)), "Error preparing canary")
+
+	start := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := b.Start(runCtx, start)
+		if err != nil {
+			printError("Canary error:", err)
+		}
+	}()
+	close(start)
+	monitor.InfoLn(fmt.Sprintf("Canary running. Evaluating SLOs every %v over %v. Press Ctrl+C to stop.", ctx.Duration("interval"), ctx.Duration("window")))
+
+	liveTicker := time.NewTicker(time.Second)
+	defer liveTicker.Stop()
+	evalTicker := time.NewTicker(ctx.Duration("interval"))
+	defer evalTicker.Stop()
+	breached := false
+	for running := true; running; {
+		select {
+		case <-runCtx.Done():
+			running = false
+		case <-liveTicker.C:
+			if l := live.get(); l != nil {
+				monitor.SetLive(*l)
+			}
+		case <-evalTicker.C:
+			st := slo.evaluate(time.Now())
+			monitor.SetSLO(st)
+			printSLOStatus(st)
+			if st.Breached != breached {
+				breached = st.Breached
+				postWebhooks(ctx.StringSlice("webhook"), st)
+			}
+		}
+	}
+
+	monitor.InfoLn("Stopping canary...")
+	<-done
+	monitor.InfoLn("Starting cleanup...")
+	b.Cleanup(context.Background())
+	monitor.InfoLn("Cleanup Done.")
+	return nil
+}
+
+// printSLOStatus prints the result of an SLO evaluation.
+func printSLOStatus(st api.SLOStatus) {
+	if st.Requests == 0 {
+		printInfo("SLO: No operations in window")
+		return
+	}
+	msg := fmt.Sprintf("SLO: %d requests, availability %.3f%% (target %v%%), p99 %v (target %v)",
+		st.Requests, st.Availability, st.AvailabilityTarget,
+		time.Duration(st.P99Nanos).Round(time.Millisecond/10), time.Duration(st.P99TargetNanos))
+	if st.Breached {
+		printError(msg, "BREACHED:", st.Breaches)
+		return
+	}
+	printInfo(msg)
+}
+
+// postWebhooks sends the status as JSON to all urls.
+// Failures are printed, but otherwise ignored.
+func postWebhooks(urls []string, st api.SLOStatus) {
+	if len(urls) == 0 {
+		return
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		printError("Unable to encode webhook:", err)
+		return
+	}
+	cl := http.Client{Timeout: 10 * time.Second}
+	for _, u := range urls {
+		resp, err := cl.Post(u, "application/json", bytes.NewReader(b))
+		if err != nil {
+			printError("Webhook", u, "failed:", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			printError("Webhook", u, "returned", resp.Status)
+		}
+	}
+}
+
+// sloSample is a single operation within the SLO window.
+type sloSample struct {
+	end    time.Time
+	dur    time.Duration
+	failed bool
+}
+
+// sloEvaluator keeps operations within a rolling window and evaluates SLOs on them.
+type sloEvaluator struct {
+	mu      sync.Mutex
+	samples []sloSample
+
+	window       time.Duration
+	availability float64
+	p99          time.Duration
+}
+
+// newSLOEvaluator returns an evaluator and the channel operations should be sent to.
+func newSLOEvaluator(ctx *cli.Context) (*sloEvaluator, chan<- bench.Operation) {
+	e := &sloEvaluator{
+		window:       ctx.Duration("window"),
+		availability: parsePercent(ctx, "slo.availability"),
+		p99:          ctx.Duration("slo.p99"),
+	}
+	ch := make(chan bench.Operation, 1000)
+	go func() {
+		for op := range ch {
+			e.mu.Lock()
+			e.samples = append(e.samples, sloSample{end: op.End, dur: op.End.Sub(op.Start), failed: op.Err != ""})
+			e.mu.Unlock()
+		}
+	}()
+	return e, ch
+}
+
+// evaluate removes operations that ended before the window and evaluates the rest.
+func (e *sloEvaluator) evaluate(now time.Time) api.SLOStatus {
+	e.mu.Lock()
+	cutoff := now.Add(-e.window)
+	keep := e.samples[:0]
+	for _, s := range e.samples {
+		if !s.end.Before(cutoff) {
+			keep = append(keep, s)
+		}
+	}
+	e.samples = keep
+	durs := make([]time.Duration, 0, len(keep))
+	var errs int64
+	for _, s := range keep {
+		if s.failed {
+			errs++
+			continue
+		}
+		durs = append(durs, s.dur)
+	}
+	e.mu.Unlock()
+
+	st := api.SLOStatus{
+		Time:               now,
+		WindowNanos:        int64(e.window),
+		Requests:           int64(len(durs)) + errs,
+		Errors:             errs,
+		AvailabilityTarget: e.availability,
+		P99TargetNanos:     int64(e.p99),
+	}
+	if st.Requests == 0 {
+		return st
+	}
+	st.Availability = 100 * float64(st.Requests-errs) / float64(st.Requests)
+	if len(durs) > 0 {
+		slices.Sort(durs)
+		st.P99Nanos = int64(durs[int(math.Ceil(0.99*float64(len(durs))))-1])
+	}
+	if st.Availability < e.availability {
+		st.Breaches = append(st.Breaches, fmt.Sprintf("availability %.3f%% below %v%%", st.Availability, e.availability))
+	}
+	if time.Duration(st.P99Nanos) > e.p99 {
+		st.Breaches = append(st.Breaches, fmt.Sprintf("p99 %v above %v", time.Duration(st.P99Nanos).Round(time.Millisecond/10), e.p99))
+	}
+	st.Breached = len(st.Breaches) > 0
+	return st
+}
+
+func checkCanarySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if ctx.Float64("rate") <= 0 {
+		console.Fatal("--rate must be positive")
+	}
+	if ctx.Duration("interval") <= 0 {
+		console.Fatal("--interval must be positive")
+	}
+	if ctx.Duration("window") < ctx.Duration("interval") {
+		console.Fatal("--window must be at least --interval")
+	}
+	if pct := parsePercent(ctx, "slo.availability"); pct < 0 || pct > 100 {
+		console.Fatal("--slo.availability must be between 0% and 100%")
+	}
+	if ctx.Duration("slo.p99") <= 0 {
+		console.Fatal("--slo.p99 must be positive")
+	}
+}
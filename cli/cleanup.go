@@ -0,0 +1,91 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// verifyCleanup lists the bucket after cleanup and reports leftover data.
+func verifyCleanup(ctx *cli.Context, c *bench.Common) {
+	if !ctx.Bool("cleanup.verify") || ctx.Bool("keep-data") || ctx.Bool("noclear") {
+		return
+	}
+	if ctx.Bool("list-existing") || ctx.String("manifest.in") != "" {
+		printInfo("Skipping cleanup verification, since existing data was used.")
+		return
+	}
+	bg := context.Background()
+	prefix := ctx.String("prefix")
+	versioned := c.Versioned
+	var cl bench.ObjectClient
+	var s3 *minio.Client
+	if c.ObjectClient != nil {
+		// Other providers have no versions or incomplete uploads to check.
+		ocl, done := c.ObjectClient()
+		defer done()
+		cl = ocl
+	} else {
+		s3cl, done := c.Client()
+		defer done()
+		s3, cl = s3cl, bench.S3ObjectClient(s3cl)
+		if vc, err := s3.GetBucketVersioning(bg, c.Bucket); err == nil {
+			versioned = versioned || vc.Enabled() || vc.Suspended()
+		}
+	}
+	var objects, versions int
+	var size int64
+	for obj := range cl.ListObjects(bg, c.Bucket, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithVersions: versioned,
+	}) {
+		if obj.Err != nil {
+			printError(fmt.Sprintf("Unable to verify cleanup: %v", obj.Err))
+			return
+		}
+		if obj.IsDeleteMarker {
+			versions++
+			continue
+		}
+		objects++
+		size += obj.Size
+	}
+	var uploads int
+	if s3 != nil {
+		for up := range s3.ListIncompleteUploads(bg, c.Bucket, prefix, true) {
+			if up.Err != nil {
+				printError(fmt.Sprintf("Unable to list incomplete uploads: %v", up.Err))
+				break
+			}
+			uploads++
+		}
+	}
+	if objects+versions+uploads == 0 {
+		printInfo(fmt.Sprintf("Cleanup verified. No data left in bucket %q.", c.Bucket))
+		return
+	}
+	printError(fmt.Sprintf("Cleanup incomplete. Bucket %q contains %d objects (%s), %d delete markers and %d incomplete uploads.",
+		c.Bucket, objects, humanize.IBytes(uint64(size)), versions, uploads))
+}
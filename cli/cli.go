@@ -91,6 +91,12 @@ func init() {
 		deleteCmd,
 		listCmd,
 		statCmd,
+		copyCmd,
+		composeCmd,
+		lifecycleCmd,
+		notifyCmd,
+		presignedCmd,
+		bucketsCmd,
 		selectCmd,
 		versionedCmd,
 		retentionCmd,
@@ -98,6 +104,11 @@ func init() {
 		zipCmd,
 		snowballCmd,
 		fanoutCmd,
+		ingestCmd,
+		multipartPutCmd,
+		wormCmd,
+		replicationCmd,
+		consistencyCmd,
 	}
 	b := []cli.Command{
 		analyzeCmd,
@@ -105,6 +116,11 @@ func init() {
 		mergeCmd,
 		clientCmd,
 		runCmd,
+		experimentCmd,
+		doctorCmd,
+		annotateCmd,
+		canaryCmd,
+		replayCmd,
 	}
 	appCmds = append(append(appCmds, a...), b...)
 	benchCmds = a
@@ -19,6 +19,7 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -27,9 +28,11 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/minio/cli"
@@ -42,6 +45,7 @@ import (
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/pkg/v2/ellipses"
 	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/bench"
 	"golang.org/x/net/http2"
 )
 
@@ -53,15 +57,54 @@ const (
 )
 
 func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
+	return newClientCreds(ctx, ctx.String("access-key"), ctx.String("secret-key"))
+}
+
+// newClientCreds returns a client selector like newClient, but using the supplied credentials.
+func newClientCreds(ctx *cli.Context, accessKey, secretKey string) func() (cl *minio.Client, done func()) {
+	return newClientHosts(ctx, ctx.String("host"), accessKey, secretKey)
+}
+
+// newClientHosts returns a client selector like newClient, but using the supplied hosts and credentials.
+func newClientHosts(ctx *cli.Context, host, accessKey, secretKey string) func() (cl *minio.Client, done func()) {
+	hosts := parseHosts(host, ctx.Bool("resolve-host"))
+	creds := newCredentials(ctx, accessKey, secretKey)
+	return selectHost(ctx, hosts, func(host string) *minio.Client {
+		cl, err := getClient(ctx, host, creds)
+		fatalIf(probe.NewError(err), "Unable to create MinIO client")
+		return cl
+	})
+}
+
+// newObjectClient returns a selector for native clients of the provider set with --provider.
+// Returns nil for S3, since benchmarks then use the S3 client.
+func newObjectClient(ctx *cli.Context) func() (cl bench.ObjectClient, done func()) {
+	p := bench.Provider(ctx.String("provider"))
+	if p == bench.ProviderS3 {
+		return nil
+	}
 	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	return selectHost(ctx, hosts, func(host string) bench.ObjectClient {
+		u := &url.URL{Scheme: "http", Host: host}
+		if ctx.Bool("tls") {
+			u.Scheme = "https"
+		}
+		tr := bench.WireCounter(bench.StatusRecorder(clientTransport(ctx)))
+		cl, err := bench.NewObjectClient(p, u, ctx.String("access-key"), ctx.String("secret-key"), tr)
+		fatalIf(probe.NewError(err), "Unable to create %s client", p)
+		return cl
+	})
+}
+
+// selectHost creates a client for each host using newHost and returns a selector
+// handing out the clients as specified by --host-select.
+func selectHost[T any](ctx *cli.Context, hosts []string, newHost func(host string) T) func() (cl T, done func()) {
 	switch len(hosts) {
 	case 0:
 		fatalIf(probe.NewError(errors.New("no host defined")), "Unable to create MinIO client")
 	case 1:
-		cl, err := getClient(ctx, hosts[0])
-		fatalIf(probe.NewError(err), "Unable to create MinIO client")
-
-		return func() (*minio.Client, func()) {
+		cl := newHost(hosts[0])
+		return func() (T, func()) {
 			return cl, func() {}
 		}
 	}
@@ -71,13 +114,11 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 		// Do round-robin.
 		var current int
 		var mu sync.Mutex
-		clients := make([]*minio.Client, len(hosts))
+		clients := make([]T, len(hosts))
 		for i := range hosts {
-			cl, err := getClient(ctx, hosts[i])
-			fatalIf(probe.NewError(err), "Unable to create MinIO client")
-			clients[i] = cl
+			clients[i] = newHost(hosts[i])
 		}
-		return func() (*minio.Client, func()) {
+		return func() (T, func()) {
 			mu.Lock()
 			now := current % len(clients)
 			current++
@@ -88,11 +129,9 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 		// Keep track of handed out clients.
 		// Select random between the clients that have the fewest handed out.
 		var mu sync.Mutex
-		clients := make([]*minio.Client, len(hosts))
+		clients := make([]T, len(hosts))
 		for i := range hosts {
-			cl, err := getClient(ctx, hosts[i])
-			fatalIf(probe.NewError(err), "Unable to create MinIO client")
-			clients[i] = cl
+			clients[i] = newHost(hosts[i])
 		}
 		running := make([]int, len(hosts))
 		lastFinished := make([]time.Time, len(hosts))
@@ -123,7 +162,7 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 			}
 			return earliestIdx
 		}
-		return func() (*minio.Client, func()) {
+		return func() (T, func()) {
 			mu.Lock()
 			idx := find()
 			running[idx]++
@@ -141,35 +180,75 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 		}
 	}
 	console.Fatalln("unknown host-select:", hostSelect)
-	return nil
+	panic("unreachable")
 }
 
-// getClient creates a client with the specified host and the options set in the context.
-func getClient(ctx *cli.Context, host string) (*minio.Client, error) {
-	var creds *credentials.Credentials
-	switch strings.ToUpper(ctx.String("signature")) {
-	case "S3V4":
-		// if Signature version '4' use NewV4 directly.
-		creds = credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), "")
-	case "S3V2":
-		// if Signature version '2' use NewV2 directly.
-		creds = credentials.NewStaticV2(ctx.String("access-key"), ctx.String("secret-key"), "")
-	default:
-		fatal(probe.NewError(errors.New("unknown signature method. S3V2 and S3V4 is available")), strings.ToUpper(ctx.String("signature")))
+// newThreadClient returns a function creating a client with its own connection.
+// Hosts are assigned round-robin to the created clients.
+// The conn function of a client returns the local address of its latest connection.
+// Returns nil if --client-per-thread is not set.
+func newThreadClient(ctx *cli.Context) func() (cl *minio.Client, conn func() string) {
+	if !ctx.Bool("client-per-thread") {
+		return nil
+	}
+	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	if len(hosts) == 0 {
+		fatalIf(probe.NewError(errors.New("no host defined")), "Unable to create MinIO client")
+	}
+	creds := newCredentials(ctx, ctx.String("access-key"), ctx.String("secret-key"))
+	var mu sync.Mutex
+	var current int
+	return func() (*minio.Client, func() string) {
+		mu.Lock()
+		host := hosts[current%len(hosts)]
+		current++
+		mu.Unlock()
+		var local atomic.Value
+		local.Store("")
+		dialer := newDialer(ctx)
+		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer(ctx, network, addr)
+			if err == nil {
+				local.Store(conn.LocalAddr().String())
+			}
+			return conn, err
+		}
+		cl, err := getClientTransport(ctx, host, creds, clientTransportDial(ctx, dial, 1))
+		fatalIf(probe.NewError(err), "Unable to create MinIO client")
+		return cl, func() string {
+			return local.Load().(string)
+		}
 	}
+}
+
+// getClient creates a client with the specified host, credentials and the options set in the context.
+func getClient(ctx *cli.Context, host string, creds *credentials.Credentials) (*minio.Client, error) {
+	return getClientTransport(ctx, host, creds, clientTransport(ctx))
+}
+
+// getClientTransport creates a client like getClient, but using the supplied transport.
+func getClientTransport(ctx *cli.Context, host string, creds *credentials.Credentials, tr http.RoundTripper) (*minio.Client, error) {
 	lookup := minio.BucketLookupAuto
 	if ctx.String("lookup") == "host" {
 		lookup = minio.BucketLookupDNS
 	} else if ctx.String("lookup") == "path" {
 		lookup = minio.BucketLookupPath
 	}
+	if root, ok := fsBackend(ctx); ok {
+		// Requests are served from the filesystem, so host and credentials are not used.
+		host = fsBackendHost
+		creds = credentials.NewStatic("", "", "", credentials.SignatureAnonymous)
+		tr = bench.FSTransport(root)
+	}
+	_, trailing := parseChecksum(ctx)
 	cl, err := minio.New(host, &minio.Options{
-		Creds:        creds,
-		Secure:       ctx.Bool("tls"),
-		Region:       ctx.String("region"),
-		BucketLookup: lookup,
-		CustomMD5:    md5simd.NewServer().NewHash,
-		Transport:    clientTransport(ctx),
+		Creds:           creds,
+		Secure:          ctx.Bool("tls"),
+		Region:          ctx.String("region"),
+		BucketLookup:    lookup,
+		CustomMD5:       md5simd.NewServer().NewHash,
+		Transport:       bench.WireCounter(bench.StatusRecorder(tr)),
+		TrailingHeaders: trailing,
 	})
 	if err != nil {
 		return nil, err
@@ -183,17 +262,254 @@ func getClient(ctx *cli.Context, host string) (*minio.Client, error) {
 	return cl, nil
 }
 
+// fsBackendHost is the endpoint recorded for operations on the filesystem backend.
+const fsBackendHost = "fs"
+
+// fsBackend returns the directory to use if the filesystem backend is selected.
+func fsBackend(ctx *cli.Context) (root string, ok bool) {
+	return strings.CutPrefix(ctx.String("backend"), "fs:")
+}
+
+// checkBackend validates the --backend flag.
+func checkBackend(ctx *cli.Context) {
+	b := ctx.String("backend")
+	if b == "" {
+		return
+	}
+	root, ok := fsBackend(ctx)
+	if !ok {
+		fatal(errDummy(), "unknown --backend %q. Use 'fs:/path' to benchmark a filesystem", b)
+	}
+	st, err := os.Stat(root)
+	if err == nil && !st.IsDir() {
+		err = fmt.Errorf("%s is not a directory", root)
+	}
+	fatalIf(probe.NewError(err), "Invalid --backend directory")
+	if _, trailing := parseChecksum(ctx); trailing {
+		fatal(errDummy(), "--checksum with trailing checksums cannot be used with --backend")
+	}
+	if ctx.String("serverprof") != "" {
+		fatal(errDummy(), "--serverprof cannot be used with --backend")
+	}
+}
+
+// checkProvider validates the --provider flag.
+func checkProvider(ctx *cli.Context) {
+	p := bench.Provider(ctx.String("provider"))
+	switch p {
+	case bench.ProviderS3:
+		return
+	case bench.ProviderAzure, bench.ProviderGCS:
+	default:
+		fatal(errDummy(), "unknown --provider %q. Use 's3', 'azure' or 'gcs'", p)
+	}
+	if ctx.String("backend") != "" {
+		fatal(errDummy(), "--provider cannot be used with --backend")
+	}
+	switch ctx.Command.Name {
+	case "get", "put", "stat", "delete", "list", "mixed":
+	default:
+		// Other benchmarks use S3 APIs without an equivalent in the providers.
+		fatal(errDummy(), "--provider %s is only supported by get, put, stat, delete, list and mixed", p)
+	}
+	// S3 specific features of the supported benchmarks.
+	for _, flag := range []string{"client-per-thread", "post", "md5", "scan", "delete-mode", "bypass-governance",
+		"read-access-key", "write-access-key"} {
+		if ctx.IsSet(flag) {
+			fatal(errDummy(), "--%s cannot be used with --provider %s", flag, p)
+		}
+	}
+	if ctx.Int("versions") > 1 {
+		fatal(errDummy(), "--versions cannot be used with --provider %s", p)
+	}
+	if p == bench.ProviderGCS && bench.GCSFixedToken(ctx.String("secret-key")) {
+		// Access tokens expire after an hour, including the time to prepare.
+		if d := ctx.Duration("duration"); d <= 0 || d > 45*time.Minute {
+			fatal(errDummy(), "GCS access tokens cannot be refreshed. For runs longer than 45m use a credentials file or a file containing the token as --secret-key")
+		}
+	}
+	if p == bench.ProviderGCS && ctx.Float64("multipart-distrib") > 0 {
+		fatal(errDummy(), "--multipart-distrib cannot be used with --provider %s, since objects are uploaded with a single request", p)
+	}
+	if ctx.Command.Name == "list" && ctx.Bool("metadata") {
+		fatal(errDummy(), "--metadata cannot be used with --provider %s", p)
+	}
+	if ctx.String("sts-endpoint") != "" {
+		fatal(errDummy(), "--sts-endpoint cannot be used with --provider %s", p)
+	}
+	if ctx.String("serverprof") != "" {
+		fatal(errDummy(), "--serverprof cannot be used with --provider %s", p)
+	}
+	if sse, _ := parseSSE(ctx); sse != nil {
+		fatal(errDummy(), "server-side encryption cannot be used with --provider %s", p)
+	}
+	if algo, trailing := parseChecksum(ctx); algo != minio.ChecksumNone {
+		if p == bench.ProviderAzure || trailing || algo != minio.ChecksumCRC32C {
+			fatal(errDummy(), "--checksum %s cannot be used with --provider %s", ctx.String("checksum"), p)
+		}
+	}
+	_, err := bench.NewObjectClient(p, &url.URL{Scheme: "https", Host: "localhost"}, ctx.String("access-key"), ctx.String("secret-key"), nil)
+	fatalIf(probe.NewError(err), "Invalid --provider credentials")
+}
+
+// newCredentials returns credentials for the signature type set in the context.
+// If an STS endpoint is given, temporary credentials are requested using
+// the supplied keys or a web identity token and refreshed before they expire.
+func newCredentials(ctx *cli.Context, accessKey, secretKey string) *credentials.Credentials {
+	switch strings.ToUpper(ctx.String("signature")) {
+	case "S3V4":
+		if ctx.String("sts-endpoint") != "" {
+			return stsCredentials(ctx, accessKey, secretKey)
+		}
+		// if Signature version '4' use NewV4 directly.
+		return credentials.NewStaticV4(accessKey, secretKey, "")
+	case "S3V2":
+		if ctx.String("sts-endpoint") != "" {
+			fatal(errDummy(), "--sts-endpoint cannot be used with S3V2 signatures")
+		}
+		// if Signature version '2' use NewV2 directly.
+		return credentials.NewStaticV2(accessKey, secretKey, "")
+	}
+	fatal(probe.NewError(errors.New("unknown signature method. S3V2 and S3V4 is available")), strings.ToUpper(ctx.String("signature")))
+	return nil
+}
+
+// stsCache contains STS credentials by access key,
+// so all clients using the same keys share credentials.
+var stsCache = struct {
+	sync.Mutex
+	creds map[string]*credentials.Credentials
+}{creds: make(map[string]*credentials.Credentials)}
+
+// stsCredentials returns STS credentials using the parameters in the context.
+func stsCredentials(ctx *cli.Context, accessKey, secretKey string) *credentials.Credentials {
+	stsCache.Lock()
+	defer stsCache.Unlock()
+	key := accessKey + "\x00" + secretKey
+	if c := stsCache.creds[key]; c != nil {
+		return c
+	}
+	hc := &http.Client{Transport: clientTransport(ctx)}
+	endpoint := ctx.String("sts-endpoint")
+	var p credentials.Provider
+	name := "AssumeRole"
+	if fn := ctx.String("web-identity-token-file"); fn != "" {
+		name = "WebIdentity"
+		p = &credentials.STSWebIdentity{
+			Client:      hc,
+			STSEndpoint: endpoint,
+			RoleARN:     ctx.String("role-arn"),
+			GetWebIDTokenExpiry: func() (*credentials.WebIdentityToken, error) {
+				token, err := os.ReadFile(fn)
+				if err != nil {
+					return nil, err
+				}
+				return &credentials.WebIdentityToken{
+					Token:  strings.TrimSpace(string(token)),
+					Expiry: int(ctx.Duration("sts-duration").Seconds()),
+				}, nil
+			},
+		}
+	} else {
+		p = &credentials.STSAssumeRole{
+			Client:      hc,
+			STSEndpoint: endpoint,
+			Options: credentials.STSAssumeRoleOptions{
+				AccessKey:       accessKey,
+				SecretKey:       secretKey,
+				Location:        ctx.String("region"),
+				DurationSeconds: int(ctx.Duration("sts-duration").Seconds()),
+				RoleARN:         ctx.String("role-arn"),
+			},
+		}
+	}
+	c := credentials.New(&stsRefreshLogger{Provider: p, name: name})
+	_, err := c.Get()
+	fatalIf(probe.NewError(err), "Unable to get STS credentials")
+	stsCache.creds[key] = c
+	return c
+}
+
+// stsRefreshLogger prints when credentials are retrieved.
+// Refreshes after the initial retrieval are also recorded as annotations.
+type stsRefreshLogger struct {
+	credentials.Provider
+	name      string
+	refreshed bool
+}
+
+// Retrieve retrieves credentials from the wrapped provider and prints the result.
+func (s *stsRefreshLogger) Retrieve() (credentials.Value, error) {
+	v, err := s.Provider.Retrieve()
+	refresh := s.refreshed
+	s.refreshed = true
+	if err != nil {
+		msg := fmt.Sprintf("Unable to refresh %s credentials: %v", s.name, err)
+		printError(msg)
+		if refresh {
+			stsEvents.add(msg)
+		}
+		return v, err
+	}
+	printInfo(fmt.Sprintf("Retrieved %s credentials, valid until %s", s.name, v.Expiration.Local().Format(time.RFC3339)))
+	if refresh {
+		stsEvents.add(fmt.Sprintf("Refreshed %s credentials", s.name))
+	}
+	return v, nil
+}
+
+// stsEvents contains STS credential refreshes, which are added to benchmark data as annotations.
+var stsEvents stsAnnotations
+
+type stsAnnotations struct {
+	mu     sync.Mutex
+	events bench.Annotations
+}
+
+func (s *stsAnnotations) add(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, bench.Annotation{Time: time.Now(), Text: text})
+}
+
+// since returns the events recorded at or after t.
+func (s *stsAnnotations) since(t time.Time) bench.Annotations {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res bench.Annotations
+	for _, e := range s.events {
+		if !e.Time.Before(t) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
 func clientTransport(ctx *cli.Context) http.RoundTripper {
+	return clientTransportDial(ctx, newDialer(ctx), 0)
+}
+
+// clientTransportDial returns a transport like clientTransport, using dial to create connections.
+// If connsPerHost is > 0, it overrides the connection limits set in the context.
+func clientTransportDial(ctx *cli.Context, dial dialFunc, connsPerHost int) http.RoundTripper {
+	idlePerHost := ctx.Int("conn.max-idle-per-host")
+	if idlePerHost <= 0 {
+		idlePerHost = ctx.Int("concurrent")
+	}
+	maxConns := ctx.Int("conn.per-host")
+	if connsPerHost > 0 {
+		idlePerHost = connsPerHost
+		maxConns = connsPerHost
+	}
 	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 10 * time.Second,
-		}).DialContext,
-		MaxIdleConnsPerHost:   ctx.Int("concurrent"),
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dial,
+		MaxIdleConns:          ctx.Int("conn.max-idle"),
+		MaxIdleConnsPerHost:   idlePerHost,
+		MaxConnsPerHost:       maxConns,
 		WriteBufferSize:       ctx.Int("sndbuf"), // Configure beyond 4KiB default buffer size.
 		ReadBufferSize:        ctx.Int("rcvbuf"), // Configure beyond 4KiB default buffer size.
-		IdleConnTimeout:       90 * time.Second,
+		IdleConnTimeout:       ctx.Duration("conn.idle-timeout"),
 		TLSHandshakeTimeout:   15 * time.Second,
 		ExpectContinueTimeout: 10 * time.Second,
 		ResponseHeaderTimeout: 2 * time.Minute,
@@ -206,24 +522,50 @@ func clientTransport(ctx *cli.Context) http.RoundTripper {
 		DisableCompression: true,
 		DisableKeepAlives:  ctx.Bool("disable-http-keepalive"),
 	}
+	useHTTP2 := ctx.Bool("http2") || ctx.String("http-version") == "2"
+	var rt http.RoundTripper = tr
 	if ctx.Bool("tls") {
 		// Keep TLS config.
-		tr.TLSClientConfig = &tls.Config{
-			RootCAs: mustGetSystemCertPool(),
-			// Can't use SSLv3 because of POODLE and BEAST
-			// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
-			// Can't use TLSv1.1 because of RC4 cipher usage
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: ctx.Bool("insecure"),
-		}
+		tr.TLSClientConfig = clientTLSConfig(ctx)
 
 		// Because we create a custom TLSClientConfig, we have to opt-in to HTTP/2.
 		// See https://github.com/golang/go/issues/14275
-		if ctx.Bool("http2") {
+		if useHTTP2 {
 			http2.ConfigureTransport(tr)
 		}
+	} else if useHTTP2 {
+		// Without TLS, use HTTP/2 with prior knowledge.
+		rt = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+			DisableCompression: true,
+			IdleConnTimeout:    tr.IdleConnTimeout,
+		}
 	}
-	return tr
+	if bp := strings.Trim(ctx.String("base-path"), "/"); bp != "" {
+		return &basePathTransport{base: "/" + bp, rt: rt}
+	}
+	return rt
+}
+
+// basePathTransport prefixes all request paths with a fixed base path.
+// Requests are signed before reaching the transport, so the signature
+// matches the path seen by the server once a proxy strips the prefix.
+type basePathTransport struct {
+	base string
+	rt   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (b *basePathTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r2 := req.Clone(req.Context())
+	r2.URL.Path = b.base + req.URL.Path
+	if req.URL.RawPath != "" {
+		r2.URL.RawPath = b.base + req.URL.RawPath
+	}
+	return b.rt.RoundTrip(r2)
 }
 
 // parseHosts will parse the host parameter given.
@@ -311,14 +653,64 @@ func mustGetSystemCertPool() *x509.CertPool {
 	return rootCAs
 }
 
+// clientTLSConfig returns the TLS configuration used for connecting to the hosts.
+func clientTLSConfig(ctx *cli.Context) *tls.Config {
+	cfg := &tls.Config{
+		RootCAs: mustGetSystemCertPool(),
+		// Can't use SSLv3 because of POODLE and BEAST
+		// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
+		// Can't use TLSv1.1 because of RC4 cipher usage
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: ctx.Bool("insecure"),
+	}
+	addTLSFlags(ctx, cfg)
+	return cfg
+}
+
+// addTLSFlags adds the client certificate and CAs given as parameters to cfg.
+func addTLSFlags(ctx *cli.Context, cfg *tls.Config) {
+	certFile, keyFile := ctx.String("tls-client-cert"), ctx.String("tls-client-key")
+	if (certFile == "") != (keyFile == "") {
+		fatal(errDummy(), "--tls-client-cert and --tls-client-key must be specified together")
+	}
+	if certFile != "" {
+		certPEM, err := readPEM(certFile)
+		fatalIf(probe.NewError(err), "Unable to read client certificate")
+		keyPEM, err := readPEM(keyFile)
+		fatalIf(probe.NewError(err), "Unable to read client key")
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		fatalIf(probe.NewError(err), "Unable to load client certificate")
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if ca := ctx.String("tls-ca"); ca != "" {
+		caPEM, err := readPEM(ca)
+		fatalIf(probe.NewError(err), "Unable to read CA certificates")
+		if !cfg.RootCAs.AppendCertsFromPEM(caPEM) {
+			fatal(errDummy(), "No CA certificates found in --tls-ca")
+		}
+	}
+}
+
+// readPEM returns s if it contains PEM data, otherwise the content of the file named s.
+func readPEM(s string) ([]byte, error) {
+	if strings.Contains(s, "-----BEGIN ") {
+		return []byte(s), nil
+	}
+	return os.ReadFile(s)
+}
+
 func newAdminClient(ctx *cli.Context) *madmin.AdminClient {
 	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
 	if len(hosts) == 0 {
 		fatalIf(probe.NewError(errors.New("no host defined")), "Unable to create MinIO admin client")
 	}
 
+	creds := credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), "")
+	if ctx.String("sts-endpoint") != "" {
+		creds = stsCredentials(ctx, ctx.String("access-key"), ctx.String("secret-key"))
+	}
 	cl, err := madmin.NewWithOptions(hosts[0], &madmin.Options{
-		Creds:     credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), ""),
+		Creds:     creds,
 		Secure:    ctx.Bool("tls"),
 		Transport: clientTransport(ctx),
 	})
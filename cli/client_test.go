@@ -0,0 +1,66 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type testProvider struct {
+	credentials.Expiry
+	errs []error
+}
+
+func (p *testProvider) Retrieve() (credentials.Value, error) {
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return credentials.Value{AccessKeyID: "access", SecretAccessKey: "secret", Expiration: time.Now().Add(time.Hour)}, err
+}
+
+func TestSTSRefreshAnnotations(t *testing.T) {
+	start := time.Now()
+	p := &testProvider{errs: []error{nil, nil, errors.New("denied"), nil}}
+	s := &stsRefreshLogger{Provider: p, name: "AssumeRole"}
+	for range p.errs {
+		s.Retrieve()
+	}
+	var got []string
+	for _, a := range stsEvents.since(start) {
+		got = append(got, a.Text)
+	}
+	want := []string{
+		"Refreshed AssumeRole credentials",
+		"Unable to refresh AssumeRole credentials: denied",
+		"Refreshed AssumeRole credentials",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got annotations %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+	if len(stsEvents.since(time.Now().Add(time.Second))) != 0 {
+		t.Error("annotations returned from before the given time")
+	}
+}
@@ -18,16 +18,54 @@
 package cli
 
 import (
+	"crypto/tls"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 )
 
-var clientFlags = []cli.Flag{}
+var clientFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "warp-auth",
+		Usage:  "Shared secret required from warp servers connecting to this client",
+		EnvVar: appNameUC + "_AUTH",
+	},
+	cli.StringFlag{
+		Name:   "tls-cert",
+		Usage:  "Certificate to accept TLS (wss://) connections from warp servers. File name or PEM data",
+		EnvVar: appNameUC + "_CLIENT_TLS_CERT",
+	},
+	cli.StringFlag{
+		Name:   "tls-key",
+		Usage:  "Private key of --tls-cert. File name or PEM data",
+		EnvVar: appNameUC + "_CLIENT_TLS_KEY",
+	},
+	cli.StringFlag{
+		Name:  "health-addr",
+		Usage: "Serve /healthz and /status without authentication on this local address for service managers and orchestration, eg: localhost:7762",
+	},
+	cli.DurationFlag{
+		Name:  "queue-timeout",
+		Usage: "Maximum time a warp server waits for the session of another server to end. 0 rejects the server",
+		Value: time.Hour,
+	},
+	cli.DurationFlag{
+		Name:  "server-timeout",
+		Usage: "Consider the warp server lost when no requests or heartbeats are received within this time. 0 waits forever",
+		Value: 30 * time.Second,
+	},
+}
+
+// clientAuth is the secret servers must send to connect.
+var clientAuth string
+
+// serverTimeout is the time after which a silent server connection is closed.
+var serverTimeout time.Duration
 
 // Put command.
 var clientCmd = cli.Command{
@@ -49,7 +87,13 @@ FLAGS:
 
 EXAMPLES:
   1. Listen on port '6001' with ip 192.168.1.101:
-     {{.Prompt}} {{.HelpName}} 192.168.1.101:6001
+     {{.Prompt}} {{.HelpName}} --warp-auth=secret 192.168.1.101:6001
+
+  2. Accept TLS (wss://) connections using a certificate and key:
+     {{.Prompt}} {{.HelpName}} --warp-auth=secret --tls-cert=public.crt --tls-key=private.key
+
+  3. Serve health checks for a service manager on localhost port '7762':
+     {{.Prompt}} {{.HelpName}} --warp-auth=secret --health-addr=localhost:7762
  `,
 }
 
@@ -69,11 +113,50 @@ func mainClient(ctx *cli.Context) error {
 	default:
 		fatal(errInvalidArgument(), "Too many parameters")
 	}
+	clientAuth = ctx.String("warp-auth")
+	serverTimeout = ctx.Duration("server-timeout")
+	clientQueueTimeout = ctx.Duration("queue-timeout")
 	http.HandleFunc("/ws", serveWs)
+	if healthAddr := ctx.String("health-addr"); healthAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", serveClientHealth)
+		mux.HandleFunc("/status", serveClientStatus)
+		console.Infoln("Serving health on", healthAddr)
+		go func() {
+			fatalIf(probe.NewError(http.ListenAndServe(healthAddr, mux)), "Unable to start health endpoint")
+		}()
+	}
+	if certFile := ctx.String("tls-cert"); certFile != "" {
+		certPEM, err := readPEM(certFile)
+		fatalIf(probe.NewError(err), "Unable to read certificate")
+		keyPEM, err := readPEM(ctx.String("tls-key"))
+		fatalIf(probe.NewError(err), "Unable to read key")
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		fatalIf(probe.NewError(err), "Unable to load certificate")
+		srv := &http.Server{
+			Addr:      addr,
+			TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		}
+		console.Infoln("Listening on", addr, "using TLS")
+		fatalIf(probe.NewError(srv.ListenAndServeTLS("", "")), "Unable to start client")
+		return nil
+	}
 	console.Infoln("Listening on", addr)
 	fatalIf(probe.NewError(http.ListenAndServe(addr, nil)), "Unable to start client")
 	return nil
 }
 
-func checkClientSyntax(_ *cli.Context) {
+func checkClientSyntax(ctx *cli.Context) {
+	if ctx.String("warp-auth") == "" {
+		console.Fatal("--warp-auth or " + appNameUC + "_AUTH must be set to a shared secret")
+	}
+	if (ctx.String("tls-cert") == "") != (ctx.String("tls-key") == "") {
+		console.Fatal("--tls-cert and --tls-key must be specified together")
+	}
+	if ctx.Duration("server-timeout") < 0 {
+		console.Fatal("--server-timeout cannot be negative")
+	}
+	if ctx.Duration("queue-timeout") < 0 {
+		console.Fatal("--queue-timeout cannot be negative")
+	}
 }
@@ -0,0 +1,195 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/minio/warp/pkg"
+)
+
+// queuedServer is a server waiting for the current session to end.
+type queuedServer struct {
+	ID    string    `json:"id"`
+	Addr  string    `json:"addr"`
+	Since time.Time `json:"since"`
+}
+
+var (
+	// sessionQueue contains servers waiting for a session in order of arrival.
+	// Protected by connectedMu.
+	sessionQueue []queuedServer
+	// connectedAddr is the remote address of the connected server.
+	connectedAddr string
+	// clientQueueTimeout is how long servers can wait for a session.
+	// If 0, servers are rejected when another session is active.
+	clientQueueTimeout time.Duration
+)
+
+// clientQueueUpdate is the interval at which queued servers are sent their position.
+const clientQueueUpdate = 10 * time.Second
+
+// admitServer returns whether server s can start or resume a session.
+// connectedMu must be held.
+func admitServer(s serverInfo) (resumed, abandoned bool, err error) {
+	if connected.ID == s.ID && connected.ID != "" {
+		// Same server reconnecting, keep the session.
+		return true, false, nil
+	}
+	if len(sessionQueue) > 0 && sessionQueue[0].ID != s.ID {
+		return false, false, fmt.Errorf("%d servers waiting for a session", len(sessionQueue))
+	}
+	switch {
+	case connected.ID == "":
+		// First connection.
+	case connected.connected:
+		err = errors.New("another server already connected")
+	case !benchmarkRunning():
+		// Previous server has disconnected and nothing is running.
+	case time.Since(disconnectedAt) < clientSessionTimeout:
+		err = fmt.Errorf("waiting for server %s to reconnect", connected.ID)
+	default:
+		abandoned = true
+	}
+	return false, abandoned, err
+}
+
+// acceptServer makes s the connected server and returns the generation of the connection.
+// connectedMu must be held.
+func acceptServer(s serverInfo, addr string) uint64 {
+	connectedGen++
+	connected = s
+	connectedAddr = addr
+	return connectedGen
+}
+
+// releaseServer marks the connection with generation gen as lost,
+// unless the server has already reconnected.
+func releaseServer(gen uint64) {
+	connectedMu.Lock()
+	defer connectedMu.Unlock()
+	if connectedGen == gen {
+		connected.connected = false
+		disconnectedAt = time.Now()
+	}
+}
+
+// queuePosition returns the 1-based position of server id in the queue, or 0.
+// connectedMu must be held.
+func queuePosition(id string) int {
+	return slices.IndexFunc(sessionQueue, func(q queuedServer) bool { return q.ID == id }) + 1
+}
+
+// waitForSession queues server s until it can be admitted or clientQueueTimeout has passed.
+// The queue position is sent to the server while waiting.
+// connectedMu must be held and is held when returning.
+func waitForSession(s serverInfo, addr string, send func(pos int) error) (resumed, abandoned bool, err error) {
+	sessionQueue = append(sessionQueue, queuedServer{ID: s.ID, Addr: addr, Since: time.Now()})
+	defer func() {
+		if i := queuePosition(s.ID); i > 0 {
+			sessionQueue = slices.Delete(sessionQueue, i-1, i)
+		}
+	}()
+	deadline := time.Now().Add(clientQueueTimeout)
+	sentPos := 0
+	var lastSent time.Time
+	for {
+		resumed, abandoned, err = admitServer(s)
+		if err == nil || time.Now().After(deadline) {
+			return resumed, abandoned, err
+		}
+		pos := queuePosition(s.ID)
+		connectedMu.Unlock()
+		if pos != sentPos || time.Since(lastSent) >= clientQueueUpdate {
+			if werr := send(pos); werr != nil {
+				connectedMu.Lock()
+				return false, false, werr
+			}
+			sentPos, lastSent = pos, time.Now()
+		}
+		time.Sleep(time.Second)
+		connectedMu.Lock()
+	}
+}
+
+// clientStatus is returned by the client status endpoint.
+type clientStatus struct {
+	Version string `json:"version"`
+	// Protocol is the version of the protocol between warp server and client.
+	Protocol int `json:"protocol"`
+	Session  struct {
+		Server         string         `json:"server,omitempty"`
+		Addr           string         `json:"addr,omitempty"`
+		Connected      bool           `json:"connected"`
+		DisconnectedAt *time.Time     `json:"disconnected_at,omitempty"`
+		Stage          benchmarkStage `json:"stage,omitempty"`
+		Running        bool           `json:"running"`
+	} `json:"session"`
+	Queue []queuedServer `json:"queue"`
+}
+
+// getClientStatus returns the current session and queued servers.
+func getClientStatus() clientStatus {
+	st := clientStatus{Version: pkg.Version, Protocol: warpServerVersion}
+	connectedMu.Lock()
+	st.Session.Server = connected.ID
+	st.Session.Addr = connectedAddr
+	st.Session.Connected = connected.connected
+	if !connected.connected && connected.ID != "" {
+		t := disconnectedAt
+		st.Session.DisconnectedAt = &t
+	}
+	st.Queue = slices.Clone(sessionQueue)
+	connectedMu.Unlock()
+	if st.Queue == nil {
+		st.Queue = []queuedServer{}
+	}
+	activeBenchmarkMu.Lock()
+	ab := activeBenchmark
+	activeBenchmarkMu.Unlock()
+	if ab != nil {
+		ab.Lock()
+		st.Session.Stage = ab.stage
+		ab.Unlock()
+		st.Session.Running = benchmarkRunning()
+	}
+	return st
+}
+
+// serveClientStatus returns the current session and queued servers as JSON.
+func serveClientStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(getClientStatus())
+}
+
+// serveClientHealth returns 200 OK when the client is healthy.
+// A client running a benchmark abandoned by its server is unhealthy,
+// since it will not stop before another server connects.
+func serveClientHealth(w http.ResponseWriter, _ *http.Request) {
+	st := getClientStatus()
+	if st.Session.Running && st.Session.DisconnectedAt != nil && time.Since(*st.Session.DisconnectedAt) > clientSessionTimeout {
+		http.Error(w, "benchmark abandoned by server "+st.Session.Server, http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("OK\n"))
+}
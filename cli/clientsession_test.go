@@ -0,0 +1,99 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdmitServer(t *testing.T) {
+	running := &clientBenchmark{stage: stageBenchmark}
+	done := &clientBenchmark{stage: stageDone}
+	tests := []struct {
+		name      string
+		connected serverInfo
+		discAgo   time.Duration
+		bench     *clientBenchmark
+		queue     []queuedServer
+		server    string
+		resumed   bool
+		abandoned bool
+		wantErr   bool
+	}{
+		{name: "first", server: "a"},
+		{name: "reconnect", connected: serverInfo{ID: "a", connected: true}, bench: running, server: "a", resumed: true},
+		{name: "connected-running", connected: serverInfo{ID: "a", connected: true}, bench: running, server: "b", wantErr: true},
+		{name: "connected-idle", connected: serverInfo{ID: "a", connected: true}, server: "b", wantErr: true},
+		{name: "connected-done", connected: serverInfo{ID: "a", connected: true}, bench: done, server: "b", wantErr: true},
+		{name: "disconnected-idle", connected: serverInfo{ID: "a"}, bench: done, server: "b"},
+		{name: "disconnected-running", connected: serverInfo{ID: "a"}, discAgo: time.Minute, bench: running, server: "b", wantErr: true},
+		{name: "abandoned", connected: serverInfo{ID: "a"}, discAgo: 2 * clientSessionTimeout, bench: running, server: "b", abandoned: true},
+		{name: "queued-ahead", connected: serverInfo{ID: "a"}, queue: []queuedServer{{ID: "c"}}, server: "b", wantErr: true},
+		{name: "queue-head", connected: serverInfo{ID: "a"}, queue: []queuedServer{{ID: "b"}, {ID: "c"}}, server: "b"},
+	}
+	defer func() {
+		connected, disconnectedAt, sessionQueue, activeBenchmark = serverInfo{}, time.Time{}, nil, nil
+	}()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connected = test.connected
+			disconnectedAt = time.Now().Add(-test.discAgo)
+			sessionQueue = test.queue
+			activeBenchmark = test.bench
+			resumed, abandoned, err := admitServer(serverInfo{ID: test.server, connected: true})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got err %v, want error: %v", err, test.wantErr)
+			}
+			if resumed != test.resumed || abandoned != test.abandoned {
+				t.Errorf("got resumed=%v abandoned=%v, want resumed=%v abandoned=%v", resumed, abandoned, test.resumed, test.abandoned)
+			}
+		})
+	}
+}
+
+func TestReleaseServer(t *testing.T) {
+	defer func() {
+		connected, disconnectedAt, sessionQueue, activeBenchmark = serverInfo{}, time.Time{}, nil, nil
+	}()
+	connected, sessionQueue, activeBenchmark = serverInfo{}, nil, nil
+	admit := func(id string) (uint64, error) {
+		connectedMu.Lock()
+		defer connectedMu.Unlock()
+		s := serverInfo{ID: id, connected: true}
+		if _, _, err := admitServer(s); err != nil {
+			return 0, err
+		}
+		return acceptServer(s, id), nil
+	}
+	genA, err := admit("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A rejected server must not take over the connection generation.
+	if _, err := admit("b"); err == nil {
+		t.Fatal("second server admitted while first is connected")
+	}
+	releaseServer(genA)
+	if connected.connected {
+		t.Fatal("connection still marked as connected after release")
+	}
+	if _, err := admit("b"); err != nil {
+		t.Fatalf("server not admitted after disconnect: %v", err)
+	}
+}
@@ -18,19 +18,28 @@
 package cli
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
 )
 
-var cmpFlags = []cli.Flag{}
+var cmpFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "threshold.throughput",
+		Usage: "Fail if average throughput of any operation type drops more than this percentage, for example '5%'",
+	},
+	cli.StringFlag{
+		Name:  "threshold.latency",
+		Usage: "Fail if median or 99th percentile request time or TTFB of any operation type increases more than this percentage, for example '10%'",
+	},
+}
 
 var cmpCmd = cli.Command{
 	Name:   "cmp",
@@ -55,20 +64,14 @@ func mainCmp(ctx *cli.Context) error {
 	checkAnalyze(ctx)
 	checkCmp(ctx)
 	args := ctx.Args()
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
 	log := console.Printf
 	if globalQuiet {
 		log = nil
 	}
 	readOps := func(s string) bench.Operations {
-		f, err := os.Open(s)
-		fatalIf(probe.NewError(err), "Unable to open input file")
-		defer f.Close()
-		err = zstdDec.Reset(f)
-		fatalIf(probe.NewError(err), "Unable to read input")
-		ops, err := bench.OperationsFromCSV(zstdDec, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		ops, _, err := readBenchData(ctx, s, true, log)
 		fatalIf(probe.NewError(err), "Unable to parse input")
+		ops, _ = ops.SplitAnnotations()
 		return ops
 	}
 	printCompare(ctx, readOps(args[0]), readOps(args[1]))
@@ -98,6 +101,28 @@ func printCompare(ctx *cli.Context, before, after bench.Operations) {
 		start, end := ops.ActiveTimeRange(!isMultiOp)
 		return end.Sub(start).Round(time.Second)
 	}
+	maxDrop := parsePercent(ctx, "threshold.throughput")
+	maxIncrease := parsePercent(ctx, "threshold.latency")
+	gated := maxDrop >= 0 || maxIncrease >= 0
+	var failed []string
+	defer func() {
+		if !gated {
+			return
+		}
+		console.Println("-------------------")
+		if len(failed) == 0 {
+			console.SetColor("Print", color.New(color.FgHiGreen))
+			console.Println("PASS: All thresholds met.")
+			console.SetColor("Print", color.New(color.FgWhite))
+			return
+		}
+		console.SetColor("Print", color.New(color.FgHiRed))
+		for _, f := range failed {
+			console.Println("FAIL:", f)
+		}
+		console.SetColor("Print", color.New(color.FgWhite))
+		fatalIf(errDummy(), "%d threshold(s) exceeded", len(failed))
+	}()
 	afterOps := after.SortSplitByOpType()
 	for typ, before := range before.SortSplitByOpType() {
 		if wantOp := ctx.String("analyze.op"); wantOp != "" {
@@ -106,7 +131,11 @@ func printCompare(ctx *cli.Context, before, after bench.Operations) {
 			}
 		}
 
-		after := afterOps[typ]
+		after, ok := afterOps[typ]
+		if !ok && gated {
+			failed = append(failed, fmt.Sprintf("%s: no operations in after benchmark", typ))
+			continue
+		}
 		console.Println("-------------------")
 		console.SetColor("Print", color.New(color.FgHiWhite))
 		console.Println("Operation:", typ)
@@ -115,8 +144,12 @@ func printCompare(ctx *cli.Context, before, after bench.Operations) {
 		cmp, err := bench.Compare(before, after, analysisDur(ctx, before.Duration()), !isMultiOp)
 		if err != nil {
 			console.Println(err)
+			if gated {
+				failed = append(failed, fmt.Sprintf("%s: %v", typ, err))
+			}
 			continue
 		}
+		failed = append(failed, cmpThresholds(typ, cmp, maxDrop, maxIncrease)...)
 		if bErrs, aErrs := before.NErrors(), after.NErrors(); bErrs+aErrs > 0 {
 			console.SetColor("Print", color.New(color.FgHiRed))
 			console.Println("Errors:", bErrs, "->", aErrs)
@@ -140,9 +173,12 @@ func printCompare(ctx *cli.Context, before, after bench.Operations) {
 			console.Println("Duration:", timeDur(before), "->", timeDur(after))
 		}
 		if cmp.Reqs.Before.AvgObjSize != cmp.Reqs.After.AvgObjSize {
-			console.Println("Object size: %d->%d, ", cmp.Reqs.Before.AvgObjSize, cmp.Reqs.After.AvgObjSize)
+			console.Printf("Object size: %d->%d, \n", cmp.Reqs.Before.AvgObjSize, cmp.Reqs.After.AvgObjSize)
 		}
 		console.Println("* Average:", cmp.Average)
+		if cmp.AverageCI != nil {
+			console.Println("* 95% CI:", cmp.AverageCI)
+		}
 		console.Println("* Requests:", cmp.Reqs.String())
 
 		if cmp.TTFB != nil {
@@ -162,3 +198,35 @@ func checkCmp(ctx *cli.Context) {
 		console.Fatal("Two data sources must be supplied")
 	}
 }
+
+// cmpThresholds returns the thresholds exceeded by the comparison.
+// Negative thresholds are not checked.
+func cmpThresholds(typ string, cmp *bench.Comparison, maxDrop, maxIncrease float64) []string {
+	var failed []string
+	if maxDrop >= 0 {
+		change, unit := cmp.Average.ThroughputPerSec, "throughput"
+		if mib, _, _ := cmp.Average.Before.SpeedPerSec(); mib == 0 {
+			change, unit = cmp.Average.ObjPerSec, "obj/s"
+		}
+		if -change > maxDrop {
+			failed = append(failed, fmt.Sprintf("%s: average %s dropped %.02f%%, threshold %.02f%%", typ, unit, -change, maxDrop))
+		}
+	}
+	if maxIncrease >= 0 {
+		check := func(name string, before, after time.Duration) {
+			if before <= 0 {
+				return
+			}
+			if inc := 100 * float64(after-before) / float64(before); inc > maxIncrease {
+				failed = append(failed, fmt.Sprintf("%s: %s increased %.02f%% (%v -> %v), threshold %.02f%%", typ, name, inc, before, after, maxIncrease))
+			}
+		}
+		check("request P50", cmp.Reqs.Before.Median, cmp.Reqs.After.Median)
+		check("request P99", cmp.Reqs.Before.P99, cmp.Reqs.After.P99)
+		if cmp.TTFB != nil {
+			check("TTFB P50", cmp.TTFB.Before.Median, cmp.TTFB.After.Median)
+			check("TTFB P99", cmp.TTFB.Before.P99, cmp.TTFB.After.P99)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,102 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var composeFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 1000,
+		Usage: "Number of source objects to upload.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "16MiB",
+		Usage: "Size of each source object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary. Must be at least 5MiB",
+	},
+	cli.IntFlag{
+		Name:  "sources",
+		Value: 4,
+		Usage: "Number of source objects concatenated into each destination",
+	},
+	cli.BoolFlag{
+		Name:  "sources.random",
+		Usage: "Use a random number of sources between 2 and --sources for each destination",
+	},
+}
+
+var ComposeCombinedFlags = combineFlags(globalFlags, ioFlags, composeFlags, genFlags, benchFlags, analyzeFlags)
+
+var composeCmd = cli.Command{
+	Name:   "compose",
+	Usage:  "benchmark server-side concatenation of objects",
+	Action: mainCompose,
+	Before: setGlobalsFromContext,
+	Flags:  ComposeCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#compose
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainCompose is the entry point for compose command.
+func mainCompose(ctx *cli.Context) error {
+	checkComposeSyntax(ctx)
+	b := bench.Copy{
+		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects: ctx.Int("objects"),
+		Compose:       ctx.Int("sources"),
+		ComposeRandom: ctx.Bool("sources.random"),
+		SSE:           newSSE(ctx),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkComposeSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if n := ctx.Int("sources"); n < 2 || n > 10000 {
+		console.Fatal("--sources must be between 2 and 10000")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.randsize cannot be used, since sources must be at least 5MiB")
+	}
+	if ctx.String("obj.size-dist") != "" {
+		console.Fatal("--obj.size-dist cannot be used, since sources must be at least 5MiB")
+	}
+	if sz, err := toSize(ctx.String("obj.size")); err == nil && sz < 5<<20 {
+		console.Fatal("--obj.size must be at least 5MiB")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
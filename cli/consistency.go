@@ -0,0 +1,146 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var consistencyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "64KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "readers",
+		Value: 1,
+		Usage: "Number of reader threads for each writer thread",
+	},
+	cli.StringFlag{
+		Name:  "checks",
+		Value: "get,stat,list",
+		Usage: "Comma separated operations used to read back each object. Can be 'get', 'stat' and 'list'",
+	},
+	cli.Float64Flag{
+		Name:  "overwrite",
+		Value: 0.25,
+		Usage: "Fraction of uploads that overwrite a previously written object, 0 to 1",
+	},
+	cli.StringFlag{
+		Name:   "read-host",
+		Usage:  "Host(s) used by readers. Multiple hosts can be specified as a comma separated list. Defaults to --host",
+		EnvVar: appNameUC + "_READ_HOST",
+	},
+	cli.DurationFlag{
+		Name:  "poll",
+		Value: 100 * time.Millisecond,
+		Usage: "Interval between checks of objects that are not yet visible",
+	},
+	cli.DurationFlag{
+		Name:  "max-lag",
+		Value: time.Minute,
+		Usage: "Record an error if an object is not visible within this time",
+	},
+}
+
+var ConsistencyCombinedFlags = combineFlags(globalFlags, ioFlags, consistencyFlags, genFlags, benchFlags, analyzeFlags)
+
+var consistencyCmd = cli.Command{
+	Name:   "consistency",
+	Usage:  "benchmark read-after-write consistency",
+	Action: mainConsistency,
+	Before: setGlobalsFromContext,
+	Flags:  ConsistencyCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#consistency
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainConsistency is the entry point for consistency command.
+func mainConsistency(ctx *cli.Context) error {
+	checkConsistencySyntax(ctx)
+	b := bench.Consistency{
+		Common:       getCommon(ctx, newGenSource(ctx, "obj.size")),
+		Readers:      ctx.Int("readers"),
+		Checks:       consistencyChecks(ctx),
+		Overwrite:    ctx.Float64("overwrite"),
+		PollInterval: ctx.Duration("poll"),
+		MaxLag:       ctx.Duration("max-lag"),
+	}
+	if h := ctx.String("read-host"); h != "" {
+		b.ReadClient = newClientHosts(ctx, h, ctx.String("access-key"), ctx.String("secret-key"))
+	}
+	return runBench(ctx, &b)
+}
+
+// consistencyChecks returns the operation types of the --checks flag.
+func consistencyChecks(ctx *cli.Context) []string {
+	var checks []string
+	for _, s := range strings.Split(ctx.String("checks"), ",") {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "":
+		case "get":
+			checks = append(checks, http.MethodGet)
+		case "stat":
+			checks = append(checks, "STAT")
+		case "list":
+			checks = append(checks, "LIST")
+		default:
+			console.Fatalf("Unknown check %q. Must be 'get', 'stat' or 'list'\n", s)
+		}
+	}
+	return checks
+}
+
+func checkConsistencySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("readers") < 1 {
+		console.Fatal("--readers must be at least 1")
+	}
+	if len(consistencyChecks(ctx)) == 0 {
+		console.Fatal("At least one check must be specified")
+	}
+	if o := ctx.Float64("overwrite"); o < 0 || o > 1 {
+		console.Fatal("--overwrite must be between 0 and 1")
+	}
+	if ctx.Duration("poll") <= 0 {
+		console.Fatal("--poll must be positive")
+	}
+	if ctx.Duration("max-lag") < ctx.Duration("poll") {
+		console.Fatal("--max-lag must be at least --poll")
+	}
+
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
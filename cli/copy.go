@@ -0,0 +1,99 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var copyFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 1000,
+		Usage: "Number of objects to upload as copy sources.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "10MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "compose",
+		Value: 0,
+		Usage: "Combine this many source objects into each destination using multipart copy (ComposeObject). Sources must be at least 5MiB",
+	},
+}
+
+var CopyCombinedFlags = combineFlags(globalFlags, ioFlags, copyFlags, genFlags, benchFlags, analyzeFlags)
+
+var copyCmd = cli.Command{
+	Name:   "copy",
+	Usage:  "benchmark server-side object copy",
+	Action: mainCopy,
+	Before: setGlobalsFromContext,
+	Flags:  CopyCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#copy
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainCopy is the entry point for copy command.
+func mainCopy(ctx *cli.Context) error {
+	checkCopySyntax(ctx)
+	b := bench.Copy{
+		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects: ctx.Int("objects"),
+		Compose:       ctx.Int("compose"),
+		SSE:           newSSE(ctx),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkCopySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if n := ctx.Int("compose"); n < 0 || n > 10000 {
+		console.Fatal("--compose must be between 0 and 10000")
+	}
+	if ctx.Int("compose") > 1 {
+		if ctx.Bool("obj.randsize") {
+			console.Fatal("--compose cannot be used with --obj.randsize")
+		}
+		if ctx.String("obj.size-dist") != "" {
+			console.Fatal("--compose cannot be used with --obj.size-dist")
+		}
+		if sz, err := toSize(ctx.String("obj.size")); err == nil && sz < 5<<20 {
+			console.Fatal("--compose requires --obj.size of at least 5MiB")
+		}
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
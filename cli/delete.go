@@ -47,6 +47,15 @@ var deleteFlags = []cli.Flag{
 		Name:  "list-flat",
 		Usage: "When using --list-existing, do not use recursive listing",
 	},
+	cli.StringFlag{
+		Name:  "delete-mode",
+		Value: "",
+		Usage: "Delete on a versioned bucket. 'marker' creates delete markers, 'version' deletes version IDs, 'both' does both per batch",
+	},
+	cli.BoolFlag{
+		Name:  "bypass-governance",
+		Usage: "Upload objects with governance retention and bypass it when deleting versions. Requires --delete-mode=version or both",
+	},
 }
 
 var DeletedCombinedFlags = combineFlags(globalFlags, ioFlags, deleteFlags, genFlags, benchFlags, analyzeFlags)
@@ -81,6 +90,12 @@ func mainDelete(ctx *cli.Context) error {
 		ListExisting:  ctx.Bool("list-existing"),
 		ListFlat:      ctx.Bool("list-flat"),
 		ListPrefix:    ctx.String("prefix"),
+
+		Mode:             bench.DeleteMode(ctx.String("delete-mode")),
+		BypassGovernance: ctx.Bool("bypass-governance"),
+	}
+	if b.BypassGovernance && !b.ListExisting {
+		b.Locking = true
 	}
 	if b.ListExisting && !ctx.IsSet("objects") {
 		b.CreateObjects = 0
@@ -97,6 +112,15 @@ func checkDeleteSyntax(ctx *cli.Context) {
 	if ctx.Int("batch") < 1 {
 		console.Fatal("batch size much be 1 or bigger")
 	}
+	mode := bench.DeleteMode(ctx.String("delete-mode"))
+	switch mode {
+	case "", bench.DeleteModeMarker, bench.DeleteModeVersion, bench.DeleteModeBoth:
+	default:
+		console.Fatalf("unknown --delete-mode %q. Must be 'marker', 'version' or 'both'", mode)
+	}
+	if ctx.Bool("bypass-governance") && mode != bench.DeleteModeVersion && mode != bench.DeleteModeBoth {
+		console.Fatal("--bypass-governance requires --delete-mode=version or --delete-mode=both")
+	}
 	if !ctx.Bool("list-existing") {
 		wantO := ctx.Int("batch") * ctx.Int("concurrent") * 4
 		if ctx.Int("objects") < wantO {
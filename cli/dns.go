@@ -0,0 +1,225 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// dialFunc creates network connections.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newDialer returns the dial function for client connections.
+// If --source-ip is set, connections are bound to the local addresses round-robin.
+// If --dns.ttl, --dns.resolver or --prefer-ipv6 is set, host names are resolved using a shared DNS cache.
+func newDialer(ctx *cli.Context) dialFunc {
+	ips, err := parseSourceIPs(ctx.String("source-ip"))
+	fatalIf(probe.NewError(err), "Invalid --source-ip")
+	var dial dialFunc
+	if len(ips) == 0 {
+		dial = newNetDialer(nil).DialContext
+	} else {
+		dial = sourceIPDialer(ips)
+	}
+	if ctx.Duration("dns.ttl") <= 0 && ctx.String("dns.resolver") == "" && !ctx.Bool("prefer-ipv6") {
+		return dial
+	}
+	return getDNSCache(ctx).dialer(dial)
+}
+
+func newNetDialer(local net.Addr) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 10 * time.Second,
+		LocalAddr: local,
+	}
+}
+
+// parseSourceIPs parses a comma separated list of local IPs.
+func parseSourceIPs(s string) ([]net.IP, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ips []net.IP
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", v)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// sourceIPDialer returns a dial function that binds connections to the local IPs round-robin.
+// If the remote address is an IP, only local IPs of the same family are used.
+func sourceIPDialer(ips []net.IP) dialFunc {
+	dialers := make([]*net.Dialer, len(ips))
+	for i, ip := range ips {
+		dialers[i] = newNetDialer(&net.TCPAddr{IP: ip})
+	}
+	var next atomic.Uint64
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var remote net.IP
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			remote = net.ParseIP(host)
+		}
+		n := next.Add(1)
+		for i := range ips {
+			j := (n + uint64(i)) % uint64(len(ips))
+			if remote != nil && (remote.To4() == nil) != (ips[j].To4() == nil) {
+				continue
+			}
+			return dialers[j].DialContext(ctx, network, addr)
+		}
+		return nil, fmt.Errorf("no --source-ip matching the address family of %s", addr)
+	}
+}
+
+var (
+	dnsCacheOnce sync.Once
+	dnsCacheG    *dnsCache
+)
+
+// getDNSCache returns the DNS cache configured in the context.
+// Only one cache is created.
+func getDNSCache(ctx *cli.Context) *dnsCache {
+	dnsCacheOnce.Do(func() {
+		dnsCacheG = &dnsCache{
+			resolver:   net.DefaultResolver,
+			ttl:        ctx.Duration("dns.ttl"),
+			preferIPv6: ctx.Bool("prefer-ipv6"),
+			hosts:      make(map[string]*dnsEntry),
+		}
+		if addr := ctx.String("dns.resolver"); addr != "" {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(addr, "53")
+			}
+			dnsCacheG.resolver = &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					d := net.Dialer{Timeout: 5 * time.Second}
+					return d.DialContext(ctx, network, addr)
+				},
+			}
+		}
+	})
+	return dnsCacheG
+}
+
+// dnsCache resolves host names and keeps the results for a fixed time.
+type dnsCache struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+	// preferIPv6 will try IPv6 addresses before IPv4 addresses.
+	preferIPv6 bool
+
+	mu    sync.Mutex
+	hosts map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	ips     []string
+	expires time.Time
+	// next is the index of the address to try first on the next dial.
+	next int
+}
+
+// lookup returns the addresses of host, rotated so consecutive calls start with different addresses.
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	e, ok := d.hosts[host]
+	if !ok || time.Now().After(e.expires) {
+		d.mu.Unlock()
+		ips, err := d.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(ips) == 0 {
+			return nil, errors.New("no addresses found for " + host)
+		}
+		d.mu.Lock()
+		e = &dnsEntry{ips: ips, expires: time.Now().Add(d.ttl)}
+		d.hosts[host] = e
+	}
+	ips := rotate(e.ips, e.next)
+	e.next++
+	d.mu.Unlock()
+	if d.preferIPv6 {
+		ips = sortIPv6First(ips)
+	}
+	return ips, nil
+}
+
+// rotate returns a copy of ips starting at index n.
+func rotate(ips []string, n int) []string {
+	res := make([]string, 0, len(ips))
+	res = append(res, ips[n%len(ips):]...)
+	return append(res, ips[:n%len(ips)]...)
+}
+
+// sortIPv6First returns ips with IPv6 addresses before IPv4 addresses.
+// The order within each family is kept.
+func sortIPv6First(ips []string) []string {
+	res := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if strings.Contains(ip, ":") {
+			res = append(res, ip)
+		}
+	}
+	for _, ip := range ips {
+		if !strings.Contains(ip, ":") {
+			res = append(res, ip)
+		}
+	}
+	return res
+}
+
+// dialer returns a dial function that resolves host names using the cache.
+// Addresses are tried in order until a connection succeeds.
+func (d *dnsCache) dialer(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		ips, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
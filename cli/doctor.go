@@ -0,0 +1,405 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/pkg/v2/sys"
+)
+
+var doctorFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "10MiB",
+		Usage: "Planned object size, used for estimating memory use.",
+	},
+}
+
+var doctorCmd = cli.Command{
+	Name:   "doctor",
+	Usage:  "check environment and limits before benchmarking",
+	Action: mainDoctor,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, doctorFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#doctor
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "OK"
+	doctorWarn doctorStatus = "WARN"
+	doctorFail doctorStatus = "FAIL"
+	doctorSkip doctorStatus = "SKIP"
+)
+
+// doctorResult is the result of a single check.
+type doctorResult struct {
+	Check   string       `json:"check"`
+	Status  doctorStatus `json:"status"`
+	Message string       `json:"message"`
+	Fix     string       `json:"fix,omitempty"`
+}
+
+// mainDoctor is the entry point for doctor command.
+func mainDoctor(ctx *cli.Context) error {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	hosts := parseHosts(ctx.String("host"), false)
+	concurrent := ctx.Int("concurrent")
+	if concurrent < 1 {
+		console.Fatal("--concurrent must be at least 1")
+	}
+
+	var res []doctorResult
+	res = append(res, doctorOpenFiles(concurrent, len(hosts)))
+	res = append(res, doctorPorts(concurrent)...)
+	res = append(res, doctorConntrack(concurrent))
+	res = append(res, doctorMemory(ctx, concurrent))
+	for _, host := range hosts {
+		res = append(res, doctorDNS(host))
+		if ctx.Bool("tls") {
+			res = append(res, doctorTLS(ctx, host))
+		}
+		res = append(res, doctorClock(ctx, host))
+	}
+
+	failed := 0
+	for _, r := range res {
+		if r.Status == doctorFail {
+			failed++
+		}
+	}
+	if globalJSON {
+		b, err := json.MarshalIndent(res, "", "  ")
+		fatalIf(probe.NewError(err), "Unable to marshal data.")
+		os.Stdout.Write(b)
+	} else {
+		for _, r := range res {
+			c := color.New(color.FgGreen)
+			switch r.Status {
+			case doctorWarn:
+				c = color.New(color.FgYellow)
+			case doctorFail:
+				c = color.New(color.FgRed)
+			case doctorSkip:
+				c = color.New(color.FgHiBlack)
+			}
+			console.SetColor("Print", c)
+			console.Printf("%-5s", r.Status)
+			console.SetColor("Print", color.New(color.FgWhite))
+			console.Printf(" %s: %s\n", r.Check, r.Message)
+			if r.Fix != "" {
+				console.Printf("      Fix: %s\n", r.Fix)
+			}
+		}
+	}
+	if failed > 0 {
+		fatalIf(errDummy(), "%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// doctorOpenFiles checks the open files limit warp will raise itself to.
+func doctorOpenFiles(concurrent, hosts int) doctorResult {
+	r := doctorResult{Check: "Open files"}
+	_, maxLimit, err := sys.GetMaxOpenFileLimit()
+	if err != nil {
+		r.Status, r.Message = doctorSkip, err.Error()
+		return r
+	}
+	// Each thread may keep an idle connection to every host.
+	need := uint64(concurrent*max(hosts, 1) + 256)
+	r.Message = fmt.Sprintf("limit %d, need about %d", maxLimit, need)
+	switch {
+	case maxLimit < need:
+		r.Status = doctorFail
+		r.Fix = fmt.Sprintf("raise the hard nofile limit, for example 'ulimit -Hn %d' or 'LimitNOFILE=%d' for systemd services", need*2, need*2)
+	case maxLimit < need*2:
+		r.Status = doctorWarn
+		r.Fix = fmt.Sprintf("raise the hard nofile limit to at least %d", need*2)
+	default:
+		r.Status = doctorOK
+	}
+	return r
+}
+
+// readProcInt reads the integer fields from a /proc file.
+func readProcInt(fn string) ([]int64, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var res []int64
+	for _, f := range strings.Fields(string(b)) {
+		v, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// doctorPorts checks the ephemeral port range and TIME_WAIT reuse.
+func doctorPorts(concurrent int) []doctorResult {
+	r := doctorResult{Check: "Ephemeral ports"}
+	rng, err := readProcInt("/proc/sys/net/ipv4/ip_local_port_range")
+	if err != nil || len(rng) != 2 {
+		r.Status, r.Message = doctorSkip, "port range not available on this system"
+		return []doctorResult{r}
+	}
+	ports := rng[1] - rng[0] + 1
+	r.Message = fmt.Sprintf("%d ports (%d-%d) for %d concurrent connections per host", ports, rng[0], rng[1], concurrent)
+	switch {
+	case ports < int64(concurrent):
+		r.Status = doctorFail
+	case ports < int64(concurrent)*4:
+		r.Status = doctorWarn
+	default:
+		r.Status = doctorOK
+	}
+	if r.Status != doctorOK {
+		r.Fix = `sysctl -w net.ipv4.ip_local_port_range="1024 65535"`
+	}
+	res := []doctorResult{r}
+	if v, err := readProcInt("/proc/sys/net/ipv4/tcp_tw_reuse"); err == nil && len(v) == 1 {
+		r := doctorResult{Check: "TIME_WAIT reuse", Status: doctorOK, Message: fmt.Sprintf("net.ipv4.tcp_tw_reuse=%d", v[0])}
+		if v[0] == 0 {
+			r.Status = doctorWarn
+			r.Message += ", closed connections may exhaust ports"
+			r.Fix = "sysctl -w net.ipv4.tcp_tw_reuse=1"
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// doctorConntrack checks NAT/conntrack table size, if connection tracking is enabled.
+func doctorConntrack(concurrent int) doctorResult {
+	r := doctorResult{Check: "Conntrack"}
+	maxV, err := readProcInt("/proc/sys/net/netfilter/nf_conntrack_max")
+	if err != nil || len(maxV) != 1 {
+		r.Status, r.Message = doctorSkip, "connection tracking not enabled"
+		return r
+	}
+	var count int64
+	if v, err := readProcInt("/proc/sys/net/netfilter/nf_conntrack_count"); err == nil && len(v) == 1 {
+		count = v[0]
+	}
+	free := maxV[0] - count
+	r.Message = fmt.Sprintf("%d of %d entries used", count, maxV[0])
+	// Closed connections stay in the table for a while.
+	need := int64(concurrent) * 4
+	switch {
+	case free < int64(concurrent):
+		r.Status = doctorFail
+	case free < need:
+		r.Status = doctorWarn
+	default:
+		r.Status = doctorOK
+	}
+	if r.Status != doctorOK {
+		r.Fix = fmt.Sprintf("sysctl -w net.netfilter.nf_conntrack_max=%d", max(maxV[0]*2, count+need*2))
+	}
+	return r
+}
+
+// doctorMemory checks available memory against the planned concurrency.
+func doctorMemory(ctx *cli.Context, concurrent int) doctorResult {
+	r := doctorResult{Check: "Memory"}
+	stats, err := sys.GetStats()
+	if err != nil {
+		r.Status, r.Message = doctorSkip, err.Error()
+		return r
+	}
+	avail := stats.TotalRAM
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var kb uint64
+			if n, _ := fmt.Sscanf(sc.Text(), "MemAvailable: %d kB", &kb); n == 1 && kb*1024 < avail {
+				avail = kb * 1024
+			}
+		}
+		f.Close()
+	}
+	size, err := toSize(ctx.String("obj.size"))
+	fatalIf(probe.NewError(err), "Invalid obj.size specified")
+	// Smaller objects are streamed, multipart uploads buffer up to 4 parts in flight.
+	const partSize = 16 << 20
+	perThread := uint64(1 << 20)
+	if size > partSize {
+		perThread += 4 * partSize
+	}
+	need := perThread * uint64(concurrent)
+	r.Message = fmt.Sprintf("%s available, about %s needed for %d concurrent operations", humanize.IBytes(avail), humanize.IBytes(need), concurrent)
+	switch {
+	case need > avail:
+		r.Status = doctorFail
+		r.Fix = "lower --concurrent or distribute the load with multiple warp clients"
+	case need > avail/2:
+		r.Status = doctorWarn
+		r.Fix = "lower --concurrent or distribute the load with multiple warp clients"
+	default:
+		r.Status = doctorOK
+	}
+	return r
+}
+
+// doctorDNS checks that the host resolves.
+func doctorDNS(hostport string) doctorResult {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	r := doctorResult{Check: "DNS " + host}
+	if net.ParseIP(host) != nil {
+		r.Status, r.Message = doctorOK, "IP address, no lookup needed"
+		return r
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		r.Status, r.Message = doctorFail, err.Error()
+		r.Fix = "check the host name and /etc/resolv.conf, or use IP addresses"
+		return r
+	}
+	took := time.Since(start)
+	r.Status = doctorOK
+	r.Message = fmt.Sprintf("resolved to %d address(es) in %v", len(addrs), took.Round(time.Millisecond))
+	if len(addrs) > 1 {
+		r.Message += ", use --resolve-host to spread load across all of them"
+	}
+	if took > time.Second {
+		r.Status = doctorWarn
+		r.Fix = "slow DNS lookups will delay new connections, consider a local resolver cache"
+	}
+	return r
+}
+
+// doctorTLS checks the certificate presented by the host.
+func doctorTLS(ctx *cli.Context, hostport string) doctorResult {
+	r := doctorResult{Check: "TLS " + hostport}
+	addr := hostport
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	// Use the configuration of the benchmark, but always verify the certificate.
+	cfg := clientTLSConfig(ctx)
+	cfg.ServerName = host
+	cfg.InsecureSkipVerify = false
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	if err != nil {
+		r.Status, r.Message = doctorFail, err.Error()
+		r.Fix = "install the CA certificate (~/.mc/certs/CAs or system store), add it with --tls-ca or use --insecure"
+		if ctx.Bool("insecure") {
+			// Verification is skipped by the benchmark, only warn.
+			r.Status, r.Fix = doctorWarn, ""
+			r.Message += " (ignored due to --insecure)"
+		}
+		return r
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		r.Status, r.Message = doctorFail, "no certificate presented"
+		return r
+	}
+	left := time.Until(certs[0].NotAfter)
+	r.Message = fmt.Sprintf("valid, expires %s", certs[0].NotAfter.Format(time.RFC3339))
+	r.Status = doctorOK
+	if left < 14*24*time.Hour {
+		r.Status = doctorWarn
+		r.Fix = "renew the server certificate"
+	}
+	return r
+}
+
+// doctorClock compares the local clock to the Date header returned by the host.
+// Requests will be rejected by S3 if clocks differ more than 15 minutes.
+func doctorClock(ctx *cli.Context, hostport string) doctorResult {
+	r := doctorResult{Check: "Clock " + hostport}
+	scheme := "http://"
+	if ctx.Bool("tls") {
+		scheme = "https://"
+	}
+	cl := http.Client{Transport: clientTransport(ctx), Timeout: 10 * time.Second}
+	start := time.Now()
+	resp, err := cl.Head(scheme + hostport + "/")
+	if err != nil {
+		r.Status, r.Message = doctorFail, err.Error()
+		r.Fix = "check that the host is reachable and --tls matches the server"
+		return r
+	}
+	resp.Body.Close()
+	rtt := time.Since(start)
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		r.Status, r.Message = doctorSkip, "server did not return a Date header"
+		return r
+	}
+	// Date has second precision, compare against the middle of the request.
+	skew := start.Add(rtt / 2).Sub(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	r.Message = fmt.Sprintf("offset %v (round trip %v)", skew.Round(time.Millisecond), rtt.Round(time.Millisecond))
+	switch {
+	case skew > 15*time.Minute:
+		r.Status = doctorFail
+	case skew > 2*time.Second:
+		r.Status = doctorWarn
+	default:
+		r.Status = doctorOK
+	}
+	if r.Status != doctorOK {
+		r.Fix = "synchronize clocks with NTP (for example chrony or systemd-timesyncd) on clients and servers"
+	}
+	return r
+}
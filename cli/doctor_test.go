@@ -0,0 +1,114 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+func TestDoctorTLSClientCert(t *testing.T) {
+	// Client certificate, self-signed.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "warp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	// Rejected handshakes are expected.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	clients := x509.NewCertPool()
+	clients.AddCert(clientCert)
+	gotCert := make(chan bool, 10)
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  clients,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			gotCert <- len(cs.PeerCertificates) > 0
+			return nil
+		},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	doctor := func(args map[string]string) doctorResult {
+		set := flag.NewFlagSet("doctor", flag.ContinueOnError)
+		for _, name := range []string{"tls-client-cert", "tls-client-key", "tls-ca"} {
+			set.String(name, args[name], "")
+		}
+		set.Bool("insecure", false, "")
+		return doctorTLS(cli.NewContext(nil, set, nil), host)
+	}
+
+	r := doctor(nil)
+	if r.Status != doctorFail {
+		t.Errorf("unknown CA: got status %v: %s", r.Status, r.Message)
+	}
+	r = doctor(map[string]string{"tls-ca": caPEM, "tls-client-cert": certPEM, "tls-client-key": keyPEM})
+	if r.Status != doctorOK {
+		t.Fatalf("got status %v: %s", r.Status, r.Message)
+	}
+	select {
+	case got := <-gotCert:
+		if !got {
+			t.Error("client certificate not sent")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("server did not complete the handshake")
+	}
+}
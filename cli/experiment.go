@@ -0,0 +1,256 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var experimentCmd = cli.Command{
+	Name:   "experiment",
+	Usage:  "run benchmark defined in YAML file for every combination of a parameter matrix",
+	Action: mainExperiment,
+	Before: setGlobalsFromContext,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:   "debug",
+			Usage:  "enable debug logging before executing",
+			Hidden: true,
+		},
+		cli.StringSliceFlag{
+			Name:  "var",
+			Usage: "Set variables for template replacement. Can be used multiple times. Example: ObjSize=1KB",
+		},
+		cli.StringFlag{
+			Name:  "out",
+			Usage: "Write combined results to this CSV file. Defaults to the input file name with .experiment.csv extension",
+		},
+		cli.BoolFlag{
+			Name:  "restart",
+			Usage: "Run all cells, even if they are already in the output file",
+		},
+	},
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+  Execute the benchmark as defined in YAML file once for each cell in the 'matrix' element.
+  Completed cells are skipped if the output file already contains them.
+USAGE:
+  {{.HelpName}} <file.yaml>
+    -> see https://github.com/minio/warp#experiments
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// experimentCell is a single combination of matrix values.
+type experimentCell struct {
+	// doc contains the matrix values, as they would be specified in the 'warp' element.
+	doc map[string]any
+	// values are the formatted values in matrix key order.
+	values []string
+}
+
+// key identifies the cell in the output.
+func (c experimentCell) key() string {
+	return strings.Join(c.values, "\x00")
+}
+
+// mainExperiment is the entry point for experiment command.
+func mainExperiment(ctx *cli.Context) error {
+	doc := readRunDoc(ctx)
+	matrix, ok := doc["matrix"].(map[string]any)
+	if !ok || len(matrix) == 0 {
+		fatal(errDummy(), "Expected top level 'matrix' element could not be found")
+	}
+	benchCmd, wdoc := runDocBench(doc)
+	base := runDocFlags(benchCmd, wdoc)
+	for _, p := range sweepParams {
+		if _, ok := base[p.flag]; ok {
+			fatal(errDummy(), "%s cannot be used in experiments", p.flag)
+		}
+	}
+	for _, f := range []string{"warp-client", serverFlagName} {
+		if _, ok := base[f]; ok {
+			fatal(errDummy(), "%s cannot be used in experiments", f)
+		}
+	}
+
+	keys, cells := expandMatrix(matrix)
+	fn := ctx.String("out")
+	if fn == "" {
+		in := ctx.Args()[0]
+		fn = strings.TrimSuffix(in, filepath.Ext(in)) + ".experiment.csv"
+	}
+	header := append([]string{"cell"}, keys...)
+	header = append(header, sweepCSVHeader...)
+	completed := map[string]bool{}
+	if !ctx.Bool("restart") {
+		var err error
+		completed, err = experimentCompleted(fn, header, len(keys))
+		fatalIf(probe.NewError(err), "Unable to read experiment results")
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if len(completed) == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(fn, flags, 0o666)
+	fatalIf(probe.NewError(err), "Unable to create experiment output")
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if len(completed) == 0 {
+		fatalIf(probe.NewError(w.Write(header)), "Unable to write experiment output")
+	}
+
+	sw := &sweepState{param: sweepParam{title: "Cell", target: "cell"}}
+	activeSweep = sw
+	defer func() {
+		activeSweep = nil
+	}()
+	for i, cell := range cells {
+		desc := make([]string, len(keys))
+		for j, k := range keys {
+			desc[j] = k + "=" + cell.values[j]
+		}
+		if completed[cell.key()] {
+			printInfo(fmt.Sprintf("Experiment cell %d/%d already completed: %s", i+1, len(cells), strings.Join(desc, ", ")))
+			continue
+		}
+		printInfo(fmt.Sprintf("Experiment cell %d/%d: %s", i+1, len(cells), strings.Join(desc, ", ")))
+
+		cellFlags := make(map[string]string, len(base))
+		for k, v := range base {
+			cellFlags[k] = v
+		}
+		for k, v := range runDocFlags(benchCmd, cell.doc) {
+			cellFlags[k] = v
+		}
+		sw.current = "cell" + strconv.Itoa(i+1)
+		sw.rows = sw.rows[:0]
+		if err := runCommand(newRunContext(ctx, benchCmd, cellFlags), benchCmd); err != nil {
+			return err
+		}
+		for _, r := range sw.rows {
+			rec := append([]string{strconv.Itoa(i + 1)}, cell.values...)
+			if err := w.Write(append(rec, r.record()...)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		if err := errors.Join(w.Error(), f.Sync()); err != nil {
+			return err
+		}
+	}
+	console.Infof("Experiment results written to %q\n", fn)
+	return f.Close()
+}
+
+// expandMatrix returns the sorted matrix keys and all combinations of values.
+// The last key changes fastest.
+func expandMatrix(matrix map[string]any) ([]string, []experimentCell) {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	cells := []experimentCell{{doc: map[string]any{}}}
+	for _, k := range keys {
+		values, ok := matrix[k].([]any)
+		if !ok || len(values) == 0 {
+			fatal(errDummy(), "matrix value of %q must be a non-empty list", k)
+		}
+		next := make([]experimentCell, 0, len(cells)*len(values))
+		for _, c := range cells {
+			for _, v := range values {
+				doc := make(map[string]any, len(c.doc)+1)
+				for k, v := range c.doc {
+					doc[k] = v
+				}
+				doc[k] = v
+				next = append(next, experimentCell{doc: doc, values: append(slices.Clip(c.values), matrixValue(v))})
+			}
+		}
+		cells = next
+	}
+	return keys, cells
+}
+
+// matrixValue formats a matrix value for output.
+// Maps are written as sorted key=value pairs.
+func matrixValue(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	s := make([]string, 0, len(m))
+	for k, v := range m {
+		s = append(s, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(s)
+	return strings.Join(s, " ")
+}
+
+// experimentCompleted returns the cells already present in the output file.
+// An error is returned if the file was written with a different matrix.
+func experimentCompleted(fn string, header []string, nKeys int) (map[string]bool, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	got, err := r.Read()
+	if err == io.EOF {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(got, header) {
+		return nil, fmt.Errorf("%s has different columns. Use --restart to overwrite it", fn)
+	}
+	completed := map[string]bool{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return completed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		completed[strings.Join(rec[1:1+nKeys], "\x00")] = true
+	}
+}
@@ -29,10 +29,19 @@ var fanoutFlags = []cli.Flag{
 		Value: "1MiB",
 		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
 	},
+	cli.IntFlag{
+		Name:  "fanout.count",
+		Value: 100,
+		Usage: "Number of destination objects written by each fan-out request",
+	},
+	cli.BoolFlag{
+		Name:  "fanout.entries",
+		Usage: "Record each fan-out entry as an operation with its own result instead of one operation per request",
+	},
 	cli.IntFlag{
 		Name:   "copies",
 		Value:  100,
-		Usage:  "Number of copies per uploaded object",
+		Usage:  "Number of copies per uploaded object. Use --fanout.count",
 		Hidden: true,
 	},
 }
@@ -60,18 +69,28 @@ FLAGS:
 func mainFanout(ctx *cli.Context) error {
 	checkFanoutSyntax(ctx)
 	b := bench.Fanout{
-		Copies: ctx.Int("copies"),
-		Common: getCommon(ctx, newGenSource(ctx, "obj.size")),
+		Copies:  fanoutCount(ctx),
+		Entries: ctx.Bool("fanout.entries"),
+		Common:  getCommon(ctx, newGenSource(ctx, "obj.size")),
 	}
 	return runBench(ctx, &b)
 }
 
+// fanoutCount returns the number of entries per request.
+// The deprecated --copies is used if set.
+func fanoutCount(ctx *cli.Context) int {
+	if ctx.IsSet("copies") {
+		return ctx.Int("copies")
+	}
+	return ctx.Int("fanout.count")
+}
+
 func checkFanoutSyntax(ctx *cli.Context) {
 	if ctx.NArg() > 0 {
 		console.Fatal("Command takes no arguments")
 	}
-	if ctx.Int("copies") <= 0 {
-		console.Fatal("Copies must be bigger than 0")
+	if fanoutCount(ctx) <= 0 {
+		console.Fatal("--fanout.count must be bigger than 0")
 	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
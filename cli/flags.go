@@ -20,7 +20,10 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
@@ -164,11 +167,61 @@ var ioFlags = []cli.Flag{
 		EnvVar: appNameUC + "_SECRET_KEY",
 		Value:  "",
 	},
+	cli.StringFlag{
+		Name:  "backend",
+		Usage: "Benchmark a local or network filesystem instead of S3 using 'fs:/path'. Host and credentials are ignored. Results include S3 request handling by warp in the same process",
+	},
+	cli.StringFlag{
+		Name:   "provider",
+		Value:  string(bench.ProviderS3),
+		Usage:  "Storage API to benchmark. Can be 's3', 'azure' or 'gcs'. Only get, put, stat, delete, list and mixed support 'azure' and 'gcs'",
+		EnvVar: appNameUC + "_PROVIDER",
+	},
+	cli.StringFlag{
+		Name:   "sts-endpoint",
+		Usage:  "Get temporary credentials from this STS endpoint, for example 'https://sts.example.com'",
+		EnvVar: appNameUC + "_STS_ENDPOINT",
+	},
+	cli.StringFlag{
+		Name:   "role-arn",
+		Usage:  "ARN of the role to assume when using --sts-endpoint",
+		EnvVar: appNameUC + "_ROLE_ARN",
+	},
+	cli.StringFlag{
+		Name:   "web-identity-token-file",
+		Usage:  "Get STS credentials using the web identity token in this file instead of --access-key and --secret-key. The file is read on every refresh",
+		EnvVar: appNameUC + "_WEB_IDENTITY_TOKEN_FILE",
+	},
+	cli.DurationFlag{
+		Name:  "sts-duration",
+		Value: time.Hour,
+		Usage: "Requested validity of STS credentials. Credentials are refreshed before they expire",
+	},
 	cli.BoolFlag{
 		Name:   "tls",
 		Usage:  "Use TLS (HTTPS) for transport",
 		EnvVar: appNameUC + "_TLS",
 	},
+	cli.StringFlag{
+		Name:   "tls-client-cert",
+		Usage:  "Client certificate for mutual TLS. File name or PEM data",
+		EnvVar: appNameUC + "_TLS_CLIENT_CERT",
+	},
+	cli.StringFlag{
+		Name:   "tls-client-key",
+		Usage:  "Private key of the client certificate. File name or PEM data",
+		EnvVar: appNameUC + "_TLS_CLIENT_KEY",
+	},
+	cli.StringFlag{
+		Name:   "tls-ca",
+		Usage:  "Additional CA certificates to trust. File name or PEM data",
+		EnvVar: appNameUC + "_TLS_CA",
+	},
+	cli.StringFlag{
+		Name:   "base-path",
+		Usage:  "Serve S3 requests under this path prefix on the host, for example '/s3' when behind a proxy",
+		EnvVar: appNameUC + "_BASE_PATH",
+	},
 	cli.StringFlag{
 		Name:   "region",
 		Usage:  "Specify a custom region",
@@ -188,6 +241,26 @@ var ioFlags = []cli.Flag{
 		Name:  "sse-s3-encrypt",
 		Usage: "server-side sse-s3 encrypt/decrypt objects",
 	},
+	cli.StringFlag{
+		Name:  "encrypt.type",
+		Usage: "server-side encrypt/decrypt objects. Can be 's3', 'kms' or 'c'",
+	},
+	cli.StringFlag{
+		Name:  "encrypt.kms-key",
+		Usage: "KMS key ID to use with --encrypt.type=kms. The default key of the server is used if not set",
+	},
+	cli.StringFlag{
+		Name:  "encrypt.kms-context",
+		Usage: "KMS encryption context to use with --encrypt.type=kms as a JSON object",
+	},
+	cli.StringFlag{
+		Name:  "encrypt.key",
+		Usage: "SSE-C key to use with --encrypt.type=c as 64 hex characters. A random key is generated if not set",
+	},
+	cli.StringFlag{
+		Name:  "bucket.encryption",
+		Usage: "Configure the default encryption of the bucket. Can be 'none', 'sse-s3' or 'sse-kms'. Use 'sse-kms:<key-id>' to select the KMS key",
+	},
 	cli.StringFlag{
 		Name:  "bucket",
 		Value: appName + "-benchmark-bucket",
@@ -240,11 +313,43 @@ var ioFlags = []cli.Flag{
 		Name:  "md5",
 		Usage: "Add MD5 sum to uploads",
 	},
+	cli.StringFlag{
+		Name:  "checksum",
+		Usage: "Add checksum to uploads. Can be 'crc32c', 'crc32', 'sha1' or 'sha256'. Use 'crc32c,trailing' to send it as a trailing checksum",
+	},
 	cli.StringFlag{
 		Name:  "storage-class",
 		Value: "",
 		Usage: "Specify custom storage class, for instance 'STANDARD' or 'REDUCED_REDUNDANCY'.",
 	},
+	cli.DurationFlag{
+		Name:  "dns.ttl",
+		Usage: "Cache resolved host names for this long. Connections rotate between the resolved addresses. 0 resolves on every connection",
+	},
+	cli.StringFlag{
+		Name:  "dns.resolver",
+		Usage: "Resolve host names using this DNS server, for example '10.0.0.2:53'",
+	},
+	cli.StringFlag{
+		Name:  "source-ip",
+		Usage: "Bind connections to these comma separated local IPs, used round-robin. For example '10.0.0.5,10.0.1.5'",
+	},
+	cli.BoolFlag{
+		Name:  "prefer-ipv6",
+		Usage: "Connect to IPv6 addresses of hosts before IPv4 addresses",
+	},
+	cli.BoolFlag{
+		Name:  "record-ip",
+		Usage: "Record the IP of the server each operation was sent to",
+	},
+	cli.BoolFlag{
+		Name:  "record-request-id",
+		Usage: "Record the request ID and deployment ID returned by the server for each operation",
+	},
+	cli.BoolFlag{
+		Name:  "client-per-thread",
+		Usage: "Use a separate client with a single persistent connection for each thread. The local address of the connection is recorded for each operation",
+	},
 	cli.BoolFlag{
 		Name:   "disable-http-keepalive",
 		Usage:  "Disable HTTP Keep-Alive",
@@ -255,6 +360,28 @@ var ioFlags = []cli.Flag{
 		Usage:  "enable HTTP2 support if server supports it",
 		Hidden: true,
 	},
+	cli.StringFlag{
+		Name:  "http-version",
+		Usage: "HTTP version to use, '1.1' or '2'. HTTP/2 without TLS uses prior knowledge (h2c)",
+		Value: "1.1",
+	},
+	cli.IntFlag{
+		Name:  "conn.max-idle",
+		Usage: "Maximum number of idle connections across all hosts. 0 means no limit",
+	},
+	cli.IntFlag{
+		Name:  "conn.max-idle-per-host",
+		Usage: "Maximum number of idle connections per host. Defaults to --concurrent",
+	},
+	cli.IntFlag{
+		Name:  "conn.per-host",
+		Usage: "Maximum number of connections per host, including active ones. 0 means no limit",
+	},
+	cli.DurationFlag{
+		Name:  "conn.idle-timeout",
+		Usage: "Close idle connections after this duration",
+		Value: 90 * time.Second,
+	},
 	cli.BoolFlag{
 		Name:  "stress",
 		Usage: "stress test only and discard output",
@@ -264,6 +391,34 @@ var ioFlags = []cli.Flag{
 		EnvVar: appNameUC + "_INFLUXDB_CONNECT",
 		Usage:  "Send operations to InfluxDB. Specify as 'http://<token>@<hostname>:<port>/<bucket>/<org>'",
 	},
+	cli.StringFlag{
+		Name:   "statsd-server",
+		EnvVar: appNameUC + "_STATSD_SERVER",
+		Usage:  "Send live metrics every second to this StatsD server, eg: localhost:8125",
+	},
+	cli.StringFlag{
+		Name:  "statsd-prefix",
+		Usage: "Prefix of metric names sent with --statsd-server",
+		Value: appName,
+	},
+	cli.StringFlag{
+		Name:   "otlp.endpoint",
+		EnvVar: appNameUC + "_OTLP_ENDPOINT",
+		Usage:  "Send a trace span per operation to this OpenTelemetry OTLP/HTTP endpoint, eg: http://localhost:4318",
+	},
+	cli.Float64Flag{
+		Name:  "otlp.sample",
+		Usage: "Share of operations to send as spans with --otlp.endpoint, from 0 to 1",
+		Value: 1,
+	},
+	cli.StringFlag{
+		Name:  "error-journal",
+		Usage: "Write failed operations as JSON lines to this file while running",
+	},
+	cli.StringFlag{
+		Name:  "stream-ops",
+		Usage: "Write all operations as JSON lines to this file as they complete. Use '-' for stdout",
+	},
 	cli.Float64Flag{
 		Name:  "rps-limit",
 		Value: 0,
@@ -296,6 +451,15 @@ func getCommon(ctx *cli.Context, src func() generator.Source) bench.Common {
 			extra = append(extra, in)
 		}
 	}
+	if ctx.String("otlp.endpoint") != "" {
+		extra = append(extra, newOTLPExporter(ctx, &globalWG))
+	}
+	if ctx.String("error-journal") != "" {
+		extra = append(extra, newErrorJournal(ctx, &globalWG))
+	}
+	if ctx.String("stream-ops") != "" {
+		extra = append(extra, newOpStream(ctx, &globalWG))
+	}
 
 	rpsLimit := ctx.Float64("rps-limit")
 	var rpsLimiter *rate.Limiter
@@ -304,16 +468,99 @@ func getCommon(ctx *cli.Context, src func() generator.Source) bench.Common {
 		rpsLimiter = rate.NewLimiter(rate.Limit(rpsLimit), 1)
 	}
 
+	var autoPause *bench.AutoPause
+	if d := ctx.Duration("autopause"); d > 0 {
+		autoPause = &bench.AutoPause{
+			After: d,
+			Probe: ctx.Duration("autopause.probe"),
+		}
+	}
+
+	concurrency := ctx.Int("concurrent")
+	var ramp *bench.Ramp
+	if r := ctx.String("concurrency-ramp"); r != "" {
+		var err error
+		ramp, err = bench.ParseRamp(r)
+		fatalIf(probe.NewError(err), "Invalid --concurrency-ramp")
+		concurrency = ramp.MaxConcurrency()
+	}
+	var autoTune *bench.AutoTune
+	if ctx.Bool("autotune") {
+		autoTune = &bench.AutoTune{
+			Start:      concurrency,
+			Max:        ctx.Int("autotune.max"),
+			Step:       ctx.Duration("autotune.step"),
+			MinGain:    ctx.Float64("autotune.gain") / 100,
+			MaxErrors:  ctx.Float64("autotune.errors") / 100,
+			MaxLatency: ctx.Duration("autotune.latency"),
+		}
+		concurrency = autoTune.Max
+	}
+	var phases *bench.Phases
+	if p := ctx.String("phases"); p != "" {
+		var err error
+		phases, err = bench.ParsePhases(p, concurrency)
+		fatalIf(probe.NewError(err), "Invalid --phases")
+	}
+
+	client, objectClient := newClient(ctx), newObjectClient(ctx)
+	if s := sharedSetup(); s != nil {
+		// Use the clients of the first sweep step.
+		client, objectClient = s.Client, s.ObjectClient
+	}
+
 	return bench.Common{
-		Client:        newClient(ctx),
-		Concurrency:   ctx.Int("concurrent"),
+		AutoPause:     autoPause,
+		Ramp:          ramp,
+		AutoTune:      autoTune,
+		Phases:        phases,
+		Client:        client,
+		ObjectClient:  objectClient,
+		Concurrency:   concurrency,
 		Source:        src,
 		Bucket:        ctx.String("bucket"),
 		Location:      ctx.String("region"),
 		PutOpts:       putOpts(ctx),
 		DiscardOutput: ctx.Bool("stress"),
+		HDRCollector:  ctx.String("collector") == "hdr",
 		ExtraOut:      extra,
 		RpsLimiter:    rpsLimiter,
 		Transport:     clientTransport(ctx),
+		Encryption:    newCSE(ctx),
+		BucketSSE:     bucketEncryption(ctx),
+		Checksum:      checksumType(ctx),
+		Seed:          ctx.Int64("seed"),
+
+		NewThreadClient: newThreadClient(ctx),
+		RecordRemote:    ctx.Bool("record-ip"),
+		RecordRequestID: ctx.Bool("record-request-id"),
+		ThreadStagger:   ctx.Duration("thread-stagger"),
+		ThreadJitter:    ctx.Duration("thread-jitter"),
+		PrepareRetries:  ctx.Int("prepare.retries"),
+		PrepareTolerate: parsePrepareTolerate(ctx),
+	}
+}
+
+// parsePrepareTolerate returns --prepare.tolerate-errors as a fraction.
+func parsePrepareTolerate(ctx *cli.Context) float64 {
+	if ctx.String("prepare.tolerate-errors") == "" {
+		return 0
+	}
+	pct := parsePercent(ctx, "prepare.tolerate-errors")
+	if pct < 0 || pct >= 100 {
+		fatalIf(errDummy(), "--prepare.tolerate-errors must be at least 0%% and less than 100%%")
+	}
+	return pct / 100
+}
+
+// parsePercent returns the percentage given to the flag, with or without a '%' suffix.
+// Returns -1 if the flag is empty.
+func parsePercent(ctx *cli.Context, flag string) float64 {
+	s := strings.TrimSpace(strings.TrimSuffix(ctx.String(flag), "%"))
+	if s == "" {
+		return -1
 	}
+	pct, err := strconv.ParseFloat(s, 64)
+	fatalIf(probe.NewError(err), "Unable to parse --"+flag)
+	return pct
 }
@@ -20,12 +20,15 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/dustin/go-humanize"
 	"github.com/minio/mc/pkg/probe"
 
 	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/generator"
 
 	hist "github.com/jfsmig/prng/histogram"
@@ -35,12 +38,16 @@ var genFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:  "obj.generator",
 		Value: "random",
-		Usage: "Use specific data generator",
+		Usage: "Use specific data generator. Can be 'random', 'csv', 'block-dedupe:<duplicate fraction>' or 'text:<words>'",
 	},
 	cli.BoolFlag{
 		Name:  "obj.randsize",
 		Usage: "Randomize size of objects so they will be up to the specified size",
 	},
+	cli.StringFlag{
+		Name:  "obj.size-dist",
+		Usage: "Select object sizes from weighted sizes, for example '4KiB:40,1MiB:50,128MiB:10', or a CSV or JSON file of sizes",
+	},
 }
 
 func newGenSourceCSV(ctx *cli.Context) func() generator.Source {
@@ -58,24 +65,50 @@ func newGenSourceCSV(ctx *cli.Context) func() generator.Source {
 		generator.WithPrefixSize(prefixSize),
 		generator.WithSize(int64(size)),
 		generator.WithRandomSize(ctx.Bool("obj.randsize")),
+		withSeed(ctx),
+		withSizeDist(ctx),
 	)
 	fatalIf(probe.NewError(err), "Unable to create data generator")
 	return src
 }
 
 // newGenSource returns a new generator
-func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
+// Extra options are applied after the options from the context.
+func newGenSource(ctx *cli.Context, sizeField string, extra ...generator.Option) func() generator.Source {
 	prefixSize := 8
 	if ctx.Bool("noprefix") {
 		prefixSize = 0
 	}
 
 	var g generator.OptionApplier
-	switch ctx.String("obj.generator") {
-	case "random":
+	kind, param, hasParam := strings.Cut(ctx.String("obj.generator"), ":")
+	switch {
+	case kind == "random" && !hasParam:
 		g = generator.WithRandomData()
-	case "csv":
+	case kind == "csv" && !hasParam:
 		g = generator.WithCSV().Size(25, 1000)
+	case kind == "block-dedupe":
+		d := generator.WithDedupe()
+		if hasParam {
+			f, err := strconv.ParseFloat(param, 64)
+			if err != nil || f < 0 || f > 1 {
+				fatal(errDummy(), "Invalid -generator parameter. Duplicate fraction must be between 0 and 1, for example 'block-dedupe:0.5'")
+				return nil
+			}
+			d = d.Dupes(f)
+		}
+		g = d
+	case kind == "text":
+		t := generator.WithText()
+		if hasParam {
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				fatal(probe.NewError(err), "Invalid -generator parameter. Number of words must be an integer, for example 'text:5000'")
+				return nil
+			}
+			t = t.Words(n)
+		}
+		g = t
 	default:
 		err := errors.New("unknown generator type:" + ctx.String("obj.generator"))
 		fatal(probe.NewError(err), "Invalid -generator parameter")
@@ -84,6 +117,7 @@ func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
 	opts := []generator.Option{
 		generator.WithCustomPrefix(ctx.String("prefix")),
 		generator.WithPrefixSize(prefixSize),
+		withSeed(ctx),
 	}
 	if strings.IndexRune(ctx.String(sizeField), ':') > 0 {
 		if _, err := hist.ParseCSV(ctx.String(sizeField)); err != nil {
@@ -117,11 +151,76 @@ func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
 		opts = append([]generator.Option{g.Apply()}, append(opts, generator.WithRandomSize(ctx.Bool("obj.randsize")))...)
 	}
 
+	opts = append(opts, withSizeDist(ctx))
+	opts = append(opts, extra...)
+
 	src, err := generator.NewFn(opts...)
 	fatalIf(probe.NewError(err), "Unable to create data generator")
 	return src
 }
 
+// withSizeDist returns a generator option for the --obj.size-dist flag.
+// If the value is an existing file, the distribution is read from it.
+func withSizeDist(ctx *cli.Context) generator.Option {
+	return func(o *generator.Options) error {
+		d, err := parseSizeDist(ctx)
+		if err != nil || d == nil {
+			return err
+		}
+		return generator.WithSizeDist(d)(o)
+	}
+}
+
+// parseSizeDist returns the --obj.size-dist distribution or nil if not set.
+func parseSizeDist(ctx *cli.Context) (*generator.SizeDist, error) {
+	v := ctx.String("obj.size-dist")
+	if v == "" {
+		return nil, nil
+	}
+	f, err := os.Open(v)
+	if err != nil {
+		return generator.ParseSizeDist(v)
+	}
+	defer f.Close()
+	return generator.ReadSizeDist(f)
+}
+
+// checkSizeDist validates the --obj.size-dist flag.
+func checkSizeDist(ctx *cli.Context) {
+	if ctx.String("obj.size-dist") == "" {
+		return
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.size-dist cannot be used with --obj.randsize")
+	}
+	if strings.Contains(ctx.String("obj.size"), ":") {
+		console.Fatal("--obj.size-dist cannot be used with bucketed --obj.size")
+	}
+	_, err := parseSizeDist(ctx)
+	fatalIf(probe.NewError(err), "Invalid obj.size-dist specified")
+}
+
+// withSeed returns a generator option for the --seed flag.
+func withSeed(ctx *cli.Context) generator.Option {
+	return func(o *generator.Options) error {
+		if seed := ctx.Int64("seed"); seed != 0 {
+			return generator.WithSeed(seed)(o)
+		}
+		return nil
+	}
+}
+
+// withSeedStream returns a generator option that derives the seed for stream n from --seed.
+// This allows several generators in the same benchmark to produce different object names.
+func withSeedStream(ctx *cli.Context, n uint64) generator.Option {
+	return func(o *generator.Options) error {
+		if seed := ctx.Int64("seed"); seed != 0 {
+			return generator.WithSeed(generator.DeriveSeed(seed, n))(o)
+		}
+		return nil
+	}
+}
+
 // toSize converts a size indication to bytes.
 func toSize(size string) (uint64, error) {
 	return humanize.ParseBytes(size)
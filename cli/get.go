@@ -42,17 +42,32 @@ var getFlags = []cli.Flag{
 		Hidden: true,
 	},
 	cli.BoolFlag{
-		Name:  "range",
-		Usage: "Do ranged get operations. Will request with random offset and length.",
+		Name:  "range.random",
+		Usage: "Do ranged get operations with random offset and length. Lengths are limited to --range.size, if set",
 	},
 	cli.StringFlag{
-		Name:  "range-size",
-		Usage: "Use a fixed range size while doing random range offsets, --range is implied",
+		Name:  "range.size",
+		Usage: "Do ranged get operations with a fixed range size at random offsets",
+	},
+	cli.Float64Flag{
+		Name:  "read-fraction",
+		Value: 1,
+		Usage: "Abort downloads after reading this fraction of the object or range. Must be > 0 and <= 1",
+	},
+	cli.BoolFlag{
+		Name:   "range",
+		Usage:  "Do ranged get operations. Will request with random offset and length. Same as --range.random",
+		Hidden: true,
+	},
+	cli.StringFlag{
+		Name:   "range-size",
+		Usage:  "Use a fixed range size while doing random range offsets, --range is implied. Same as --range.size",
+		Hidden: true,
 	},
 	cli.IntFlag{
 		Name:  "versions",
 		Value: 1,
-		Usage: "Number of versions to upload. If more than 1, versioned listing will be benchmarked",
+		Usage: "Number of versions to upload of each object. If more than 1, GETs will request random versions",
 	},
 	cli.BoolFlag{
 		Name:  "list-existing",
@@ -62,9 +77,13 @@ var getFlags = []cli.Flag{
 		Name:  "list-flat",
 		Usage: "When using --list-existing, do not use recursive listing",
 	},
+	cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Verify the content of downloaded objects. Mismatches are recorded as errors",
+	},
 }
 
-var GetCombinedFlags = combineFlags(globalFlags, ioFlags, getFlags, genFlags, benchFlags, analyzeFlags)
+var GetCombinedFlags = combineFlags(globalFlags, ioFlags, getFlags, accessFlags, manifestFlags, cseFlags, genFlags, benchFlags, analyzeFlags)
 
 var getCmd = cli.Command{
 	Name:   "get",
@@ -89,7 +108,11 @@ func mainGet(ctx *cli.Context) error {
 	checkGetSyntax(ctx)
 
 	var rangeSize int64
-	if rs := ctx.String("range-size"); rs != "" {
+	rs := ctx.String("range.size")
+	if rs == "" {
+		rs = ctx.String("range-size")
+	}
+	if rs != "" {
 		s, err := toSize(rs)
 		if err != nil {
 			return err
@@ -101,17 +124,28 @@ func mainGet(ctx *cli.Context) error {
 	b := bench.Get{
 		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
 		Versions:      ctx.Int("versions"),
-		RandomRanges:  ctx.Bool("range") || ctx.IsSet("range-size"),
+		RandomRanges:  getRanged(ctx),
 		RangeSize:     rangeSize,
 		CreateObjects: ctx.Int("objects"),
 		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
 		ListExisting:  ctx.Bool("list-existing"),
 		ListFlat:      ctx.Bool("list-flat"),
 		ListPrefix:    ctx.String("prefix"),
+		Manifest:      readManifest(ctx),
+		Verify:        ctx.Bool("verify"),
+		Access:        accessDist(ctx),
+
+		RandomRangeLen: ctx.Bool("range.random") && rangeSize > 0,
+		ReadFraction:   ctx.Float64("read-fraction"),
 	}
 	return runBench(ctx, &b)
 }
 
+// getRanged returns whether ranged get operations are requested.
+func getRanged(ctx *cli.Context) bool {
+	return ctx.Bool("range.random") || ctx.Bool("range") || ctx.IsSet("range.size") || ctx.IsSet("range-size")
+}
+
 func checkGetSyntax(ctx *cli.Context) {
 	if ctx.NArg() > 0 {
 		console.Fatal("Command takes no arguments")
@@ -122,6 +156,25 @@ func checkGetSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if ctx.Bool("cse-encrypt") {
+		for _, flag := range []string{"range", "range-size", "range.random", "range.size", "read-fraction", "list-existing", "manifest.in"} {
+			if ctx.IsSet(flag) {
+				console.Fatalf("--cse-encrypt cannot be combined with --%s\n", flag)
+			}
+		}
+	}
+	if ctx.Bool("verify") && getRanged(ctx) {
+		console.Fatal("--verify cannot be combined with --range.random or --range.size")
+	}
+	if f := ctx.Float64("read-fraction"); f <= 0 || f > 1 {
+		console.Fatal("--read-fraction must be > 0 and <= 1")
+	}
+	if ctx.Bool("verify") && ctx.Float64("read-fraction") < 1 {
+		console.Fatal("--verify cannot be combined with --read-fraction")
+	}
+	if _, err := bench.ParseAccessDist(ctx.String("access-dist")); err != nil {
+		console.Fatal(err)
+	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
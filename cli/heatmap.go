@@ -0,0 +1,96 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cheggaaa/pb"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// latencyHeatmaps returns a latency heatmap for each operation type in o.
+func latencyHeatmaps(ctx *cli.Context, o bench.Operations) []bench.LatencyHeatmap {
+	var res []bench.LatencyHeatmap
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		h := ops.LatencyHeatmap(bench.HeatmapOptions{
+			SliceDuration: analysisDur(ctx, ops.Duration()),
+			Buckets:       ctx.Int("analyze.heatmap.buckets"),
+		})
+		if len(h.Counts) > 0 {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// writeHeatmaps writes the latency heatmaps of o to the file fn.
+// JSON is written if the file name ends with '.json', otherwise CSV.
+// If fn is "-" output is written to stdout.
+func writeHeatmaps(ctx *cli.Context, fn string, o bench.Operations) error {
+	w := os.Stdout
+	if fn != "-" {
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	asJSON := strings.HasSuffix(strings.ToLower(fn), ".json")
+	for _, h := range latencyHeatmaps(ctx, o) {
+		var err error
+		if asJSON {
+			err = h.JSON(w)
+		} else {
+			err = h.CSV(w)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printHeatmaps prints the latency heatmaps of o to the terminal.
+func printHeatmaps(ctx *cli.Context, o bench.Operations) {
+	heatmaps := latencyHeatmaps(ctx, o)
+	if len(heatmaps) == 0 {
+		return
+	}
+	width := 80
+	if w, _ := pb.GetTerminalWidth(); w > 0 {
+		width = w
+	}
+	for _, h := range heatmaps {
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Println("\n----------------------------------------")
+		console.Printf("Latency heatmap, %s:\n", h.OpType)
+		console.SetColor("Print", color.New(color.FgWhite))
+		var sb strings.Builder
+		// Leave room for the bucket labels.
+		errorIf(probe.NewError(h.ASCII(&sb, max(width-16, 10))), "Unable to render heatmap")
+		console.Print(sb.String())
+	}
+}
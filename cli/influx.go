@@ -22,12 +22,13 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
-	"github.com/influxdata/influxdb-client-go/v2/api/http"
+	influxapi "github.com/influxdata/influxdb-client-go/v2/api"
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
@@ -55,10 +56,14 @@ func newInfluxDB(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
 		}
 	}
 	tags["warp_id"] = pRandASCII(8)
+	if _, ok := tags["client"]; !ok {
+		// Identify the machine, so distributed runs can be broken down by client.
+		tags["client"], _ = os.Hostname()
+	}
 
 	// Create a new client using an InfluxDB server base URL and an authentication token
 	serverURL := u.Scheme + "://" + u.Host
-	client := influxdb2.NewClientWithOptions(serverURL, token, influxdb2.DefaultOptions().SetMaxRetryTime(1000).SetMaxRetries(2))
+	client := influxdb2.NewClient(serverURL, token)
 	{
 		to, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
@@ -67,74 +72,143 @@ func newInfluxDB(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
 			errorIf(probe.NewError(err), "unable to reach influxdb")
 		}
 	}
-	// Use blocking write client for writes to desired bucket
 	path := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
-	writeAPI := client.WriteAPI(path[1], path[0])
-	writeAPI.SetWriteFailedCallback(func(_ string, err http.Error, _ uint) bool {
-		errorIf(probe.NewError(&err), "unable to write to influxdb")
-		return false
-	})
+	w := influxWriter{
+		api:   client.WriteAPIBlocking(path[1], path[0]),
+		tags:  tags,
+		stats: make(map[influxSeries]*aggregatedStats, 100),
+		dirty: make(map[influxSeries]struct{}, 100),
+	}
 	ch := make(chan bench.Operation, 10000)
-	wg.Add(1)
+	done := make(chan struct{})
+	wg.Add(2)
 	go func() {
-		defer func() {
-			writeAPI.Flush()
-			wg.Done()
-		}()
-		hosts := make(map[string]map[string]aggregatedStats, 100)
-		totalOp := make(map[string]aggregatedStats, 5)
+		defer wg.Done()
+		defer close(done)
 		for op := range ch {
-			host := hosts[op.Endpoint]
-			var hostStats aggregatedStats
-			if host == nil {
-				host = make(map[string]aggregatedStats, 5)
-				hosts[op.Endpoint] = host
-			} else {
-				hostStats = host[op.OpType]
+			w.add(op)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer client.Close()
+		t := time.NewTicker(influxFlushInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				w.flush(false)
+			case <-done:
+				w.flush(true)
+				return
 			}
-			total := totalOp[op.OpType]
-			hostStats.add(op)
-			total.add(op)
+		}
+	}()
+	return ch
+}
 
-			// Store
-			totalOp[op.OpType] = total
-			host[op.OpType] = hostStats
+const (
+	// influxFlushInterval is the interval at which points are written.
+	influxFlushInterval = time.Second
+	// influxBatchSize is the maximum number of points written in one request.
+	influxBatchSize = 5000
+	// influxRetries is the number of times a failed batch is retried.
+	influxRetries = 3
+)
 
-			// Send
-			pTot := total.point(op)
-			pHost := hostStats.point(op)
+// influxSeries identifies a series of points.
+// Totals of all endpoints have an empty endpoint.
+type influxSeries struct {
+	endpoint, op, size string
+}
 
-			for key, tag := range tags {
-				pTot.AddTag(key, tag)
-				pHost.AddTag(key, tag)
-			}
-			pHost.AddTag("endpoint", op.Endpoint)
-			writeAPI.WritePoint(pHost)
-			writeAPI.WritePoint(pTot)
+// influxWriter aggregates operations and writes the running totals of
+// changed series periodically, so a slow InfluxDB does not slow down the benchmark.
+type influxWriter struct {
+	api  influxapi.WriteAPIBlocking
+	tags map[string]string
+
+	mu    sync.Mutex
+	stats map[influxSeries]*aggregatedStats
+	dirty map[influxSeries]struct{}
+}
+
+// add an operation to the endpoint and total series.
+func (w *influxWriter) add(op bench.Operation) {
+	size := bench.SizeBucket(op.Size)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range []influxSeries{{endpoint: op.Endpoint, op: op.OpType, size: size}, {op: op.OpType, size: size}} {
+		st := w.stats[s]
+		if st == nil {
+			st = &aggregatedStats{}
+			w.stats[s] = st
 		}
-		// Send summaries
-		for host, ops := range hosts {
-			for op, stats := range ops {
-				p := stats.summary(op)
+		st.add(op)
+		w.dirty[s] = struct{}{}
+	}
+}
 
-				for key, tag := range tags {
-					p.AddTag(key, tag)
-				}
-				p.AddTag("endpoint", host)
-				writeAPI.WritePoint(p)
-			}
+// flush writes points of all series changed since the last flush.
+// When final is set, run summaries of all series are written as well.
+func (w *influxWriter) flush(final bool) {
+	now := time.Now()
+	w.mu.Lock()
+	points := make([]*write.Point, 0, len(w.dirty))
+	for s := range w.dirty {
+		points = append(points, w.point(s, w.stats[s].point(s.op)))
+	}
+	clear(w.dirty)
+	if final {
+		for s, st := range w.stats {
+			p := w.point(s, st.summary(s.op))
+			// Summaries of totals are tagged with an empty endpoint.
+			p.AddTag("endpoint", s.endpoint)
+			points = append(points, p)
 		}
-		for op, stats := range totalOp {
-			p := stats.summary(op)
+	}
+	w.mu.Unlock()
 
-			for key, tag := range tags {
-				p.AddTag(key, tag)
-			}
-			p.AddTag("endpoint", "")
-			writeAPI.WritePoint(p)
+	for len(points) > 0 {
+		batch := points[:min(len(points), influxBatchSize)]
+		points = points[len(batch):]
+		for _, p := range batch {
+			p.SetTime(now)
 		}
-	}()
-	return ch
+		w.write(batch)
+	}
+}
+
+// point adds the tags of series s to p.
+func (w *influxWriter) point(s influxSeries, p *write.Point) *write.Point {
+	for key, tag := range w.tags {
+		p.AddTag(key, tag)
+	}
+	if s.endpoint != "" {
+		p.AddTag("endpoint", s.endpoint)
+	}
+	p.AddTag("size_bucket", s.size)
+	return p
+}
+
+// write a batch of points, retrying with backoff.
+// Points are running totals, so a dropped batch is superseded by the next flush.
+func (w *influxWriter) write(batch []*write.Point) {
+	backoff := time.Second
+	for i := 0; ; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := w.api.WritePoint(ctx, batch...)
+		cancel()
+		if err == nil {
+			return
+		}
+		if i == influxRetries {
+			errorIf(probe.NewError(err), "unable to write to influxdb")
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
 }
 
 func parseInfluxURL(ctx *cli.Context) (*url.URL, error) {
@@ -220,9 +294,9 @@ func (a *aggregatedStats) add(o bench.Operation) {
 	}
 }
 
-func (a aggregatedStats) point(op bench.Operation) *write.Point {
+func (a aggregatedStats) point(opType string) *write.Point {
 	p := influxdb2.NewPointWithMeasurement("warp")
-	p.AddTag("op", op.OpType)
+	p.AddTag("op", opType)
 	p.AddField("requests", a.ops)
 	p.AddField("objects", a.objects)
 	p.AddField("bytes_total", a.bytes)
@@ -0,0 +1,117 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var ingestFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "100GiB",
+		Usage: "Total size of each uploaded object. Can be a number or 10KiB/MiB/GiB/TiB. All sizes are base 2 binary.",
+	},
+	cli.StringFlag{
+		Name:  "part.size",
+		Value: "64MiB",
+		Usage: "Size of each uploaded part. Can be a number or MiB/GiB. Must be >= 5MiB",
+	},
+	cli.StringFlag{
+		Name:  "part.pace",
+		Value: "",
+		Usage: "Limit each upload to this speed per second by delaying parts, for example '100MiB'. Default is unlimited.",
+	},
+}
+
+var IngestCombinedFlags = combineFlags(globalFlags, ioFlags, ingestFlags, genFlags, benchFlags, analyzeFlags)
+
+// Ingest command.
+var ingestCmd = cli.Command{
+	Name:   "ingest",
+	Usage:  "benchmark sustained uploads of very large objects",
+	Action: mainIngest,
+	Before: setGlobalsFromContext,
+	Flags:  IngestCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#ingest
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainIngest is the entry point for ingest command.
+func mainIngest(ctx *cli.Context) error {
+	checkIngestSyntax(ctx)
+	objSize, _ := toSize(ctx.String("obj.size"))
+	partSize, _ := toSize(ctx.String("part.size"))
+	var pace uint64
+	if p := ctx.String("part.pace"); p != "" {
+		pace, _ = toSize(p)
+	}
+	b := bench.Ingest{
+		Common:   getCommon(ctx, newGenSource(ctx, "part.size")),
+		ObjSize:  int64(objSize),
+		PartSize: int64(partSize),
+		PartPace: int64(pace),
+	}
+	b.PutOpts = multipartOpts(ctx)
+	return runBench(ctx, &b)
+}
+
+func checkIngestSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("obj.randsize is not supported for ingest")
+	}
+	if ctx.String("obj.size-dist") != "" {
+		console.Fatal("obj.size-dist is not supported for ingest")
+	}
+	objSize, err := toSize(ctx.String("obj.size"))
+	if err != nil {
+		console.Fatal("error parsing obj.size:", err)
+	}
+	partSize, err := toSize(ctx.String("part.size"))
+	if err != nil {
+		console.Fatal("error parsing part.size:", err)
+	}
+	if partSize < 5<<20 {
+		console.Fatal("part.size must be >= 5MiB")
+	}
+	if objSize < partSize {
+		console.Fatal("obj.size must be >= part.size")
+	}
+	if (objSize+partSize-1)/partSize > 10000 {
+		console.Fatal("obj.size/part.size exceeds 10000 parts. Increase part.size")
+	}
+	if p := ctx.String("part.pace"); p != "" {
+		if _, err := toSize(p); err != nil {
+			console.Fatal("error parsing part.pace:", err)
+		}
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
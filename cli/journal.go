@@ -0,0 +1,106 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// journalEntry is a single failed operation written to the error journal.
+// Entries contain enough information to re-issue the request individually.
+type journalEntry struct {
+	Bucket    string     `json:"bucket"`
+	OpType    string     `json:"op"`
+	Endpoint  string     `json:"endpoint"`
+	File      string     `json:"file,omitempty"`
+	Size      int64      `json:"size"`
+	ObjPerOp  int        `json:"ops"`
+	Range     string     `json:"range,omitempty"`
+	Thread    uint32     `json:"thread"`
+	Start     time.Time  `json:"start"`
+	FirstByte *time.Time `json:"first_byte,omitempty"`
+	End       time.Time  `json:"end"`
+	DurNanos  int64      `json:"duration_ns"`
+	Err       string     `json:"err"`
+	// Status, RequestID and DeploymentID are taken from the last response, if captured.
+	Status       int    `json:"status,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+}
+
+// journalOpened contains journal files opened by this process.
+var journalOpened sync.Map
+
+// newErrorJournal returns a channel that will write all failed operations
+// as JSON lines to the file specified by --error-journal.
+// Successful operations are discarded.
+func newErrorJournal(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
+	fn := ctx.String("error-journal")
+	// Truncate only the first time the journal is opened,
+	// so sweep steps append to the same journal.
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if _, loaded := journalOpened.LoadOrStore(fn, struct{}{}); !loaded {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(fn, flags, 0o666)
+	fatalIf(probe.NewError(err), "Unable to create error journal")
+	bucket := ctx.String("bucket")
+
+	ch := make(chan bench.Operation, 1000)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer f.Close()
+		bw := bufio.NewWriter(f)
+		enc := json.NewEncoder(bw)
+		for op := range ch {
+			if op.Err == "" {
+				continue
+			}
+			err := enc.Encode(journalEntry{
+				Bucket:       bucket,
+				OpType:       op.OpType,
+				Endpoint:     op.Endpoint,
+				File:         op.File,
+				Size:         op.Size,
+				ObjPerOp:     op.ObjPerOp,
+				Range:        op.Range,
+				Thread:       op.Thread,
+				Start:        op.Start,
+				FirstByte:    op.FirstByte,
+				End:          op.End,
+				DurNanos:     int64(op.End.Sub(op.Start)),
+				Err:          op.Err,
+				Status:       op.Status,
+				RequestID:    op.RequestID,
+				DeploymentID: op.DeploymentID,
+			})
+			errorIf(probe.NewError(err), "Unable to write error journal")
+			// Flush so the journal can be followed while running.
+			errorIf(probe.NewError(bw.Flush()), "Unable to write error journal")
+		}
+	}()
+	return ch
+}
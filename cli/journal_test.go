@@ -0,0 +1,93 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg/bench"
+)
+
+func TestErrorJournal(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "errors.jsonl")
+	set := flag.NewFlagSet("journal", flag.ContinueOnError)
+	set.String("error-journal", fn, "")
+	set.String("bucket", "warp-benchmark-bucket", "")
+
+	var wg sync.WaitGroup
+	ch := newErrorJournal(cli.NewContext(nil, set, nil), &wg)
+	start := time.Now()
+	ch <- bench.Operation{OpType: "GET", File: "ok", Start: start, End: start.Add(time.Millisecond)}
+	ch <- bench.Operation{
+		OpType:       "GET",
+		Endpoint:     "localhost:9000",
+		File:         "obj/1",
+		Size:         1 << 10,
+		ObjPerOp:     1,
+		Range:        "1024-2047",
+		Thread:       3,
+		Start:        start,
+		End:          start.Add(time.Second),
+		Err:          "We encountered an internal error, please try again.",
+		Status:       500,
+		RequestID:    "17A3B5C3F9E2D1A0",
+		DeploymentID: "f7b1c2d3-0000-4000-8000-000000000000",
+	}
+	close(ch)
+	wg.Wait()
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("want 1 journal entry, got %d: %s", len(lines), b)
+	}
+	var got journalEntry
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := journalEntry{
+		Bucket:       "warp-benchmark-bucket",
+		OpType:       "GET",
+		Endpoint:     "localhost:9000",
+		File:         "obj/1",
+		Size:         1 << 10,
+		ObjPerOp:     1,
+		Range:        "1024-2047",
+		Thread:       3,
+		DurNanos:     int64(time.Second),
+		Err:          "We encountered an internal error, please try again.",
+		Status:       500,
+		RequestID:    "17A3B5C3F9E2D1A0",
+		DeploymentID: "f7b1c2d3-0000-4000-8000-000000000000",
+	}
+	got.Start, got.End = time.Time{}, time.Time{}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var lifecycleFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 10000,
+		Usage: "Number of objects to upload.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "days",
+		Value: 0,
+		Usage: "Days after creation objects are expired or transitioned. 0 makes objects eligible immediately",
+	},
+	cli.StringFlag{
+		Name:  "tier",
+		Usage: "Transition objects to this storage class instead of expiring them. The tier must be configured on the server",
+	},
+	cli.DurationFlag{
+		Name:  "poll",
+		Value: time.Second,
+		Usage: "Interval between listings checking for processed objects",
+	},
+}
+
+var LifecycleCombinedFlags = combineFlags(globalFlags, ioFlags, lifecycleFlags, genFlags, benchFlags, analyzeFlags)
+
+var lifecycleCmd = cli.Command{
+	Name:   "lifecycle",
+	Usage:  "benchmark lifecycle expiry and transition",
+	Action: mainLifecycle,
+	Before: setGlobalsFromContext,
+	Flags:  LifecycleCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#lifecycle
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainLifecycle is the entry point for lifecycle command.
+func mainLifecycle(ctx *cli.Context) error {
+	checkLifecycleSyntax(ctx)
+	b := bench.Lifecycle{
+		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects: ctx.Int("objects"),
+		Days:          ctx.Int("days"),
+		Tier:          ctx.String("tier"),
+		PollInterval:  ctx.Duration("poll"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkLifecycleSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if ctx.Int("days") < 0 {
+		console.Fatal("--days cannot be negative")
+	}
+	if ctx.Duration("poll") <= 0 {
+		console.Fatal("--poll must be positive")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
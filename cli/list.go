@@ -43,6 +43,28 @@ var listFlags = []cli.Flag{
 		Name:  "metadata",
 		Usage: "Enable extended MinIO ListObjects with metadata, by default this benchmarking uses ListObjectsV2 API.",
 	},
+	cli.IntFlag{
+		Name:  "tree-depth",
+		Usage: "Place objects in a directory tree of this depth in each prefix. 0 places objects directly in the prefix",
+	},
+	cli.IntFlag{
+		Name:  "fanout",
+		Value: 4,
+		Usage: "Number of sub-directories of each directory in the tree",
+	},
+	cli.IntFlag{
+		Name:  "objects-per-leaf",
+		Value: 10,
+		Usage: "Number of objects in each leaf directory of the tree. Replaces --objects when --tree-depth is set",
+	},
+	cli.BoolFlag{
+		Name:  "delimiter",
+		Usage: "List a single directory level using '/' as delimiter instead of listing recursively",
+	},
+	cli.Float64Flag{
+		Name:  "verify",
+		Usage: "Fraction of listed objects to verify with HEAD after each listing, for example 0.01. Discrepancies are recorded as validation errors",
+	},
 }
 
 var ListCombinedFlags = combineFlags(globalFlags, ioFlags, listFlags, genFlags, benchFlags, analyzeFlags)
@@ -75,6 +97,12 @@ func mainList(ctx *cli.Context) error {
 		Metadata:      ctx.Bool("metadata"),
 		CreateObjects: ctx.Int("objects"),
 		NoPrefix:      ctx.Bool("noprefix"),
+
+		TreeDepth:      ctx.Int("tree-depth"),
+		Fanout:         ctx.Int("fanout"),
+		ObjectsPerLeaf: ctx.Int("objects-per-leaf"),
+		Delimiter:      ctx.Bool("delimiter"),
+		VerifySample:   ctx.Float64("verify"),
 	}
 	return runBench(ctx, &b)
 }
@@ -89,6 +117,23 @@ func checkListSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if f := ctx.Float64("verify"); f < 0 || f > 1 {
+		console.Fatal("--verify must be between 0 and 1")
+	}
+	if ctx.Int("tree-depth") < 0 {
+		console.Fatal("--tree-depth cannot be negative")
+	}
+	if ctx.Int("tree-depth") > 0 {
+		if ctx.Int("fanout") < 1 {
+			console.Fatal("--fanout must be at least 1")
+		}
+		if ctx.Int("objects-per-leaf") < 1 {
+			console.Fatal("--objects-per-leaf must be at least 1")
+		}
+		if ctx.Bool("noprefix") {
+			console.Fatal("--tree-depth cannot be used with --noprefix")
+		}
+	}
 
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
@@ -0,0 +1,83 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"sync"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/api"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// liveCollector keeps running totals of operations as they complete.
+type liveCollector struct {
+	mu    sync.Mutex
+	stats api.LiveStats
+}
+
+// addLiveCollector adds a live collector to the outputs of c if the live stats are used,
+// which is when serving the benchmark API, sending StatsD metrics or running as a warp client.
+// Returns nil otherwise, so operations are not passed to it.
+func addLiveCollector(ctx *cli.Context, c *bench.Common, client bool) *liveCollector {
+	if !client && ctx.String(serverFlagName) == "" && ctx.String("statsd-server") == "" {
+		return nil
+	}
+	live, ch := newLiveCollector()
+	c.ExtraOut = append(c.ExtraOut, ch)
+	return live
+}
+
+// newLiveCollector returns a collector and the channel operations should be sent to.
+// The collector will stop updating when the channel is closed.
+func newLiveCollector() (*liveCollector, chan<- bench.Operation) {
+	l := &liveCollector{}
+	ch := make(chan bench.Operation, 1000)
+	go func() {
+		for op := range ch {
+			l.mu.Lock()
+			l.stats.Add(op)
+			l.mu.Unlock()
+		}
+	}()
+	return l, ch
+}
+
+// reset removes all stats.
+func (l *liveCollector) reset() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.stats = api.LiveStats{}
+	l.mu.Unlock()
+}
+
+// get returns a copy of the current stats.
+// Returns nil if no operations have been received.
+func (l *liveCollector) get() *api.LiveStats {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stats.Operations == nil {
+		return nil
+	}
+	s := l.stats.Clone()
+	return &s
+}
@@ -0,0 +1,84 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"os"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/generator"
+)
+
+var manifestFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "manifest.out",
+		Usage: "After preparing, write the objects used for the benchmark to this file as JSON lines",
+	},
+	cli.StringFlag{
+		Name:  "manifest.in",
+		Usage: "Read objects from this JSON lines manifest instead of uploading. Objects must exist in the bucket",
+	},
+}
+
+// preparedObjectser is implemented by benchmarks that can report the objects they prepared.
+type preparedObjectser interface {
+	PreparedObjects() generator.Objects
+}
+
+// readManifest reads the manifest specified with --manifest.in, if any.
+func readManifest(ctx *cli.Context) generator.Objects {
+	fn := ctx.String("manifest.in")
+	if fn == "" {
+		return nil
+	}
+	f, err := os.Open(fn)
+	fatalIf(probe.NewError(err), "Unable to open manifest")
+	defer f.Close()
+	objs, err := generator.ReadManifest(f)
+	fatalIf(probe.NewError(err), "Unable to read manifest")
+	if len(objs) == 0 {
+		fatalIf(errDummy(), "Manifest %s contains no objects", fn)
+	}
+	return objs
+}
+
+// writeManifest writes the prepared objects to the file specified with --manifest.out.
+// Benchmarks that cannot report their objects are ignored.
+func writeManifest(ctx *cli.Context, b bench.Benchmark) {
+	fn := ctx.String("manifest.out")
+	if fn == "" {
+		return
+	}
+	po, ok := b.(preparedObjectser)
+	if !ok {
+		return
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		printError("Unable to create manifest:", err)
+		return
+	}
+	defer f.Close()
+	err = po.PreparedObjects().WriteManifest(f)
+	if err != nil {
+		printError("Unable to write manifest:", err)
+		return
+	}
+	printInfo("Manifest written to ", fn)
+}
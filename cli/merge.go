@@ -23,7 +23,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
@@ -36,6 +35,7 @@ var mergeFlags = []cli.Flag{
 		Value: "",
 		Usage: "Output combined data to this file. By default unique filename is generated.",
 	},
+	benchDataCompressionFlag,
 }
 
 var mergeCmd = cli.Command{
@@ -63,26 +63,11 @@ func mainMerge(ctx *cli.Context) error {
 	if len(args) <= 1 {
 		console.Fatal("Two or more benchmark data files must be supplied")
 	}
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
-	var allOps bench.Operations
-	threads := uint16(0)
 	log := console.Printf
 	if globalQuiet {
 		log = nil
 	}
-	for _, arg := range args {
-		f, err := os.Open(arg)
-		fatalIf(probe.NewError(err), "Unable to open input file")
-		defer f.Close()
-		err = zstdDec.Reset(f)
-		fatalIf(probe.NewError(err), "Unable to decompress input")
-		ops, err := bench.OperationsFromCSV(zstdDec, false, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
-		fatalIf(probe.NewError(err), "Unable to parse input")
-
-		threads = ops.OffsetThreads(threads)
-		allOps = append(allOps, ops...)
-	}
+	allOps, _ := mergeBenchData(ctx, args, false, log)
 	if len(allOps) == 0 {
 		return errors.New("benchmark files contains no data")
 	}
@@ -98,11 +83,7 @@ func mainMerge(ctx *cli.Context) error {
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
-				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
-
-				defer enc.Close()
-				err = allOps.CSV(enc, commandLine(ctx))
+				err = writeCSVZstd(ctx, f, allOps)
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
 				console.Infof("Benchmark data written to %q\n", fileName+".csv.zst")
@@ -120,3 +101,42 @@ func mainMerge(ctx *cli.Context) error {
 
 func checkMerge(_ *cli.Context) {
 }
+
+// mergeBenchData reads and merges the operations of several benchmark data files.
+// Threads are offset, so threads of different files do not overlap,
+// and client IDs used in more than one file are made unique.
+// The metadata of the first file is returned.
+func mergeBenchData(ctx *cli.Context, files []string, analyzeOnly bool, log func(msg string, v ...interface{})) (bench.Operations, bench.Metadata) {
+	var allOps bench.Operations
+	var meta bench.Metadata
+	threads := uint32(0)
+	seenClients := make(map[string]struct{})
+	for i, fn := range files {
+		ops, m, err := readBenchData(ctx, fn, analyzeOnly, log)
+		fatalIf(probe.NewError(err), "Unable to parse input")
+		if i == 0 {
+			meta = m
+		}
+
+		threads, err = ops.OffsetThreads(threads)
+		fatalIf(probe.NewError(err), "Unable to merge %s", fn)
+
+		// Rename clients already seen in previous files.
+		rename := make(map[string]string)
+		for _, id := range ops.ClientIDs("") {
+			if _, ok := seenClients[id]; ok {
+				rename[id] = fmt.Sprintf("%s-%d", id, i+1)
+			}
+		}
+		for j := range ops {
+			if id, ok := rename[ops[j].ClientID]; ok {
+				ops[j].ClientID = id
+			}
+		}
+		for _, id := range ops.ClientIDs("") {
+			seenClients[id] = struct{}{}
+		}
+		allOps = append(allOps, ops...)
+	}
+	return allOps, meta
+}
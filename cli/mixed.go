@@ -38,6 +38,20 @@ var mixedFlags = []cli.Flag{
 		Value: "10MiB",
 		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
 	},
+	cli.StringFlag{
+		Name:  "put.obj.size",
+		Usage: "Size of objects uploaded by PUT operations. Defaults to --obj.size, which is the size of the objects uploaded before the benchmark",
+	},
+	cli.StringFlag{
+		Name:  "multipart.obj.size",
+		Value: "64MiB",
+		Usage: "Size of objects uploaded by multipart operations.",
+	},
+	cli.StringFlag{
+		Name:  "multipart.part.size",
+		Value: "16MiB",
+		Usage: "Part size of multipart operations. Must be at least 5MiB.",
+	},
 	cli.Float64Flag{
 		Name:  "get-distrib",
 		Usage: "The amount of GET operations.",
@@ -55,12 +69,51 @@ var mixedFlags = []cli.Flag{
 	},
 	cli.Float64Flag{
 		Name:  "delete-distrib",
-		Usage: "The amount of DELETE operations. Must be same or lower than -put-distrib",
+		Usage: "The amount of DELETE operations. Must be same or lower than -put-distrib and -multipart-distrib combined",
 		Value: 10,
 	},
+	cli.Float64Flag{
+		Name:  "multipart-distrib",
+		Usage: "The amount of multipart upload operations.",
+		Value: 0,
+	},
+	cli.Float64Flag{
+		Name:  "list-distrib",
+		Usage: "The amount of LIST operations. Each lists all objects in a random prefix",
+		Value: 0,
+	},
+	cli.IntFlag{
+		Name:  "list.max-keys",
+		Value: 100,
+		Usage: "Number of objects requested per page by LIST operations.",
+	},
+	cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Verify the content of downloaded objects. Mismatches are recorded as errors",
+	},
+	cli.StringFlag{
+		Name:   "read-access-key",
+		Usage:  "Specify access key used for GET and STAT operations. Defaults to --access-key",
+		EnvVar: appNameUC + "_READ_ACCESS_KEY",
+	},
+	cli.StringFlag{
+		Name:   "read-secret-key",
+		Usage:  "Specify secret key used for GET and STAT operations. Defaults to --secret-key",
+		EnvVar: appNameUC + "_READ_SECRET_KEY",
+	},
+	cli.StringFlag{
+		Name:   "write-access-key",
+		Usage:  "Specify access key used for PUT and DELETE operations. Defaults to --access-key",
+		EnvVar: appNameUC + "_WRITE_ACCESS_KEY",
+	},
+	cli.StringFlag{
+		Name:   "write-secret-key",
+		Usage:  "Specify secret key used for PUT and DELETE operations. Defaults to --secret-key",
+		EnvVar: appNameUC + "_WRITE_SECRET_KEY",
+	},
 }
 
-var MixedCombinedFlags = combineFlags(globalFlags, ioFlags, mixedFlags, genFlags, benchFlags, analyzeFlags)
+var MixedCombinedFlags = combineFlags(globalFlags, ioFlags, mixedFlags, accessFlags, genFlags, benchFlags, analyzeFlags)
 
 var mixedCmd = cli.Command{
 	Name:   "mixed",
@@ -90,7 +143,10 @@ func mainMixed(ctx *cli.Context) error {
 			"STAT":            ctx.Float64("stat-distrib"),
 			http.MethodPut:    ctx.Float64("put-distrib"),
 			http.MethodDelete: ctx.Float64("delete-distrib"),
+			"MULTIPART":       ctx.Float64("multipart-distrib"),
+			"LIST":            ctx.Float64("list-distrib"),
 		},
+		Access: accessDist(ctx),
 	}
 	err := dist.Generate(ctx.Int("objects") * 2)
 	fatalIf(probe.NewError(err), "Invalid distribution")
@@ -101,7 +157,22 @@ func mainMixed(ctx *cli.Context) error {
 		StatOpts: minio.StatObjectOptions{
 			ServerSideEncryption: sse,
 		},
-		Dist: &dist,
+		Dist:        &dist,
+		Verify:      ctx.Bool("verify"),
+		ListMaxKeys: ctx.Int("list.max-keys"),
+	}
+	if ctx.String("put.obj.size") != "" {
+		b.PutSource = newGenSource(ctx, "put.obj.size", withSeedStream(ctx, 1))
+	}
+	if ctx.Float64("multipart-distrib") > 0 {
+		b.MultipartSource = newGenSource(ctx, "multipart.obj.size", withSeedStream(ctx, 2))
+		b.MultipartPartSize, _ = toSize(ctx.String("multipart.part.size"))
+	}
+	if ctx.String("read-access-key") != "" {
+		b.ReadClient = newClientCreds(ctx, ctx.String("read-access-key"), ctx.String("read-secret-key"))
+	}
+	if ctx.String("write-access-key") != "" {
+		b.WriteClient = newClientCreds(ctx, ctx.String("write-access-key"), ctx.String("write-secret-key"))
 	}
 	return runBench(ctx, &b)
 }
@@ -113,6 +184,31 @@ func checkMixedSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	for _, role := range []string{"read", "write"} {
+		if (ctx.String(role+"-access-key") == "") != (ctx.String(role+"-secret-key") == "") {
+			console.Fatalf("--%s-access-key and --%s-secret-key must be specified together\n", role, role)
+		}
+	}
+	if _, err := bench.ParseAccessDist(ctx.String("access-dist")); err != nil {
+		console.Fatal(err)
+	}
+	if ctx.Float64("multipart-distrib") > 0 {
+		partSize, err := toSize(ctx.String("multipart.part.size"))
+		if err != nil {
+			console.Fatal("error parsing multipart.part.size:", err)
+		}
+		if partSize < 5<<20 {
+			console.Fatal("multipart.part.size must be >= 5MiB")
+		}
+	}
+	if ctx.Int("list.max-keys") < 0 {
+		console.Fatal("list.max-keys cannot be negative")
+	}
+	for _, f := range []string{"put.obj.size", "multipart.obj.size"} {
+		if ctx.IsSet(f) && ctx.String("obj.size-dist") != "" {
+			console.Fatalf("--%s cannot be used with --obj.size-dist\n", f)
+		}
+	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
@@ -0,0 +1,104 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"math"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var multipartPutFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "part.size",
+		Value: "5MiB",
+		Usage: "Size of each part. Can be a number or MiB/GiB. Must be >= 5MiB",
+	},
+	cli.IntFlag{
+		Name:  "parts",
+		Value: 10,
+		Usage: "Number of parts in each object",
+	},
+	cli.IntFlag{
+		Name:  "part.concurrency",
+		Value: 4,
+		Usage: "Number of parts uploaded concurrently for each object",
+	},
+}
+
+var MultipartPutCombinedFlags = combineFlags(globalFlags, ioFlags, multipartPutFlags, genFlags, benchFlags, analyzeFlags)
+
+// MultipartPut command.
+var multipartPutCmd = cli.Command{
+	Name:   "multipart-put",
+	Usage:  "benchmark multipart uploads",
+	Action: mainMultipartPut,
+	Before: setGlobalsFromContext,
+	Flags:  MultipartPutCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#multipart-put
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainMultipartPut is the entry point for multipart-put command.
+func mainMultipartPut(ctx *cli.Context) error {
+	checkMultipartPutSyntax(ctx)
+	b := bench.MultipartPut{
+		Common:          getCommon(ctx, newGenSource(ctx, "part.size")),
+		Parts:           ctx.Int("parts"),
+		PartConcurrency: ctx.Int("part.concurrency"),
+	}
+	b.PutOpts = multipartOpts(ctx)
+	return runBench(ctx, &b)
+}
+
+func checkMultipartPutSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("obj.randsize is not supported for multipart-put")
+	}
+	partSize, err := toSize(ctx.String("part.size"))
+	if err != nil {
+		console.Fatal("error parsing part.size:", err)
+	}
+	if partSize < 5<<20 {
+		console.Fatal("part.size must be >= 5MiB")
+	}
+	if ctx.Int("parts") < 1 || ctx.Int("parts") > 10000 {
+		console.Fatal("parts must be between 1 and 10000")
+	}
+	if ctx.Int("part.concurrency") < 1 {
+		console.Fatal("part.concurrency must be at least 1")
+	}
+	if uint64(ctx.Int("concurrent"))*uint64(ctx.Int("part.concurrency")) > math.MaxUint32 {
+		console.Fatal("concurrent * part.concurrency must be less than 4294967296")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,97 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var notifyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.StringFlag{
+		Name:  "target",
+		Value: string(bench.NotifyWebhook),
+		Usage: "Receive notifications with an embedded 'webhook' receiver or the MinIO 'listen' API",
+	},
+	cli.StringFlag{
+		Name:  "webhook.addr",
+		Value: ":9080",
+		Usage: "Address the webhook receiver listens on. The server must send bucket events to it",
+	},
+	cli.DurationFlag{
+		Name:  "notify.timeout",
+		Value: 30 * time.Second,
+		Usage: "Time to wait for outstanding notifications after uploads have stopped",
+	},
+}
+
+var NotifyCombinedFlags = combineFlags(globalFlags, ioFlags, notifyFlags, genFlags, benchFlags, analyzeFlags)
+
+var notifyCmd = cli.Command{
+	Name:   "notify",
+	Usage:  "benchmark bucket event notification latency",
+	Action: mainNotify,
+	Before: setGlobalsFromContext,
+	Flags:  NotifyCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#notify
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainNotify is the entry point for notify command.
+func mainNotify(ctx *cli.Context) error {
+	checkNotifySyntax(ctx)
+	b := bench.Notify{
+		Common:  getCommon(ctx, newGenSource(ctx, "obj.size")),
+		Target:  bench.NotifyTarget(ctx.String("target")),
+		Addr:    ctx.String("webhook.addr"),
+		Timeout: ctx.Duration("notify.timeout"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkNotifySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	switch bench.NotifyTarget(ctx.String("target")) {
+	case bench.NotifyWebhook, bench.NotifyListen:
+	default:
+		console.Fatalf("unknown --target %q. Must be 'webhook' or 'listen'\n", ctx.String("target"))
+	}
+	if ctx.Duration("notify.timeout") <= 0 {
+		console.Fatal("--notify.timeout must be positive")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
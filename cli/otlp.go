@@ -0,0 +1,318 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/bench"
+)
+
+const (
+	// otlpFlushInterval is the interval at which spans are sent.
+	otlpFlushInterval = time.Second
+	// otlpBatchSize is the maximum number of spans sent in one request.
+	otlpBatchSize = 1000
+	// otlpMaxPending is the maximum number of spans waiting to be sent.
+	// Spans beyond this are dropped, so a slow collector does not slow down the benchmark.
+	otlpMaxPending = 100_000
+	// otlpRetries is the number of times a failed request is retried.
+	otlpRetries = 3
+)
+
+// OTLP span kind and status codes.
+const (
+	otlpSpanKindClient  = 3
+	otlpStatusCodeOK    = 1
+	otlpStatusCodeError = 2
+)
+
+// parseOTLPURL returns the OTLP/HTTP traces URL from --otlp.endpoint.
+func parseOTLPURL(ctx *cli.Context) (*url.URL, error) {
+	s := ctx.String("otlp.endpoint")
+	if s == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "":
+		return nil, errors.New("otlp: no scheme specified (http/https)")
+	default:
+		return nil, fmt.Errorf("otlp: unknown scheme %s - must be http/https", u.Scheme)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u, nil
+}
+
+// newOTLPExporter returns a channel that sends a span per operation to the OTLP/HTTP endpoint given by --otlp.endpoint.
+// Operations are sampled with the rate given by --otlp.sample.
+func newOTLPExporter(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
+	u, err := parseOTLPURL(ctx)
+	if err != nil {
+		fatalIf(probe.NewError(err), "unable to parse otlp parameter")
+	}
+	hostname, _ := os.Hostname()
+	e := otlpExporter{
+		url:    u.String(),
+		client: &http.Client{Timeout: 10 * time.Second},
+		resource: []otlpAttr{
+			otlpString("service.name", appName),
+			otlpString("service.version", pkg.Version),
+			otlpString("host.name", hostname),
+			otlpString("warp.id", pRandASCII(8)),
+		},
+	}
+	sample := ctx.Float64("otlp.sample")
+	ch := make(chan bench.Operation, 10000)
+	done := make(chan struct{})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		rng := mrand.New(mrand.NewSource(time.Now().UnixNano()))
+		for op := range ch {
+			if sample < 1 && rng.Float64() >= sample {
+				continue
+			}
+			e.add(op)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(otlpFlushInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				e.flush()
+			case <-done:
+				e.flush()
+				if e.dropped > 0 {
+					printError(fmt.Sprintf("otlp: %d spans dropped, collector too slow", e.dropped))
+				}
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// otlpExporter collects spans and sends them in batches.
+type otlpExporter struct {
+	url      string
+	client   *http.Client
+	resource []otlpAttr
+
+	mu      sync.Mutex
+	pending []otlpSpan
+	dropped int
+}
+
+// add a span for op.
+func (e *otlpExporter) add(op bench.Operation) {
+	s := otlpSpan{
+		TraceID:   randHex(16),
+		SpanID:    randHex(8),
+		Name:      op.OpType,
+		Kind:      otlpSpanKindClient,
+		StartTime: strconv.FormatInt(op.Start.UnixNano(), 10),
+		EndTime:   strconv.FormatInt(op.End.UnixNano(), 10),
+		Attributes: []otlpAttr{
+			otlpString("warp.op", op.OpType),
+			otlpInt("warp.size", op.Size),
+			otlpInt("warp.objects", int64(op.ObjPerOp)),
+			otlpString("warp.endpoint", op.Endpoint),
+			otlpInt("warp.thread", int64(op.Thread)),
+		},
+		Status: otlpStatus{Code: otlpStatusCodeOK},
+	}
+	if op.File != "" {
+		s.Attributes = append(s.Attributes, otlpString("warp.object", op.File))
+	}
+	if op.FirstByte != nil {
+		s.Attributes = append(s.Attributes, otlpInt("warp.ttfb_ns", int64(op.FirstByte.Sub(op.Start))))
+		s.Events = []otlpEvent{{Time: strconv.FormatInt(op.FirstByte.UnixNano(), 10), Name: "first_byte"}}
+	}
+	if op.Err != "" {
+		s.Status = otlpStatus{Code: otlpStatusCodeError, Message: op.Err}
+		if op.ErrClass != "" {
+			s.Attributes = append(s.Attributes, otlpString("error.type", op.ErrClass))
+		}
+	}
+	e.mu.Lock()
+	if len(e.pending) < otlpMaxPending {
+		e.pending = append(e.pending, s)
+	} else {
+		e.dropped++
+	}
+	e.mu.Unlock()
+}
+
+// flush sends all pending spans.
+func (e *otlpExporter) flush() {
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	for len(spans) > 0 {
+		batch := spans[:min(len(spans), otlpBatchSize)]
+		spans = spans[len(batch):]
+		err := e.send(batch)
+		if err != nil {
+			errorIf(probe.NewError(err), "unable to send spans to otlp collector")
+		}
+	}
+}
+
+// send a batch of spans, retrying with backoff.
+func (e *otlpExporter) send(spans []otlpSpan) error {
+	var req otlpTraces
+	req.ResourceSpans = []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: e.resource},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: appName, Version: pkg.Version},
+			Spans: spans,
+		}},
+	}}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	backoff := time.Second
+	for i := 0; ; i++ {
+		err = e.post(body)
+		if err == nil || i == otlpRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (e *otlpExporter) post(body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: %s returned %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+// randHex returns n random bytes as hex.
+func randHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// OTLP/HTTP JSON encoding of trace data.
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID    string      `json:"traceId"`
+	SpanID     string      `json:"spanId"`
+	Name       string      `json:"name"`
+	Kind       int         `json:"kind"`
+	StartTime  string      `json:"startTimeUnixNano"`
+	EndTime    string      `json:"endTimeUnixNano"`
+	Attributes []otlpAttr  `json:"attributes,omitempty"`
+	Events     []otlpEvent `json:"events,omitempty"`
+	Status     otlpStatus  `json:"status"`
+}
+
+type otlpEvent struct {
+	Time string `json:"timeUnixNano"`
+	Name string `json:"name"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string        `json:"key"`
+	Value otlpAttrValue `json:"value"`
+}
+
+type otlpAttrValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func otlpString(key, value string) otlpAttr {
+	return otlpAttr{Key: key, Value: otlpAttrValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) otlpAttr {
+	v := strconv.FormatInt(value, 10)
+	return otlpAttr{Key: key, Value: otlpAttrValue{IntValue: &v}}
+}
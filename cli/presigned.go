@@ -0,0 +1,97 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var presignedFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 2500,
+		Usage: "Number of objects to upload for downloads.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "10MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.BoolFlag{
+		Name:  "put",
+		Usage: "Upload new objects using presigned PUT URLs instead of downloading",
+	},
+	cli.DurationFlag{
+		Name:  "expiry",
+		Value: time.Hour,
+		Usage: "Validity of the presigned URLs",
+	},
+}
+
+var PresignedCombinedFlags = combineFlags(globalFlags, ioFlags, presignedFlags, genFlags, benchFlags, analyzeFlags)
+
+var presignedCmd = cli.Command{
+	Name:   "presigned",
+	Usage:  "benchmark requests using presigned URLs",
+	Action: mainPresigned,
+	Before: setGlobalsFromContext,
+	Flags:  PresignedCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#presigned
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainPresigned is the entry point for presigned command.
+func mainPresigned(ctx *cli.Context) error {
+	checkPresignedSyntax(ctx)
+	b := bench.Presigned{
+		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects: ctx.Int("objects"),
+		Put:           ctx.Bool("put"),
+		Expiry:        ctx.Duration("expiry"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkPresignedSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if d := ctx.Duration("expiry"); d < time.Second || d > 7*24*time.Hour {
+		console.Fatal("--expiry must be between 1s and 7 days")
+	}
+	if ctx.Bool("encrypt") || ctx.Bool("cse-encrypt") {
+		console.Fatal("Encryption cannot be used with presigned URLs")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -20,6 +20,7 @@ package cli
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strings"
 
 	"github.com/minio/cli"
@@ -44,9 +45,17 @@ var putFlags = []cli.Flag{
 		Name:  "post",
 		Usage: "Use PostObject for upload. Will force single part upload",
 	},
+	cli.IntFlag{
+		Name:  "post.status",
+		Usage: "success_action_status to request for PostObject uploads. Can be 200, 201 or 204. Default is the server default",
+	},
+	cli.BoolFlag{
+		Name:  "stream",
+		Usage: "Upload objects without a content length, as streaming multipart uploads",
+	},
 }
 
-var PutCombinedFlags = combineFlags(globalFlags, ioFlags, putFlags, genFlags, benchFlags, analyzeFlags)
+var PutCombinedFlags = combineFlags(globalFlags, ioFlags, putFlags, cseFlags, genFlags, benchFlags, analyzeFlags)
 
 // Put command.
 var putCmd = cli.Command{
@@ -73,10 +82,19 @@ func mainPut(ctx *cli.Context) error {
 	b := bench.Put{
 		Common:     getCommon(ctx, newGenSource(ctx, "obj.size")),
 		PostObject: ctx.Bool("post"),
+		PostStatus: ctx.Int("post.status"),
+		Stream:     ctx.Bool("stream"),
+	}
+	if b.Stream && b.PutOpts.PartSize == 0 {
+		// Without a part size, the client will buffer parts sized for a 5TiB object.
+		b.PutOpts.PartSize = streamPartSize
 	}
 	return runBench(ctx, &b)
 }
 
+// streamPartSize is the default part size of streaming uploads.
+const streamPartSize = 16 << 20
+
 const metadataChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890-_."
 
 // putOpts retrieves put options from the context.
@@ -102,7 +120,7 @@ func putOpts(ctx *cli.Context) minio.PutObjectOptions {
 			key := v[:idx]
 			value := v[idx+1:]
 			if len(value) == 0 {
-				console.Fatal("--%s value can't be empty", flag)
+				console.Fatalf("--%s value can't be empty", flag)
 			}
 			var randN int
 			if _, err := fmt.Sscanf(value, "rand:%d", &randN); err == nil {
@@ -126,10 +144,80 @@ func putOpts(ctx *cli.Context) minio.PutObjectOptions {
 	return options
 }
 
+// parseChecksum returns the checksum type given by --checksum,
+// and whether it should be sent as a trailing checksum.
+func parseChecksum(ctx *cli.Context) (minio.ChecksumType, bool) {
+	s := ctx.String("checksum")
+	if s == "" {
+		return minio.ChecksumNone, false
+	}
+	algo, opt, _ := strings.Cut(strings.ToLower(s), ",")
+	var t minio.ChecksumType
+	switch algo {
+	case "crc32c":
+		t = minio.ChecksumCRC32C
+	case "crc32":
+		t = minio.ChecksumCRC32
+	case "sha1":
+		t = minio.ChecksumSHA1
+	case "sha256":
+		t = minio.ChecksumSHA256
+	default:
+		fatal(errDummy(), "unknown --checksum algorithm %q. Use crc32c, crc32, sha1 or sha256", algo)
+	}
+	switch opt {
+	case "":
+		return t, false
+	case "trailing":
+	default:
+		fatal(errDummy(), "unknown --checksum option %q", opt)
+	}
+	if t != minio.ChecksumCRC32C {
+		fatal(errDummy(), "only crc32c can be sent as a trailing checksum")
+	}
+	if ctx.Bool("md5") {
+		fatal(errDummy(), "trailing checksums cannot be combined with --md5")
+	}
+	if !ctx.Bool("tls") && !ctx.Bool("disable-sha256-payload") {
+		fatal(errDummy(), "trailing checksums require --tls or --disable-sha256-payload")
+	}
+	return t, true
+}
+
+// checksumType returns the checksum type to calculate for uploads.
+// Trailing checksums are added by the client and return ChecksumNone.
+func checksumType(ctx *cli.Context) minio.ChecksumType {
+	t, trailing := parseChecksum(ctx)
+	if trailing {
+		return minio.ChecksumNone
+	}
+	return t
+}
+
 func checkPutSyntax(ctx *cli.Context) {
 	if ctx.NArg() > 0 {
 		console.Fatal("Command takes no arguments")
 	}
+	if ctx.Bool("cse-encrypt") && ctx.Bool("post") {
+		console.Fatal("--cse-encrypt cannot be combined with --post")
+	}
+	switch ctx.Int("post.status") {
+	case 0:
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		if !ctx.Bool("post") {
+			console.Fatal("--post.status requires --post")
+		}
+	default:
+		console.Fatal("--post.status must be 200, 201 or 204")
+	}
+	if ctx.Bool("stream") {
+		if ctx.Bool("post") {
+			console.Fatal("--stream cannot be combined with --post")
+		}
+		if ctx.Bool("disable-multipart") {
+			console.Fatal("--stream cannot be combined with --disable-multipart")
+		}
+	}
 
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
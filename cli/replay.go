@@ -0,0 +1,100 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/generator"
+)
+
+var replayFlags = []cli.Flag{
+	cli.Float64Flag{
+		Name:  "speed",
+		Value: 1,
+		Usage: "Replay speed relative to the recorded timing. 2 replays twice as fast. 0 issues operations as fast as possible",
+	},
+}
+
+var ReplayCombinedFlags = combineFlags(globalFlags, ioFlags, replayFlags, benchFlags, analyzeFlags)
+
+var replayCmd = cli.Command{
+	Name:   "replay",
+	Usage:  "replay operations recorded in benchmark data",
+	Action: mainReplay,
+	Before: setGlobalsFromContext,
+	Flags:  ReplayCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] benchmark-data-file
+  -> see https://github.com/minio/warp#replay
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainReplay is the entry point for replay command.
+func mainReplay(ctx *cli.Context) error {
+	checkReplaySyntax(ctx)
+	log := console.Printf
+	if globalQuiet {
+		log = nil
+	}
+	ops, _, err := readBenchData(ctx, ctx.Args().First(), false, log)
+	fatalIf(probe.NewError(err), "Unable to read benchmark data")
+	ops, _ = ops.SplitAnnotations()
+
+	// Generate objects big enough for all recorded sizes.
+	var maxSize int64 = 1
+	for _, op := range ops {
+		maxSize = max(maxSize, op.Size)
+	}
+	src, err := generator.NewFn(generator.WithRandomData().Apply(),
+		generator.WithSize(maxSize),
+		withSeed(ctx),
+	)
+	fatalIf(probe.NewError(err), "Unable to create data generator")
+	b := bench.Replay{
+		Common: getCommon(ctx, src),
+		Ops:    ops,
+		Speed:  ctx.Float64("speed"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkReplaySyntax(ctx *cli.Context) {
+	if ctx.NArg() != 1 {
+		console.Fatal("A single benchmark data file must be given")
+	}
+	if ctx.Float64("speed") < 0 {
+		console.Fatal("--speed cannot be negative")
+	}
+	if ctx.String("warp-client") != "" {
+		console.Fatal("Replay cannot be used with --warp-client")
+	}
+	if ctx.Bool("encrypt") || ctx.Bool("cse-encrypt") {
+		console.Fatal("Encryption cannot be used when replaying")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,125 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var replicationFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "64KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.StringFlag{
+		Name:   "host-dst",
+		Usage:  "Replication destination host. Multiple hosts can be specified as a comma separated list.",
+		EnvVar: appNameUC + "_HOST_DST",
+	},
+	cli.StringFlag{
+		Name:  "bucket-dst",
+		Usage: "Replication destination bucket. Defaults to --bucket",
+	},
+	cli.StringFlag{
+		Name:   "access-key-dst",
+		Usage:  "Specify access key of the destination. Defaults to --access-key",
+		EnvVar: appNameUC + "_ACCESS_KEY_DST",
+	},
+	cli.StringFlag{
+		Name:   "secret-key-dst",
+		Usage:  "Specify secret key of the destination. Defaults to --secret-key",
+		EnvVar: appNameUC + "_SECRET_KEY_DST",
+	},
+	cli.DurationFlag{
+		Name:  "poll",
+		Value: 100 * time.Millisecond,
+		Usage: "Interval between checks for replicated objects on the destination",
+	},
+	cli.DurationFlag{
+		Name:  "max-lag",
+		Value: 5 * time.Minute,
+		Usage: "Record an error if an object is not replicated within this time",
+	},
+}
+
+var ReplicationCombinedFlags = combineFlags(globalFlags, ioFlags, replicationFlags, genFlags, benchFlags, analyzeFlags)
+
+var replicationCmd = cli.Command{
+	Name:   "replication",
+	Usage:  "benchmark bucket replication lag",
+	Action: mainReplication,
+	Before: setGlobalsFromContext,
+	Flags:  ReplicationCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#replication
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainReplication is the entry point for replication command.
+func mainReplication(ctx *cli.Context) error {
+	checkReplicationSyntax(ctx)
+	accessKey, secretKey := ctx.String("access-key"), ctx.String("secret-key")
+	if ctx.String("access-key-dst") != "" {
+		accessKey, secretKey = ctx.String("access-key-dst"), ctx.String("secret-key-dst")
+	}
+	dstBucket := ctx.String("bucket-dst")
+	if dstBucket == "" {
+		dstBucket = ctx.String("bucket")
+	}
+	b := bench.Replication{
+		Common:       getCommon(ctx, newGenSource(ctx, "obj.size")),
+		DstClient:    newClientHosts(ctx, ctx.String("host-dst"), accessKey, secretKey),
+		DstBucket:    dstBucket,
+		PollInterval: ctx.Duration("poll"),
+		MaxLag:       ctx.Duration("max-lag"),
+	}
+	return runBench(ctx, &b)
+}
+
+func checkReplicationSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.String("host-dst") == "" {
+		console.Fatal("--host-dst must be specified")
+	}
+	if (ctx.String("access-key-dst") == "") != (ctx.String("secret-key-dst") == "") {
+		console.Fatal("--access-key-dst and --secret-key-dst must be specified together")
+	}
+	if ctx.Duration("poll") <= 0 {
+		console.Fatal("--poll must be positive")
+	}
+	if ctx.Duration("max-lag") < ctx.Duration("poll") {
+		console.Fatal("--max-lag must be at least --poll")
+	}
+
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,275 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/aggregate"
+)
+
+// reportOp is an operation type in the HTML report.
+type reportOp struct {
+	aggregate.Operation
+	// Throughput over time, requests percentiles and time to first byte percentiles as charts.
+	ThroughputChart template.HTML
+	LatencyChart    template.HTML
+	TTFBChart       template.HTML
+	ByHost          []reportHost
+}
+
+// reportHost is the throughput of a single host.
+type reportHost struct {
+	Name string
+	aggregate.Throughput
+	// Share is the throughput relative to the fastest host in percent.
+	Share float64
+}
+
+// writeHTMLReport writes aggr as a standalone HTML report to fn.
+func writeHTMLReport(fn string, aggr aggregate.Aggregated) error {
+	data := struct {
+		aggregate.Aggregated
+		Title     string
+		Generated time.Time
+		Version   string
+		Ops       []reportOp
+	}{
+		Aggregated: aggr,
+		Title:      "Warp benchmark report",
+		Generated:  time.Now(),
+		Version:    pkg.Version,
+	}
+	for _, op := range aggr.Operations {
+		if op.Skipped {
+			continue
+		}
+		rop := reportOp{Operation: op}
+		if seg := op.Throughput.Segmented; seg != nil && len(seg.Segments) > 1 {
+			segs := slices.Clone(seg.Segments)
+			slices.SortFunc(segs, func(a, b aggregate.SegmentSmall) int { return a.Start.Compare(b.Start) })
+			vals := make([]float64, len(segs))
+			unit := "MiB/s"
+			for i, s := range segs {
+				vals[i] = s.BPS / (1 << 20)
+			}
+			if op.Throughput.AverageBPS == 0 {
+				unit = "obj/s"
+				for i, s := range segs {
+					vals[i] = s.OPS
+				}
+			}
+			segDur := time.Duration(seg.SegmentDurationMillis) * time.Millisecond
+			rop.ThroughputChart = svgLineChart(vals, unit, "0s", (segDur * time.Duration(len(segs))).String())
+		}
+		if r := op.SingleSizedRequests; r != nil && !r.Skipped {
+			rop.LatencyChart = svgLineChart(pctMillis(r.DurPct), "ms", "p0", "p100")
+			if r.FirstByte != nil {
+				rop.TTFBChart = svgLineChart(pctMillis(r.FirstByte.PercentilesMillis), "ms", "p0", "p100")
+			}
+		}
+		var fastest float64
+		for name, t := range op.ThroughputByHost {
+			rop.ByHost = append(rop.ByHost, reportHost{Name: name, Throughput: t})
+			fastest = max(fastest, t.AverageBPS, t.AverageOPS)
+		}
+		slices.SortFunc(rop.ByHost, func(a, b reportHost) int { return strings.Compare(a.Name, b.Name) })
+		for i := range rop.ByHost {
+			h := &rop.ByHost[i]
+			v := h.AverageBPS
+			if v == 0 {
+				v = h.AverageOPS
+			}
+			if fastest > 0 {
+				h.Share = 100 * v / fastest
+			}
+		}
+		data.Ops = append(data.Ops, rop)
+	}
+
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	err = reportTemplate.Execute(f, data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// pctMillis returns percentiles as float values.
+func pctMillis(pct [101]int) []float64 {
+	vals := make([]float64, len(pct))
+	for i, v := range pct {
+		vals[i] = float64(v)
+	}
+	return vals
+}
+
+// svgLineChart renders vals as an inline SVG line chart.
+// The y axis starts at 0 and is labeled with unit. The x axis is labeled with first and last.
+func svgLineChart(vals []float64, unit, first, last string) template.HTML {
+	const w, h, padL, padB, padT = 720.0, 220.0, 70.0, 24.0, 10.0
+	top := 0.0
+	for _, v := range vals {
+		top = max(top, v)
+	}
+	if top == 0 || len(vals) < 2 {
+		return ""
+	}
+	// Round the top of the y axis up to 1, 2 or 5 times a power of 10.
+	mag := math.Pow(10, math.Floor(math.Log10(top)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if top <= m*mag {
+			top = m * mag
+			break
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %.0f %.0f" class="chart">`, w, h)
+	for i := 0; i <= 4; i++ {
+		y := padT + (h-padT-padB)*float64(i)/4
+		fmt.Fprintf(&b, `<line x1="%.0f" y1="%.1f" x2="%.0f" y2="%.1f" class="grid"/>`, padL, y, w, y)
+		fmt.Fprintf(&b, `<text x="%.0f" y="%.1f" class="ylabel">%s</text>`, padL-6, y+4, template.HTMLEscapeString(formatAxis(top*float64(4-i)/4, unit)))
+	}
+	fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" class="xlabel" text-anchor="start">%s</text>`, padL, h-6, template.HTMLEscapeString(first))
+	fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" class="xlabel" text-anchor="end">%s</text>`, w, h-6, template.HTMLEscapeString(last))
+	b.WriteString(`<polyline class="line" points="`)
+	for i, v := range vals {
+		x := padL + (w-padL)*float64(i)/float64(len(vals)-1)
+		y := padT + (h-padT-padB)*(1-v/top)
+		fmt.Fprintf(&b, "%.1f,%.1f ", x, y)
+	}
+	b.WriteString(`"/></svg>`)
+	return template.HTML(b.String())
+}
+
+// formatAxis formats an axis value with unit.
+func formatAxis(v float64, unit string) string {
+	switch {
+	case v == 0:
+		return "0"
+	case v >= 100:
+		return fmt.Sprintf("%.0f %s", v, unit)
+	case v >= 10:
+		return fmt.Sprintf("%.1f %s", v, unit)
+	}
+	return fmt.Sprintf("%.2f %s", v, unit)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"mib": func(bps float64) string { return fmt.Sprintf("%.2f MiB/s", bps/(1<<20)) },
+	"ops": func(ops float64) string { return fmt.Sprintf("%.2f obj/s", ops) },
+	"ms":  func(ms int) string { return (time.Duration(ms) * time.Millisecond).String() },
+	"dur": func(start, end time.Time) string { return end.Sub(start).Round(time.Millisecond).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h1, h2, h3 { font-weight: 600; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .3em; margin-top: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: .3em .8em; text-align: left; border-bottom: 1px solid #eee; }
+th { color: #555; font-weight: 600; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.bar { background: #c72e49; height: .8em; }
+.chart { width: 100%; height: auto; }
+.chart .grid { stroke: #e5e5e5; }
+.chart .line { fill: none; stroke: #c72e49; stroke-width: 2; }
+.chart text { font-size: 11px; fill: #666; }
+.chart .ylabel { text-anchor: end; }
+.muted { color: #888; font-size: .9em; }
+.errors { color: #c00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}} by warp {{.Version}}.</p>
+{{with .Metadata}}{{if not .IsEmpty}}
+<h2>Metadata</h2>
+<table>
+{{if .Command}}<tr><th>Command</th><td><code>{{.Command}}</code></td></tr>{{end}}
+{{if .Version}}<tr><th>Version</th><td>{{.Version}}</td></tr>{{end}}
+{{range $k, $v := .Values}}<tr><th>{{$k}}</th><td>{{$v}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+{{with .MixedServerStats}}
+<h2>Mixed Operations</h2>
+<p>Total throughput: {{if .AverageBPS}}{{mib .AverageBPS}}, {{end}}{{ops .AverageOPS}}{{if .Errors}}, <span class="errors">{{.Errors}} errors</span>{{end}}.</p>
+{{end}}
+{{range .Ops}}
+<h2>{{.Type}}</h2>
+<table>
+<tr><th>Operations</th><td class="num">{{.N}}</td></tr>
+<tr><th>Concurrency</th><td class="num">{{.Concurrency}}</td></tr>
+<tr><th>Hosts</th><td class="num">{{.Hosts}}</td></tr>
+{{if gt .Clients 1}}<tr><th>Clients</th><td class="num">{{.Clients}}</td></tr>{{end}}
+<tr><th>Duration</th><td class="num">{{dur .StartTime .EndTime}}</td></tr>
+<tr><th>Average</th><td class="num">{{if .Throughput.AverageBPS}}{{mib .Throughput.AverageBPS}}, {{end}}{{ops .Throughput.AverageOPS}}</td></tr>
+{{with .Throughput.Segmented}}
+<tr><th>Fastest</th><td class="num">{{if .FastestBPS}}{{mib .FastestBPS}}, {{end}}{{ops .FastestOPS}}</td></tr>
+<tr><th>Median</th><td class="num">{{if .MedianBPS}}{{mib .MedianBPS}}, {{end}}{{ops .MedianOPS}}</td></tr>
+<tr><th>Slowest</th><td class="num">{{if .SlowestBPS}}{{mib .SlowestBPS}}, {{end}}{{ops .SlowestOPS}}</td></tr>
+{{end}}
+{{if .Errors}}<tr><th>Errors</th><td class="num errors">{{.Errors}}</td></tr>{{end}}
+</table>
+{{if .FirstErrors}}<p class="errors">First errors:</p><ul>{{range .FirstErrors}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{if .ThroughputChart}}<h3>Throughput over time</h3>
+{{.ThroughputChart}}{{end}}
+{{with .SingleSizedRequests}}{{if not .Skipped}}
+<h3>Request latency</h3>
+<table>
+<tr><th>Average</th><th>Median</th><th>90%</th><th>99%</th><th>Fastest</th><th>Slowest</th><th>StdDev</th></tr>
+<tr><td class="num">{{ms .DurAvgMillis}}</td><td class="num">{{ms .DurMedianMillis}}</td><td class="num">{{ms .Dur90Millis}}</td><td class="num">{{ms .Dur99Millis}}</td><td class="num">{{ms .FastestMillis}}</td><td class="num">{{ms .SlowestMillis}}</td><td class="num">{{ms .StdDev}}</td></tr>
+{{with .FirstByte}}<tr><th colspan="7">Time to first byte</th></tr>
+<tr><td class="num">{{ms .AverageMillis}}</td><td class="num">{{ms .MedianMillis}}</td><td class="num">{{ms .P90Millis}}</td><td class="num">{{ms .P99Millis}}</td><td class="num">{{ms .FastestMillis}}</td><td class="num">{{ms .SlowestMillis}}</td><td class="num">{{ms .StdDevMillis}}</td></tr>{{end}}
+</table>
+{{end}}{{end}}
+{{if .LatencyChart}}<h3>Request time percentiles</h3>
+{{.LatencyChart}}{{end}}
+{{if .TTFBChart}}<h3>Time to first byte percentiles</h3>
+{{.TTFBChart}}{{end}}
+{{with .MultiSizedRequests}}{{if not .Skipped}}
+<h3>Requests by size</h3>
+<table>
+<tr><th>Size</th><th>Requests</th><th>Average</th><th>Median</th><th>Fastest</th><th>Slowest</th></tr>
+{{range .BySize}}<tr><td>{{.MinSizeString}} - {{.MaxSizeString}}</td><td class="num">{{.Requests}}</td><td class="num">{{mib .BpsAverage}}</td><td class="num">{{mib .BpsMedian}}</td><td class="num">{{mib .BpsFastest}}</td><td class="num">{{mib .BpsSlowest}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+{{if gt (len .ByHost) 1}}
+<h3>Throughput by host</h3>
+<table>
+<tr><th>Host</th><th>Average</th><th>Errors</th><th></th></tr>
+{{range .ByHost}}<tr><td>{{.Name}}</td><td class="num">{{if .AverageBPS}}{{mib .AverageBPS}}, {{end}}{{ops .AverageOPS}}</td><td class="num">{{.Errors}}</td><td style="width:200px"><div class="bar" style="width:{{printf "%.0f" .Share}}%"></div></td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
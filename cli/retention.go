@@ -39,13 +39,26 @@ var retentionFlags = []cli.Flag{
 		Value: "1KiB",
 		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
 	},
+	cli.BoolFlag{
+		Name:  "retain-on-put",
+		Usage: "Upload objects with governance retention set",
+	},
+	cli.BoolFlag{
+		Name:  "legal-hold",
+		Usage: "Upload objects with legal hold enabled and benchmark PutObjectLegalHold as well",
+	},
+	cli.Float64Flag{
+		Name:  "get-ratio",
+		Value: 0,
+		Usage: "Fraction of operations reading retention/legal hold status instead of setting it. Must be between 0 and 1",
+	},
 }
 
 var RetentionCombinedFlags = combineFlags(globalFlags, ioFlags, retentionFlags, genFlags, benchFlags, analyzeFlags)
 
 var retentionCmd = cli.Command{
 	Name:   "retention",
-	Usage:  "benchmark PutObjectRetention",
+	Usage:  "benchmark object retention and legal hold",
 	Action: mainRetention,
 	Before: setGlobalsFromContext,
 	Flags:  RetentionCombinedFlags,
@@ -61,13 +74,16 @@ FLAGS:
   {{end}}`,
 }
 
-// mainGet is the entry point for get command.
+// mainRetention is the entry point for retention command.
 func mainRetention(ctx *cli.Context) error {
 	checkRetentionSyntax(ctx)
 	b := bench.Retention{
 		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
 		CreateObjects: ctx.Int("objects"),
 		Versions:      ctx.Int("versions"),
+		RetainOnPut:   ctx.Bool("retain-on-put"),
+		LegalHold:     ctx.Bool("legal-hold"),
+		GetRatio:      ctx.Float64("get-ratio"),
 	}
 	b.Locking = true
 	return runBench(ctx, &b)
@@ -83,6 +99,9 @@ func checkRetentionSyntax(ctx *cli.Context) {
 	if ctx.Int("versions") <= 0 {
 		console.Fatal("There must be more than 0 versions per object.")
 	}
+	if r := ctx.Float64("get-ratio"); r < 0 || r > 1 {
+		console.Fatal("get-ratio must be between 0 and 1")
+	}
 
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
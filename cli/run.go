@@ -61,6 +61,14 @@ FLAGS:
 
 // mainExec is the entry point for exe command.
 func mainExec(ctx *cli.Context) error {
+	benchCmd, doc := runDocBench(readRunDoc(ctx))
+	flags := runDocFlags(benchCmd, doc)
+	return runCommand(newRunContext(ctx, benchCmd, flags), benchCmd)
+}
+
+// readRunDoc reads the YAML file given as argument, applies template replacements
+// and returns the top level 'warp' element.
+func readRunDoc(ctx *cli.Context) map[string]any {
 	var yFile []byte
 	switch ctx.NArg() {
 	case 1:
@@ -102,9 +110,16 @@ func mainExec(ctx *cli.Context) error {
 	if err != nil {
 		fatal(probe.NewError(err), "error parsing YAML file")
 	}
+	return doc
+}
+
+// runDocBench checks the API version of the 'warp' element of doc
+// and returns the benchmark command it specifies and the element.
+// The 'api' and 'benchmark' keys are removed from the element.
+func runDocBench(doc map[string]any) (*cli.Command, map[string]any) {
 	doc, ok := doc["warp"].(map[string]any)
 	if !ok {
-		fatal(probe.NewError(err), "Expected top level 'warp' element could not be found")
+		fatal(errDummy(), "Expected top level 'warp' element could not be found")
 	}
 	switch ver := mustGetString(doc, "api"); ver {
 	case "v1":
@@ -124,18 +139,31 @@ func mainExec(ctx *cli.Context) error {
 		fatal(probe.NewError(fmt.Errorf("unknown benchmark: %s", op)), "Unknown benchmark")
 	}
 	delete(doc, "benchmark")
+	return benchCmd, doc
+}
 
+// runDocFlags converts a 'warp' element to commandline flags of benchCmd.
+func runDocFlags(benchCmd *cli.Command, doc map[string]any) map[string]string {
 	// Rename input fields to commandline params:
 	rename := map[string]string{
 		"sse-c-encrypt":            "encrypt",
+		"encrypt-type":             "encrypt.type",
+		"encrypt-kms-key":          "encrypt.kms-key",
+		"encrypt-kms-context":      "encrypt.kms-context",
+		"encrypt-key":              "encrypt.key",
 		"analyze.verbose":          "analyze.v",
 		"obj.part-size":            "part.size",
 		"analyze.skip-duration":    "analyze.skip",
 		"analyze.segment-duration": "analyze.dur",
 		"analyze.filter-op":        "analyze.op",
+		"analyze.out-format":       "analyze.out.format",
+		"analyze.heatmap-out":      "analyze.heatmap.out",
+		"analyze.heatmap-buckets":  "analyze.heatmap.buckets",
+		"scan-max-keys":            "scan.max-keys",
 		"server-profile":           "serverprof",
 		"bench-data":               "benchdata",
 		"autoterm.enabled":         "autoterm",
+		"autotune.enabled":         "autotune",
 		"obj.versions":             "versions",
 		"obj.rand-size":            "obj.randsize",
 		"no-prefix":                "noprefix",
@@ -144,14 +172,28 @@ func mainExec(ctx *cli.Context) error {
 		"distribution.stat":        "stat-distrib",
 		"distribution.put":         "put-distrib",
 		"distribution.delete":      "delete-distrib",
+		"distribution.multipart":   "multipart-distrib",
+		"distribution.list":        "list-distrib",
+		"list-max-keys":            "list.max-keys",
+		"obj.put-size":             "put.obj.size",
+		"multipart.size":           "multipart.obj.size",
+		"multipart.part-size":      "multipart.part.size",
 		"obj.parts":                "parts",
+		"post-status":              "post.status",
 	}
 
 	// Allow some fields to be string lists
 	commaListed := map[string]bool{
-		"server-profile": true,
-		"remote.host":    true,
-		"warp-client":    true,
+		"server-profile":    true,
+		"remote.host":       true,
+		"warp-client":       true,
+		"size-sweep":        true,
+		"concurrency-sweep": true,
+		"encryption-sweep":  true,
+		"buckets-sweep":     true,
+		"source-ip":         true,
+		"concurrency-ramp":  true,
+		"phases":            true,
 	}
 
 	var prefixStack []string
@@ -216,7 +258,7 @@ func mainExec(ctx *cli.Context) error {
 					setFlag = []byte(v)
 				}
 			}
-		case cli.IntFlag, cli.Float64Flag, cli.UintFlag, cli.Uint64Flag:
+		case cli.IntFlag, cli.Int64Flag, cli.Float64Flag, cli.UintFlag, cli.Uint64Flag:
 			switch v := value.(type) {
 			case float64, int:
 				setFlag, err = json.Marshal(v)
@@ -241,7 +283,11 @@ func mainExec(ctx *cli.Context) error {
 	}
 	parseDoc(doc, &prefixStack, &currDept, setFlag)
 
-	// Reconstruct command
+	return flags
+}
+
+// newRunContext returns a context for running benchCmd with the supplied flags.
+func newRunContext(ctx *cli.Context, benchCmd *cli.Command, flags map[string]string) *cli.Context {
 	app := registerApp("warp", benchCmds)
 	fs, err := flagSet(benchCmd.Name, benchCmd.Flags, nil)
 	if err != nil {
@@ -257,7 +303,7 @@ func mainExec(ctx *cli.Context) error {
 		}
 	}
 
-	return runCommand(ctx2, benchCmd)
+	return ctx2
 }
 
 func parseDoc(doc map[string]any, prefixStack, printStack *[]string, setFlag func(key string, value any)) {
@@ -273,7 +319,7 @@ func parseDoc(doc map[string]any, prefixStack, printStack *[]string, setFlag fun
 	}
 	for k, v := range doc {
 		switch k {
-		case "analyze", "obj", "autoterm", "distribution":
+		case "analyze", "obj", "autoterm", "autotune", "distribution", "multipart", "otlp":
 			// These automatically adds the prefix to the flag name.
 			pop := push(prefixStack, k)
 			pop2 := push(printStack, k)
@@ -0,0 +1,152 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/minio/warp/pkg"
+)
+
+// clientInfo describes the machine and build of a warp client.
+type clientInfo struct {
+	Version  string    `json:"version"`
+	Commit   string    `json:"commit"`
+	Hostname string    `json:"hostname"`
+	OS       string    `json:"os"`
+	Arch     string    `json:"arch"`
+	CPUs     int       `json:"cpus"`
+	NICs     []nicInfo `json:"nics,omitempty"`
+}
+
+// nicInfo describes a network interface.
+type nicInfo struct {
+	Name         string   `json:"name"`
+	MTU          int      `json:"mtu"`
+	HardwareAddr string   `json:"hardware_addr,omitempty"`
+	Flags        string   `json:"flags"`
+	Addrs        []string `json:"addrs,omitempty"`
+}
+
+// localClientInfo returns information about this machine.
+func localClientInfo() *clientInfo {
+	ci := clientInfo{
+		Version: pkg.Version,
+		Commit:  pkg.ShortCommitID,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		CPUs:    runtime.NumCPU(),
+	}
+	ci.Hostname, _ = os.Hostname()
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		nic := nicInfo{
+			Name:         iface.Name,
+			MTU:          iface.MTU,
+			HardwareAddr: iface.HardwareAddr.String(),
+			Flags:        iface.Flags.String(),
+		}
+		addrs, _ := iface.Addrs()
+		for _, a := range addrs {
+			nic.Addrs = append(nic.Addrs, a.String())
+		}
+		ci.NICs = append(ci.NICs, nic)
+	}
+	return &ci
+}
+
+// runManifest describes a distributed benchmark run,
+// so results can be audited and reproduced.
+type runManifest struct {
+	Command string      `json:"command"`
+	Args    []string    `json:"args,omitempty"`
+	Created time.Time   `json:"created"`
+	Server  *clientInfo `json:"server"`
+	// Seed is the seed clients derive their seed from, unless --seed.shared is set.
+	Seed    string           `json:"seed,omitempty"`
+	Clients []manifestClient `json:"clients"`
+}
+
+// manifestClient describes a single client of a distributed benchmark.
+type manifestClient struct {
+	Index int    `json:"index"`
+	Host  string `json:"host"`
+	State string `json:"state"`
+	// ClockOffset is the clock of the client minus the clock of the server.
+	ClockOffset time.Duration     `json:"clock_offset_ns"`
+	Flags       map[string]string `json:"flags"`
+	Info        *clientInfo       `json:"info,omitempty"`
+}
+
+// manifestRedact contains flags that are not written to the manifest.
+var manifestRedact = map[string]string{
+	"access-key":      "*REDACTED*",
+	"secret-key":      "*REDACTED*",
+	"influxdb":        "*REDACTED*",
+	"tls-client-key":  "*REDACTED*",
+	"tls-client-cert": "(PEM data)",
+	"tls-ca":          "(PEM data)",
+}
+
+// newRunManifest creates a manifest of a run where flags[i] were sent to client i.
+func newRunManifest(req serverRequest, c *connections, flags []map[string]string) runManifest {
+	m := runManifest{
+		Command: req.Benchmark.Command,
+		Args:    req.Benchmark.Args,
+		Created: time.Now(),
+		Server:  localClientInfo(),
+		Seed:    req.Benchmark.Flags["seed"],
+	}
+	status := c.clientStatus()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, host := range c.hosts {
+		mc := manifestClient{
+			Index:       i,
+			Host:        host,
+			State:       status[i].State,
+			ClockOffset: c.offsets[i],
+			Info:        c.infos[i],
+			Flags:       make(map[string]string, len(flags[i])),
+		}
+		for k, v := range flags[i] {
+			if r, ok := manifestRedact[k]; ok {
+				v = r
+			}
+			mc.Flags[k] = v
+		}
+		m.Clients = append(m.Clients, mc)
+	}
+	return m
+}
+
+// write the manifest to fileName.
+func (m runManifest) write(fileName string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, b, 0o644)
+}
@@ -40,6 +40,16 @@ var selectFlags = []cli.Flag{
 		Value: "select * from s3object",
 		Usage: "select query expression",
 	},
+	cli.StringFlag{
+		Name:  "input-format",
+		Value: "csv",
+		Usage: "Format of uploaded objects. Can be 'csv', 'json' (lines) or 'parquet'",
+	},
+	cli.StringFlag{
+		Name:  "compression",
+		Value: "none",
+		Usage: "Compression of uploaded csv and json objects. Can be 'none', 'gzip' or 'zstd'",
+	},
 }
 
 var selectCmd = cli.Command{
@@ -64,29 +74,44 @@ FLAGS:
 func mainSelect(ctx *cli.Context) error {
 	checkSelectSyntax(ctx)
 	sse := newSSE(ctx)
+	comp := selectCompression(ctx)
+	opts := minio.SelectObjectOptions{
+		Expression:     ctx.String("query"),
+		ExpressionType: minio.QueryExpressionTypeSQL,
+		// Set any encryption headers
+		ServerSideEncryption: sse,
+		InputSerialization: minio.SelectObjectInputSerialization{
+			CompressionType: comp,
+		},
+	}
+	switch ctx.String("input-format") {
+	case bench.SelectJSON:
+		opts.InputSerialization.JSON = &minio.JSONInputOptions{Type: minio.JSONLinesType}
+		opts.OutputSerialization.JSON = &minio.JSONOutputOptions{RecordDelimiter: "\n"}
+	case bench.SelectParquet:
+		opts.InputSerialization.CompressionType = ""
+		opts.InputSerialization.Parquet = &minio.ParquetInputOptions{}
+		opts.OutputSerialization.CSV = &minio.CSVOutputOptions{
+			RecordDelimiter: "\n",
+			FieldDelimiter:  ",",
+		}
+	default:
+		opts.InputSerialization.CSV = &minio.CSVInputOptions{
+			RecordDelimiter: "\n",
+			FieldDelimiter:  ",",
+			FileHeaderInfo:  minio.CSVFileHeaderInfoUse,
+		}
+		opts.OutputSerialization.CSV = &minio.CSVOutputOptions{
+			RecordDelimiter: "\n",
+			FieldDelimiter:  ",",
+		}
+	}
 	b := bench.Select{
 		Common:        getCommon(ctx, newGenSourceCSV(ctx)),
 		CreateObjects: ctx.Int("objects"),
-		SelectOpts: minio.SelectObjectOptions{
-			Expression:     ctx.String("query"),
-			ExpressionType: minio.QueryExpressionTypeSQL,
-			// Set any encryption headers
-			ServerSideEncryption: sse,
-			// TODO: support all variations including, json/parquet
-			InputSerialization: minio.SelectObjectInputSerialization{
-				CSV: &minio.CSVInputOptions{
-					RecordDelimiter: "\n",
-					FieldDelimiter:  ",",
-					FileHeaderInfo:  minio.CSVFileHeaderInfoUse,
-				},
-			},
-			OutputSerialization: minio.SelectObjectOutputSerialization{
-				CSV: &minio.CSVOutputOptions{
-					RecordDelimiter: "\n",
-					FieldDelimiter:  ",",
-				},
-			},
-		},
+		SelectOpts:    opts,
+		InputFormat:   ctx.String("input-format"),
+		Compression:   comp,
 	}
 	return runBench(ctx, &b)
 }
@@ -95,6 +120,30 @@ func checkSelectSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	switch ctx.String("input-format") {
+	case bench.SelectCSV, bench.SelectJSON:
+	case bench.SelectParquet:
+		if selectCompression(ctx) != minio.SelectCompressionNONE {
+			console.Fatal("--compression cannot be used with parquet input")
+		}
+	default:
+		console.Fatal("--input-format must be 'csv', 'json' or 'parquet'")
+	}
+	selectCompression(ctx)
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
+
+// selectCompression returns the compression type given to --compression.
+func selectCompression(ctx *cli.Context) minio.SelectCompressionType {
+	switch ctx.String("compression") {
+	case "none", "":
+		return minio.SelectCompressionNONE
+	case "gzip":
+		return minio.SelectCompressionGZIP
+	case "zstd":
+		return minio.SelectCompressionZSTD
+	}
+	console.Fatal("--compression must be 'none', 'gzip' or 'zstd'")
+	return ""
+}
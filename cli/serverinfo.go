@@ -0,0 +1,132 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var (
+	serverMeta     map[string]string
+	serverMetaOnce sync.Once
+)
+
+// captureServerMeta queries the target server for version and configuration,
+// which is then added to the metadata of benchmark data.
+// The server is only queried once.
+func captureServerMeta(ctx *cli.Context) {
+	serverMetaOnce.Do(func() {
+		if _, ok := fsBackend(ctx); ok {
+			// There is no server to query.
+			serverMeta = map[string]string{"backend": ctx.String("backend")}
+			return
+		}
+		if p := ctx.String("provider"); p != string(bench.ProviderS3) {
+			// Not a MinIO server.
+			serverMeta = map[string]string{"provider": p}
+			return
+		}
+		ctx2, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		serverMeta = serverInfoMeta(ctx2, ctx)
+		if len(serverMeta) == 0 {
+			serverMeta = serverHeaderMeta(ctx2, ctx)
+		}
+	})
+}
+
+// serverInfoMeta returns the version and erasure configuration reported by the MinIO admin API.
+// Returns nil if the information is not available.
+func serverInfoMeta(ctx2 context.Context, ctx *cli.Context) map[string]string {
+	info, err := newAdminClient(ctx).ServerInfo(ctx2)
+	if err != nil || len(info.Servers) == 0 {
+		return nil
+	}
+	var versions, commits []string
+	for _, s := range info.Servers {
+		if s.Version != "" && !slices.Contains(versions, s.Version) {
+			versions = append(versions, s.Version)
+		}
+		if s.CommitID != "" && !slices.Contains(commits, s.CommitID) {
+			commits = append(commits, s.CommitID)
+		}
+	}
+	meta := map[string]string{
+		"server-version": strings.Join(versions, ","),
+		"server-commit":  strings.Join(commits, ","),
+		"server-nodes":   strconv.Itoa(len(info.Servers)),
+	}
+	if info.DeploymentID != "" {
+		meta["server-deployment-id"] = info.DeploymentID
+	}
+	if b := info.Backend; b.Type != "" {
+		meta["server-backend"] = string(b.Type)
+		if b.Type == madmin.ErasureType {
+			meta["erasure-sets"] = joinInts(b.TotalSets)
+			meta["erasure-drives-per-set"] = joinInts(b.DrivesPerSet)
+			meta["erasure-parity"] = strconv.Itoa(b.StandardSCParity)
+			meta["erasure-drives"] = strconv.Itoa(b.OnlineDisks + b.OfflineDisks)
+		}
+	}
+	return meta
+}
+
+// serverHeaderMeta returns the Server header returned by the first host.
+// Returns nil if no header is returned.
+func serverHeaderMeta(ctx2 context.Context, ctx *cli.Context) map[string]string {
+	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	if len(hosts) == 0 {
+		return nil
+	}
+	scheme := "http://"
+	if ctx.Bool("tls") {
+		scheme = "https://"
+	}
+	req, err := http.NewRequestWithContext(ctx2, http.MethodHead, scheme+hosts[0]+"/", nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := (&http.Client{Transport: clientTransport(ctx)}).Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	if s := resp.Header.Get("Server"); s != "" {
+		return map[string]string{"server": s}
+	}
+	return nil
+}
+
+// joinInts returns the values as a comma separated string.
+func joinInts(v []int) string {
+	s := make([]string, len(v))
+	for i, n := range v {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
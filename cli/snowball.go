@@ -39,6 +39,10 @@ var snowballFlags = []cli.Flag{
 		Name:  "compress",
 		Usage: "Compress each snowball file. Available for MinIO servers only.",
 	},
+	cli.BoolFlag{
+		Name:  "individual",
+		Usage: "Upload objects with individual PUTs on half of the threads to compare ingest rates. Snowball uploads are recorded as SNOWBALL operations.",
+	},
 }
 
 // Put command.
@@ -64,10 +68,11 @@ FLAGS:
 func mainSnowball(ctx *cli.Context) error {
 	checkSnowballSyntax(ctx)
 	b := bench.Snowball{
-		Common:    getCommon(ctx, newGenSource(ctx, "obj.size")),
-		Compress:  ctx.Bool("compress"),
-		Duplicate: ctx.Bool("compress"),
-		NumObjs:   ctx.Int("objs.per"),
+		Common:     getCommon(ctx, newGenSource(ctx, "obj.size")),
+		Compress:   ctx.Bool("compress"),
+		Duplicate:  ctx.Bool("compress"),
+		NumObjs:    ctx.Int("objs.per"),
+		Individual: ctx.Bool("individual"),
 	}
 	b.PutOpts = snowballOpts(ctx)
 	if b.Compress {
@@ -108,6 +113,9 @@ func checkSnowballSyntax(ctx *cli.Context) {
 	if err != nil {
 		console.Fatalf("Unable to parse --obj.size: %v", err)
 	}
+	if ctx.Bool("individual") && ctx.Int("concurrent") < 2 {
+		console.Fatal("--individual requires --concurrent of at least 2")
+	}
 	gotTotal := int64(sz) * int64(ctx.Int("concurrent"))
 	compress := ctx.Bool("compress")
 	if compress && sz > 10<<20 {
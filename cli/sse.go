@@ -19,36 +19,136 @@ package cli
 
 import (
 	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/sse"
+	"github.com/minio/warp/pkg/bench"
 )
 
+var cseFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "cse-encrypt",
+		Usage: "client-side encrypt objects before upload and decrypt after download. Crypto time is reported separately",
+	},
+}
+
 var sseKey encrypt.ServerSide
 
-// newSSE returns a randomly generated key if SSE is requested.
+// newSSE returns the server-side encryption requested, if any.
 // Only one key will be generated.
 func newSSE(ctx *cli.Context) encrypt.ServerSide {
-	if !ctx.Bool("encrypt") && !ctx.Bool("sse-s3-encrypt") {
-		return nil
-	}
 	if sseKey != nil {
 		return sseKey
 	}
+	var err error
+	sseKey, err = parseSSE(ctx)
+	fatalIf(probe.NewError(err), "Invalid server-side encryption options")
+	return sseKey
+}
 
-	if ctx.Bool("sse-s3-encrypt") {
-		sseKey = encrypt.NewSSE()
-		return sseKey
+// sseType returns the server-side encryption type requested.
+// '--encrypt' and '--sse-s3-encrypt' are shorthands for 'c' and 's3'.
+func sseType(ctx *cli.Context) (string, error) {
+	typ := strings.ToLower(ctx.String("encrypt.type"))
+	for flag, t := range map[string]string{"encrypt": "c", "sse-s3-encrypt": "s3"} {
+		if !ctx.Bool(flag) {
+			continue
+		}
+		if typ != "" && typ != t {
+			return "", fmt.Errorf("--%s cannot be combined with --encrypt.type=%s", flag, typ)
+		}
+		typ = t
 	}
+	switch typ {
+	case "", "s3", "kms", "c":
+	default:
+		return "", fmt.Errorf("unknown --encrypt.type %q. Must be 's3', 'kms' or 'c'", typ)
+	}
+	if typ != "kms" && (ctx.String("encrypt.kms-key") != "" || ctx.String("encrypt.kms-context") != "") {
+		return "", errors.New("--encrypt.kms-key and --encrypt.kms-context require --encrypt.type=kms")
+	}
+	if typ != "c" && ctx.String("encrypt.key") != "" {
+		return "", errors.New("--encrypt.key requires --encrypt.type=c")
+	}
+	return typ, nil
+}
 
+// parseSSE returns the server-side encryption configured on ctx.
+// SSE-C uses a random key unless '--encrypt.key' is set.
+func parseSSE(ctx *cli.Context) (encrypt.ServerSide, error) {
+	typ, err := sseType(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case "s3":
+		return encrypt.NewSSE(), nil
+	case "kms":
+		var kmsCtx map[string]any
+		if s := ctx.String("encrypt.kms-context"); s != "" {
+			if err := json.Unmarshal([]byte(s), &kmsCtx); err != nil {
+				return nil, fmt.Errorf("--encrypt.kms-context must be a JSON object: %w", err)
+			}
+		}
+		return encrypt.NewSSEKMS(ctx.String("encrypt.kms-key"), kmsCtx)
+	case "c":
+		key := make([]byte, 32)
+		if s := ctx.String("encrypt.key"); s != "" {
+			key, err = hex.DecodeString(s)
+			if err != nil || len(key) != 32 {
+				return nil, errors.New("--encrypt.key must be 64 hex characters")
+			}
+		} else if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return encrypt.NewSSEC(key)
+	}
+	return nil, nil
+}
+
+// newCSE returns client-side encryption with a randomly generated key if requested.
+func newCSE(ctx *cli.Context) *bench.ClientEncryption {
+	if !ctx.Bool("cse-encrypt") {
+		return nil
+	}
 	var key [32]byte
 	_, err := rand.Read(key[:])
 	if err != nil {
 		panic(err)
 	}
-	sseKey, err = encrypt.NewSSEC(key[:])
-	if err != nil {
-		panic(err)
+	cse, err := bench.NewClientEncryption(key[:])
+	fatalIf(probe.NewError(err), "Unable to set up client-side encryption")
+	return cse
+}
+
+// parseBucketEncryption parses a bucket encryption mode.
+// 'none' returns an empty configuration.
+func parseBucketEncryption(s string) (*sse.Configuration, error) {
+	mode, key, _ := strings.Cut(s, ":")
+	switch strings.ToLower(mode) {
+	case "none":
+		return &sse.Configuration{}, nil
+	case "sse-s3":
+		return sse.NewConfigurationSSES3(), nil
+	case "sse-kms":
+		return sse.NewConfigurationSSEKMS(key), nil
 	}
-	return sseKey
+	return nil, fmt.Errorf("unknown bucket encryption %q. Must be 'none', 'sse-s3' or 'sse-kms'", s)
+}
+
+// bucketEncryption returns the bucket encryption configuration requested, if any.
+func bucketEncryption(ctx *cli.Context) *sse.Configuration {
+	if ctx.String("bucket.encryption") == "" {
+		return nil
+	}
+	cfg, err := parseBucketEncryption(ctx.String("bucket.encryption"))
+	fatalIf(probe.NewError(err), "Invalid --bucket.encryption")
+	return cfg
 }
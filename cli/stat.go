@@ -46,11 +46,20 @@ var statFlags = []cli.Flag{
 	},
 	cli.BoolFlag{
 		Name:  "list-flat",
-		Usage: "When using --list-existing, do not use recursive listing",
+		Usage: "When using --list-existing or --scan, do not use recursive listing",
+	},
+	cli.BoolFlag{
+		Name:  "scan",
+		Usage: "Scan the bucket by listing it and stat every listed object, like a backup scanner",
+	},
+	cli.IntFlag{
+		Name:  "scan.max-keys",
+		Value: 1000,
+		Usage: "Number of keys to request in each listing when scanning",
 	},
 }
 
-var StatCombinedFlags = combineFlags(globalFlags, ioFlags, statFlags, genFlags, benchFlags, analyzeFlags)
+var StatCombinedFlags = combineFlags(globalFlags, ioFlags, statFlags, accessFlags, manifestFlags, genFlags, benchFlags, analyzeFlags)
 
 var statCmd = cli.Command{
 	Name:   "stat",
@@ -85,6 +94,10 @@ func mainStat(ctx *cli.Context) error {
 		ListExisting: ctx.Bool("list-existing"),
 		ListFlat:     ctx.Bool("list-flat"),
 		ListPrefix:   ctx.String("prefix"),
+		Manifest:     readManifest(ctx),
+		Access:       accessDist(ctx),
+		Scan:         ctx.Bool("scan"),
+		ScanPageSize: ctx.Int("scan.max-keys"),
 	}
 	return runBench(ctx, &b)
 }
@@ -99,6 +112,20 @@ func checkStatSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if _, err := bench.ParseAccessDist(ctx.String("access-dist")); err != nil {
+		console.Fatal(err)
+	}
+	if ctx.Bool("scan") {
+		if ctx.Int("versions") > 1 {
+			console.Fatal("--scan cannot be combined with --versions")
+		}
+		if ctx.IsSet("manifest.in") {
+			console.Fatal("--scan cannot be combined with --manifest.in")
+		}
+		if n := ctx.Int("scan.max-keys"); n < 1 || n > 1000 {
+			console.Fatal("--scan.max-keys must be between 1 and 1000")
+		}
+	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
@@ -0,0 +1,137 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/api"
+)
+
+// statsdMaxPacket is the maximum size of a StatsD UDP packet.
+const statsdMaxPacket = 1432
+
+// startStatsD sends metrics from live to the StatsD server given by --statsd-server
+// every second until ctx is canceled.
+func startStatsD(cliCtx *cli.Context, ctx context.Context, live *liveCollector) {
+	addr := cliCtx.String("statsd-server")
+	if addr == "" {
+		return
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		errorIf(probe.NewError(err), "unable to connect to statsd server")
+		return
+	}
+	s := statsdSink{conn: conn, prefix: cliCtx.String("statsd-prefix")}
+	go func() {
+		defer conn.Close()
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		last := time.Now()
+		for {
+			select {
+			case now := <-t.C:
+				s.send(live.get(), now.Sub(last))
+				last = now
+			case <-ctx.Done():
+				s.send(live.get(), time.Since(last))
+				return
+			}
+		}
+	}()
+}
+
+// statsdSink sends the change in live stats as StatsD metrics.
+type statsdSink struct {
+	conn   net.Conn
+	prefix string
+	prev   map[string]api.LiveOperation
+}
+
+// send metrics for the change since the previous call.
+// elapsed is the time since the previous call.
+func (s *statsdSink) send(l *api.LiveStats, elapsed time.Duration) {
+	if l == nil {
+		return
+	}
+	if s.prev == nil {
+		s.prev = make(map[string]api.LiveOperation, len(l.Operations))
+	}
+	secs := elapsed.Seconds()
+	var buf bytes.Buffer
+	write := func(format string, args ...interface{}) {
+		line := fmt.Sprintf(format, args...)
+		if buf.Len() > 0 && buf.Len()+len(line) >= statsdMaxPacket {
+			s.conn.Write(buf.Bytes())
+			buf.Reset()
+		}
+		buf.WriteString(line)
+	}
+	for typ, cur := range l.Operations {
+		prev := s.prev[typ]
+		if cur.Requests < prev.Requests {
+			// Stats were reset.
+			prev = api.LiveOperation{}
+		}
+		s.prev[typ] = cur
+		reqs := cur.Requests - prev.Requests
+		if reqs == 0 {
+			continue
+		}
+		name := s.prefix + "." + statsdName(typ)
+		nBytes := cur.Bytes - prev.Bytes
+		objs := cur.Objects - prev.Objects
+		errs := cur.Errors - prev.Errors
+		write("%s.requests:%d|c\n", name, reqs)
+		write("%s.errors:%d|c\n", name, errs)
+		write("%s.bytes:%d|c\n", name, nBytes)
+		write("%s.objects:%d|c\n", name, objs)
+		if secs > 0 {
+			write("%s.ops_per_sec:%.2f|g\n", name, float64(reqs)/secs)
+			write("%s.bytes_per_sec:%.0f|g\n", name, float64(nBytes)/secs)
+		}
+		if ok := reqs - errs; ok > 0 {
+			avg := time.Duration((cur.ReqDurNanos - prev.ReqDurNanos) / ok)
+			write("%s.latency:%.3f|ms\n", name, float64(avg)/float64(time.Millisecond))
+		}
+	}
+	if buf.Len() > 0 {
+		s.conn.Write(buf.Bytes())
+	}
+}
+
+// statsdName returns s with characters not allowed in StatsD metric names replaced.
+func statsdName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,68 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// newOpStream returns a channel that will write all completed operations
+// as JSON lines to the file specified by --stream-ops, or stdout if it is "-".
+func newOpStream(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
+	fn := ctx.String("stream-ops")
+	var w io.WriteCloser = os.Stdout
+	if fn != "-" {
+		// Truncate only the first time the file is opened,
+		// so sweep steps append to the same stream.
+		flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if _, loaded := journalOpened.LoadOrStore(fn, struct{}{}); !loaded {
+			flags |= os.O_TRUNC
+		}
+		f, err := os.OpenFile(fn, flags, 0o666)
+		fatalIf(probe.NewError(err), "Unable to create operation stream")
+		w = f
+	}
+
+	ch := make(chan bench.Operation, 10000)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if w != os.Stdout {
+			defer w.Close()
+		}
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		for op := range ch {
+			errorIf(probe.NewError(enc.Encode(op)), "Unable to write operation stream")
+			// Flush when no more operations are waiting, so the stream can be followed while running.
+			if len(ch) == 0 {
+				errorIf(probe.NewError(bw.Flush()), "Unable to write operation stream")
+			}
+		}
+		errorIf(probe.NewError(bw.Flush()), "Unable to write operation stream")
+	}()
+	return ch
+}
@@ -0,0 +1,419 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/aggregate"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// sweepParam describes a flag that can be swept.
+type sweepParam struct {
+	// flag is the sweep flag.
+	flag string
+	// target is the benchmark flag that is changed for each step.
+	target string
+	// title is used as column header.
+	title string
+	// check validates a single value.
+	check func(s string) error
+	// relative adds the change of each step compared to the first.
+	relative bool
+	// shared uses the bucket and clients set up by the first step for all steps.
+	shared bool
+	// rerun prepares data once for the highest concurrency of all steps.
+	// Benchmarks that leave the prepared data unchanged are then started again by each step.
+	rerun bool
+}
+
+var sweepParams = []sweepParam{
+	{
+		flag:   "size-sweep",
+		target: "obj.size",
+		title:  "Size",
+		shared: true,
+		check: func(s string) error {
+			_, err := toSize(s)
+			return err
+		},
+	},
+	{
+		flag:   "concurrency-sweep",
+		target: "concurrent",
+		title:  "Concurrency",
+		shared: true,
+		rerun:  true,
+		check: func(s string) error {
+			n, err := strconv.Atoi(s)
+			if err == nil && n <= 0 {
+				err = errors.New("concurrency must be at least 1")
+			}
+			return err
+		},
+	},
+	{
+		flag:   "buckets-sweep",
+		target: "buckets",
+		title:  "Buckets",
+		check: func(s string) error {
+			n, err := strconv.Atoi(s)
+			if err == nil && n <= 0 {
+				err = errors.New("bucket count must be at least 1")
+			}
+			return err
+		},
+	},
+	{
+		flag:     "encryption-sweep",
+		target:   "bucket.encryption",
+		title:    "Encryption",
+		relative: true,
+		check: func(s string) error {
+			_, err := parseBucketEncryption(s)
+			return err
+		},
+	},
+}
+
+// sweepState is the state of a running sweep.
+type sweepState struct {
+	param   sweepParam
+	current string
+	// rows contains the results of the completed steps.
+	// Steps are aggregated when they finish, so their operations can be released.
+	rows []sweepRow
+	// setup contains the parameters of the first step, once it has been prepared.
+	// Only set if the setup is shared.
+	setup *bench.Common
+	// maxConcurrency is the highest concurrency of all steps of a rerun sweep.
+	maxConcurrency int
+	// rerun is the benchmark prepared by the first step, if it is started again by each step.
+	rerun bench.Rerunner
+	// last is set while running the last step.
+	last bool
+}
+
+// activeSweep is set while a sweep is running.
+// Benchmarks started while it is set run as a single step.
+var activeSweep *sweepState
+
+// sharedSetup returns the parameters of the first step of the active sweep,
+// if its bucket and clients are used by the following steps.
+func sharedSetup() *bench.Common {
+	if activeSweep == nil || !activeSweep.param.shared {
+		return nil
+	}
+	return activeSweep.setup
+}
+
+// prepared records the setup of c, if it is the first step of a sweep sharing its setup.
+func (s *sweepState) prepared(c *bench.Common) {
+	if s != nil && s.param.shared && s.setup == nil {
+		s.setup = c
+	}
+}
+
+// prepareStep prepares b for the current step with prepare and returns the benchmark to start.
+// If the benchmark of the first step is started again, it is returned instead,
+// ready to run with the concurrency and outputs of b.
+func (s *sweepState) prepareStep(b bench.Benchmark, prepare func(b bench.Benchmark)) bench.Benchmark {
+	c := b.GetCommon()
+	if s != nil && s.rerun != nil {
+		s.rerun.Rerun(c.Concurrency, c.ExtraOut)
+		return s.rerun
+	}
+	r, ok := b.(bench.Rerunner)
+	if s == nil || !s.param.rerun || !ok {
+		prepare(b)
+		s.prepared(c)
+		return b
+	}
+	// Prepare the data with the highest concurrency, so all steps can use it.
+	concurrency := c.Concurrency
+	c.Concurrency = s.maxConcurrency
+	prepare(b)
+	r.Rerun(concurrency, c.ExtraOut)
+	s.rerun = r
+	s.prepared(c)
+	return b
+}
+
+// keepPrepared returns whether the prepared data must be kept for the following steps.
+func (s *sweepState) keepPrepared() bool {
+	return s != nil && s.rerun != nil && !s.last
+}
+
+// getSweep returns the requested sweep parameter and values, if any.
+func getSweep(ctx *cli.Context) (*sweepParam, []string) {
+	for i, p := range sweepParams {
+		v := ctx.String(p.flag)
+		if v == "" {
+			continue
+		}
+		var values []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		return &sweepParams[i], values
+	}
+	return nil, nil
+}
+
+// checkSweep validates sweep parameters.
+func checkSweep(ctx *cli.Context) {
+	p, values := getSweep(ctx)
+	if p == nil {
+		return
+	}
+	n := 0
+	for _, sp := range sweepParams {
+		if ctx.String(sp.flag) != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		fatalIf(errDummy(), "Only one sweep can be specified")
+	}
+	if len(values) == 0 {
+		fatalIf(errDummy(), "No values given for --%s", p.flag)
+	}
+	found := false
+	for _, f := range ctx.Command.Flags {
+		if f.GetName() == p.target {
+			found = true
+			break
+		}
+	}
+	if !found {
+		fatalIf(errDummy(), "--%s is not supported by %s", p.flag, ctx.Command.Name)
+	}
+	for _, v := range values {
+		if err := p.check(v); err != nil {
+			fatalIf(probe.NewError(err), "Invalid --%s value %q", p.flag, v)
+		}
+	}
+	if ctx.String("warp-client") != "" {
+		fatalIf(errDummy(), "--%s cannot be used with --warp-client", p.flag)
+	}
+	if ctx.String(serverFlagName) != "" {
+		fatalIf(errDummy(), "--%s cannot be used with --%s", p.flag, serverFlagName)
+	}
+	if ctx.Duration("each") < 0 {
+		fatalIf(errDummy(), "--each cannot be negative")
+	}
+}
+
+// runSweep will run the benchmark command once per sweep value, if a sweep is requested.
+// Returns whether a sweep was executed.
+// The supplied benchmark is not used, since each step creates its own.
+func runSweep(ctx *cli.Context, b bench.Benchmark) (bool, error) {
+	if activeSweep != nil {
+		return false, nil
+	}
+	p, values := getSweep(ctx)
+	if p == nil {
+		return false, nil
+	}
+	// Close all extra output channels of the unused benchmark.
+	for _, out := range b.GetCommon().ExtraOut {
+		close(out)
+	}
+	if each := ctx.Duration("each"); each > 0 {
+		if err := ctx.Set("duration", each.String()); err != nil {
+			return true, err
+		}
+	}
+	sw := &sweepState{param: *p}
+	if p.rerun {
+		for _, v := range values {
+			n, _ := strconv.Atoi(v)
+			sw.maxConcurrency = max(sw.maxConcurrency, n)
+		}
+	}
+	activeSweep = sw
+	defer func() {
+		activeSweep = nil
+	}()
+	for i, v := range values {
+		if err := ctx.Set(p.target, v); err != nil {
+			return true, err
+		}
+		sw.current, sw.last = v, i == len(values)-1
+		printInfo(fmt.Sprintf("Sweep step %d/%d: --%s=%s", i+1, len(values), p.target, v))
+		if err := runCommand(ctx, &ctx.Command); err != nil {
+			return true, err
+		}
+	}
+	printSweep(ctx, sw)
+	return true, nil
+}
+
+// add the result of the current step.
+func (s *sweepState) add(ops bench.Operations) {
+	s.rows = append(s.rows, sweepRows(s.current, ops)...)
+}
+
+// sweepRow is a single line of sweep output.
+type sweepRow struct {
+	value    string
+	op       string
+	requests int
+	errors   int
+	bps      float64
+	ops      float64
+	avg      time.Duration
+	p50      time.Duration
+	p90      time.Duration
+	p99      time.Duration
+}
+
+// sweepRows returns the rows of a step with the given value, one per operation type.
+func sweepRows(value string, stepOps bench.Operations) []sweepRow {
+	var rows []sweepRow
+	aggr := aggregate.Aggregate(stepOps, aggregate.Options{
+		DurFunc: func(total time.Duration) time.Duration {
+			return total / 10
+		},
+	})
+	for _, op := range aggr.Operations {
+		ops := stepOps.FilterByOp(op.Type).FilterSuccessful()
+		row := sweepRow{
+			value:    value,
+			op:       op.Type,
+			requests: op.N,
+			errors:   op.Errors,
+		}
+		if !op.Skipped {
+			row.bps = op.Throughput.AverageBPS
+			row.ops = op.Throughput.AverageOPS
+		}
+		if len(ops) > 0 {
+			row.avg = ops.AvgDuration()
+			ops.SortByDuration()
+			row.p50 = ops.Median(0.5).Duration()
+			row.p90 = ops.Median(0.9).Duration()
+			row.p99 = ops.Median(0.99).Duration()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// printSweep prints the consolidated sweep results and writes them as CSV.
+func printSweep(ctx *cli.Context, s *sweepState) {
+	rows := s.rows
+	if len(rows) == 0 {
+		console.Errorln("No sweep results")
+		return
+	}
+	console.Println("\n----------------------------------------")
+	console.Printf("Sweep results, %s:\n\n", s.param.title)
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := s.param.title + "\tOp\tRequests\tErrors\tThroughput\tObj/s\tAvg\t50%\t90%\t99%\t"
+	if s.param.relative {
+		header += "Obj/s vs first\tAvg vs first\t"
+	}
+	fmt.Fprintln(tw, header)
+	first := make(map[string]sweepRow)
+	for _, r := range rows {
+		tp := "-"
+		if r.bps > 0 {
+			tp = bench.Throughput(r.bps).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%.2f\t%v\t%v\t%v\t%v\t", r.value, r.op, r.requests, r.errors, tp, r.ops,
+			r.avg.Round(time.Microsecond), r.p50.Round(time.Microsecond), r.p90.Round(time.Microsecond), r.p99.Round(time.Microsecond))
+		if s.param.relative {
+			f, ok := first[r.op]
+			if !ok {
+				first[r.op] = r
+				f = r
+			}
+			fmt.Fprintf(tw, "%s\t%s\t", relChange(r.ops, f.ops), relChange(float64(r.avg), float64(f.avg)))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	console.Print(sb.String())
+
+	fn := ctx.String("benchdata")
+	if fn == "" {
+		fn = fmt.Sprintf("%s-%s-sweep-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"))
+	}
+	fn += ".sweep.csv"
+	err := writeSweepCSV(fn, s.param.target, rows)
+	if err != nil {
+		console.Errorln("Unable to write sweep data:", err)
+		return
+	}
+	console.Infof("Sweep data written to %q\n", fn)
+}
+
+// relChange returns the change of v compared to base as a percentage.
+func relChange(v, base float64) string {
+	if base == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", 100*(v-base)/base)
+}
+
+// sweepCSVHeader is the header of the statistics columns written by record.
+var sweepCSVHeader = []string{"op", "requests", "errors", "bytes_per_sec", "objs_per_sec", "avg_ns", "p50_ns", "p90_ns", "p99_ns"}
+
+// record returns the statistics of the row as CSV fields.
+func (r sweepRow) record() []string {
+	return []string{
+		r.op,
+		strconv.Itoa(r.requests), strconv.Itoa(r.errors),
+		strconv.FormatFloat(r.bps, 'f', 1, 64), strconv.FormatFloat(r.ops, 'f', 2, 64),
+		strconv.FormatInt(int64(r.avg), 10), strconv.FormatInt(int64(r.p50), 10),
+		strconv.FormatInt(int64(r.p90), 10), strconv.FormatInt(int64(r.p99), 10),
+	}
+}
+
+func writeSweepCSV(fn, param string, rows []sweepRow) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	// Write errors are kept by the writer and returned by Error after Flush.
+	w := csv.NewWriter(f)
+	w.Write(append([]string{param}, sweepCSVHeader...))
+	for _, r := range rows {
+		w.Write(append([]string{r.value}, r.record()...))
+	}
+	w.Flush()
+	err = w.Error()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -33,6 +33,11 @@ var versionedFlags = []cli.Flag{
 		Value: 250,
 		Usage: "Number of objects to upload.",
 	},
+	cli.IntFlag{
+		Name:  "versions-per-object",
+		Value: 1,
+		Usage: "Number of versions to upload for each object before starting the benchmark.",
+	},
 	cli.StringFlag{
 		Name:  "obj.size",
 		Value: "10MiB",
@@ -95,9 +100,10 @@ func mainVersioned(ctx *cli.Context) error {
 	err := dist.Generate(ctx.Int("objects") * 2)
 	fatalIf(probe.NewError(err), "Invalid distribution")
 	b := bench.Versioned{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateObjects: ctx.Int("objects"),
-		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
+		Common:            getCommon(ctx, newGenSource(ctx, "obj.size")),
+		CreateObjects:     ctx.Int("objects"),
+		VersionsPerObject: ctx.Int("versions-per-object"),
+		GetOpts:           minio.GetObjectOptions{ServerSideEncryption: sse},
 		StatOpts: minio.StatObjectOptions{
 			ServerSideEncryption: sse,
 		},
@@ -113,6 +119,9 @@ func checkVersionedSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if ctx.Int("versions-per-object") < 1 {
+		console.Fatal("--versions-per-object must be at least 1")
+	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
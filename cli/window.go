@@ -0,0 +1,108 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/api"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// benchWindows writes the operations of each completed window to a separate file,
+// so memory use does not grow during long benchmarks.
+type benchWindows struct {
+	fileName string
+	cID      string
+	n        int
+	done     chan struct{}
+
+	// Error counts of written windows.
+	errs, validation int
+}
+
+// startBenchWindows will start writing windows of operations if --window is set.
+// Windows are written until ctx2 is canceled. Returns nil if --window is not set.
+func startBenchWindows(ctx *cli.Context, ctx2 context.Context, c *bench.Common, live *liveCollector, tStart time.Time, fileName, cID string, monitor *api.Server) *benchWindows {
+	window := ctx.Duration("window")
+	if window <= 0 {
+		return nil
+	}
+	w := &benchWindows{fileName: fileName, cID: cID, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		select {
+		case <-time.After(time.Until(tStart)):
+		case <-ctx2.Done():
+			return
+		}
+		tick := time.NewTicker(window)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+			case <-ctx2.Done():
+				return
+			}
+			ops := c.Collector.TakeOps()
+			live.reset()
+			w.write(ctx, ops, monitor)
+		}
+	}()
+	return w
+}
+
+// windowName returns the file name of window n, without extension.
+func (w *benchWindows) windowName(n int) string {
+	return fmt.Sprintf("%s-w%04d", w.fileName, n)
+}
+
+// write will write the operations of the next window.
+func (w *benchWindows) write(ctx *cli.Context, ops bench.Operations, monitor *api.Server) {
+	w.n++
+	errs, validation := opErrors(ops)
+	w.errs += errs
+	w.validation += validation
+	if len(ops) == 0 {
+		return
+	}
+	ops.SortByStartTime()
+	ops.SetClientID(w.cID)
+	fn := w.windowName(w.n) + benchDataExt(ctx)
+	f, err := os.Create(fn)
+	if err != nil {
+		monitor.Errorln("Unable to write benchmark data:", err)
+		return
+	}
+	defer f.Close()
+	if err := writeBenchData(ctx, f, ops); err != nil {
+		monitor.Errorln("Unable to write benchmark data:", err)
+		return
+	}
+	monitor.InfoLn(fmt.Sprintf("Window %d: %d operations written to %q", w.n, len(ops), fn))
+}
+
+// finish waits for windows to be written and returns the file name of the final window.
+func (w *benchWindows) finish() string {
+	<-w.done
+	return w.windowName(w.n + 1)
+}
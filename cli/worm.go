@@ -0,0 +1,91 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var wormFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "64KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "attempts",
+		Value: 2,
+		Usage: "Number of delete or retention shortening attempts on locked objects for each upload",
+	},
+	cli.DurationFlag{
+		Name:  "retain",
+		Value: 10 * time.Second,
+		Usage: "Retain objects this long after the benchmark has ended. Cleanup will wait for retention to expire",
+	},
+}
+
+var WormCombinedFlags = combineFlags(globalFlags, ioFlags, wormFlags, genFlags, benchFlags, analyzeFlags)
+
+var wormCmd = cli.Command{
+	Name:   "worm",
+	Usage:  "benchmark object lock compliance mode enforcement",
+	Action: mainWorm,
+	Before: setGlobalsFromContext,
+	Flags:  WormCombinedFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#worm
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainWorm is the entry point for worm command.
+func mainWorm(ctx *cli.Context) error {
+	checkWormSyntax(ctx)
+	b := bench.Worm{
+		Common:   getCommon(ctx, newGenSource(ctx, "obj.size")),
+		Retain:   ctx.Duration("retain"),
+		Attempts: ctx.Int("attempts"),
+	}
+	b.Locking = true
+	return runBench(ctx, &b)
+}
+
+func checkWormSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("attempts") < 0 {
+		console.Fatal("attempts cannot be negative")
+	}
+	if ctx.Duration("retain") < time.Second {
+		console.Fatal("retain must be at least 1s")
+	}
+
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -36,6 +36,8 @@ type Aggregated struct {
 	Type                  string                `json:"type"`
 	Operations            []Operation           `json:"operations,omitempty"`
 	Mixed                 bool                  `json:"mixed"`
+	// Metadata of the benchmark data, if loaded from a file.
+	Metadata *bench.Metadata `json:"metadata,omitempty"`
 }
 
 // Operation returns statistics for a single operation type.
@@ -52,12 +54,18 @@ type Operation struct {
 	MultiSizedRequests *MultiSizedRequests `json:"multi_sized_requests,omitempty"`
 	// Populated if requests are all of same object size.
 	SingleSizedRequests *SingleSizedRequests `json:"single_sized_requests,omitempty"`
+	// 95% confidence intervals of throughput and request times.
+	Confidence *Confidence `json:"confidence,omitempty"`
 	// Operation type
 	Type string `json:"type"`
 	// HostNames are sorted names of hosts
 	HostNames []string `json:"host_names"`
 	// Subset of errors.
 	FirstErrors []string `json:"first_errors"`
+	// Errors by category, most frequent first.
+	ErrorCategories []ErrorCategory `json:"error_categories,omitempty"`
+	// Response status and retry statistics, if recorded.
+	Retries *Retries `json:"retries,omitempty"`
 	// Numbers of hosts
 	Hosts int `json:"hosts"`
 	// Number of warp clients.
@@ -66,6 +74,8 @@ type Operation struct {
 	Concurrency int `json:"concurrency"`
 	// Total errors recorded.
 	Errors int `json:"errors"`
+	// Errors that failed validation. Included in Errors.
+	ValidationErrors int `json:"validation_errors,omitempty"`
 	// Objects per operation.
 	ObjectsPerOperation int `json:"objects_per_operation"`
 	// N is the number of operations.
@@ -188,14 +198,19 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 			errs := ops.FilterErrors()
 			if len(errs) > 0 {
 				a.Errors = len(errs)
+				a.ValidationErrors = len(errs.FilterValidationErrors())
 				for _, err := range errs {
 					if len(a.FirstErrors) >= 10 {
 						break
 					}
 					a.FirstErrors = append(a.FirstErrors, fmt.Sprintf("%s, %s: %v", err.Endpoint, err.End.Round(time.Second), err.Err))
 				}
+				byClient := len(ops.Endpoints()) == 1 && ops.Clients() > 1
+				a.ErrorCategories = errorCategories(countErrorCategories(errs, byClient), ops.Duration())
 			}
 
+			a.Retries = newRetries(retryCounts(ops))
+
 			segmentDur := opts.DurFunc(ops.Duration())
 			segs := ops.Segment(bench.SegmentOptions{
 				From:           time.Time{},
@@ -236,6 +251,8 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 			} else {
 				a.MultiSizedRequests = RequestAnalysisMultiSized(ops, !opts.Prefiltered)
 			}
+			// Request times use the same requests as the request analysis.
+			a.Confidence = newConfidence(segs, ops.FilterInsideRange(ops.ActiveTimeRange(!opts.Prefiltered)), a.SingleSizedRequests != nil)
 
 			eps := allOps.SortSplitByEndpoint()
 			if len(eps) == 1 {
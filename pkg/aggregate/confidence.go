@@ -0,0 +1,107 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// Confidence contains 95% confidence intervals.
+// Throughput intervals are based on the throughput of each analysis segment.
+// Request time intervals are based on the order statistics of all requests.
+type Confidence struct {
+	// BPS is the interval of the average bytes per second. Not set if no bytes were transferred.
+	BPS *bench.Interval `json:"bps,omitempty"`
+	// OPS is the interval of the average objects per second.
+	OPS bench.Interval `json:"ops"`
+
+	// Request time percentile intervals in milliseconds.
+	// Only set if requests are all of same object size.
+	DurMedianMillis *bench.Interval `json:"dur_median_millis,omitempty"`
+	Dur90Millis     *bench.Interval `json:"dur_90_millis,omitempty"`
+	Dur99Millis     *bench.Interval `json:"dur_99_millis,omitempty"`
+}
+
+// newConfidence returns confidence intervals for segments and successful operations.
+// Request times are only added if durations is true.
+// Returns nil if there are too few segments.
+func newConfidence(segs bench.Segments, ops bench.Operations, durations bool) *Confidence {
+	bps, objs, ok := segs.ThroughputIntervals()
+	if !ok {
+		return nil
+	}
+	c := Confidence{OPS: objs}
+	if bps.High > 0 {
+		c.BPS = &bps
+	}
+	if !durations {
+		return &c
+	}
+	durs := make([]time.Duration, len(ops))
+	for i, op := range ops {
+		durs[i] = op.Duration()
+	}
+	slices.Sort(durs)
+	pct := func(p float64) *bench.Interval {
+		lo, hi, ok := bench.PercentileInterval(durs, p)
+		if !ok {
+			return nil
+		}
+		return &bench.Interval{Low: durToMillisF(lo), High: durToMillisF(hi)}
+	}
+	c.DurMedianMillis = pct(0.5)
+	c.Dur90Millis = pct(0.9)
+	c.Dur99Millis = pct(0.99)
+	return &c
+}
+
+// durToMillisF converts a duration to fractional milliseconds.
+func durToMillisF(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// StringThroughput returns the throughput intervals as a string.
+func (c Confidence) StringThroughput() string {
+	speed := ""
+	if c.BPS != nil {
+		speed = fmt.Sprintf("%s - %s, ", bench.Throughput(c.BPS.Low), bench.Throughput(c.BPS.High))
+	}
+	return fmt.Sprintf("%s%.02f - %.02f obj/s (±%.1f%%)", speed, c.OPS.Low, c.OPS.High, 100*c.OPS.RelWidth())
+}
+
+// StringRequests returns the request time intervals as a string.
+// Returns an empty string if request times are not available.
+func (c Confidence) StringRequests() string {
+	if c.DurMedianMillis == nil {
+		return ""
+	}
+	ms := func(iv *bench.Interval) string {
+		if iv == nil {
+			return "-"
+		}
+		toDur := func(f float64) time.Duration {
+			return time.Duration(f * float64(time.Millisecond)).Round(time.Microsecond)
+		}
+		return fmt.Sprintf("%v - %v", toDur(iv.Low), toDur(iv.High))
+	}
+	return fmt.Sprintf("50%%: %s, 90%%: %s, 99%%: %s", ms(c.DurMedianMillis), ms(c.Dur90Millis), ms(c.Dur99Millis))
+}
@@ -0,0 +1,72 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+func TestNewConfidence(t *testing.T) {
+	start := time.Unix(1000, 0)
+	var segs bench.Segments
+	for i, objs := range []float64{10, 12, 8, 10} {
+		s := start.Add(time.Duration(i) * time.Second)
+		segs = append(segs, bench.Segment{Start: s, EndsBefore: s.Add(time.Second), Objects: objs, TotalBytes: int64(objs) << 20})
+	}
+	ops := make(bench.Operations, 100)
+	for i := range ops {
+		// Shuffled durations of 0 to 99ms.
+		d := time.Duration(i*37%100) * time.Millisecond
+		ops[i] = bench.Operation{Start: start, End: start.Add(d)}
+	}
+
+	if c := newConfidence(segs[:1], ops, true); c != nil {
+		t.Errorf("got confidence of a single segment: %+v", c)
+	}
+	c := newConfidence(segs, ops, true)
+	if c == nil {
+		t.Fatal("no confidence")
+	}
+	// Mean 10 obj/s, standard error sqrt(8/3/4), t(3) = 3.182.
+	half := 3.182 * math.Sqrt(8.0/3/4)
+	if math.Abs(c.OPS.Low-(10-half)) > 1e-9 || math.Abs(c.OPS.High-(10+half)) > 1e-9 {
+		t.Errorf("got %+v obj/s, want %.4f - %.4f", c.OPS, 10-half, 10+half)
+	}
+	if c.BPS == nil || math.Abs(c.BPS.Low-(10-half)*(1<<20)) > 1e-3 || math.Abs(c.BPS.High-(10+half)*(1<<20)) > 1e-3 {
+		t.Errorf("got %+v bytes/s, want %.4f - %.4f MiB/s", c.BPS, 10-half, 10+half)
+	}
+	want := map[string]bench.Interval{
+		"median": {Low: 40, High: 60},
+		"90":     {Low: 84, High: 96},
+		"99":     {Low: 97, High: 99},
+	}
+	for name, got := range map[string]*bench.Interval{"median": c.DurMedianMillis, "90": c.Dur90Millis, "99": c.Dur99Millis} {
+		if got == nil || *got != want[name] {
+			t.Errorf("%s: got %+v ms, want %+v", name, got, want[name])
+		}
+	}
+
+	c = newConfidence(segs, ops, false)
+	if c.DurMedianMillis != nil || c.StringRequests() != "" {
+		t.Errorf("got request times: %+v", c)
+	}
+}
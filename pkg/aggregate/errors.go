@@ -0,0 +1,94 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// ErrorCategory contains the errors of a single category.
+type ErrorCategory struct {
+	// Category is the S3 error code or type of error, like "SlowDown" or "ConnectionReset".
+	Category string `json:"category"`
+	// Status is the HTTP status code of the category, if known.
+	Status int `json:"status,omitempty"`
+	// Count is the number of errors.
+	Count int `json:"count"`
+	// PerSec is the number of errors per second.
+	PerSec float64 `json:"per_sec"`
+	// ByHost contains the number of errors for each host.
+	ByHost map[string]int `json:"by_host,omitempty"`
+}
+
+// countErrorCategories returns the number of errors in errs by category and host.
+// If byClient is set, hosts are client IDs.
+func countErrorCategories(errs bench.Operations, byClient bool) map[string]map[string]int {
+	if len(errs) == 0 {
+		return nil
+	}
+	res := make(map[string]map[string]int)
+	for _, op := range errs {
+		cat, _ := op.ErrCategory()
+		if cat == "" {
+			continue
+		}
+		host := op.Endpoint
+		if byClient {
+			host = clientAsHostPrefix + op.ClientID
+		}
+		if res[cat] == nil {
+			res[cat] = make(map[string]int)
+		}
+		res[cat][host]++
+	}
+	return res
+}
+
+// errorCategories returns the error categories of counts, most frequent first.
+// dur is the duration used for calculating error rates.
+func errorCategories(counts map[string]map[string]int, dur time.Duration) []ErrorCategory {
+	if len(counts) == 0 {
+		return nil
+	}
+	res := make([]ErrorCategory, 0, len(counts))
+	for cat, hosts := range counts {
+		c := ErrorCategory{
+			Category: cat,
+			Status:   bench.ErrCategoryStatus(cat),
+			ByHost:   make(map[string]int, len(hosts)),
+		}
+		for host, n := range hosts {
+			c.Count += n
+			c.ByHost[host] = n
+		}
+		if dur > 0 {
+			c.PerSec = float64(c.Count) / dur.Seconds()
+		}
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Category < res[j].Category
+	})
+	return res
+}
@@ -0,0 +1,201 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// HDR returns statistics from operations collected as histograms.
+// SkipDur is ignored, since histograms cannot be filtered by time.
+func HDR(s *bench.HDRStats, opts Options) Aggregated {
+	a := Aggregated{
+		Type: "single",
+	}
+	if s == nil || s.All == nil {
+		return a
+	}
+	if s.IsMixed() {
+		a.Mixed = true
+		a.Type = "mixed"
+		total := s.All.Total()
+		a.MixedServerStats = &Throughput{}
+		a.MixedServerStats.fill(total)
+		segmentDur := opts.DurFunc(total.Duration())
+		segs := s.All.Segments(segmentDur)
+		if len(segs) > 1 {
+			a.MixedServerStats.Segmented = &ThroughputSegmented{
+				SegmentDurationMillis: durToMillis(segmentDur),
+			}
+			a.MixedServerStats.Segmented.fill(segs, total)
+		}
+		a.MixedThroughputByHost = hdrHosts(s.All)
+	}
+	for _, typ := range s.OpTypes() {
+		a.Operations = append(a.Operations, hdrOperation(s.Ops[typ], opts))
+	}
+	return a
+}
+
+func hdrOperation(op *bench.HDROp, opts Options) Operation {
+	a := Operation{
+		Type:                op.OpType,
+		N:                   op.Requests,
+		Errors:              op.Errors,
+		ValidationErrors:    op.ValidationErrors,
+		FirstErrors:         op.FirstErrors,
+		ErrorCategories:     errorCategories(op.ErrCategories, op.End.Sub(op.Start)),
+		Retries:             newRetries(op.Retries),
+		StartTime:           op.Start,
+		EndTime:             op.End,
+		ObjectsPerOperation: op.ObjPerOp,
+		Concurrency:         op.Threads(),
+		Clients:             op.Clients(),
+		Hosts:               len(op.Hosts),
+		HostNames:           op.Endpoints(),
+		ThroughputByHost:    hdrHosts(op),
+	}
+	total := op.Total()
+	segmentDur := opts.DurFunc(total.Duration())
+	segs := op.Segments(segmentDur)
+	if len(segs) <= 1 || op.Latency.N() == 0 {
+		a.Skipped = true
+		return a
+	}
+	a.Throughput.fill(total)
+	a.Throughput.Segmented = &ThroughputSegmented{
+		SegmentDurationMillis: durToMillis(segmentDur),
+	}
+	a.Throughput.Segmented.fill(segs, total)
+	if !op.MultipleSizes() {
+		a.SingleSizedRequests = hdrSingleSized(op)
+	} else {
+		a.MultiSizedRequests = hdrMultiSized(op)
+	}
+	return a
+}
+
+func hdrHosts(op *bench.HDROp) map[string]Throughput {
+	res := make(map[string]Throughput, len(op.Hosts))
+	for ep, h := range op.Hosts {
+		var t Throughput
+		t.fill(bench.Segment{
+			Start:      op.Start,
+			EndsBefore: op.End,
+			FullOps:    h.Requests - h.Errors,
+			OpsEnded:   h.Requests - h.Errors,
+			Objects:    h.Objects,
+			TotalBytes: h.TotalBytes,
+			Errors:     h.Errors,
+		})
+		res[ep] = t
+	}
+	return res
+}
+
+// usToMillis converts microseconds to rounded milliseconds.
+func usToMillis(us float64) int {
+	return durToMillis(time.Duration(us * float64(time.Microsecond)))
+}
+
+func hdrSingleSized(op *bench.HDROp) *SingleSizedRequests {
+	h := &op.Latency
+	res := SingleSizedRequests{
+		Requests:        h.N(),
+		ObjSize:         op.Size(),
+		DurAvgMillis:    usToMillis(h.Mean()),
+		StdDev:          usToMillis(h.StdDev()),
+		DurMedianMillis: usToMillis(float64(h.Percentile(0.5))),
+		Dur90Millis:     usToMillis(float64(h.Percentile(0.9))),
+		Dur99Millis:     usToMillis(float64(h.Percentile(0.99))),
+		SlowestMillis:   usToMillis(float64(h.Percentile(1))),
+		FastestMillis:   usToMillis(float64(h.Percentile(0))),
+		FirstByte:       hdrTTFB(&op.TTFB),
+		HostNames:       op.Endpoints(),
+	}
+	for i := range res.DurPct[:] {
+		res.DurPct[i] = usToMillis(float64(h.Percentile(float64(i) / 100)))
+	}
+	return &res
+}
+
+func hdrMultiSized(op *bench.HDROp) *MultiSizedRequests {
+	res := MultiSizedRequests{
+		Requests:  op.Latency.N(),
+		HostNames: op.Endpoints(),
+	}
+	if res.Requests > 0 {
+		res.AvgObjSize = op.TotalBytes / int64(res.Requests)
+	}
+	keys := make([]int, 0, len(op.Sizes))
+	for k := range op.Sizes {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		s := op.Sizes[k]
+		r := RequestSizeRange{
+			Requests: s.Requests,
+			MinSize:  int(s.Smallest),
+			MaxSize:  int(s.Biggest),
+		}
+		r.MinSizeString, r.MaxSizeString = s.SizesString()
+		if s.Requests > 0 {
+			r.AvgObjSize = int(s.TotalBytes / int64(s.Requests))
+			r.AvgDurationMillis = durToMillis(s.TotalDur / time.Duration(s.Requests))
+		}
+		if s.TotalDur > 0 {
+			r.BpsAverage = float64(s.TotalBytes) / s.TotalDur.Seconds()
+		}
+		// Fastest requests have the highest throughput.
+		r.BpsMedian = float64(s.BPS.Percentile(0.5))
+		r.Bps90 = float64(s.BPS.Percentile(0.1))
+		r.Bps99 = float64(s.BPS.Percentile(0.01))
+		r.BpsFastest = float64(s.BPS.Percentile(1))
+		r.BpsSlowest = float64(s.BPS.Percentile(0))
+		for i := range r.BpsPct[:] {
+			r.BpsPct[i] = float64(s.BPS.Percentile(1 - float64(i)/100))
+		}
+		res.BySize = append(res.BySize, r)
+	}
+	return &res
+}
+
+func hdrTTFB(h *bench.Histogram) *TTFB {
+	if h.N() == 0 {
+		return nil
+	}
+	t := TTFB{
+		AverageMillis: usToMillis(h.Mean()),
+		FastestMillis: usToMillis(float64(h.Percentile(0))),
+		P25Millis:     usToMillis(float64(h.Percentile(0.25))),
+		MedianMillis:  usToMillis(float64(h.Percentile(0.5))),
+		P75Millis:     usToMillis(float64(h.Percentile(0.75))),
+		P90Millis:     usToMillis(float64(h.Percentile(0.9))),
+		P99Millis:     usToMillis(float64(h.Percentile(0.99))),
+		SlowestMillis: usToMillis(float64(h.Percentile(1))),
+		StdDevMillis:  usToMillis(h.StdDev()),
+	}
+	for i := range t.PercentilesMillis[:] {
+		t.PercentilesMillis[i] = usToMillis(float64(h.Percentile(float64(i) / 100)))
+	}
+	return &t
+}
@@ -0,0 +1,67 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import "github.com/minio/warp/pkg/bench"
+
+// Retries contains response status and retry statistics of operations.
+// Only populated when response statuses were recorded.
+type Retries struct {
+	// Ops is the number of operations with a recorded status.
+	Ops int `json:"ops"`
+	// FirstTry is the number of successful operations without retries.
+	FirstTry int `json:"first_try"`
+	// RetriedOK is the number of operations that succeeded after one or more retries.
+	RetriedOK int `json:"retried_ok"`
+	// RetriedFailed is the number of operations that failed after one or more retries.
+	RetriedFailed int `json:"retried_failed"`
+	// Retries is the total number of retried requests.
+	Retries int `json:"retries"`
+	// Amplification is the number of requests sent for each operation,
+	// assuming one request per operation without retries.
+	Amplification float64 `json:"amplification"`
+	// Statuses contains the number of operations by final HTTP status code.
+	// 0 is used for operations that received no response.
+	Statuses map[int]int `json:"statuses,omitempty"`
+}
+
+// newRetries returns retry statistics from counts.
+// Returns nil if no statuses were recorded.
+func newRetries(counts bench.RetryCounts) *Retries {
+	if counts.Ops == 0 {
+		return nil
+	}
+	return &Retries{
+		Ops:           counts.Ops,
+		FirstTry:      counts.FirstTry,
+		RetriedOK:     counts.RetriedOK,
+		RetriedFailed: counts.RetriedFailed,
+		Retries:       counts.Retries,
+		Amplification: float64(counts.Ops+counts.Retries) / float64(counts.Ops),
+		Statuses:      counts.Statuses,
+	}
+}
+
+// retryCounts returns the retry counts of ops.
+func retryCounts(ops bench.Operations) bench.RetryCounts {
+	var res bench.RetryCounts
+	for _, op := range ops {
+		res.Add(op)
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// AccessDist is the distribution of objects chosen for reads.
+// The zero value selects objects uniformly at random.
+type AccessDist struct {
+	// Zipf is the exponent of a Zipfian distribution.
+	// If > 1, objects earlier in the object list are read more often.
+	Zipf float64
+
+	// Sequential reads objects in order.
+	// Each thread starts at a different offset.
+	Sequential bool
+}
+
+// ParseAccessDist parses an access distribution like "uniform", "sequential" or "zipf:1.1".
+func ParseAccessDist(s string) (AccessDist, error) {
+	kind, param, hasParam := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind {
+	case "", "uniform":
+		if hasParam {
+			return AccessDist{}, fmt.Errorf("invalid access distribution %q: uniform takes no parameter", s)
+		}
+		return AccessDist{}, nil
+	case "sequential":
+		if hasParam {
+			return AccessDist{}, fmt.Errorf("invalid access distribution %q: sequential takes no parameter", s)
+		}
+		return AccessDist{Sequential: true}, nil
+	case "zipf":
+		exp := 1.1
+		if hasParam {
+			var err error
+			exp, err = strconv.ParseFloat(param, 64)
+			if err != nil {
+				return AccessDist{}, fmt.Errorf("invalid access distribution %q: %w", s, err)
+			}
+		}
+		// Also rejects NaN and infinity.
+		if !(exp > 1) || math.IsInf(exp, 1) {
+			return AccessDist{}, fmt.Errorf("invalid access distribution %q: zipf exponent must be a finite number > 1", s)
+		}
+		return AccessDist{Zipf: exp}, nil
+	}
+	return AccessDist{}, fmt.Errorf("unknown access distribution %q. Must be 'uniform', 'sequential' or 'zipf:exponent'", s)
+}
+
+// String returns the distribution in the format accepted by ParseAccessDist.
+func (a AccessDist) String() string {
+	switch {
+	case a.Sequential:
+		return "sequential"
+	case a.Zipf > 0:
+		return "zipf:" + strconv.FormatFloat(a.Zipf, 'g', -1, 64)
+	}
+	return "uniform"
+}
+
+// uniform returns true if objects are selected uniformly at random.
+func (a AccessDist) uniform() bool {
+	return !a.Sequential && a.Zipf <= 0
+}
+
+// picker returns a function that selects the index of the next of n objects to read.
+// n must be at least 1. Sequential reads start at offset start.
+func (a AccessDist) picker(rng *rand.Rand, n, start int) func() int {
+	switch {
+	case a.Sequential:
+		pos := start
+		return func() int {
+			i := pos % n
+			pos = i + 1
+			return i
+		}
+	case a.Zipf > 0:
+		z := rand.NewZipf(rng, a.Zipf, 1, uint64(n-1))
+		return func() int {
+			return int(z.Uint64())
+		}
+	}
+	return func() int {
+		return rng.Intn(n)
+	}
+}
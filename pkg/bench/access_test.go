@@ -0,0 +1,123 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseAccessDist(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    AccessDist
+		wantErr bool
+	}{
+		{in: "", want: AccessDist{}},
+		{in: "uniform", want: AccessDist{}},
+		{in: " sequential ", want: AccessDist{Sequential: true}},
+		{in: "zipf", want: AccessDist{Zipf: 1.1}},
+		{in: "zipf:1.5", want: AccessDist{Zipf: 1.5}},
+		{in: "zipf:1", wantErr: true},
+		{in: "zipf:0.5", wantErr: true},
+		{in: "zipf:-2", wantErr: true},
+		{in: "zipf:inf", wantErr: true},
+		{in: "zipf:+Inf", wantErr: true},
+		{in: "zipf:nan", wantErr: true},
+		{in: "zipf:", wantErr: true},
+		{in: "zipf:abc", wantErr: true},
+		{in: "uniform:1", wantErr: true},
+		{in: "sequential:2", wantErr: true},
+		{in: "random", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseAccessDist(test.in)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: got %+v, want error", test.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.in, got, test.want)
+		}
+		// The string form must parse to the same distribution.
+		if again, err := ParseAccessDist(got.String()); err != nil || again != got {
+			t.Errorf("%q: round trip of %q gave %+v, %v", test.in, got.String(), again, err)
+		}
+	}
+}
+
+func TestAccessDistPicker(t *testing.T) {
+	dists := []AccessDist{{}, {Sequential: true}, {Zipf: 1.1}}
+	for _, d := range dists {
+		pick := d.picker(rand.New(rand.NewSource(1)), 1, 5)
+		for i := 0; i < 100; i++ {
+			if got := pick(); got != 0 {
+				t.Fatalf("%v: got index %d of 1 object", d, got)
+			}
+		}
+	}
+
+	pick := AccessDist{Sequential: true}.picker(rand.New(rand.NewSource(1)), 4, 6)
+	want := []int{2, 3, 0, 1, 2, 3, 0}
+	for i, w := range want {
+		if got := pick(); got != w {
+			t.Errorf("sequential pick %d: got %d, want %d", i, got, w)
+		}
+	}
+
+	const n, picks = 100, 100000
+	for _, d := range []AccessDist{{}, {Zipf: 1.5}} {
+		counts := make([]int, n)
+		pick := d.picker(rand.New(rand.NewSource(1)), n, 0)
+		for i := 0; i < picks; i++ {
+			idx := pick()
+			if idx < 0 || idx >= n {
+				t.Fatalf("%v: got index %d of %d objects", d, idx, n)
+			}
+			counts[idx]++
+		}
+		first, last := counts[0], counts[n-1]
+		if d.uniform() {
+			// Each object is expected picks/n = 1000 times.
+			for i, c := range counts {
+				if c < 800 || c > 1200 {
+					t.Errorf("uniform: object %d picked %d times", i, c)
+				}
+			}
+			continue
+		}
+		// P(0) = 1/H(100, 1.5) ≈ 0.43, P(99) ≈ 0.0004.
+		if first < picks*35/100 || first > picks*50/100 {
+			t.Errorf("%v: first object picked %d times", d, first)
+		}
+		if last*100 > first {
+			t.Errorf("%v: last object picked %d times, first %d times", d, last, first)
+		}
+		for i := 1; i < 10; i++ {
+			if counts[i] > counts[i-1] {
+				t.Errorf("%v: object %d picked %d times, more than object %d (%d)", d, i, counts[i], i-1, counts[i-1])
+			}
+		}
+	}
+}
@@ -19,9 +19,11 @@ package bench
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"slices"
 	"sort"
 	"time"
 )
@@ -50,6 +52,24 @@ type Segment struct {
 	ReqAvg     float64   `json:"req_avg_ms"` // Average duration of operations ending in segment.
 	TotalBytes int64     `json:"total_bytes"`
 	ObjsPerOp  int       `json:"objects_per_op"`
+
+	// Latency contains request latency percentiles of operations ending in segment.
+	// Only populated when added by Segments.AddLatency.
+	Latency *SegmentLatency `json:"latency,omitempty"`
+}
+
+// SegmentLatency contains latency percentiles of successful operations
+// ending within a segment. All values are in milliseconds.
+type SegmentLatency struct {
+	Requests int     `json:"requests"`
+	ReqP50   float64 `json:"req_p50_ms"`
+	ReqP90   float64 `json:"req_p90_ms"`
+	ReqP99   float64 `json:"req_p99_ms"`
+	ReqP999  float64 `json:"req_p999_ms"`
+	TTFBP50  float64 `json:"ttfb_p50_ms,omitempty"`
+	TTFBP90  float64 `json:"ttfb_p90_ms,omitempty"`
+	TTFBP99  float64 `json:"ttfb_p99_ms,omitempty"`
+	TTFBP999 float64 `json:"ttfb_p999_ms,omitempty"`
 }
 
 // TTFB contains time to first byte stats.
@@ -223,6 +243,60 @@ func (s Segment) SpeedPerSec() (mib, ops, objs float64) {
 	return
 }
 
+// AddLatency will add latency percentiles to the segments.
+// Successful operations from o ending within each segment are included.
+// Segments must be sorted by time.
+func (s Segments) AddLatency(o Operations) {
+	if len(s) == 0 {
+		return
+	}
+	reqs := make([][]time.Duration, len(s))
+	ttfbs := make([][]time.Duration, len(s))
+	for i := range o {
+		op := &o[i]
+		if len(op.Err) != 0 {
+			continue
+		}
+		idx := sort.Search(len(s), func(i int) bool {
+			return op.End.Before(s[i].EndsBefore)
+		})
+		if idx >= len(s) || op.End.Before(s[idx].Start) {
+			continue
+		}
+		if len(s[idx].OpType) > 0 && op.OpType != s[idx].OpType {
+			continue
+		}
+		reqs[idx] = append(reqs[idx], op.Duration())
+		if op.FirstByte != nil {
+			ttfbs[idx] = append(ttfbs[idx], op.TTFB())
+		}
+	}
+	percentile := func(d []time.Duration, p float64) float64 {
+		if len(d) == 0 {
+			return 0
+		}
+		n := int(math.Round(float64(len(d)) * p))
+		n = min(max(n, 0), len(d)-1)
+		return float64(d[n]) / float64(time.Millisecond)
+	}
+	for i := range s {
+		r, t := reqs[i], ttfbs[i]
+		slices.Sort(r)
+		slices.Sort(t)
+		s[i].Latency = &SegmentLatency{
+			Requests: len(r),
+			ReqP50:   percentile(r, 0.5),
+			ReqP90:   percentile(r, 0.9),
+			ReqP99:   percentile(r, 0.99),
+			ReqP999:  percentile(r, 0.999),
+			TTFBP50:  percentile(t, 0.5),
+			TTFBP90:  percentile(t, 0.9),
+			TTFBP99:  percentile(t, 0.99),
+			TTFBP999: percentile(t, 0.999),
+		}
+	}
+}
+
 // Print segments to a supplied writer.
 func (s Segments) Print(w io.Writer) error {
 	for i, seg := range s {
@@ -256,6 +330,14 @@ func (s Segments) CSV(w io.Writer, hostOvr string) error {
 		"reqs_ended_avg_ms",
 		"start_time",
 		"end_time",
+		"req_p50_ms",
+		"req_p90_ms",
+		"req_p99_ms",
+		"req_p999_ms",
+		"ttfb_p50_ms",
+		"ttfb_p90_ms",
+		"ttfb_p99_ms",
+		"ttfb_p999_ms",
 	})
 	if err != nil {
 		return err
@@ -267,6 +349,37 @@ func (s Segments) CSV(w io.Writer, hostOvr string) error {
 		}
 	}
 	cw.Flush()
+	return cw.Error()
+}
+
+// JSON writes segments to a supplied writer as JSON lines.
+// Each line contains a single segment.
+func (s Segments) JSON(w io.Writer, hostOvr string) error {
+	enc := json.NewEncoder(w)
+	for i, seg := range s {
+		if hostOvr != "" {
+			seg.Host = hostOvr
+		}
+		mib, ops, objs := seg.SpeedPerSec()
+		err := enc.Encode(struct {
+			Index int `json:"index"`
+			Segment
+			Duration       float64 `json:"duration_s"`
+			MBPerSec       float64 `json:"mb_per_sec"`
+			OpsEndedPerSec float64 `json:"ops_ended_per_sec"`
+			ObjsPerSec     float64 `json:"objs_per_sec"`
+		}{
+			Index:          i,
+			Segment:        seg,
+			Duration:       seg.Duration().Seconds(),
+			MBPerSec:       mib,
+			OpsEndedPerSec: ops,
+			ObjsPerSec:     objs,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -276,7 +389,17 @@ func (s Segment) CSV(w *csv.Writer, idx int, host string) error {
 	if host == "" {
 		host = s.Host
 	}
-	return w.Write([]string{
+	lat := make([]string, 8)
+	if l := s.Latency; l != nil && l.Requests > 0 {
+		lat = []string{
+			fmt.Sprint(l.ReqP50), fmt.Sprint(l.ReqP90), fmt.Sprint(l.ReqP99), fmt.Sprint(l.ReqP999),
+			"", "", "", "",
+		}
+		if l.TTFBP50 > 0 || l.TTFBP999 > 0 {
+			lat[4], lat[5], lat[6], lat[7] = fmt.Sprint(l.TTFBP50), fmt.Sprint(l.TTFBP90), fmt.Sprint(l.TTFBP99), fmt.Sprint(l.TTFBP999)
+		}
+	}
+	return w.Write(append([]string{
 		fmt.Sprint(idx),
 		s.OpType,
 		host,
@@ -294,7 +417,7 @@ func (s Segment) CSV(w *csv.Writer, idx int, host string) error {
 		fmt.Sprint(s.ReqAvg),
 		fmt.Sprint(s.Start),
 		fmt.Sprint(s.EndsBefore),
-	})
+	}, lat...))
 }
 
 // Duration returns the duration of the segment
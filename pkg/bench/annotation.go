@@ -0,0 +1,78 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"strings"
+	"time"
+)
+
+// OpAnnotation is the operation type used for storing annotations with benchmark data.
+// The annotation text is stored as the file name.
+const OpAnnotation = "ANNOTATION"
+
+// Annotation is an external event recorded during a benchmark,
+// for example "failover triggered".
+type Annotation struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Annotations is a collection of annotations.
+type Annotations []Annotation
+
+// WithAnnotations returns the operations with the annotations added as operations.
+// The input is not modified.
+func (o Operations) WithAnnotations(a Annotations) Operations {
+	if len(a) == 0 {
+		return o
+	}
+	res := make(Operations, 0, len(o)+len(a))
+	res = append(res, o...)
+	for _, an := range a {
+		res = append(res, Operation{
+			OpType: OpAnnotation,
+			Start:  an.Time,
+			End:    an.Time,
+			File:   strings.Join(strings.Fields(an.Text), " "),
+		})
+	}
+	res.SortByStartTime()
+	return res
+}
+
+// SplitAnnotations returns the operations without annotations and the annotations.
+// If there are no annotations the operations are returned as is.
+func (o Operations) SplitAnnotations() (Operations, Annotations) {
+	var a Annotations
+	for _, op := range o {
+		if op.OpType == OpAnnotation {
+			a = append(a, Annotation{Time: op.Start, Text: op.File})
+		}
+	}
+	if len(a) == 0 {
+		return o, nil
+	}
+	res := make(Operations, 0, len(o)-len(a))
+	for _, op := range o {
+		if op.OpType != OpAnnotation {
+			res = append(res, op)
+		}
+	}
+	return res, a
+}
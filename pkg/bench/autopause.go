@@ -0,0 +1,125 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/minio/pkg/v2/console"
+)
+
+// AutoPause pauses the workload when no operations have succeeded on any endpoint for a while.
+// While paused a single probe operation is allowed at regular intervals.
+// The workload is resumed when an operation succeeds.
+type AutoPause struct {
+	// After is the duration all operations must have failed before pausing.
+	After time.Duration
+	// Probe is the interval between probe operations while paused.
+	Probe time.Duration
+
+	mu          sync.Mutex
+	lastOK      time.Time
+	firstErr    time.Time
+	paused      bool
+	lastProbe   time.Time
+	outageStart time.Time
+	resumed     chan struct{}
+	outages     []Outage
+}
+
+// Outage is a time window where the workload was paused.
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// observe records the outcome of an operation.
+func (a *AutoPause) observe(op Operation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if op.Err == "" {
+		if op.End.After(a.lastOK) {
+			a.lastOK = op.End
+		}
+		a.firstErr = time.Time{}
+		if a.paused {
+			a.paused = false
+			a.outages = append(a.outages, Outage{Start: a.outageStart, End: op.End})
+			close(a.resumed)
+			console.Eraseline()
+			console.Infof("\rTarget healthy again at %s after %v. Resuming benchmark.\n", op.End.Format(time.TimeOnly), op.End.Sub(a.outageStart).Round(time.Second))
+		}
+		return
+	}
+	if a.paused {
+		return
+	}
+	if a.firstErr.IsZero() || op.End.Before(a.firstErr) {
+		if op.End.After(a.lastOK) {
+			a.firstErr = op.End
+		}
+	}
+	if a.firstErr.IsZero() || time.Since(a.firstErr) < a.After {
+		return
+	}
+	a.paused = true
+	a.outageStart = a.firstErr
+	a.lastProbe = time.Now()
+	a.resumed = make(chan struct{})
+	console.Eraseline()
+	console.Errorf("\rAll operations have failed since %s. Pausing benchmark until target is healthy.\n", a.firstErr.Format(time.TimeOnly))
+}
+
+// wait blocks while the workload is paused, except for probe operations.
+func (a *AutoPause) wait(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		if !a.paused {
+			a.mu.Unlock()
+			return nil
+		}
+		next := a.lastProbe.Add(a.Probe)
+		if !time.Now().Before(next) {
+			a.lastProbe = time.Now()
+			a.mu.Unlock()
+			return nil
+		}
+		resumed := a.resumed
+		a.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumed:
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// Outages returns all outages.
+// An ongoing outage will have a zero End time.
+func (a *AutoPause) Outages() []Outage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := append([]Outage{}, a.outages...)
+	if a.paused {
+		res = append(res, Outage{Start: a.outageStart})
+	}
+	return res
+}
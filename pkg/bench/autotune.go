@@ -0,0 +1,234 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AutoTune increases the number of active threads in steps during the benchmark,
+// until throughput stops increasing or the error rate or latency target is exceeded.
+// Threads with an index at or above the current concurrency will wait.
+// When tuning has finished Done is called.
+type AutoTune struct {
+	// Start is the concurrency of the first step.
+	Start int
+	// Max is the highest concurrency that will be tried.
+	Max int
+	// Step is the duration of each step.
+	Step time.Duration
+	// MinGain is the relative throughput increase required to continue (0 -> 1).
+	MinGain float64
+	// MaxErrors is the highest allowed fraction of failed operations in a step (0 -> 1).
+	MaxErrors float64
+	// MaxLatency is the highest allowed 90th percentile request time of a step, if set.
+	MaxLatency time.Duration
+	// Done is called when tuning has finished.
+	Done func()
+
+	mu       sync.Mutex
+	once     sync.Once
+	started  time.Time
+	steps    []AutoTuneStep
+	current  *autoTuneStep
+	finished bool
+	changed  chan struct{}
+}
+
+// AutoTuneStep contains the result of a single tuning step.
+type AutoTuneStep struct {
+	Concurrency int
+	Start       time.Time
+	End         time.Time
+	Requests    int
+	Errors      int
+	// BPS is bytes per second, OPS is objects per second.
+	BPS float64
+	OPS float64
+	// P90 is the 90th percentile request time of successful requests.
+	P90 time.Duration
+	// Rejected is set when the step exceeded the error rate or latency target.
+	Rejected string
+}
+
+// autoTuneStep is the step currently running.
+type autoTuneStep struct {
+	AutoTuneStep
+	bytes int64
+	objs  int64
+	durs  []time.Duration
+}
+
+// MaxDuration returns the longest duration tuning can take.
+func (a *AutoTune) MaxDuration() time.Duration {
+	var d time.Duration
+	for c := max(a.Start, 1); ; c *= 2 {
+		d += a.Step
+		if c >= a.Max {
+			return d
+		}
+	}
+}
+
+// begin starts tuning, if not already started.
+func (a *AutoTune) begin() {
+	a.once.Do(func() {
+		a.mu.Lock()
+		a.changed = make(chan struct{})
+		a.started = time.Now()
+		a.next(min(max(a.Start, 1), a.Max), a.started)
+		a.mu.Unlock()
+	})
+}
+
+// next starts a step with concurrency c.
+// a.mu must be held.
+func (a *AutoTune) next(c int, now time.Time) {
+	a.current = &autoTuneStep{AutoTuneStep: AutoTuneStep{Concurrency: c, Start: now}}
+	time.AfterFunc(a.Step, a.endStep)
+}
+
+// endStep evaluates the current step and starts the next one or finishes tuning.
+func (a *AutoTune) endStep() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.finished {
+		return
+	}
+	s := a.current
+	s.End = time.Now()
+	if secs := s.End.Sub(s.Start).Seconds(); secs > 0 {
+		s.BPS = float64(s.bytes) / secs
+		s.OPS = float64(s.objs) / secs
+	}
+	if len(s.durs) > 0 {
+		slices.Sort(s.durs)
+		s.P90 = s.durs[len(s.durs)*9/10]
+	}
+	switch {
+	case s.Requests == 0:
+		s.Rejected = "no requests completed"
+	case float64(s.Errors) > a.MaxErrors*float64(s.Requests):
+		s.Rejected = fmt.Sprintf("error rate %.1f%%", 100*float64(s.Errors)/float64(s.Requests))
+	case a.MaxLatency > 0 && s.P90 > a.MaxLatency:
+		s.Rejected = fmt.Sprintf("90th percentile %v", s.P90.Round(time.Millisecond))
+	}
+	best, hasBest := a.best()
+	a.steps = append(a.steps, s.AutoTuneStep)
+	done := s.Rejected != "" || s.Concurrency >= a.Max
+	if !done && hasBest && s.throughput() < best.throughput()*(1+a.MinGain) {
+		done = true
+	}
+	close(a.changed)
+	a.changed = make(chan struct{})
+	if done {
+		a.finished = true
+		if a.Done != nil {
+			go a.Done()
+		}
+		return
+	}
+	a.next(min(s.Concurrency*2, a.Max), s.End)
+}
+
+// throughput returns the bytes per second, or objects per second if no bytes were transferred.
+func (s AutoTuneStep) throughput() float64 {
+	if s.BPS > 0 {
+		return s.BPS
+	}
+	return s.OPS
+}
+
+// best returns the accepted step with the highest throughput.
+// a.mu must be held.
+func (a *AutoTune) best() (AutoTuneStep, bool) {
+	var best AutoTuneStep
+	found := false
+	for _, s := range a.steps {
+		if s.Rejected == "" && (!found || s.throughput() > best.throughput()) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// observe records a completed operation in the current step.
+func (a *AutoTune) observe(op Operation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.current
+	if s == nil || a.finished || op.End.Before(s.Start) {
+		return
+	}
+	s.Requests++
+	if op.Err != "" {
+		s.Errors++
+		return
+	}
+	s.bytes += op.Size
+	s.objs += int64(op.ObjPerOp)
+	s.durs = append(s.durs, op.End.Sub(op.Start))
+}
+
+// wait until thread is active.
+func (a *AutoTune) wait(ctx context.Context, thread int) error {
+	a.begin()
+	for {
+		a.mu.Lock()
+		if thread < a.current.Concurrency {
+			a.mu.Unlock()
+			return nil
+		}
+		changed := a.changed
+		a.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// concurrencyAt returns the concurrency at time t.
+// Returns 0 if tuning has not started.
+func (a *AutoTune) concurrencyAt(t time.Time) uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current == nil || t.Before(a.started) {
+		return 0
+	}
+	for _, s := range a.steps {
+		if t.Before(s.End) {
+			return uint32(s.Concurrency)
+		}
+	}
+	return uint32(a.current.Concurrency)
+}
+
+// Result returns all completed steps and the best step.
+// If no step was accepted, ok is false.
+func (a *AutoTune) Result() (steps []AutoTuneStep, best AutoTuneStep, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	best, ok = a.best()
+	return append([]AutoTuneStep{}, a.steps...), best, ok
+}
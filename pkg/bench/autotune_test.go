@@ -0,0 +1,190 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// autoTuneTestStep describes the operations observed in a step.
+type autoTuneTestStep struct {
+	ok, errs int
+	dur      time.Duration
+}
+
+// run observes the operations of s in the current step of a and ends it.
+// The step is moved one second back, so throughput equals the number of operations.
+func (s autoTuneTestStep) run(a *AutoTune) {
+	a.mu.Lock()
+	now := time.Now()
+	a.current.Start = now.Add(-time.Second)
+	a.mu.Unlock()
+	for i := 0; i < s.ok+s.errs; i++ {
+		op := Operation{Start: now.Add(-s.dur), End: now, Size: 1000, ObjPerOp: 1}
+		if i >= s.ok {
+			op.Err = "failed"
+		}
+		a.observe(op)
+	}
+	// Operations that ended before the step are ignored.
+	a.observe(Operation{Start: now.Add(-2 * time.Second), End: now.Add(-2 * time.Second), Size: 1000, ObjPerOp: 1})
+	a.endStep()
+}
+
+func TestAutoTuneSteps(t *testing.T) {
+	ms := time.Millisecond
+	for _, test := range []struct {
+		name     string
+		tune     *AutoTune
+		steps    []autoTuneTestStep
+		want     []int
+		rejected string
+		best     int
+	}{
+		{
+			name:  "gain",
+			tune:  &AutoTune{Start: 1, Max: 64, MinGain: 0.1},
+			steps: []autoTuneTestStep{{ok: 100}, {ok: 200}, {ok: 300}, {ok: 320}},
+			want:  []int{1, 2, 4, 8},
+			best:  8,
+		},
+		{
+			name:  "max",
+			tune:  &AutoTune{Start: 3, Max: 10, MinGain: 0.1},
+			steps: []autoTuneTestStep{{ok: 100}, {ok: 200}, {ok: 400}},
+			want:  []int{3, 6, 10},
+			best:  10,
+		},
+		{
+			name:     "errors",
+			tune:     &AutoTune{Start: 1, Max: 64, MaxErrors: 0.05},
+			steps:    []autoTuneTestStep{{ok: 100, errs: 2}, {ok: 180, errs: 20}},
+			want:     []int{1, 2},
+			rejected: "error rate 10.0%",
+			best:     1,
+		},
+		{
+			name:     "latency",
+			tune:     &AutoTune{Start: 2, Max: 64, MaxLatency: 100 * ms},
+			steps:    []autoTuneTestStep{{ok: 100, dur: 50 * ms}, {ok: 200, dur: 50 * ms}, {ok: 400, dur: 200 * ms}},
+			want:     []int{2, 4, 8},
+			rejected: "90th percentile 200ms",
+			best:     4,
+		},
+		{
+			name:     "no requests",
+			tune:     &AutoTune{Start: 1, Max: 64},
+			steps:    []autoTuneTestStep{{}},
+			want:     []int{1},
+			rejected: "no requests completed",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := test.tune
+			a.Step = time.Hour
+			done := make(chan struct{})
+			a.Done = func() { close(done) }
+			a.begin()
+			for i, s := range test.steps {
+				select {
+				case <-done:
+					t.Fatalf("finished after %d steps", i)
+				default:
+				}
+				s.run(a)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Done not called")
+			}
+			steps, best, ok := a.Result()
+			if len(steps) != len(test.want) {
+				t.Fatalf("got %d steps, want %d", len(steps), len(test.want))
+			}
+			for i, s := range steps {
+				if s.Concurrency != test.want[i] {
+					t.Errorf("step %d: got concurrency %d, want %d", i, s.Concurrency, test.want[i])
+				}
+				if s.Requests != test.steps[i].ok+test.steps[i].errs || s.Errors != test.steps[i].errs {
+					t.Errorf("step %d: got %d requests, %d errors", i, s.Requests, s.Errors)
+				}
+			}
+			if last := steps[len(steps)-1]; last.Rejected != test.rejected {
+				t.Errorf("got rejected %q, want %q", last.Rejected, test.rejected)
+			}
+			if ok != (test.best != 0) || best.Concurrency != test.best {
+				t.Errorf("got best concurrency %d (%v), want %d", best.Concurrency, ok, test.best)
+			}
+			// Steps after tuning has finished are ignored.
+			a.observe(Operation{Start: time.Now(), End: time.Now()})
+			a.endStep()
+			if steps, _, _ := a.Result(); len(steps) != len(test.want) {
+				t.Errorf("got %d steps after finishing", len(steps))
+			}
+		})
+	}
+}
+
+func TestAutoTuneWait(t *testing.T) {
+	a := &AutoTune{Start: 1, Max: 4, Step: time.Hour, MinGain: 0.1}
+	if got := a.MaxDuration(); got != 3*time.Hour {
+		t.Errorf("got max duration %v, want %v", got, 3*time.Hour)
+	}
+	before := time.Now()
+	ctx := context.Background()
+	if err := a.wait(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	waiting := make(chan error)
+	go func() {
+		waiting <- a.wait(ctx, 1)
+	}()
+	select {
+	case err := <-waiting:
+		t.Fatalf("thread 1 started at concurrency 1: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	autoTuneTestStep{ok: 100}.run(a)
+	select {
+	case err := <-waiting:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("thread 1 not started at concurrency 2")
+	}
+	steps, _, _ := a.Result()
+	if got := a.concurrencyAt(before.Add(-time.Second)); got != 0 {
+		t.Errorf("concurrency before start: got %d", got)
+	}
+	if got := a.concurrencyAt(steps[0].End.Add(-time.Millisecond)); got != 1 {
+		t.Errorf("concurrency of first step: got %d", got)
+	}
+	if got := a.concurrencyAt(time.Now()); got != 2 {
+		t.Errorf("current concurrency: got %d", got)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := a.wait(ctx, 3); err != context.Canceled {
+		t.Errorf("canceled wait: got %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// azureVersion is the Blob service REST API version used for requests.
+const azureVersion = "2021-08-06"
+
+// azureClient is a native client for the Azure Blob Storage REST API.
+// Buckets are containers and objects are block blobs.
+// Uploads of at least the part size are staged as blocks and committed as a block list.
+type azureClient struct {
+	endpoint *url.URL
+	rt       http.RoundTripper
+	account  string
+	key      []byte
+}
+
+func newAzureClient(endpoint *url.URL, account, key string, rt http.RoundTripper) (*azureClient, error) {
+	if account == "" {
+		return nil, errors.New("azure: storage account name required as access key")
+	}
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, errors.New("azure: account key must be base64 encoded")
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &azureClient{endpoint: endpoint, rt: rt, account: account, key: k}, nil
+}
+
+// EndpointURL implements ObjectClient.
+func (c *azureClient) EndpointURL() *url.URL {
+	return c.endpoint
+}
+
+// do sends a signed request for path to the blob service.
+func (c *azureClient) do(ctx context.Context, method, path string, q url.Values, h http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := *c.endpoint
+	// Keep the path of the endpoint, for example the account of an emulator.
+	u.Path, u.RawPath, u.RawQuery = strings.TrimSuffix(c.endpoint.Path, "/")+path, "", q.Encode()
+	if body == nil || size == 0 {
+		body = http.NoBody
+	}
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if h != nil {
+		r.Header = h
+	}
+	r.ContentLength = size
+	c.sign(r)
+	return c.rt.RoundTrip(r)
+}
+
+// sign adds Shared Key authorization to r.
+// See https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (c *azureClient) sign(r *http.Request) {
+	r.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	r.Header.Set("x-ms-version", azureVersion)
+	length := ""
+	if r.ContentLength > 0 {
+		length = strconv.FormatInt(r.ContentLength, 10)
+	}
+	var sb strings.Builder
+	sb.WriteString(r.Method + "\n")
+	for _, v := range []string{
+		r.Header.Get("Content-Encoding"),
+		r.Header.Get("Content-Language"),
+		length,
+		r.Header.Get("Content-MD5"),
+		r.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used.
+		r.Header.Get("If-Modified-Since"),
+		r.Header.Get("If-Match"),
+		r.Header.Get("If-None-Match"),
+		r.Header.Get("If-Unmodified-Since"),
+		r.Header.Get("Range"),
+	} {
+		sb.WriteString(v + "\n")
+	}
+	msHeaders := make(map[string]string)
+	for k, v := range r.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			msHeaders[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(msHeaders)) {
+		sb.WriteString(k + ":" + msHeaders[k] + "\n")
+	}
+	// The canonicalized resource includes the path of the endpoint.
+	sb.WriteString("/" + c.account + r.URL.EscapedPath())
+	q := r.URL.Query()
+	for _, k := range slices.Sorted(maps.Keys(q)) {
+		v := slices.Clone(q[k])
+		slices.Sort(v)
+		sb.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(v, ","))
+	}
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(sb.String()))
+	r.Header.Set("Authorization", "SharedKey "+c.account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// error returns the error of a failed request.
+// The response body is closed.
+func (c *azureClient) error(resp *http.Response, bucket, object string) error {
+	defer resp.Body.Close()
+	var e struct {
+		Code    string
+		Message string
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&e)
+	if code := resp.Header.Get("x-ms-error-code"); code != "" {
+		e.Code = code
+	}
+	// Messages contain the request ID and time on separate lines.
+	msg, _, _ := strings.Cut(e.Message, "\n")
+	return providerError(resp, e.Code, msg, resp.Header.Get("x-ms-request-id"), bucket, object)
+}
+
+// BucketExists implements ObjectClient.
+func (c *azureClient) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/"+bucket, url.Values{"restype": {"container"}}, nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		resp.Body.Close()
+		return true, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return false, nil
+	}
+	return false, c.error(resp, bucket, "")
+}
+
+// MakeBucket implements ObjectClient.
+// The region is set by the storage account and is ignored.
+func (c *azureClient) MakeBucket(ctx context.Context, bucket string, _ minio.MakeBucketOptions) error {
+	resp, err := c.do(ctx, http.MethodPut, "/"+bucket, url.Values{"restype": {"container"}}, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return c.error(resp, bucket, "")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RemoveBucket implements ObjectClient.
+func (c *azureClient) RemoveBucket(ctx context.Context, bucket string) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/"+bucket, url.Values{"restype": {"container"}}, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return c.error(resp, bucket, "")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// azureBlobHeaders returns the content type, access tier and user metadata headers of an upload.
+func azureBlobHeaders(opts minio.PutObjectOptions) http.Header {
+	h := make(http.Header)
+	for k, v := range opts.UserMetadata {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-") {
+			continue
+		}
+		h.Set("x-ms-meta-"+k, v)
+	}
+	if opts.ContentType != "" {
+		h.Set("x-ms-blob-content-type", opts.ContentType)
+	}
+	if opts.StorageClass != "" {
+		h.Set("x-ms-access-tier", opts.StorageClass)
+	}
+	return h
+}
+
+// PutObject implements ObjectClient.
+// Objects smaller than the part size are uploaded with a single Put Blob request.
+// Larger objects and objects of unknown size are uploaded as blocks of the part size,
+// one at a time, and committed with Put Block List.
+func (c *azureClient) PutObject(ctx context.Context, bucket, object string, r io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	partSize := int64(opts.PartSize)
+	if partSize == 0 {
+		partSize = minPartSize
+	}
+	h := azureBlobHeaders(opts)
+	if size < 0 || (size >= partSize && !opts.DisableMultipart) {
+		return c.putBlocks(ctx, bucket, object, r, partSize, h)
+	}
+	h.Set("x-ms-blob-type", "BlockBlob")
+	resp, err := c.do(ctx, http.MethodPut, "/"+bucket+"/"+object, nil, h, r, size)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return minio.UploadInfo{}, c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return azureUploadInfo(resp, bucket, object, size), nil
+}
+
+// putBlocks uploads r as blocks of partSize and commits them.
+func (c *azureClient) putBlocks(ctx context.Context, bucket, object string, r io.Reader, partSize int64, h http.Header) (minio.UploadInfo, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return minio.UploadInfo{}, err
+	}
+	uploadID := hex.EncodeToString(id[:])
+	path := "/" + bucket + "/" + object
+	buf := make([]byte, partSize)
+	var list bytes.Buffer
+	list.WriteString(xml.Header + "<BlockList>")
+	var size int64
+	for part := 1; ; part++ {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			blockID := azureBlockID(uploadID, part)
+			resp, err := c.do(ctx, http.MethodPut, path, url.Values{"comp": {"block"}, "blockid": {blockID}}, nil, bytes.NewReader(buf[:n]), int64(n))
+			if err != nil {
+				return minio.UploadInfo{}, err
+			}
+			if resp.StatusCode != http.StatusCreated {
+				return minio.UploadInfo{}, c.error(resp, bucket, object)
+			}
+			resp.Body.Close()
+			list.WriteString("<Latest>" + blockID + "</Latest>")
+			size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+	}
+	list.WriteString("</BlockList>")
+	h.Set("Content-Type", "application/xml")
+	resp, err := c.do(ctx, http.MethodPut, path, url.Values{"comp": {"blocklist"}}, h, &list, int64(list.Len()))
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return minio.UploadInfo{}, c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return azureUploadInfo(resp, bucket, object, size), nil
+}
+
+// azureBlockID returns the block ID of a part of an upload.
+// IDs of all blocks of a blob must have the same length.
+func azureBlockID(uploadID string, partNumber int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%05d", uploadID, partNumber)))
+}
+
+func azureUploadInfo(resp *http.Response, bucket, object string, size int64) minio.UploadInfo {
+	mod, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return minio.UploadInfo{
+		Bucket:       bucket,
+		Key:          object,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		Size:         size,
+		LastModified: mod,
+	}
+}
+
+// GetObject implements ObjectClient.
+func (c *azureClient) GetObject(ctx context.Context, bucket, object string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/"+bucket+"/"+object, nil, providerReadHeaders(opts.Header()), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, c.error(resp, bucket, object)
+	}
+	return resp.Body, nil
+}
+
+// StatObject implements ObjectClient.
+func (c *azureClient) StatObject(ctx context.Context, bucket, object string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/"+bucket+"/"+object, nil, providerReadHeaders(opts.Header()), nil, 0)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return minio.ObjectInfo{}, c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return providerObjectInfo(resp.Header, object, "X-Ms-Meta-"), nil
+}
+
+// RemoveObject implements ObjectClient.
+// Deleting a blob that does not exist succeeds, like on S3.
+func (c *azureClient) RemoveObject(ctx context.Context, bucket, object string, _ minio.RemoveObjectOptions) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/"+bucket+"/"+object, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && resp.Header.Get("x-ms-error-code") == "BlobNotFound":
+	case resp.StatusCode >= 300:
+		return c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RemoveObjects implements ObjectClient.
+// The Blob service has no multi-object delete, so blobs are deleted one by one.
+func (c *azureClient) RemoveObjects(ctx context.Context, bucket string, objects <-chan minio.ObjectInfo, _ minio.RemoveObjectsOptions) <-chan minio.RemoveObjectError {
+	return removeEach(objects, func(key string) error {
+		return c.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{})
+	})
+}
+
+// ListObjects implements ObjectClient.
+func (c *azureClient) ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	q := url.Values{"restype": {"container"}, "comp": {"list"}}
+	if opts.Prefix != "" {
+		q.Set("prefix", opts.Prefix)
+	}
+	if !opts.Recursive {
+		q.Set("delimiter", "/")
+	}
+	if opts.MaxKeys > 0 {
+		q.Set("maxresults", strconv.Itoa(opts.MaxKeys))
+	}
+	return listPages(ctx, func(marker string) ([]minio.ObjectInfo, string, error) {
+		q.Del("marker")
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		resp, err := c.do(ctx, http.MethodGet, "/"+bucket, q, nil, nil, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", c.error(resp, bucket, "")
+		}
+		defer resp.Body.Close()
+		var res struct {
+			Blobs []struct {
+				Name       string
+				Properties struct {
+					LastModified  string `xml:"Last-Modified"`
+					Etag          string
+					ContentLength int64 `xml:"Content-Length"`
+				}
+			} `xml:"Blobs>Blob"`
+			Prefixes []struct {
+				Name string
+			} `xml:"Blobs>BlobPrefix"`
+			NextMarker string
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return nil, "", err
+		}
+		objs := make([]minio.ObjectInfo, 0, len(res.Blobs)+len(res.Prefixes))
+		for _, b := range res.Blobs {
+			mod, _ := http.ParseTime(b.Properties.LastModified)
+			objs = append(objs, minio.ObjectInfo{
+				Key:          b.Name,
+				Size:         b.Properties.ContentLength,
+				ETag:         strings.Trim(b.Properties.Etag, `"`),
+				LastModified: mod,
+			})
+		}
+		for _, p := range res.Prefixes {
+			objs = append(objs, minio.ObjectInfo{Key: p.Name})
+		}
+		return objs, res.NextMarker, nil
+	})
+}
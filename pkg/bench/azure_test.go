@@ -0,0 +1,457 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// fakeAzure is a minimal Azure Blob service keeping blobs in memory.
+type fakeAzure struct {
+	t       *testing.T
+	account string
+	key     []byte
+	// base is the path of the service, like the account path of an emulator.
+	base string
+
+	mu         sync.Mutex
+	containers map[string]map[string]*fakeBlob
+	blocks     map[string][]byte
+}
+
+type fakeBlob struct {
+	data     []byte
+	header   http.Header
+	modified time.Time
+	etag     string
+}
+
+func newFakeAzure(t *testing.T) (*fakeAzure, *httptest.Server) {
+	f := &fakeAzure{
+		t:          t,
+		account:    "account",
+		key:        []byte("secret account key"),
+		containers: make(map[string]map[string]*fakeBlob),
+		blocks:     make(map[string][]byte),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// signature returns the expected Shared Key signature of r.
+func (f *fakeAzure) signature(r *http.Request) string {
+	h := r.Header
+	length := ""
+	if r.ContentLength > 0 {
+		length = fmt.Sprint(r.ContentLength)
+	}
+	lines := []string{
+		r.Method, h.Get("Content-Encoding"), h.Get("Content-Language"), length, h.Get("Content-MD5"),
+		h.Get("Content-Type"), "", h.Get("If-Modified-Since"), h.Get("If-Match"), h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"), h.Get("Range"),
+	}
+	var ms []string
+	for k := range h {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-ms-") {
+			ms = append(ms, lk+":"+strings.TrimSpace(strings.Join(h.Values(k), ",")))
+		}
+	}
+	sort.Strings(ms)
+	lines = append(lines, ms...)
+	res := "/" + f.account + r.URL.EscapedPath()
+	q := r.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := slices.Clone(q[k])
+		sort.Strings(v)
+		res += "\n" + strings.ToLower(k) + ":" + strings.Join(v, ",")
+	}
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(strings.Join(lines, "\n") + "\n" + res))
+	return "SharedKey " + f.account + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (f *fakeAzure) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?><Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("x-ms-version") != azureVersion || r.Header.Get("x-ms-date") == "" {
+		f.error(w, http.StatusBadRequest, "MissingRequiredHeader")
+		return
+	}
+	if r.Header.Get("Authorization") != f.signature(r) {
+		f.error(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+	for k := range r.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-") {
+			f.t.Errorf("%s %s: S3 header %s sent", r.Method, r.URL, k)
+		}
+	}
+	body, _ := io.ReadAll(r.Body)
+	q := r.URL.Query()
+	p, ok := strings.CutPrefix(r.URL.Path, f.base+"/")
+	if !ok {
+		f.error(w, http.StatusBadRequest, "InvalidUri")
+		return
+	}
+	container, name, _ := strings.Cut(p, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	blobs, ok := f.containers[container]
+	if name == "" && q.Get("restype") == "container" {
+		switch {
+		case r.Method == http.MethodPut && q.Get("comp") == "":
+			if ok {
+				f.error(w, http.StatusConflict, "ContainerAlreadyExists")
+				return
+			}
+			f.containers[container] = make(map[string]*fakeBlob)
+			w.WriteHeader(http.StatusCreated)
+		case !ok:
+			f.error(w, http.StatusNotFound, "ContainerNotFound")
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodDelete:
+			delete(f.containers, container)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodGet && q.Get("comp") == "list":
+			f.list(w, blobs, q)
+		default:
+			f.error(w, http.StatusBadRequest, "UnsupportedHttpVerb")
+		}
+		return
+	}
+	if !ok {
+		f.error(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	blob := blobs[name]
+	switch {
+	case r.Method == http.MethodPut && q.Get("comp") == "block":
+		f.blocks[container+"/"+name+"/"+q.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string
+		}
+		if err := xml.Unmarshal(body, &list); err != nil {
+			f.error(w, http.StatusBadRequest, "InvalidXmlDocument")
+			return
+		}
+		var data []byte
+		for _, id := range list.Latest {
+			b, ok := f.blocks[container+"/"+name+"/"+id]
+			if !ok {
+				f.error(w, http.StatusBadRequest, "InvalidBlockList")
+				return
+			}
+			data = append(data, b...)
+		}
+		f.store(w, blobs, name, data, r.Header)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			f.error(w, http.StatusBadRequest, "MissingRequiredHeader")
+			return
+		}
+		f.store(w, blobs, name, body, r.Header)
+		w.WriteHeader(http.StatusCreated)
+	case blob == nil:
+		f.error(w, http.StatusNotFound, "BlobNotFound")
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		for k, v := range blob.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", blob.etag)
+		http.ServeContent(w, r, "", blob.modified, bytes.NewReader(blob.data))
+	case r.Method == http.MethodDelete:
+		delete(blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.error(w, http.StatusBadRequest, "UnsupportedHttpVerb")
+	}
+}
+
+// store stores a blob with the content type and metadata in h.
+func (f *fakeAzure) store(w http.ResponseWriter, blobs map[string]*fakeBlob, name string, data []byte, h http.Header) {
+	b := &fakeBlob{data: data, header: make(http.Header), modified: time.Now().Truncate(time.Second)}
+	b.etag = fmt.Sprintf(`"0x%X"`, time.Now().UnixNano())
+	for k, v := range h {
+		if strings.HasPrefix(strings.ToLower(k), "x-ms-meta-") {
+			b.header[k] = v
+		}
+	}
+	b.header.Set("Content-Type", h.Get("x-ms-blob-content-type"))
+	if b.header.Get("Content-Type") == "" {
+		b.header.Set("Content-Type", h.Get("Content-Type"))
+	}
+	blobs[name] = b
+	w.Header().Set("ETag", b.etag)
+	w.Header().Set("Last-Modified", b.modified.Format(http.TimeFormat))
+}
+
+func (f *fakeAzure) list(w http.ResponseWriter, blobs map[string]*fakeBlob, q url.Values) {
+	prefix, delim, marker := q.Get("prefix"), q.Get("delimiter"), q.Get("marker")
+	max := 5000
+	fmt.Sscan(q.Get("maxresults"), &max)
+	var names []string
+	seen := make(map[string]bool)
+	for n := range blobs {
+		if !strings.HasPrefix(n, prefix) {
+			continue
+		}
+		if i := strings.Index(n[len(prefix):], delim); delim != "" && i >= 0 {
+			n = n[:len(prefix)+i+len(delim)]
+		}
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	i, _ := slices.BinarySearch(names, marker)
+	names = names[i:]
+	next := ""
+	if len(names) > max {
+		next = names[max]
+		names = names[:max]
+	}
+	var sb strings.Builder
+	for _, n := range names {
+		if b, ok := blobs[n]; ok {
+			fmt.Fprintf(&sb, "<Blob><Name>%s</Name><Properties><Last-Modified>%s</Last-Modified><Etag>%s</Etag><Content-Length>%d</Content-Length></Properties></Blob>",
+				n, b.modified.Format(http.TimeFormat), b.etag, len(b.data))
+			continue
+		}
+		fmt.Fprintf(&sb, "<BlobPrefix><Name>%s</Name></BlobPrefix>", n)
+	}
+	fmt.Fprintf(w, "<EnumerationResults><Blobs>%s</Blobs><NextMarker>%s</NextMarker></EnumerationResults>", sb.String(), next)
+}
+
+func newAzureTestClient(t *testing.T, srv *httptest.Server, key []byte) ObjectClient {
+	t.Helper()
+	u, err := url.Parse(srv.URL + srv.Config.Handler.(*fakeAzure).base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, err := NewObjectClient(ProviderAzure, u, "account", base64.StdEncoding.EncodeToString(key), http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cl
+}
+
+func TestAzureClient(t *testing.T) {
+	f, srv := newFakeAzure(t)
+	cl := newAzureTestClient(t, srv, f.key)
+	ctx := context.Background()
+
+	if ok, err := cl.BucketExists(ctx, "bucket"); ok || err != nil {
+		t.Errorf("missing bucket exists: got %v, %v", ok, err)
+	}
+	if err := cl.MakeBucket(ctx, "bucket", minio.MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	err := cl.MakeBucket(ctx, "bucket", minio.MakeBucketOptions{})
+	if code := minio.ToErrorResponse(err).Code; code != "ContainerAlreadyExists" {
+		t.Errorf("existing bucket: got code %q, err: %v", code, err)
+	}
+	if ok, err := cl.BucketExists(ctx, "bucket"); !ok || err != nil {
+		t.Errorf("bucket exists: got %v, %v", ok, err)
+	}
+
+	// Objects with metadata.
+	data := bytes.Repeat([]byte("azure"), 100)
+	for _, key := range []string{"dir/a", "dir/b", "dir/sub/c", "e"} {
+		res, err := cl.PutObject(ctx, "bucket", key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+			ContentType:  "text/plain",
+			UserMetadata: map[string]string{"Foo": "bar"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Size != int64(len(data)) || res.ETag == "" {
+			t.Errorf("put: got size %d, etag %q", res.Size, res.ETag)
+		}
+	}
+	if len(f.blocks) != 0 {
+		t.Errorf("small objects uploaded as %d blocks", len(f.blocks))
+	}
+	st, err := cl.StatObject(ctx, "bucket", "dir/a", minio.StatObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Size != int64(len(data)) || st.ContentType != "text/plain" || st.UserMetadata["Foo"] != "bar" || st.ETag == "" {
+		t.Errorf("stat: got size %d, type %q, meta %v, etag %q", st.Size, st.ContentType, st.UserMetadata, st.ETag)
+	}
+	opts := minio.GetObjectOptions{}
+	opts.SetRange(5, 9)
+	o, err := cl.GetObject(ctx, "bucket", "dir/a", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(o)
+	o.Close()
+	if err != nil || string(got) != "azure" {
+		t.Errorf("range get: got %q, %v", got, err)
+	}
+	_, err = cl.StatObject(ctx, "bucket", "missing", minio.StatObjectOptions{})
+	if e := minio.ToErrorResponse(err); e.StatusCode != http.StatusNotFound || e.Code != "BlobNotFound" {
+		t.Errorf("missing object: got status %d, code %q, %v", e.StatusCode, e.Code, err)
+	}
+	_, err = cl.GetObject(ctx, "bucket", "missing", minio.GetObjectOptions{})
+	if e := minio.ToErrorResponse(err); e.Code != "BlobNotFound" || e.Key != "missing" {
+		t.Errorf("missing object get: got code %q, key %q, %v", e.Code, e.Key, err)
+	}
+
+	// Objects of at least the part size or unknown size are uploaded as blocks.
+	for _, size := range []int64{13, -1} {
+		res, err := cl.PutObject(ctx, "bucket", "blocks", strings.NewReader("one,two,three"), size, minio.PutObjectOptions{
+			PartSize:     4,
+			UserMetadata: map[string]string{"Part": "yes"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Size != 13 {
+			t.Errorf("block upload of size %d: got size %d", size, res.Size)
+		}
+		o, err = cl.GetObject(ctx, "bucket", "blocks", minio.GetObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = io.ReadAll(o)
+		o.Close()
+		if err != nil || string(got) != "one,two,three" {
+			t.Errorf("block upload of size %d: got %q, %v", size, got, err)
+		}
+		if st, err := cl.StatObject(ctx, "bucket", "blocks", minio.StatObjectOptions{}); err != nil || st.UserMetadata["Part"] != "yes" {
+			t.Errorf("block upload metadata: got %v, %v", st.UserMetadata, err)
+		}
+	}
+	if len(f.blocks) != 8 {
+		t.Errorf("got %d blocks, want 8", len(f.blocks))
+	}
+
+	// Listing, with paging.
+	list := func(opts minio.ListObjectsOptions) []string {
+		var keys []string
+		for o := range cl.ListObjects(ctx, "bucket", opts) {
+			if o.Err != nil {
+				t.Fatal(o.Err)
+			}
+			keys = append(keys, o.Key)
+		}
+		return keys
+	}
+	for _, test := range []struct {
+		opts minio.ListObjectsOptions
+		want string
+	}{
+		{opts: minio.ListObjectsOptions{Recursive: true}, want: "blocks,dir/a,dir/b,dir/sub/c,e"},
+		{opts: minio.ListObjectsOptions{Recursive: true, MaxKeys: 1}, want: "blocks,dir/a,dir/b,dir/sub/c,e"},
+		{opts: minio.ListObjectsOptions{Prefix: "dir/"}, want: "dir/a,dir/b,dir/sub/"},
+		{opts: minio.ListObjectsOptions{Prefix: "dir/", MaxKeys: 2}, want: "dir/a,dir/b,dir/sub/"},
+	} {
+		if got := strings.Join(list(test.opts), ","); got != test.want {
+			t.Errorf("list %+v: got %s, want %s", test.opts, got, test.want)
+		}
+	}
+
+	// Multi-object delete, including a missing object.
+	objs := make(chan minio.ObjectInfo, 10)
+	for _, k := range []string{"dir/a", "dir/b", "dir/sub/c", "e", "blocks", "missing"} {
+		objs <- minio.ObjectInfo{Key: k}
+	}
+	close(objs)
+	for e := range cl.RemoveObjects(ctx, "bucket", objs, minio.RemoveObjectsOptions{}) {
+		t.Errorf("delete %s: %v", e.ObjectName, e.Err)
+	}
+	if keys := list(minio.ListObjectsOptions{Recursive: true}); len(keys) != 0 {
+		t.Errorf("objects left after delete: %v", keys)
+	}
+	if err := cl.RemoveBucket(ctx, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for o := range cl.ListObjects(ctx, "bucket", minio.ListObjectsOptions{}) {
+		if code := minio.ToErrorResponse(o.Err).Code; code != "ContainerNotFound" {
+			t.Errorf("removed bucket: got code %q, %v", code, o.Err)
+		}
+	}
+
+	// Wrong account key.
+	bad := newAzureTestClient(t, srv, []byte("wrong key"))
+	_, err = bad.BucketExists(ctx, "bucket")
+	if e := minio.ToErrorResponse(err); e.Code != "AuthenticationFailed" || e.StatusCode != http.StatusForbidden {
+		t.Errorf("wrong key: got code %q, %v", e.Code, err)
+	}
+}
+
+func TestAzureClientEndpointPath(t *testing.T) {
+	// Emulators like Azurite serve the account below a path.
+	f, srv := newFakeAzure(t)
+	f.base = "/devstoreaccount1"
+	cl := newAzureTestClient(t, srv, f.key)
+	ctx := context.Background()
+	if err := cl.MakeBucket(ctx, "bucket", minio.MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.PutObject(ctx, "bucket", "dir/obj", strings.NewReader("data"), 4, minio.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	o, err := cl.GetObject(ctx, "bucket", "dir/obj", minio.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(o)
+	o.Close()
+	if err != nil || string(got) != "data" {
+		t.Errorf("get: got %q, %v", got, err)
+	}
+	for obj := range cl.ListObjects(ctx, "bucket", minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil || obj.Key != "dir/obj" {
+			t.Errorf("list: got %q, %v", obj.Key, obj.Err)
+		}
+	}
+}
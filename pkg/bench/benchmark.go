@@ -21,12 +21,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/sse"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/generator"
 
@@ -48,6 +53,16 @@ type Benchmark interface {
 	GetCommon() *Common
 }
 
+// Rerunner is implemented by benchmarks that leave the prepared data unchanged,
+// so they can be started again without preparing.
+type Rerunner interface {
+	Benchmark
+
+	// Rerun makes the benchmark ready to start again with the given concurrency.
+	// Operations are sent to extra in addition to the collector.
+	Rerun(concurrency int, extra []chan<- Operation)
+}
+
 // Common contains common benchmark parameters.
 type Common struct {
 	// Default Put options.
@@ -68,11 +83,36 @@ type Common struct {
 
 	Client func() (cl *minio.Client, done func())
 
+	// ObjectClient returns a native client for another provider than S3, if set.
+	// Benchmarks supporting other providers use it instead of Client.
+	ObjectClient func() (cl ObjectClient, done func())
+
+	// NewThreadClient creates a client for a single thread, if set.
+	// Each thread will then use its own client and connection.
+	// conn returns the local address of the connection used by the client.
+	NewThreadClient func() (cl *minio.Client, conn func() string)
+
+	// RecordRemote will record the remote IP used for each operation.
+	RecordRemote bool
+
+	// RecordRequestID will record the request and deployment ID returned by the server for each operation.
+	RecordRequestID bool
+
+	// ThreadStagger spreads the start of threads over this duration.
+	ThreadStagger time.Duration
+
+	// ThreadJitter adds a random delay up to this duration before each operation.
+	ThreadJitter time.Duration
+
 	Collector *Collector
 
 	Location string
 	Bucket   string
 
+	// BucketSSE is set as default encryption of the bucket when it is prepared.
+	// An empty configuration removes the default encryption.
+	BucketSSE *sse.Configuration
+
 	// Auto termination is set when this is > 0.
 	AutoTermDur time.Duration
 
@@ -82,6 +122,17 @@ type Common struct {
 
 	AutoTermScale float64
 
+	// AutoTermSegments is the number of segments the benchmark is split into
+	// when checking for auto termination. Uses 25 if 0.
+	AutoTermSegments int
+
+	// AutoTermWindow is the number of final segments that must be stable.
+	// Uses 7 if 0.
+	AutoTermWindow int
+
+	// AutoTermMetric is the metric that must be stable.
+	AutoTermMetric AutoTermMetric
+
 	Concurrency int
 
 	// Running in client mode.
@@ -91,9 +142,16 @@ type Common struct {
 	// Clear bucket before benchmark
 	Clear bool
 
+	// BucketReady skips creating and clearing the bucket,
+	// since it has been set up by an earlier run.
+	BucketReady bool
+
 	// DiscardOutput output.
 	DiscardOutput bool // indicates if we prefer a terse output useful in lengthy runs
 
+	// HDRCollector will collect latency histograms instead of all operations.
+	HDRCollector bool
+
 	// Does destination support versioning?
 	Versioned bool
 
@@ -102,6 +160,49 @@ type Common struct {
 
 	// Transport used.
 	Transport http.RoundTripper
+
+	// PrepareRetries is the number of times a failed upload is retried when preparing.
+	PrepareRetries int
+
+	// PrepareTolerate is the fraction of prepare uploads that may fail
+	// before preparation is aborted.
+	PrepareTolerate float64
+
+	// Ramp will change the number of active threads over time, if set.
+	Ramp *Ramp
+
+	// AutoTune will increase the number of active threads until the optimum is found, if set.
+	AutoTune *AutoTune
+
+	// Phases will alternate the number of active threads between phases, if set.
+	Phases *Phases
+
+	// AutoPause will pause the workload while the target is unhealthy, if set.
+	AutoPause *AutoPause
+
+	// Encryption will encrypt uploads and decrypt downloads on the client, if set.
+	Encryption *ClientEncryption
+
+	// Checksum is calculated and sent with single part uploads, if set.
+	Checksum minio.ChecksumType
+
+	// Seed makes the operation schedule of each thread predictable, if set.
+	Seed int64
+
+	// threadClients contains the clients created by NewThreadClient.
+	threadClients *threadClients
+
+	// remotes contains the connection traces of threads if RecordRemote or NewThreadClient is set.
+	remotes *threadRemotes
+
+	// statuses contains the settings for recording response status of operations.
+	statuses *threadStatuses
+
+	// pacing is set if ThreadStagger or ThreadJitter is set.
+	pacing *threadPacing
+
+	// prepareErrs is the number of failed prepare uploads.
+	prepareErrs int64
 }
 
 const (
@@ -111,8 +212,29 @@ const (
 	// Number of segments that must be within limit.
 	// The last segment will be the one considered 'current speed'.
 	autoTermCheck = 7
+
+	// Part size used by the client when none is specified.
+	minPartSize = 16 << 20
 )
 
+// autoTermOpts returns the auto termination options.
+func (c *Common) autoTermOpts() AutoTermOptions {
+	o := AutoTermOptions{
+		Threshold: c.AutoTermScale,
+		Segments:  c.AutoTermSegments,
+		Window:    c.AutoTermWindow,
+		MinDur:    c.AutoTermDur,
+		Metric:    c.AutoTermMetric,
+	}
+	if o.Segments <= 0 {
+		o.Segments = autoTermSamples
+	}
+	if o.Window <= 0 {
+		o.Window = autoTermCheck
+	}
+	return o
+}
+
 // GetCommon implements interface compatible implementation
 func (c *Common) GetCommon() *Common {
 	return c
@@ -126,9 +248,16 @@ func (c *Common) ErrorF(format string, data ...interface{}) {
 // createEmptyBucket will create an empty bucket
 // or delete all content if it already exists.
 func (c *Common) createEmptyBucket(ctx context.Context) error {
-	cl, done := c.Client()
+	if c.BucketReady {
+		return nil
+	}
+	ocl, done := c.objectClient()
 	defer done()
-	x, err := cl.BucketExists(ctx, c.Bucket)
+	cl, isS3 := s3Client(ocl)
+	if !isS3 && (c.Locking || c.BucketSSE != nil) {
+		return errors.New("object locking and bucket encryption require S3")
+	}
+	x, err := ocl.BucketExists(ctx, c.Bucket)
 	if err != nil {
 		return err
 	}
@@ -157,7 +286,7 @@ func (c *Common) createEmptyBucket(ctx context.Context) error {
 	if !x {
 		console.Eraseline()
 		console.Infof("\rCreating Bucket %q...", c.Bucket)
-		err := cl.MakeBucket(ctx, c.Bucket, minio.MakeBucketOptions{
+		err := ocl.MakeBucket(ctx, c.Bucket, minio.MakeBucketOptions{
 			Region:        c.Location,
 			ObjectLocking: c.Locking,
 		})
@@ -165,7 +294,7 @@ func (c *Common) createEmptyBucket(ctx context.Context) error {
 		// Check if it exists now.
 		// We don't test against a specific error since we might run against many different servers.
 		if err != nil {
-			x, err2 := cl.BucketExists(ctx, c.Bucket)
+			x, err2 := ocl.BucketExists(ctx, c.Bucket)
 			if err2 != nil {
 				return err2
 			}
@@ -175,8 +304,15 @@ func (c *Common) createEmptyBucket(ctx context.Context) error {
 			}
 		}
 	}
-	if bvc, err := cl.GetBucketVersioning(ctx, c.Bucket); err == nil {
-		c.Versioned = bvc.Status == "Enabled"
+	if isS3 {
+		if bvc, err := cl.GetBucketVersioning(ctx, c.Bucket); err == nil {
+			c.Versioned = bvc.Status == "Enabled"
+		}
+	}
+	if c.BucketSSE != nil {
+		if err := c.setBucketEncryption(ctx, cl); err != nil {
+			return err
+		}
 	}
 
 	if c.Clear {
@@ -197,7 +333,7 @@ func (c *Common) deleteAllInBucket(ctx context.Context, prefixes ...string) {
 	doneCh := make(chan struct{})
 	defer close(doneCh)
 
-	cl, done := c.Client()
+	cl, done := c.objectClient()
 	defer done()
 
 	objectsCh := make(chan minio.ObjectInfo)
@@ -225,9 +361,11 @@ func (c *Common) deleteAllInBucket(ctx context.Context, prefixes ...string) {
 	}()
 
 	delOpts := minio.RemoveObjectsOptions{}
-	_, _, _, errLock := cl.GetBucketObjectLockConfig(ctx, c.Bucket)
-	if errLock == nil {
-		delOpts.GovernanceBypass = true
+	if s3, ok := s3Client(cl); ok {
+		_, _, _, errLock := s3.GetBucketObjectLockConfig(ctx, c.Bucket)
+		if errLock == nil {
+			delOpts.GovernanceBypass = true
+		}
 	}
 
 	errCh := cl.RemoveObjects(ctx, c.Bucket, objectsCh, delOpts)
@@ -251,16 +389,254 @@ func (c *Common) prepareProgress(progress float64) {
 	}
 }
 
+// threadRng returns the random source used for choosing operations on thread i.
+func (c *Common) threadRng(i int) *rand.Rand {
+	if c.Seed == 0 {
+		return rand.New(rand.NewSource(int64(i)))
+	}
+	// Offset streams, so they don't overlap with the object generators.
+	return rand.New(rand.NewSource(generator.DeriveSeed(c.Seed, 1<<32+uint64(i))))
+}
+
+// threadClient is a client used by a single thread.
+type threadClient struct {
+	cl   *minio.Client
+	conn func() string
+}
+
+// threadClients contains clients by thread.
+type threadClients struct {
+	mu      sync.Mutex
+	clients map[int]threadClient
+}
+
+// threadClient returns the client to use for an operation on the thread.
+// If NewThreadClient is set, each thread gets its own client.
+// Otherwise a client is returned from Client.
+func (c *Common) threadClient(thread int) (*minio.Client, func()) {
+	if c.NewThreadClient == nil {
+		return c.Client()
+	}
+	tcs := c.threadClients
+	tcs.mu.Lock()
+	defer tcs.mu.Unlock()
+	tc, ok := tcs.clients[thread]
+	if !ok {
+		tc.cl, tc.conn = c.NewThreadClient()
+		tcs.clients[thread] = tc
+	}
+	return tc.cl, func() {}
+}
+
+// objectClient returns the client to use for benchmarks supporting other providers than S3.
+// The S3 client from Client is returned if ObjectClient is not set.
+func (c *Common) objectClient() (ObjectClient, func()) {
+	if c.ObjectClient != nil {
+		return c.ObjectClient()
+	}
+	cl, done := c.Client()
+	return S3ObjectClient(cl), done
+}
+
+// threadObjectClient returns the client to use for an operation on the thread,
+// like threadClient, but for benchmarks supporting other providers than S3.
+func (c *Common) threadObjectClient(thread int) (ObjectClient, func()) {
+	if c.ObjectClient != nil {
+		return c.ObjectClient()
+	}
+	cl, done := c.threadClient(thread)
+	return S3ObjectClient(cl), done
+}
+
+// threadConn returns the local address of the connection used by the thread.
+func (c *Common) threadConn(thread uint32) string {
+	tcs := c.threadClients
+	tcs.mu.Lock()
+	tc, ok := tcs.clients[int(thread)]
+	tcs.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	return tc.conn()
+}
+
+// annotate adds the connection information recorded for the thread to op.
+// Operations are annotated after they have completed, so the connection
+// used is looked up by the end time of the operation.
+// The response status is taken from the requests made with the context of op.
+func (c *Common) annotate(op *Operation) {
+	traced := false
+	if c.remotes != nil {
+		var e remoteEvent
+		e, traced = c.threadConnAt(op)
+		if c.RecordRemote && op.Remote == "" {
+			op.Remote = e.ip
+		}
+		if c.threadClients != nil && op.Conn == "" {
+			op.Conn = e.local
+		}
+	}
+	if c.threadClients != nil && op.Conn == "" && !traced {
+		// No trace for the thread, use the latest connection.
+		op.Conn = c.threadConn(op.Thread)
+	}
+	if op.status != nil {
+		if op.Status == 0 {
+			op.status.annotate(op)
+		}
+		op.status = nil
+	}
+}
+
+// putObject uploads obj with the given size and options.
+// If a checksum type is set and the object is uploaded in a single part,
+// the checksum of the content is calculated and sent with the upload.
+func (c *Common) putObject(ctx context.Context, cl ObjectClient, obj *generator.Object, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	partSize := opts.PartSize
+	if partSize == 0 {
+		partSize = minPartSize
+	}
+	if c.Checksum.IsSet() && size >= 0 && (size < int64(partSize) || opts.DisableMultipart) {
+		cs, err := c.Checksum.ChecksumReader(obj.Reader)
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+		if _, err := obj.Reader.Seek(0, io.SeekStart); err != nil {
+			return minio.UploadInfo{}, err
+		}
+		meta := make(map[string]string, len(opts.UserMetadata)+1)
+		for k, v := range opts.UserMetadata {
+			meta[k] = v
+		}
+		meta[c.Checksum.Key()] = cs.Encoded()
+		opts.UserMetadata = meta
+	}
+	return cl.PutObject(ctx, c.Bucket, obj.Name, obj.Reader, size, opts)
+}
+
+// prepareUpload uploads an object while preparing.
+// Failed uploads are retried up to PrepareRetries times.
+func (c *Common) prepareUpload(ctx context.Context, cl ObjectClient, obj *generator.Object, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	for i := 0; ; i++ {
+		var r io.Reader = obj.Reader
+		size := obj.Size
+		if c.Encryption != nil {
+			r, _ = c.Encryption.encrypt(r)
+			size = c.Encryption.EncryptedSize(size)
+		}
+		res, err := cl.PutObject(ctx, c.Bucket, obj.Name, r, size, opts)
+		if err != nil {
+			err = fmt.Errorf("upload error: %w", err)
+		} else if res.Size != size {
+			err = fmt.Errorf("short upload. want: %d, got %d", size, res.Size)
+		}
+		if err == nil || i >= c.PrepareRetries || ctx.Err() != nil {
+			return res, err
+		}
+		if _, serr := obj.Reader.Seek(0, io.SeekStart); serr != nil {
+			return res, err
+		}
+		c.Error(fmt.Errorf("%w. Retrying", err))
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(time.Duration(i+1) * 250 * time.Millisecond):
+		}
+	}
+}
+
+// prepareFailed records a failed prepare upload out of total uploads.
+// The error is returned if more uploads than tolerated have failed.
+func (c *Common) prepareFailed(err error, total int) error {
+	n := atomic.AddInt64(&c.prepareErrs, 1)
+	if float64(n) > c.PrepareTolerate*float64(total) {
+		return err
+	}
+	return nil
+}
+
+// setBucketEncryption applies BucketSSE to the bucket.
+func (c *Common) setBucketEncryption(ctx context.Context, cl *minio.Client) error {
+	if len(c.BucketSSE.Rules) == 0 {
+		err := cl.RemoveBucketEncryption(ctx, c.Bucket)
+		if err != nil && minio.ToErrorResponse(err).Code != "ServerSideEncryptionConfigurationNotFoundError" {
+			return fmt.Errorf("unable to remove encryption of bucket %q: %w", c.Bucket, err)
+		}
+		return nil
+	}
+	if err := cl.SetBucketEncryption(ctx, c.Bucket, c.BucketSSE); err != nil {
+		return fmt.Errorf("unable to set encryption of bucket %q: %w", c.Bucket, err)
+	}
+	return nil
+}
+
 func (c *Common) addCollector() {
 	if c.DiscardOutput {
 		c.Collector = NewNullCollector()
+	} else if c.HDRCollector {
+		c.Collector = NewHDRCollector()
 	} else {
 		c.Collector = NewCollector()
 	}
 	c.Collector.extra = c.ExtraOut
+	if c.NewThreadClient != nil {
+		c.threadClients = &threadClients{clients: make(map[int]threadClient, c.Concurrency)}
+	}
+	if c.RecordRemote || c.NewThreadClient != nil {
+		c.remotes = &threadRemotes{traces: make(map[uint32]*remoteTrace, c.Concurrency)}
+	}
+	c.statuses = &threadStatuses{requestIDs: c.RecordRequestID}
+	c.Collector.annotate = c.annotate
+	if c.ThreadStagger > 0 || c.ThreadJitter > 0 {
+		c.pacing = &threadPacing{stagger: c.ThreadStagger, jitter: c.ThreadJitter, threads: c.Concurrency}
+	}
+	c.Collector.pause = c.AutoPause
+	c.Collector.ramp = c.Ramp
+	c.Collector.tune = c.AutoTune
+	c.Collector.phases = c.Phases
+}
+
+// rerun replaces the collector, so the benchmark can be started again.
+func (c *Common) rerun(concurrency int, extra []chan<- Operation) {
+	if c.Collector != nil {
+		c.Collector.discard()
+	}
+	c.Concurrency = concurrency
+	c.ExtraOut = extra
+	c.addCollector()
+}
+
+// waitThread waits until the thread is allowed to run the next operation.
+func (c *Common) waitThread(ctx context.Context, thread int) error {
+	if c.Ramp != nil {
+		if err := c.Ramp.wait(ctx, thread); err != nil {
+			return err
+		}
+	}
+	if c.AutoTune != nil {
+		if err := c.AutoTune.wait(ctx, thread); err != nil {
+			return err
+		}
+	}
+	if c.Phases != nil {
+		if err := c.Phases.wait(ctx, thread); err != nil {
+			return err
+		}
+	}
+	if c.pacing != nil {
+		if err := c.pacing.wait(ctx, thread); err != nil {
+			return err
+		}
+	}
+	return c.rpsLimit(ctx)
 }
 
 func (c *Common) rpsLimit(ctx context.Context) error {
+	if c.AutoPause != nil {
+		if err := c.AutoPause.wait(ctx); err != nil {
+			return err
+		}
+	}
 	if c.RpsLimiter == nil {
 		return nil
 	}
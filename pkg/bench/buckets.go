@@ -0,0 +1,374 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Buckets benchmarks listing and checking buckets with many buckets present.
+// Buckets are named after the benchmark bucket with a numeric suffix.
+// Buckets that already exist are reused.
+type Buckets struct {
+	Common
+
+	// CreateBuckets is the number of buckets to prepare.
+	CreateBuckets int
+
+	// HeadPerList is the number of HeadBucket requests done by each thread
+	// after each ListBuckets request.
+	HeadPerList int
+
+	// ChurnPerList is the number of MakeBucket/RemoveBucket pairs done by each thread
+	// after each ListBuckets request.
+	ChurnPerList int
+
+	// PolicyPerList is the number of PutBucketPolicy/GetBucketPolicy pairs done by each thread
+	// after each ListBuckets request.
+	PolicyPerList int
+
+	// TaggingPerList is the number of PutBucketTagging/GetBucketTagging pairs done by each thread
+	// after each ListBuckets request.
+	TaggingPerList int
+}
+
+// Operation types of the buckets benchmark.
+const (
+	bucketsList       = "LISTBUCKETS"
+	bucketsHead       = "HEADBUCKET"
+	bucketsMake       = "MAKEBUCKET"
+	bucketsRemove     = "REMOVEBUCKET"
+	bucketsPutPolicy  = "PUTBUCKETPOLICY"
+	bucketsGetPolicy  = "GETBUCKETPOLICY"
+	bucketsPutTagging = "PUTBUCKETTAGGING"
+	bucketsGetTagging = "GETBUCKETTAGGING"
+)
+
+// bucketsChurnInfix separates the benchmark bucket name from the random suffix
+// of buckets created and removed during the benchmark.
+const bucketsChurnInfix = "-c"
+
+// bucketPolicy is a policy allowing anonymous downloads from bucket.
+const bucketPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/*"]}]}`
+
+// opCycle returns the operation types each thread cycles through.
+func (b *Buckets) opCycle() []string {
+	ops := []string{bucketsList}
+	for i := 0; i < b.HeadPerList; i++ {
+		ops = append(ops, bucketsHead)
+	}
+	for i := 0; i < b.ChurnPerList; i++ {
+		ops = append(ops, bucketsMake, bucketsRemove)
+	}
+	for i := 0; i < b.PolicyPerList; i++ {
+		ops = append(ops, bucketsPutPolicy, bucketsGetPolicy)
+	}
+	for i := 0; i < b.TaggingPerList; i++ {
+		ops = append(ops, bucketsPutTagging, bucketsGetTagging)
+	}
+	return ops
+}
+
+// bucketName returns the name of bucket n.
+func (b *Buckets) bucketName(n int) string {
+	return fmt.Sprintf("%s-%d", b.Bucket, n)
+}
+
+// Prepare will create the buckets that do not exist already.
+func (b *Buckets) Prepare(ctx context.Context) error {
+	b.addCollector()
+	cl, done := b.Client()
+	existing, err := cl.ListBuckets(ctx)
+	done()
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]struct{}, len(existing))
+	for _, bucket := range existing {
+		exists[bucket.Name] = struct{}{}
+	}
+	var missing []string
+	for i := 0; i < b.CreateBuckets; i++ {
+		if _, ok := exists[b.bucketName(i)]; !ok {
+			missing = append(missing, b.bucketName(i))
+		}
+	}
+	console.Eraseline()
+	console.Info("\rCreating ", len(missing), " buckets, ", b.CreateBuckets-len(missing), " already exist")
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(b.Concurrency)
+	names := make(chan string, len(missing))
+	for _, name := range missing {
+		names <- name
+	}
+	close(names)
+	rcv := b.Collector.rcv
+	var mu sync.Mutex
+	var groupErr error
+	created := 0
+	for i := 0; i < b.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for name := range names {
+				if ctx.Err() != nil {
+					return
+				}
+				if b.rpsLimit(ctx) != nil {
+					return
+				}
+				client, cldone := b.Client()
+				op := Operation{
+					OpType:   bucketsMake,
+					Thread:   uint32(i),
+					File:     name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				err := client.MakeBucket(ctx, name, minio.MakeBucketOptions{Region: b.Location})
+				op.End = time.Now()
+				if err != nil {
+					// In client mode someone else may have created it first.
+					if x, err2 := client.BucketExists(ctx, name); err2 == nil && x {
+						err = nil
+					}
+				}
+				cldone()
+				if err != nil {
+					b.Error(err)
+					if err := b.prepareFailed(err, len(missing)); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					continue
+				}
+				mu.Lock()
+				created++
+				b.prepareProgress(float64(created) / float64(len(missing)))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (b *Buckets) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(b.Concurrency)
+	c := b.Collector
+	if b.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, bucketsList, b.autoTermOpts())
+	}
+
+	for i := 0; i < b.Concurrency; i++ {
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := b.threadContext(i)
+			rng := b.threadRng(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+
+			cycle := b.opCycle()
+			// Bucket created by this thread, waiting to be removed.
+			var churn string
+
+			<-wait
+			for n := 0; ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if b.waitThread(ctx, i) != nil {
+					return
+				}
+
+				client, cldone := b.threadClient(i)
+				op := Operation{
+					OpType:   cycle[n%len(cycle)],
+					Thread:   uint32(i),
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				switch op.OpType {
+				case bucketsList:
+					op.Start = time.Now()
+					buckets, err := client.ListBuckets(opContext(nonTerm, &op))
+					op.End = time.Now()
+					op.ObjPerOp = len(buckets)
+					if err != nil {
+						b.Error("list buckets error: ", err)
+						op.Err = err.Error()
+					} else if len(buckets) < b.CreateBuckets {
+						op.Err = fmt.Sprintf("Unexpected bucket count, want at least %d, got %d", b.CreateBuckets, len(buckets))
+						op.ErrClass = ErrClassValidation
+						b.Error(op.Err)
+					}
+				case bucketsHead:
+					op.File = b.bucketName(rng.Intn(b.CreateBuckets))
+					op.Start = time.Now()
+					found, err := client.BucketExists(opContext(nonTerm, &op), op.File)
+					op.End = time.Now()
+					if err != nil {
+						b.Error("head bucket error: ", err)
+						op.Err = err.Error()
+					} else if !found {
+						op.Err = fmt.Sprintf("bucket %q not found", op.File)
+						op.ErrClass = ErrClassValidation
+						b.Error(op.Err)
+					}
+				case bucketsMake:
+					// Random names, so clients do not collide.
+					churn = fmt.Sprintf("%s%s%016x", b.Bucket, bucketsChurnInfix, rand.Uint64())
+					op.File = churn
+					op.Start = time.Now()
+					err := client.MakeBucket(opContext(nonTerm, &op), churn, minio.MakeBucketOptions{Region: b.Location})
+					op.End = time.Now()
+					if err != nil {
+						b.Error("make bucket error: ", err)
+						op.Err = err.Error()
+						churn = ""
+					}
+				case bucketsRemove:
+					if churn == "" {
+						// Creating the bucket failed.
+						cldone()
+						continue
+					}
+					op.File = churn
+					op.Start = time.Now()
+					err := client.RemoveBucket(opContext(nonTerm, &op), churn)
+					op.End = time.Now()
+					churn = ""
+					if err != nil {
+						b.Error("remove bucket error: ", err)
+						op.Err = err.Error()
+					}
+				default:
+					op.File = b.bucketName(rng.Intn(b.CreateBuckets))
+					op.Start = time.Now()
+					err := b.bucketConfigOp(opContext(nonTerm, &op), client, op.OpType, op.File, n)
+					op.End = time.Now()
+					if err != nil {
+						b.Error(strings.ToLower(op.OpType), " error: ", err)
+						op.Err = err.Error()
+					}
+				}
+				cldone()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// bucketConfigOp sets or reads the policy or tagging configuration of bucket.
+// n is used to vary the tag value written.
+func (b *Buckets) bucketConfigOp(ctx context.Context, client *minio.Client, opType, bucket string, n int) error {
+	switch opType {
+	case bucketsPutPolicy:
+		return client.SetBucketPolicy(ctx, bucket, fmt.Sprintf(bucketPolicy, bucket))
+	case bucketsGetPolicy:
+		_, err := client.GetBucketPolicy(ctx, bucket)
+		return err
+	case bucketsPutTagging:
+		t, err := tags.NewTags(map[string]string{"warp": "benchmark", "n": fmt.Sprint(n)}, false)
+		if err != nil {
+			return err
+		}
+		return client.SetBucketTagging(ctx, bucket, t)
+	case bucketsGetTagging:
+		_, err := client.GetBucketTagging(ctx, bucket)
+		if minio.ToErrorResponse(err).Code == "NoSuchTagSet" {
+			// Not tagged yet.
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("unknown bucket operation %q", opType)
+}
+
+// Cleanup removes the prepared buckets and any bucket
+// created during the benchmark that was not removed.
+// Buckets that were reused are removed as well.
+func (b *Buckets) Cleanup(ctx context.Context) {
+	var churned []string
+	if b.ChurnPerList > 0 {
+		cl, done := b.Client()
+		existing, err := cl.ListBuckets(ctx)
+		done()
+		if err != nil {
+			b.Error("unable to list buckets: ", err)
+		}
+		for _, bucket := range existing {
+			if strings.HasPrefix(bucket.Name, b.Bucket+bucketsChurnInfix) {
+				churned = append(churned, bucket.Name)
+			}
+		}
+	}
+	console.Eraseline()
+	console.Info("\rRemoving ", b.CreateBuckets+len(churned), " buckets")
+	var wg sync.WaitGroup
+	wg.Add(b.Concurrency)
+	names := make(chan string, b.CreateBuckets+len(churned))
+	for i := 0; i < b.CreateBuckets; i++ {
+		names <- b.bucketName(i)
+	}
+	for _, name := range churned {
+		names <- name
+	}
+	close(names)
+	for i := 0; i < b.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				cl, done := b.Client()
+				err := cl.RemoveBucket(ctx, name)
+				done()
+				if err != nil && minio.ToErrorResponse(err).Code != "NoSuchBucket" {
+					b.Error("unable to remove bucket ", name, ": ", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
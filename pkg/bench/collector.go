@@ -19,7 +19,10 @@ package bench
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,10 +30,19 @@ import (
 )
 
 type Collector struct {
-	rcv   chan Operation
-	ops   Operations
-	rcvWg sync.WaitGroup
-	extra []chan<- Operation
+	rcv    chan Operation
+	ops    Operations
+	rcvWg  sync.WaitGroup
+	extra  []chan<- Operation
+	pause  *AutoPause
+	ramp   *Ramp
+	tune   *AutoTune
+	phases *Phases
+	hdr    *HDRStats
+	// closed is set when the receiver has been closed.
+	closed bool
+	// annotate is called with each operation before it is stored.
+	annotate func(op *Operation)
 	// The mutex protects the ops above.
 	// Once ops have been added, they should no longer be modified.
 	opsMu sync.Mutex
@@ -41,18 +53,11 @@ func NewCollector() *Collector {
 		ops: make(Operations, 0, 10000),
 		rcv: make(chan Operation, 1000),
 	}
-	r.rcvWg.Add(1)
-	go func() {
-		defer r.rcvWg.Done()
-		for op := range r.rcv {
-			for _, ch := range r.extra {
-				ch <- op
-			}
-			r.opsMu.Lock()
-			r.ops = append(r.ops, op)
-			r.opsMu.Unlock()
-		}
-	}()
+	r.start(func(op Operation) {
+		r.opsMu.Lock()
+		r.ops = append(r.ops, op)
+		r.opsMu.Unlock()
+	})
 	return r
 }
 
@@ -62,39 +67,130 @@ func NewNullCollector() *Collector {
 		ops: make(Operations, 0),
 		rcv: make(chan Operation, 1000),
 	}
-	r.rcvWg.Add(1)
+	r.start(nil)
+	return r
+}
+
+// NewHDRCollector collects operations into latency histograms and discards them.
+// Memory use does not grow with the number of operations.
+func NewHDRCollector() *Collector {
+	r := &Collector{
+		ops: make(Operations, 0),
+		rcv: make(chan Operation, 1000),
+		hdr: NewHDRStats(),
+	}
+	r.start(r.hdr.Add)
+	return r
+}
+
+// start receives operations until the collector is closed.
+// Each operation is processed and then passed to store, if set.
+func (c *Collector) start(store func(op Operation)) {
+	c.rcvWg.Add(1)
 	go func() {
-		defer r.rcvWg.Done()
-		for op := range r.rcv {
-			for _, ch := range r.extra {
-				ch <- op
+		defer c.rcvWg.Done()
+		for op := range c.rcv {
+			c.process(&op)
+			if store != nil {
+				store(op)
 			}
 		}
 	}()
-	return r
 }
 
-// AutoTerm will check if throughput is within 'threshold' (0 -> ) for wantSamples,
-// when the current operations are split into 'splitInto' segments.
-// The minimum duration for the calculation can be set as well.
-// Segment splitting may cause less than this duration to be used.
-func (c *Collector) AutoTerm(ctx context.Context, op string, threshold float64, wantSamples, splitInto int, minDur time.Duration) context.Context {
-	if wantSamples >= splitInto {
-		panic("wantSamples >= splitInto")
+// process updates op with the state of the benchmark when it was executed
+// and forwards it to the extra outputs.
+func (c *Collector) process(op *Operation) {
+	if c.pause != nil {
+		c.pause.observe(*op)
+	}
+	if c.ramp != nil && op.Concurrency == 0 {
+		op.Concurrency = c.ramp.concurrencyAt(op.Start)
+	}
+	if c.tune != nil {
+		c.tune.observe(*op)
+		if op.Concurrency == 0 {
+			op.Concurrency = c.tune.concurrencyAt(op.Start)
+		}
+	}
+	if c.phases != nil && op.Phase == "" {
+		op.Phase = c.phases.nameAt(op.Start)
+	}
+	if c.annotate != nil {
+		c.annotate(op)
+	}
+	for _, ch := range c.extra {
+		ch <- *op
+	}
+}
+
+// Histograms returns the collected statistics if this is a HDR collector.
+// Statistics are only complete after Close has been called.
+func (c *Collector) Histograms() *HDRStats {
+	return c.hdr
+}
+
+// AutoTermMetric is the metric that must be stable for automatic termination.
+type AutoTermMetric string
+
+const (
+	// AutoTermThroughput checks the throughput of operations.
+	AutoTermThroughput AutoTermMetric = "throughput"
+	// AutoTermLatency checks the average request time of operations.
+	AutoTermLatency AutoTermMetric = "latency"
+	// AutoTermBoth checks both throughput and request time.
+	AutoTermBoth AutoTermMetric = "both"
+)
+
+// ParseAutoTermMetric parses an auto termination metric.
+// An empty string returns AutoTermThroughput.
+func ParseAutoTermMetric(s string) (AutoTermMetric, error) {
+	switch m := AutoTermMetric(s); m {
+	case "":
+		return AutoTermThroughput, nil
+	case AutoTermThroughput, AutoTermLatency, AutoTermBoth:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown autoterm metric %q. Must be 'throughput', 'latency' or 'both'", s)
+}
+
+// AutoTermOptions controls automatic termination.
+type AutoTermOptions struct {
+	// Threshold is the allowed relative difference to the last segment (0 -> 1).
+	Threshold float64
+
+	// Segments is the number of segments the active time is split into.
+	Segments int
+
+	// Window is the number of segments at the end that must be within Threshold.
+	// The last segment is the one considered 'current'.
+	Window int
+
+	// MinDur is the minimum duration of the window.
+	// Segment splitting may cause less than this duration to be used.
+	MinDur time.Duration
+
+	// Metric is the metric that must be stable.
+	Metric AutoTermMetric
+}
+
+// AutoTerm will check if operations are within the threshold for the options window.
+// If op is empty, each operation type must be stable.
+func (c *Collector) AutoTerm(ctx context.Context, op string, opts AutoTermOptions) context.Context {
+	if opts.Window >= opts.Segments {
+		panic("window >= segments")
 	}
-	if splitInto == 0 {
-		panic("splitInto == 0 ")
+	if opts.Segments == 0 {
+		panic("segments == 0 ")
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	go func() {
 		defer cancel()
 		ticker := time.NewTicker(time.Second)
-
-	checkloop:
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
-				ticker.Stop()
 				return
 			case <-ticker.C:
 			}
@@ -103,58 +199,190 @@ func (c *Collector) AutoTerm(ctx context.Context, op string, threshold float64,
 			// copies
 			ops := c.ops.FilterByOp(op)
 			c.opsMu.Unlock()
-			start, end := ops.ActiveTimeRange(true)
-			if end.Sub(start) <= minDur*time.Duration(splitInto)/time.Duration(wantSamples) {
-				// We don't have enough.
-				continue
+			opTypes := []string{op}
+			if op == "" {
+				opTypes = ops.OpTypes()
 			}
-			segs := ops.Segment(SegmentOptions{
-				From:           start,
-				PerSegDuration: end.Sub(start) / time.Duration(splitInto),
-				AllThreads:     true,
-			})
-			if len(segs) < wantSamples {
-				continue
-			}
-			// Use last segment as our base.
-			mb, _, objs := segs[len(segs)-1].SpeedPerSec()
-			// Only use the segments we are interested in.
-			segs = segs[len(segs)-wantSamples : len(segs)-1]
-			for _, seg := range segs {
-				segMB, _, segObjs := seg.SpeedPerSec()
-				if mb > 0 {
-					if math.Abs(mb-segMB) > threshold*mb {
-						continue checkloop
-					}
-					continue
+			var desc []string
+			var window time.Duration
+			stable := len(ops) > 0
+			for _, opType := range opTypes {
+				opOps := ops
+				if len(opTypes) > 1 {
+					opOps = ops.FilterByOp(opType)
+				}
+				d, dur, ok := opts.stable(opOps)
+				if !ok {
+					stable = false
+					break
 				}
-				if math.Abs(objs-segObjs) > threshold*objs {
-					continue checkloop
+				if len(opTypes) > 1 {
+					d = opType + " " + d
 				}
+				desc = append(desc, d)
+				window = max(window, dur)
 			}
-			// All checks passed.
-			if mb > 0 {
-				console.Eraseline()
-				console.Printf("\rThroughput %0.01fMiB/s within %f%% for %v. Assuming stability. Terminating benchmark.\n",
-					mb, threshold*100,
-					segs[0].Duration().Round(time.Millisecond)*time.Duration(len(segs)+1))
-			} else {
-				console.Eraseline()
-				console.Printf("\rThroughput %0.01f objects/s within %f%% for %v. Assuming stability. Terminating benchmark.\n",
-					objs, threshold*100,
-					segs[0].Duration().Round(time.Millisecond)*time.Duration(len(segs)+1))
+			if !stable {
+				continue
 			}
+			// All checks passed.
+			console.Eraseline()
+			console.Printf("\r%s within %f%% for %v. Assuming stability. Terminating benchmark.\n",
+				strings.Join(desc, ", "), opts.Threshold*100, window)
 			return
 		}
 	}()
 	return ctx
 }
 
+// stable checks if the operations are stable.
+// If so, a description of the current values and the duration checked is returned.
+func (o AutoTermOptions) stable(ops Operations) (string, time.Duration, bool) {
+	start, end := ops.ActiveTimeRange(true)
+	if end.Sub(start) <= o.MinDur*time.Duration(o.Segments)/time.Duration(o.Window) {
+		// We don't have enough.
+		return "", 0, false
+	}
+	segs := ops.Segment(SegmentOptions{
+		From:           start,
+		PerSegDuration: end.Sub(start) / time.Duration(o.Segments),
+		AllThreads:     true,
+	})
+	if len(segs) < o.Window {
+		return "", 0, false
+	}
+	// Only use the segments we are interested in.
+	segs = segs[len(segs)-o.Window:]
+	vals := make([]autoTermValue, 0, len(segs))
+	for _, seg := range segs {
+		mb, _, objs := seg.SpeedPerSec()
+		vals = append(vals, autoTermValue{mbps: mb, objs: objs, reqAvg: seg.ReqAvg})
+	}
+	desc, ok := o.stableValues(vals)
+	if !ok {
+		return "", 0, false
+	}
+	return desc, segs[0].Duration().Round(time.Millisecond) * time.Duration(len(segs)), true
+}
+
+// autoTermValue is the throughput and average request time in milliseconds of a segment.
+type autoTermValue struct {
+	mbps, objs, reqAvg float64
+}
+
+// stableValues checks if the values of the window are within the threshold of the last one.
+// If so, a description of the last values is returned.
+func (o AutoTermOptions) stableValues(vals []autoTermValue) (string, bool) {
+	// Use last segment as our base.
+	last := vals[len(vals)-1]
+	vals = vals[:len(vals)-1]
+	within := func(v, base float64) bool {
+		return math.Abs(base-v) <= o.Threshold*base
+	}
+	var desc []string
+	if o.Metric != AutoTermLatency {
+		for _, v := range vals {
+			if last.mbps > 0 {
+				if !within(v.mbps, last.mbps) {
+					return "", false
+				}
+				continue
+			}
+			if !within(v.objs, last.objs) {
+				return "", false
+			}
+		}
+		if last.mbps > 0 {
+			desc = append(desc, fmt.Sprintf("Throughput %0.01fMiB/s", last.mbps))
+		} else {
+			desc = append(desc, fmt.Sprintf("Throughput %0.01f objects/s", last.objs))
+		}
+	}
+	if o.Metric == AutoTermLatency || o.Metric == AutoTermBoth {
+		for _, v := range vals {
+			if !within(v.reqAvg, last.reqAvg) {
+				return "", false
+			}
+		}
+		desc = append(desc, fmt.Sprintf("Request time %0.01fms", last.reqAvg))
+	}
+	return strings.Join(desc, ", "), true
+}
+
+// AutoTermSample contains the running totals of an operation type at a point in time.
+type AutoTermSample struct {
+	Time     time.Time
+	Requests int64
+	Bytes    int64
+	Objects  int64
+	ReqDur   time.Duration
+}
+
+// StableSamples checks if running totals sampled over time are stable,
+// using the same segments as for operations.
+// This allows checking totals merged from several clients, where the operations are not available.
+// Samples must be ordered by time. Totals are interpolated between samples.
+// If stable, a description of the current values and the duration checked is returned.
+func (o AutoTermOptions) StableSamples(samples []AutoTermSample) (string, time.Duration, bool) {
+	if len(samples) < 2 {
+		return "", 0, false
+	}
+	start, end := samples[0].Time, samples[len(samples)-1].Time
+	if end.Sub(start) <= o.MinDur*time.Duration(o.Segments)/time.Duration(o.Window) {
+		// We don't have enough.
+		return "", 0, false
+	}
+	// at returns the totals interpolated at t.
+	at := func(t time.Time) (reqs, bytes, objs, reqDur float64) {
+		i := sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(t) })
+		if i == 0 || i == len(samples) {
+			s := samples[min(i, len(samples)-1)]
+			return float64(s.Requests), float64(s.Bytes), float64(s.Objects), float64(s.ReqDur)
+		}
+		a, b := samples[i-1], samples[i]
+		f := float64(t.Sub(a.Time)) / float64(b.Time.Sub(a.Time))
+		lerp := func(x, y float64) float64 {
+			return x + (y-x)*f
+		}
+		return lerp(float64(a.Requests), float64(b.Requests)), lerp(float64(a.Bytes), float64(b.Bytes)),
+			lerp(float64(a.Objects), float64(b.Objects)), lerp(float64(a.ReqDur), float64(b.ReqDur))
+	}
+	segDur := end.Sub(start) / time.Duration(o.Segments)
+	vals := make([]autoTermValue, 0, o.Window)
+	for seg := o.Segments - o.Window; seg < o.Segments; seg++ {
+		r0, b0, o0, d0 := at(start.Add(time.Duration(seg) * segDur))
+		r1, b1, o1, d1 := at(start.Add(time.Duration(seg+1) * segDur))
+		v := autoTermValue{
+			mbps: (b1 - b0) / (1 << 20) / segDur.Seconds(),
+			objs: (o1 - o0) / segDur.Seconds(),
+		}
+		if r1 > r0 {
+			v.reqAvg = (d1 - d0) / (r1 - r0) / float64(time.Millisecond)
+		}
+		vals = append(vals, v)
+	}
+	desc, ok := o.stableValues(vals)
+	if !ok {
+		return "", 0, false
+	}
+	return desc, segDur.Round(time.Millisecond) * time.Duration(o.Window), true
+}
+
+// TakeOps returns the operations collected so far and removes them from the collector.
+func (c *Collector) TakeOps() Operations {
+	c.opsMu.Lock()
+	defer c.opsMu.Unlock()
+	ops := c.ops
+	c.ops = make(Operations, 0, cap(ops))
+	return ops
+}
+
 func (c *Collector) Receiver() chan<- Operation {
 	return c.rcv
 }
 
 func (c *Collector) Close() Operations {
+	c.closed = true
 	close(c.rcv)
 	c.rcvWg.Wait()
 	for _, ch := range c.extra {
@@ -162,3 +390,14 @@ func (c *Collector) Close() Operations {
 	}
 	return c.ops
 }
+
+// discard stops the collector if it has not been closed.
+// The extra outputs are left open.
+func (c *Collector) discard() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.rcv)
+	c.rcvWg.Wait()
+}
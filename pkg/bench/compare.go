@@ -30,10 +30,13 @@ type Comparison struct {
 	Op   string
 
 	Average CmpSegment
-	Fastest CmpSegment
-	Median  CmpSegment
-	Slowest CmpSegment
-	Reqs    CmpReqs
+	// AverageCI contains the 95% confidence intervals of the average throughput.
+	// Nil if there are too few segments.
+	AverageCI *CmpInterval
+	Fastest   CmpSegment
+	Median    CmpSegment
+	Slowest   CmpSegment
+	Reqs      CmpReqs
 }
 
 // CmpSegment is s comparisons between two segments.
@@ -44,6 +47,33 @@ type CmpSegment struct {
 	OpsEndedPerSec   float64
 }
 
+// CmpInterval compares confidence intervals before and after.
+// Intervals are bytes per second, or objects per second if no bytes were transferred.
+type CmpInterval struct {
+	Before, After Interval
+	Bytes         bool
+}
+
+// Significant returns whether the intervals do not overlap.
+func (c CmpInterval) Significant() bool {
+	return !c.Before.Overlaps(c.After)
+}
+
+// String returns a human readable comparison of the intervals.
+func (c CmpInterval) String() string {
+	iv := func(i Interval) string {
+		if c.Bytes {
+			return fmt.Sprintf("%s - %s", Throughput(i.Low), Throughput(i.High))
+		}
+		return fmt.Sprintf("%.02f - %.02f obj/s", i.Low, i.High)
+	}
+	sig := "difference is not significant"
+	if c.Significant() {
+		sig = "difference is significant"
+	}
+	return fmt.Sprintf("%s -> %s, %s", iv(c.Before), iv(c.After), sig)
+}
+
 type CmpReqs struct {
 	CmpRequests
 	Before, After CmpRequests
@@ -281,6 +311,14 @@ func Compare(before, after Operations, analysis time.Duration, allThreads bool)
 		return nil, fmt.Errorf("segmenting after: %w", err)
 	}
 
+	bBPS, bObjs, bOK := bs.ThroughputIntervals()
+	aBPS, aObjs, aOK := as.ThroughputIntervals()
+	if bOK && aOK {
+		res.AverageCI = &CmpInterval{Before: bObjs, After: aObjs}
+		if bBPS.High > 0 && aBPS.High > 0 {
+			res.AverageCI = &CmpInterval{Before: bBPS, After: aBPS, Bytes: true}
+		}
+	}
 	res.Median.Compare(bs.Median(0.5), as.Median(0.5))
 	res.Slowest.Compare(bs.Median(0.0), as.Median(0.0))
 	res.Fastest.Compare(bs.Median(1), as.Median(1))
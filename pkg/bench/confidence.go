@@ -0,0 +1,119 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math"
+	"time"
+)
+
+// Interval is a 95% confidence interval.
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Overlaps returns whether the intervals overlap.
+// Non-overlapping intervals indicate a significant difference.
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Low <= o.High && o.Low <= i.High
+}
+
+// RelWidth returns the half width of the interval relative to its center.
+func (i Interval) RelWidth() float64 {
+	mid := (i.Low + i.High) / 2
+	if mid == 0 {
+		return 0
+	}
+	return (i.High - i.Low) / 2 / mid
+}
+
+// tTable95 contains two-sided 95% critical values of the t-distribution
+// for 1 to 30 degrees of freedom.
+var tTable95 = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tCritical95 returns the two-sided 95% critical value of the t-distribution.
+func tCritical95(df int) float64 {
+	if df <= len(tTable95) {
+		return tTable95[df-1]
+	}
+	// Cornish-Fisher expansion, accurate to 3 decimals above 30.
+	const z = 1.959964
+	n := float64(df)
+	return z + (z*z*z+z)/(4*n) + (5*z*z*z*z*z+16*z*z*z+3*z)/(96*n*n)
+}
+
+// MeanInterval returns the 95% confidence interval of the mean of values,
+// based on the t-distribution.
+// Returns false if there are less than 2 values.
+func MeanInterval(values []float64) (Interval, bool) {
+	n := len(values)
+	if n < 2 {
+		return Interval{}, false
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(n)
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	half := tCritical95(n-1) * math.Sqrt(sq/float64(n-1)/float64(n))
+	return Interval{Low: mean - half, High: mean + half}, true
+}
+
+// ThroughputIntervals returns the 95% confidence intervals of the mean bytes
+// and objects per second of the segments.
+// Returns false if there are less than 2 segments.
+func (s Segments) ThroughputIntervals() (bps, objs Interval, ok bool) {
+	bpsVals := make([]float64, len(s))
+	objVals := make([]float64, len(s))
+	for i, seg := range s {
+		mib, _, o := seg.SpeedPerSec()
+		bpsVals[i] = mib * (1 << 20)
+		objVals[i] = o
+	}
+	if bps, ok = MeanInterval(bpsVals); !ok {
+		return bps, objs, false
+	}
+	objs, ok = MeanInterval(objVals)
+	return bps, objs, ok
+}
+
+// PercentileInterval returns the 95% confidence interval of percentile p (0 -> 1)
+// of the sorted durations.
+// The interval is distribution free, based on the order statistics around the percentile.
+// Returns false if there are less than 2 durations.
+func PercentileInterval(sorted []time.Duration, p float64) (low, high time.Duration, ok bool) {
+	n := len(sorted)
+	if n < 2 {
+		return 0, 0, false
+	}
+	p = math.Max(0, math.Min(1, p))
+	center := float64(n) * p
+	half := 1.959964 * math.Sqrt(float64(n)*p*(1-p))
+	lo := int(math.Min(float64(n-1), math.Max(0, math.Floor(center-half))))
+	hi := int(math.Min(float64(n-1), math.Ceil(center+half)))
+	return sorted[lo], sorted[hi], true
+}
@@ -0,0 +1,102 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTCritical95(t *testing.T) {
+	// Published two-sided 95% critical values.
+	tests := []struct {
+		df   int
+		want float64
+	}{
+		{1, 12.706}, {2, 4.303}, {5, 2.571}, {10, 2.228}, {30, 2.042},
+		{31, 2.040}, {40, 2.021}, {60, 2.000}, {120, 1.980}, {1000, 1.962}, {1e6, 1.960},
+	}
+	for _, test := range tests {
+		if got := tCritical95(test.df); math.Abs(got-test.want) > 0.001 {
+			t.Errorf("df %d: got %.4f, want %.3f", test.df, got, test.want)
+		}
+	}
+}
+
+func TestMeanInterval(t *testing.T) {
+	if _, ok := MeanInterval([]float64{1}); ok {
+		t.Error("got interval of a single value")
+	}
+	// Mean 3, standard error sqrt(2.5/5), t(4) = 2.776.
+	got, ok := MeanInterval([]float64{1, 2, 3, 4, 5})
+	if !ok {
+		t.Fatal("no interval")
+	}
+	half := 2.776 * math.Sqrt(0.5)
+	if math.Abs(got.Low-(3-half)) > 1e-9 || math.Abs(got.High-(3+half)) > 1e-9 {
+		t.Errorf("got %+v, want %.4f - %.4f", got, 3-half, 3+half)
+	}
+	if math.Abs(got.RelWidth()-half/3) > 1e-9 {
+		t.Errorf("got relative width %v, want %v", got.RelWidth(), half/3)
+	}
+	// Identical values have no spread.
+	got, _ = MeanInterval([]float64{7, 7, 7})
+	if got.Low != 7 || got.High != 7 {
+		t.Errorf("got %+v, want 7 - 7", got)
+	}
+	if !got.Overlaps(Interval{Low: 6, High: 7}) || got.Overlaps(Interval{Low: 7.5, High: 8}) {
+		t.Errorf("wrong overlap of %+v", got)
+	}
+}
+
+func TestPercentileInterval(t *testing.T) {
+	sorted := func(n int) []time.Duration {
+		d := make([]time.Duration, n)
+		for i := range d {
+			d[i] = time.Duration(i)
+		}
+		return d
+	}
+	tests := []struct {
+		n        int
+		p        float64
+		lo, high time.Duration
+	}{
+		// Median of 100: 50 ± 1.96*sqrt(100*0.5*0.5) = 50 ± 9.8.
+		{n: 100, p: 0.5, lo: 40, high: 60},
+		// 99th percentile of 1000: 990 ± 1.96*sqrt(9.9) = 990 ± 6.17.
+		{n: 1000, p: 0.99, lo: 983, high: 997},
+		// 90th percentile of 10000: 9000 ± 1.96*30 = 9000 ± 58.8.
+		{n: 10000, p: 0.9, lo: 8941, high: 9059},
+		// Bounds are clamped to the values.
+		{n: 10, p: 0.99, lo: 9, high: 9},
+		{n: 10, p: 1, lo: 9, high: 9},
+		{n: 10, p: 0, lo: 0, high: 0},
+		{n: 10, p: 2, lo: 9, high: 9},
+	}
+	for _, test := range tests {
+		lo, hi, ok := PercentileInterval(sorted(test.n), test.p)
+		if !ok || lo != test.lo || hi != test.high {
+			t.Errorf("n %d, p %v: got %d - %d (%v), want %d - %d", test.n, test.p, lo, hi, ok, test.lo, test.high)
+		}
+	}
+	if _, _, ok := PercentileInterval(sorted(1), 0.5); ok {
+		t.Error("got interval of a single value")
+	}
+}
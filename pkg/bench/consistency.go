@@ -0,0 +1,379 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Consistency checks read-after-write consistency.
+// Writer threads upload objects and hand each object to a reader thread,
+// which immediately reads it back and then polls until the written version is visible.
+type Consistency struct {
+	Common
+
+	// Readers is the number of reader threads for each writer.
+	Readers int
+
+	// Checks are the operations used to read back objects.
+	// Can contain GET, STAT and LIST.
+	Checks []string
+
+	// Overwrite is the fraction of uploads that overwrite a previously written object (0 -> 1).
+	Overwrite float64
+
+	// PollInterval is the delay between checks of objects that are not yet visible.
+	PollInterval time.Duration
+
+	// MaxLag is the longest time to wait for an object to become visible.
+	MaxLag time.Duration
+
+	// ReadClient is used by readers if set.
+	ReadClient func() (cl *minio.Client, done func())
+
+	prefixes map[string]struct{}
+}
+
+// opVisible is the operation type of visibility lag measurements.
+const opVisible = "VISIBLE"
+
+// consistencyHistory is the number of objects kept by each writer for overwrites.
+const consistencyHistory = 100
+
+// written is an object handed from a writer to a reader.
+type written struct {
+	name string
+	etag string
+	size int64
+	// end is when the upload completed.
+	end time.Time
+	// done is called when the reader has finished checking the object.
+	done func()
+}
+
+// errNotFound is returned by checks when the object was not found.
+var errNotFound = errors.New("object not found")
+
+// errUnexpectedSize is returned by checks when the size does not match the upload.
+var errUnexpectedSize = errors.New("unexpected size")
+
+// Prepare will create an empty bucket.
+func (c *Consistency) Prepare(ctx context.Context) error {
+	if err := c.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	c.addCollector()
+	return nil
+}
+
+func (c *Consistency) readClient(thread int) (*minio.Client, func()) {
+	if c.ReadClient != nil {
+		return c.ReadClient()
+	}
+	return c.threadClient(thread)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (c *Consistency) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	col := c.Collector
+	if c.AutoTermDur > 0 {
+		ctx = col.AutoTerm(ctx, http.MethodPut, c.autoTermOpts())
+	}
+	c.prefixes = make(map[string]struct{}, c.Concurrency)
+
+	for i := 0; i < c.Concurrency; i++ {
+		src := c.Source()
+		c.prefixes[src.Prefix()] = struct{}{}
+		objs := make(chan written)
+		for r := 0; r < c.Readers; r++ {
+			wg.Add(1)
+			go func(thread int) {
+				defer wg.Done()
+				nonTerm := c.threadContext(thread)
+				rcv := col.Receiver()
+				for w := range objs {
+					c.check(nonTerm, thread, w, rcv)
+					w.done()
+				}
+			}(c.Concurrency + i*c.Readers + r)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(objs)
+			// Non-terminating context.
+			nonTerm := c.threadContext(i)
+			rng := c.threadRng(i)
+			rcv := col.Receiver()
+			opts := c.PutOpts
+			done := ctx.Done()
+
+			// Objects written and whether they are being checked.
+			var mu sync.Mutex
+			var history []generator.Object
+			checking := make(map[string]bool)
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if c.waitThread(ctx, i) != nil {
+					return
+				}
+
+				obj := src.Object()
+				mu.Lock()
+				if len(history) > 0 && rng.Float64() < c.Overwrite {
+					// Only overwrite objects that are not being checked.
+					prev := history[rng.Intn(len(history))]
+					if !checking[prev.Name] {
+						obj.Name, obj.Prefix = prev.Name, prev.Prefix
+					}
+				}
+				checking[obj.Name] = true
+				mu.Unlock()
+				opts.ContentType = obj.ContentType
+				client, cldone := c.threadClient(i)
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				res, err := c.putObject(opContext(nonTerm, &op), S3ObjectClient(client), obj, obj.Size, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					c.Error("upload error: ", err)
+					op.Err = err.Error()
+				}
+				if res.Size != obj.Size && op.Err == "" {
+					err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+					c.Error(err)
+					op.Err = err
+					op.ErrClass = ErrClassValidation
+				}
+				rcv <- op
+				name := obj.Name
+				finished := func() {
+					mu.Lock()
+					delete(checking, name)
+					mu.Unlock()
+				}
+				if op.Err != "" {
+					finished()
+					continue
+				}
+				mu.Lock()
+				if !slices.ContainsFunc(history, func(o generator.Object) bool { return o.Name == name }) {
+					key := generator.Object{Name: name, Prefix: obj.Prefix}
+					if len(history) < consistencyHistory {
+						history = append(history, key)
+					} else {
+						history[rng.Intn(len(history))] = key
+					}
+				}
+				mu.Unlock()
+				// Wait for a reader, so the object is read back immediately.
+				objs <- written{name: name, etag: res.ETag, size: obj.Size, end: op.End, done: finished}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return col.Close(), nil
+}
+
+// check reads back a written object with all checks.
+// Each check is recorded as an operation, followed by a VISIBLE operation
+// with the time from the upload completing until all checks saw the written version.
+func (c *Consistency) check(ctx context.Context, thread int, w written, rcv chan<- Operation) {
+	client, cldone := c.readClient(thread)
+	defer cldone()
+	var pending []string
+	for _, check := range c.Checks {
+		op := Operation{
+			OpType:   check,
+			Thread:   uint32(thread),
+			File:     w.name,
+			ObjPerOp: 1,
+			Endpoint: client.EndpointURL().String(),
+		}
+		if check == http.MethodGet {
+			op.Size = w.size
+		}
+		op.Start = time.Now()
+		err := c.checkOnce(opContext(ctx, &op), client, check, w)
+		op.End = time.Now()
+		if err != nil {
+			pending = append(pending, check)
+			op.Err, op.ErrClass = err.Error(), consistencyErrClass(err)
+			c.Error(fmt.Sprintf("%s %s: %v", check, w.name, err))
+		}
+		rcv <- op
+	}
+
+	vop := Operation{
+		OpType:   opVisible,
+		Thread:   uint32(thread),
+		File:     w.name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+		Start:    w.end,
+	}
+	deadline := w.end.Add(c.MaxLag)
+	var err error
+	for len(pending) > 0 {
+		if time.Now().After(deadline) {
+			vop.Err = fmt.Sprintf("%s not visible within %v: %v", strings.Join(pending, ","), c.MaxLag, err)
+			vop.ErrClass = consistencyErrClass(err)
+			break
+		}
+		time.Sleep(c.PollInterval)
+		if ctx.Err() != nil {
+			return
+		}
+		remain := pending[:0]
+		for _, check := range pending {
+			if err = c.checkOnce(opContext(ctx, &vop), client, check, w); err != nil {
+				remain = append(remain, check)
+			}
+		}
+		pending = remain
+	}
+	vop.End = time.Now()
+	if vop.Err != "" {
+		c.Error(fmt.Sprintf("%s: %s", w.name, vop.Err))
+	}
+	rcv <- vop
+}
+
+// checkOnce reads the object using the check operation.
+// Returns errNotFound if the object was not found and a staleError if another version was returned.
+func (c *Consistency) checkOnce(ctx context.Context, client *minio.Client, check string, w written) error {
+	var etag string
+	var size int64
+	switch check {
+	case http.MethodGet:
+		o, err := client.GetObject(ctx, c.Bucket, w.name, minio.GetObjectOptions{})
+		if err != nil {
+			return notFoundErr(err)
+		}
+		defer o.Close()
+		st, err := o.Stat()
+		if err != nil {
+			return notFoundErr(err)
+		}
+		etag = st.ETag
+		size, err = io.Copy(io.Discard, o)
+		if err != nil {
+			return err
+		}
+	case "STAT":
+		st, err := client.StatObject(ctx, c.Bucket, w.name, minio.StatObjectOptions{})
+		if err != nil {
+			return notFoundErr(err)
+		}
+		etag, size = st.ETag, st.Size
+	case "LIST":
+		// Stop the listing when returning before it has completed.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		found := false
+		for obj := range client.ListObjects(ctx, c.Bucket, minio.ListObjectsOptions{Prefix: w.name, MaxKeys: 1}) {
+			if obj.Err != nil {
+				return obj.Err
+			}
+			if obj.Key == w.name {
+				etag, size, found = obj.ETag, obj.Size, true
+				break
+			}
+		}
+		if !found {
+			return errNotFound
+		}
+	default:
+		return fmt.Errorf("unknown check %q", check)
+	}
+	if strings.Trim(etag, `"`) != strings.Trim(w.etag, `"`) {
+		return staleError{want: w.etag, got: etag}
+	}
+	if size != w.size {
+		return fmt.Errorf("%w. want: %d, got: %d", errUnexpectedSize, w.size, size)
+	}
+	return nil
+}
+
+// staleError is returned when a check returned another version than the one written.
+type staleError struct {
+	want, got string
+}
+
+func (e staleError) Error() string {
+	return fmt.Sprintf("stale read. want etag: %s, got: %s", e.want, e.got)
+}
+
+// notFoundErr returns errNotFound if err is a not found response.
+func notFoundErr(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	return err
+}
+
+// consistencyErrClass returns the error class of a check error.
+func consistencyErrClass(err error) string {
+	var stale staleError
+	switch {
+	case errors.Is(err, errNotFound):
+		return ErrClassNotFound
+	case errors.As(err, &stale):
+		return ErrClassStale
+	case errors.Is(err, errUnexpectedSize):
+		return ErrClassValidation
+	}
+	return ""
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (c *Consistency) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(c.prefixes))
+	for p := range c.prefixes {
+		pf = append(pf, p)
+	}
+	c.deleteAllInBucket(ctx, pf...)
+}
@@ -0,0 +1,141 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestConsistencyCheckOnce(t *testing.T) {
+	cl, _ := newFSTestClient(t)
+	ctx := context.Background()
+	put := func(name, data string) written {
+		t.Helper()
+		res, err := cl.PutObject(ctx, "bucket", name, strings.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return written{name: name, etag: res.ETag, size: res.Size, end: time.Now()}
+	}
+	c := Consistency{Common: Common{Bucket: "bucket"}}
+
+	current := put("obj", "version 1")
+	stale := current
+	current = put("obj", "version 2, longer")
+	wrongSize := current
+	wrongSize.size++
+	// Another object with the name as prefix must not be seen by LIST.
+	put("missing-suffix", "x")
+	missing := written{name: "missing", etag: current.etag, size: current.size}
+
+	tests := []struct {
+		name  string
+		w     written
+		class string
+		err   error
+	}{
+		{name: "current", w: current},
+		{name: "stale", w: stale, class: ErrClassStale},
+		{name: "missing", w: missing, class: ErrClassNotFound, err: errNotFound},
+		{name: "size", w: wrongSize, class: ErrClassValidation, err: errUnexpectedSize},
+	}
+	for _, test := range tests {
+		for _, check := range []string{http.MethodGet, "STAT", "LIST"} {
+			err := c.checkOnce(ctx, cl, check, test.w)
+			if test.name == "current" {
+				if err != nil {
+					t.Errorf("%s %s: unexpected error: %v", test.name, check, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Errorf("%s %s: expected error", test.name, check)
+				continue
+			}
+			if got := consistencyErrClass(err); got != test.class {
+				t.Errorf("%s %s: got class %q, want %q (%v)", test.name, check, got, test.class, err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("%s %s: got error %v, want %v", test.name, check, err, test.err)
+			}
+		}
+	}
+	if got := consistencyErrClass(errors.New("connection reset")); got != "" {
+		t.Errorf("infrastructure error got class %q", got)
+	}
+}
+
+func TestConsistencyCheckVisible(t *testing.T) {
+	cl, _ := newFSTestClient(t)
+	ctx := context.Background()
+	res, err := cl.PutObject(ctx, "bucket", "obj", strings.NewReader("data"), 4, minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Consistency{
+		Common: Common{
+			Bucket: "bucket",
+			Client: func() (*minio.Client, func()) { return cl, func() {} },
+			Error:  func(...interface{}) {},
+		},
+		Checks:       []string{http.MethodGet, "STAT", "LIST"},
+		PollInterval: time.Millisecond,
+		MaxLag:       20 * time.Millisecond,
+	}
+	run := func(w written) Operations {
+		rcv := make(chan Operation, 10)
+		c.check(ctx, 0, w, rcv)
+		close(rcv)
+		var ops Operations
+		for op := range rcv {
+			ops = append(ops, op)
+		}
+		if len(ops) != len(c.Checks)+1 {
+			t.Fatalf("got %d operations, want %d", len(ops), len(c.Checks)+1)
+		}
+		return ops
+	}
+
+	ops := run(written{name: "obj", etag: res.ETag, size: 4, end: time.Now()})
+	for _, op := range ops {
+		if op.Err != "" {
+			t.Errorf("%s: unexpected error %s", op.OpType, op.Err)
+		}
+	}
+	if vis := ops[len(ops)-1]; vis.OpType != opVisible || vis.End.Before(vis.Start) {
+		t.Errorf("unexpected visibility operation: %+v", vis)
+	}
+
+	// Never becomes visible.
+	ops = run(written{name: "missing", etag: res.ETag, size: 4, end: time.Now()})
+	for _, op := range ops {
+		if op.Err == "" || op.ErrClass != ErrClassNotFound {
+			t.Errorf("%s: got error %q, class %q", op.OpType, op.Err, op.ErrClass)
+		}
+	}
+	if vis := ops[len(ops)-1]; vis.End.Sub(vis.Start) < c.MaxLag {
+		t.Errorf("visibility failed after %v, before max lag %v", vis.End.Sub(vis.Start), c.MaxLag)
+	}
+}
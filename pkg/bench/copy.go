@@ -0,0 +1,231 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Copy benchmarks server-side copy speed.
+type Copy struct {
+	Common
+
+	CreateObjects int
+
+	// Compose is the number of source objects combined into each destination
+	// using multipart copy. If <= 1 objects are copied with a single CopyObject.
+	Compose int
+
+	// ComposeRandom will use a random number of sources between 2 and Compose
+	// for each destination.
+	ComposeRandom bool
+
+	// SSE is used for both source and destination objects.
+	SSE encrypt.ServerSide
+
+	objects generator.Objects
+}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload a number of objects to copy.
+func (c *Copy) Prepare(ctx context.Context) error {
+	if err := c.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	console.Eraseline()
+	console.Info("\rUploading ", c.CreateObjects, " objects")
+
+	var wg sync.WaitGroup
+	wg.Add(c.Concurrency)
+	c.addCollector()
+	objs := splitObjs(c.CreateObjects, c.Concurrency)
+	rcv := c.Collector.rcv
+	var groupErr error
+	var mu sync.Mutex
+
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := c.Source()
+			opts := c.PutOpts
+
+			for range obj {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if c.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				client, cldone := c.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, err := c.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					c.Error(err)
+					if err := c.prepareFailed(err, c.CreateObjects); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					continue
+				}
+				obj.VersionID = res.VersionID
+				obj.ETag = res.ETag
+				mu.Lock()
+				obj.Reader = nil
+				c.objects = append(c.objects, *obj)
+				c.prepareProgress(float64(len(c.objects)) / float64(c.CreateObjects))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	if groupErr == nil && len(c.objects) == 0 {
+		groupErr = fmt.Errorf("no objects uploaded")
+	}
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (c *Copy) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(c.Concurrency)
+	opType := "COPY"
+	if c.Compose > 1 {
+		opType = "COMPOSE"
+	}
+	col := c.Collector
+	if c.AutoTermDur > 0 {
+		ctx = col.AutoTerm(ctx, opType, c.autoTermOpts())
+	}
+
+	for i := 0; i < c.Concurrency; i++ {
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := c.threadContext(i)
+			rng := c.threadRng(i)
+			rcv := col.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			nSrc := max(c.Compose, 1)
+			srcs := make([]minio.CopySrcOptions, nSrc)
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if c.waitThread(ctx, i) != nil {
+					return
+				}
+
+				srcs := srcs
+				if c.ComposeRandom && nSrc > 2 {
+					srcs = srcs[:2+rng.Intn(nSrc-1)]
+				}
+				var size int64
+				for j := range srcs {
+					obj := c.objects[rng.Intn(len(c.objects))]
+					srcs[j] = minio.CopySrcOptions{
+						Bucket:     c.Bucket,
+						Object:     obj.Name,
+						VersionID:  obj.VersionID,
+						Encryption: c.SSE,
+					}
+					size += obj.Size
+				}
+				// Copies are placed next to the first source, so they are removed with it.
+				dst := minio.CopyDestOptions{
+					Bucket:     c.Bucket,
+					Object:     fmt.Sprintf("%s.copy-%d", srcs[0].Object, i),
+					Encryption: c.SSE,
+				}
+				client, cldone := c.threadClient(i)
+				op := Operation{
+					OpType:   opType,
+					Thread:   uint32(i),
+					Size:     size,
+					File:     dst.Object,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				op.Start = time.Now()
+				var err error
+				if c.Compose > 1 {
+					var res minio.UploadInfo
+					res, err = client.ComposeObject(opContext(nonTerm, &op), dst, srcs...)
+					if err == nil && res.Size != size {
+						op.Err = fmt.Sprint("unexpected size. want:", size, ", got:", res.Size)
+						op.ErrClass = ErrClassValidation
+						c.Error(op.Err)
+					}
+				} else {
+					_, err = client.CopyObject(opContext(nonTerm, &op), dst, srcs[0])
+				}
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					c.Error("copy error: ", err)
+					op.Err = err.Error()
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return col.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (c *Copy) Cleanup(ctx context.Context) {
+	c.deleteAllInBucket(ctx, c.objects.Prefixes()...)
+}
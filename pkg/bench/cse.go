@@ -0,0 +1,110 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/secure-io/sio-go"
+)
+
+// ClientEncryption encrypts objects on the client before upload
+// and decrypts them after download.
+// Encrypted objects are stored as a random nonce followed by the AES-256-GCM encrypted stream.
+type ClientEncryption struct {
+	stream *sio.Stream
+}
+
+// NewClientEncryption returns client-side encryption using the 32 byte key.
+func NewClientEncryption(key []byte) (*ClientEncryption, error) {
+	stream, err := sio.AES_256_GCM.Stream(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientEncryption{stream: stream}, nil
+}
+
+// EncryptedSize returns the stored size of an object with the given plaintext size.
+func (c *ClientEncryption) EncryptedSize(size int64) int64 {
+	return int64(c.stream.NonceSize()) + size + c.stream.Overhead(size)
+}
+
+// cryptoTimer keeps track of time spent in a crypto reader
+// excluding the time spent reading from the underlying reader.
+type cryptoTimer struct {
+	total, src atomic.Int64
+}
+
+// Duration returns the time spent on crypto operations.
+func (t *cryptoTimer) Duration() time.Duration {
+	return time.Duration(t.total.Load() - t.src.Load())
+}
+
+type timedReader struct {
+	r io.Reader
+	d *atomic.Int64
+}
+
+func (t timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := t.r.Read(p)
+	t.d.Add(int64(time.Since(start)))
+	return n, err
+}
+
+// encrypt returns a reader with the encrypted content of r.
+func (c *ClientEncryption) encrypt(r io.Reader) (io.Reader, *cryptoTimer) {
+	t := &cryptoTimer{}
+	nonce := make([]byte, c.stream.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		panic(err)
+	}
+	enc := c.stream.EncryptReader(timedReader{r: r, d: &t.src}, nonce, nil)
+	return timedReader{r: io.MultiReader(bytes.NewReader(nonce), enc), d: &t.total}, t
+}
+
+// decrypt returns a reader with the decrypted content of r.
+func (c *ClientEncryption) decrypt(r io.Reader) (io.Reader, *cryptoTimer) {
+	t := &cryptoTimer{}
+	return timedReader{r: &decReader{c: c, src: timedReader{r: r, d: &t.src}}, d: &t.total}, t
+}
+
+// decReader reads the nonce on first read and decrypts the remaining stream.
+type decReader struct {
+	c   *ClientEncryption
+	src io.Reader
+	dec io.Reader
+}
+
+func (d *decReader) Read(p []byte) (int, error) {
+	if d.dec == nil {
+		nonce := make([]byte, d.c.stream.NonceSize())
+		if _, err := io.ReadFull(d.src, nonce); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		d.dec = d.c.stream.DecryptReader(d.src, nonce, nil)
+	}
+	return d.dec.Read(p)
+}
@@ -40,6 +40,48 @@ type Delete struct {
 	ListExisting  bool
 	ListFlat      bool
 	ListPrefix    string
+
+	// Mode selects versioned deletes.
+	// Empty means plain deletes of the uploaded objects.
+	Mode DeleteMode
+
+	// BypassGovernance will upload objects with governance retention
+	// and bypass it when deleting versions.
+	BypassGovernance bool
+
+	prefixes []string
+}
+
+// DeleteMode selects how objects are deleted on versioned buckets.
+type DeleteMode string
+
+// Delete modes.
+const (
+	// DeleteModeMarker creates delete markers without removing data.
+	DeleteModeMarker DeleteMode = "marker"
+	// DeleteModeVersion deletes the uploaded version IDs.
+	DeleteModeVersion DeleteMode = "version"
+	// DeleteModeBoth creates a delete marker and then deletes the uploaded version.
+	DeleteModeBoth DeleteMode = "both"
+)
+
+// Operation types for versioned deletes.
+const (
+	deleteMarker        = "DELETE-MARKER"
+	deleteVersion       = "DELETE-VERSION"
+	deleteVersionBypass = "DELETE-VERSION-BYPASS"
+)
+
+// deleteRetention is the governance retention applied to uploaded objects
+// when BypassGovernance is set. Cleanup bypasses it as well.
+const deleteRetention = 24 * time.Hour
+
+// versionOpType returns the operation type recorded for deleting specific versions.
+func (d *Delete) versionOpType() string {
+	if d.BypassGovernance {
+		return deleteVersionBypass
+	}
+	return deleteVersion
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -49,7 +91,7 @@ func (d *Delete) Prepare(ctx context.Context) error {
 
 	// prepare the bench by listing object from the bucket
 	if d.ListExisting {
-		cl, done := d.Client()
+		cl, done := d.objectClient()
 
 		// ensure the bucket exist
 		found, err := cl.BucketExists(ctx, d.Bucket)
@@ -64,17 +106,22 @@ func (d *Delete) Prepare(ctx context.Context) error {
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 		objectCh := cl.ListObjects(ctx, d.Bucket, minio.ListObjectsOptions{
-			Prefix:    d.ListPrefix,
-			Recursive: !d.ListFlat,
+			WithVersions: d.Mode != "",
+			Prefix:       d.ListPrefix,
+			Recursive:    !d.ListFlat,
 		})
 
 		for object := range objectCh {
 			if object.Err != nil {
 				return object.Err
 			}
+			if d.Mode != "" && (object.IsDeleteMarker || !object.IsLatest) {
+				continue
+			}
 			obj := generator.Object{
-				Name: object.Key,
-				Size: object.Size,
+				Name:      object.Key,
+				Size:      object.Size,
+				VersionID: object.VersionID,
 			}
 
 			d.objects = append(d.objects, obj)
@@ -102,6 +149,16 @@ func (d *Delete) Prepare(ctx context.Context) error {
 	if err := d.createEmptyBucket(ctx); err != nil {
 		return err
 	}
+	if d.Mode != "" && !d.Versioned {
+		cl, done := d.Client()
+		err := cl.EnableVersioning(ctx, d.Bucket)
+		done()
+		if err != nil {
+			return err
+		}
+		d.Versioned = true
+	}
+	retainUntil := time.Now().Add(deleteRetention).Truncate(time.Second).UTC()
 	src := d.Source()
 	console.Eraseline()
 	console.Info("\rUploading ", d.CreateObjects, " objects of ", src.String())
@@ -118,6 +175,10 @@ func (d *Delete) Prepare(ctx context.Context) error {
 
 			for range obj {
 				opts := d.PutOpts
+				if d.BypassGovernance {
+					opts.Mode = minio.Governance
+					opts.RetainUntilDate = retainUntil
+				}
 				rcv := d.Collector.Receiver()
 				done := ctx.Done()
 
@@ -132,10 +193,10 @@ func (d *Delete) Prepare(ctx context.Context) error {
 				}
 
 				obj := src.Object()
-				client, cldone := d.Client()
+				client, cldone := d.objectClient()
 				op := Operation{
 					OpType:   http.MethodPut,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -144,30 +205,22 @@ func (d *Delete) Prepare(ctx context.Context) error {
 
 				opts.ContentType = obj.ContentType
 				op.Start = time.Now()
-				res, err := client.PutObject(ctx, d.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				res, err := d.prepareUpload(ctx, client, obj, opts)
 				op.End = time.Now()
 				if err != nil {
-					err := fmt.Errorf("upload error: %w", err)
 					d.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+					cldone()
+					if err := d.prepareFailed(err, d.CreateObjects); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
 					}
-					mu.Unlock()
-					return
+					continue
 				}
 				obj.VersionID = res.VersionID
-
-				if res.Size != obj.Size {
-					err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-					d.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
-					}
-					mu.Unlock()
-					return
-				}
 				cldone()
 				mu.Lock()
 				obj.Reader = nil
@@ -179,6 +232,7 @@ func (d *Delete) Prepare(ctx context.Context) error {
 		}(i, obj)
 	}
 	wg.Wait()
+	d.prefixes = d.objects.Prefixes()
 
 	// Shuffle objects.
 	// Benchmark will pick from slice in order.
@@ -196,14 +250,21 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 	wg.Add(d.Concurrency)
 	c := d.Collector
 	if d.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodDelete, d.AutoTermScale, autoTermCheck, autoTermSamples, d.AutoTermDur)
+		opType := http.MethodDelete
+		switch d.Mode {
+		case DeleteModeMarker:
+			opType = deleteMarker
+		case DeleteModeVersion, DeleteModeBoth:
+			opType = d.versionOpType()
+		}
+		ctx = c.AutoTerm(ctx, opType, d.autoTermOpts())
 	}
-	// Non-terminating context.
-	nonTerm := context.Background()
 
 	var mu sync.Mutex
 	for i := 0; i < d.Concurrency; i++ {
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := d.threadContext(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
@@ -216,7 +277,7 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				default:
 				}
 
-				if d.rpsLimit(ctx) != nil {
+				if d.waitThread(ctx, i) != nil {
 					return
 				}
 
@@ -233,44 +294,19 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				d.objects = d.objects[len(objs):]
 				mu.Unlock()
 
-				// Queue all in batch.
-				objects := make(chan minio.ObjectInfo, len(objs))
-				for _, obj := range objs {
-					objects <- minio.ObjectInfo{Key: obj.Name, VersionID: obj.VersionID}
-				}
-				close(objects)
-
-				client, cldone := d.Client()
-				op := Operation{
-					OpType:   http.MethodDelete,
-					Thread:   uint16(i),
-					Size:     0,
-					File:     "",
-					ObjPerOp: len(objs),
-					Endpoint: client.EndpointURL().String(),
-				}
-				if d.DiscardOutput {
-					op.File = ""
-				}
-
-				op.Start = time.Now()
-				// RemoveObjectsWithContext will split any batches > 1000 into separate requests.
-				errCh := client.RemoveObjects(nonTerm, d.Bucket, objects, minio.RemoveObjectsOptions{})
-
-				// Wait for errCh to close.
-				for {
-					err, ok := <-errCh
-					if !ok {
-						break
-					}
-					if err.Err != nil {
-						d.Error(err.Err)
-						op.Err = err.Err.Error()
-					}
+				client, cldone := d.threadObjectClient(i)
+				switch d.Mode {
+				case DeleteModeMarker:
+					rcv <- d.deleteBatch(nonTerm, client, i, objs, deleteMarker)
+				case DeleteModeVersion:
+					rcv <- d.deleteBatch(nonTerm, client, i, objs, d.versionOpType())
+				case DeleteModeBoth:
+					rcv <- d.deleteBatch(nonTerm, client, i, objs, deleteMarker)
+					rcv <- d.deleteBatch(nonTerm, client, i, objs, d.versionOpType())
+				default:
+					rcv <- d.deleteBatch(nonTerm, client, i, objs, http.MethodDelete)
 				}
-				op.End = time.Now()
 				cldone()
-				rcv <- op
 			}
 		}(i)
 	}
@@ -278,9 +314,62 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 	return c.Close(), nil
 }
 
+// deleteBatch deletes objs in a single batch and returns the operation.
+// Delete markers are created by omitting the version ID.
+func (d *Delete) deleteBatch(ctx context.Context, client ObjectClient, thread int, objs generator.Objects, opType string) Operation {
+	// Queue all in batch.
+	objects := make(chan minio.ObjectInfo, len(objs))
+	for _, obj := range objs {
+		oi := minio.ObjectInfo{Key: obj.Name}
+		if opType != deleteMarker {
+			oi.VersionID = obj.VersionID
+		}
+		objects <- oi
+	}
+	close(objects)
+
+	op := Operation{
+		OpType:   opType,
+		Thread:   uint32(thread),
+		Size:     0,
+		File:     "",
+		ObjPerOp: len(objs),
+		Endpoint: client.EndpointURL().String(),
+	}
+	opts := minio.RemoveObjectsOptions{
+		GovernanceBypass: opType == deleteVersionBypass,
+	}
+
+	op.Start = time.Now()
+	// RemoveObjectsWithContext will split any batches > 1000 into separate requests.
+	errCh := client.RemoveObjects(opContext(ctx, &op), d.Bucket, objects, opts)
+
+	// Wait for errCh to close.
+	for {
+		err, ok := <-errCh
+		if !ok {
+			break
+		}
+		if err.Err != nil {
+			d.Error(err.Err)
+			op.Err = err.Err.Error()
+		}
+	}
+	op.End = time.Now()
+	return op
+}
+
 // Cleanup deletes everything uploaded to the bucket.
+// With versioned deletes, remaining versions and delete markers are removed as well.
 func (d *Delete) Cleanup(ctx context.Context) {
-	if len(d.objects) > 0 && !d.ListExisting {
+	if d.ListExisting {
+		return
+	}
+	if d.Mode != "" && len(d.prefixes) > 0 {
+		d.deleteAllInBucket(ctx, d.prefixes...)
+		return
+	}
+	if len(d.objects) > 0 {
 		d.deleteAllInBucket(ctx, d.objects.Prefixes()...)
 	}
 }
@@ -0,0 +1,121 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Error categories for errors that cannot be mapped to an S3 error code.
+const (
+	ErrCategoryValidation        = "Validation"
+	ErrCategoryNotFound          = "NotFound"
+	ErrCategoryStale             = "StaleRead"
+	ErrCategoryConnectionReset   = "ConnectionReset"
+	ErrCategoryConnectionRefused = "ConnectionRefused"
+	ErrCategoryTimeout           = "Timeout"
+	ErrCategoryEOF               = "EOF"
+	ErrCategoryDNS               = "DNS"
+	ErrCategoryTLS               = "TLS"
+	ErrCategoryOther             = "Other"
+)
+
+// errCategoryMatch matches a lower case substring of an error to a category.
+type errCategoryMatch struct {
+	substr   string
+	category string
+	status   int
+}
+
+// errCategories is checked in order. S3 error responses are recorded
+// as the message of the response, so the messages returned by MinIO
+// and AWS for the most common error codes are matched.
+var errCategories = []errCategoryMatch{
+	{substr: "please reduce your request rate", category: "SlowDown", status: 503},
+	{substr: "slow down", category: "SlowDown", status: 503},
+	{substr: "we encountered an internal error", category: "InternalError", status: 500},
+	{substr: "service is unable to handle request", category: "ServiceUnavailable", status: 503},
+	{substr: "service is unavailable", category: "ServiceUnavailable", status: 503},
+	{substr: "server not initialized", category: "XMinioServerNotInitialized", status: 503},
+	{substr: "your socket connection to the server was not read from or written to", category: "RequestTimeout", status: 400},
+	{substr: "access denied", category: "AccessDenied", status: 403},
+	{substr: "the access key id you provided does not exist", category: "InvalidAccessKeyId", status: 403},
+	{substr: "the request signature we calculated does not match", category: "SignatureDoesNotMatch", status: 403},
+	{substr: "the difference between the request time and the server's time is too large", category: "RequestTimeTooSkewed", status: 403},
+	{substr: "the specified key does not exist", category: "NoSuchKey", status: 404},
+	{substr: "the specified bucket does not exist", category: "NoSuchBucket", status: 404},
+	{substr: "the specified multipart upload does not exist", category: "NoSuchUpload", status: 404},
+	{substr: "the content-md5 you specified did not match", category: "BadDigest", status: 400},
+	{substr: "storage backend has reached its minimum free drive threshold", category: "XMinioStorageFull", status: 507},
+	{substr: "connection reset", category: ErrCategoryConnectionReset},
+	{substr: "broken pipe", category: ErrCategoryConnectionReset},
+	{substr: "connection refused", category: ErrCategoryConnectionRefused},
+	{substr: "timeout", category: ErrCategoryTimeout},
+	{substr: "deadline exceeded", category: ErrCategoryTimeout},
+	{substr: "no such host", category: ErrCategoryDNS},
+	{substr: "tls:", category: ErrCategoryTLS},
+	{substr: "x509:", category: ErrCategoryTLS},
+	{substr: "eof", category: ErrCategoryEOF},
+}
+
+// errResponseCode matches the error minio-go returns for responses with unknown error codes.
+var errResponseCode = regexp.MustCompile(`^Error response code ([A-Za-z0-9.]+)\.`)
+
+// ErrCategory returns the category of the error of the operation
+// and the HTTP status code of the category, if known.
+// Returns an empty category if the operation did not fail.
+func (o Operation) ErrCategory() (category string, status int) {
+	if o.Err == "" {
+		return "", 0
+	}
+	switch {
+	case o.ErrClass == ErrClassNotFound:
+		return ErrCategoryNotFound, 404
+	case o.ErrClass == ErrClassStale:
+		return ErrCategoryStale, 0
+	case o.ValidationErr():
+		return ErrCategoryValidation, 0
+	}
+	return ErrorCategory(o.Err)
+}
+
+// ErrorCategory returns the category of an error string
+// and the HTTP status code of the category, if known.
+func ErrorCategory(err string) (category string, status int) {
+	if m := errResponseCode.FindStringSubmatch(err); m != nil {
+		return m[1], 0
+	}
+	lower := strings.ToLower(err)
+	for _, c := range errCategories {
+		if strings.Contains(lower, c.substr) {
+			return c.category, c.status
+		}
+	}
+	return ErrCategoryOther, 0
+}
+
+// ErrCategoryStatus returns the HTTP status code of an error category, if known.
+func ErrCategoryStatus(category string) int {
+	for _, c := range errCategories {
+		if c.category == category {
+			return c.status
+		}
+	}
+	return 0
+}
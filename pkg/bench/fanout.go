@@ -31,6 +31,7 @@ import (
 type Fanout struct {
 	Common
 	Copies   int
+	Entries  bool // Record an operation per entry instead of per request.
 	prefixes map[string]struct{}
 }
 
@@ -47,17 +48,16 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 	u.addCollector()
 	c := u.Collector
 	if u.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodPost, u.AutoTermScale, autoTermCheck, autoTermSamples, u.AutoTermDur)
+		ctx = c.AutoTerm(ctx, http.MethodPost, u.autoTermOpts())
 	}
 	u.prefixes = make(map[string]struct{}, u.Concurrency)
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < u.Concurrency; i++ {
 		src := u.Source()
 		u.prefixes[src.Prefix()] = struct{}{}
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := u.threadContext(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := minio.PutObjectFanOutRequest{
@@ -74,6 +74,11 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					return
 				default:
 				}
+
+				if u.waitThread(ctx, i) != nil {
+					return
+				}
+
 				obj := src.Object()
 				for i := range opts.Entries {
 					opts.Entries[i] = minio.PutObjectFanOutEntry{
@@ -87,10 +92,10 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 						CacheControl:       u.PutOpts.CacheControl,
 					}
 				}
-				client, cldone := u.Client()
+				client, cldone := u.threadClient(i)
 				op := Operation{
 					OpType:   http.MethodPost,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size * int64(u.Copies),
 					ObjPerOp: u.Copies,
 					File:     obj.Name,
@@ -98,12 +103,19 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				}
 
 				op.Start = time.Now()
-				res, err := client.PutObjectFanOut(nonTerm, u.Bucket, obj.Reader, opts)
+				res, err := client.PutObjectFanOut(opContext(nonTerm, &op), u.Bucket, obj.Reader, opts)
 				op.End = time.Now()
 				if err != nil {
 					u.Error("upload error: ", err)
 					op.Err = err.Error()
 				}
+				if u.Entries {
+					for _, eop := range u.entryOps(op, opts.Entries, res, obj.Size) {
+						rcv <- eop
+					}
+					cldone()
+					continue
+				}
 
 				var firstErr string
 				nErrs := 0
@@ -123,6 +135,7 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					err := fmt.Sprint("short upload. want:", u.Copies, " copies, got:", len(res))
 					if op.Err == "" {
 						op.Err = err
+						op.ErrClass = ErrClassValidation
 					}
 					u.Error(err)
 				}
@@ -136,6 +149,34 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 	return c.Close(), nil
 }
 
+// entryOps returns an operation for each entry of a fan-out request,
+// with the timing of the request and the result of the entry.
+func (u *Fanout) entryOps(op Operation, entries []minio.PutObjectFanOutEntry, res []minio.PutObjectFanOutResponse, size int64) []Operation {
+	results := make(map[string]minio.PutObjectFanOutResponse, len(res))
+	for _, r := range res {
+		results[r.Key] = r
+	}
+	ops := make([]Operation, 0, len(entries))
+	for _, e := range entries {
+		eop := op
+		eop.Size = size
+		eop.ObjPerOp = 1
+		eop.File = e.Key
+		if eop.Err == "" {
+			r, ok := results[e.Key]
+			switch {
+			case !ok:
+				eop.Err = "no result for fan-out entry"
+				eop.ErrClass = ErrClassValidation
+			case r.Error != "":
+				eop.Err = r.Error
+			}
+		}
+		ops = append(ops, eop)
+	}
+	return ops
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (u *Fanout) Cleanup(ctx context.Context) {
 	pf := make([]string, 0, len(u.prefixes))
@@ -0,0 +1,934 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FSTransport returns a transport serving S3 requests from the directory root,
+// so benchmarks can be run against a local or network filesystem.
+// Buckets are directories in root and objects are files in them.
+// Only the operations needed for basic object benchmarks are supported
+// and only anonymous requests without streaming payloads are accepted.
+// Object metadata is not stored.
+func FSTransport(root string) http.RoundTripper {
+	return &fsTransport{h: &fsHandler{root: root}}
+}
+
+// fsMetaDir is the directory in root keeping temporary files and multipart uploads.
+// It is not a valid bucket name, so it will not collide with buckets.
+const fsMetaDir = ".warp-fs"
+
+type fsTransport struct {
+	h http.Handler
+}
+
+// RoundTrip implements http.RoundTripper.
+// The handler runs concurrently, so response bodies are streamed.
+func (t *fsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	w := &fsResponseWriter{header: make(http.Header), pw: pw, ready: make(chan struct{})}
+	go func() {
+		defer func() {
+			w.WriteHeader(http.StatusOK)
+			pw.Close()
+			if req.Body != nil {
+				req.Body.Close()
+			}
+		}()
+		t.h.ServeHTTP(w, req)
+	}()
+	select {
+	case <-w.ready:
+	case <-req.Context().Done():
+		pr.CloseWithError(req.Context().Err())
+		return nil, req.Context().Err()
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode:    w.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.header,
+		Body:          pr,
+		ContentLength: -1,
+		Request:       req,
+	}
+	if n, err := strconv.ParseInt(w.header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = n
+	}
+	return resp, nil
+}
+
+// fsResponseWriter sends the response headers when written
+// and the body through a pipe.
+type fsResponseWriter struct {
+	header http.Header
+	status int
+	pw     *io.PipeWriter
+	once   sync.Once
+	ready  chan struct{}
+}
+
+// Header implements http.ResponseWriter.
+func (w *fsResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter.
+// The response is returned to the client when called.
+func (w *fsResponseWriter) WriteHeader(status int) {
+	w.once.Do(func() {
+		w.status = status
+		close(w.ready)
+	})
+}
+
+// Write implements http.ResponseWriter.
+func (w *fsResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pw.Write(b)
+}
+
+// fsHandler serves S3 requests from a directory.
+type fsHandler struct {
+	root string
+}
+
+// s3Error is an S3 error response.
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+	status   int
+}
+
+func (e s3Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+var (
+	errFSNoSuchBucket   = s3Error{Code: "NoSuchBucket", Message: "The specified bucket does not exist", status: http.StatusNotFound}
+	errFSNoSuchKey      = s3Error{Code: "NoSuchKey", Message: "The specified key does not exist.", status: http.StatusNotFound}
+	errFSNoSuchUpload   = s3Error{Code: "NoSuchUpload", Message: "The specified multipart upload does not exist.", status: http.StatusNotFound}
+	errFSBucketExists   = s3Error{Code: "BucketAlreadyOwnedByYou", Message: "Your previous request to create the named bucket succeeded and you already own it.", status: http.StatusConflict}
+	errFSBucketNotEmpty = s3Error{Code: "BucketNotEmpty", Message: "The bucket you tried to delete is not empty", status: http.StatusConflict}
+	errFSInvalidBucket  = s3Error{Code: "InvalidBucketName", Message: "The specified bucket is not valid.", status: http.StatusBadRequest}
+	errFSInvalidKey     = s3Error{Code: "InvalidArgument", Message: "Object name is not supported by the filesystem backend", status: http.StatusBadRequest}
+	errFSNotImplemented = s3Error{Code: "NotImplemented", Message: "The request is not supported by the filesystem backend", status: http.StatusNotImplemented}
+	errFSNoObjectLock   = s3Error{Code: "ObjectLockConfigurationNotFoundError", Message: "Object Lock configuration does not exist for this bucket", status: http.StatusNotFound}
+)
+
+func (h *fsHandler) writeError(w http.ResponseWriter, r *http.Request, e s3Error) {
+	e.Resource = r.URL.Path
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		xml.NewEncoder(w).Encode(e)
+	}
+}
+
+// writeErr writes a filesystem error as an S3 error.
+func (h *fsHandler) writeErr(w http.ResponseWriter, r *http.Request, err error, notFound s3Error) {
+	var e s3Error
+	switch {
+	case errors.As(err, &e):
+	case errors.Is(err, fs.ErrNotExist):
+		e = notFound
+	default:
+		e = s3Error{Code: "InternalError", Message: err.Error(), status: http.StatusInternalServerError}
+	}
+	h.writeError(w, r, e)
+}
+
+func (h *fsHandler) writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *fsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		h.writeError(w, r, errFSNotImplemented)
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+	switch {
+	case bucket == "":
+		if r.Method == http.MethodGet {
+			h.listBuckets(w, r)
+			return
+		}
+	case key == "":
+		h.serveBucket(w, r, bucket, q)
+		return
+	default:
+		if !fsValidKey(key) {
+			h.writeError(w, r, errFSInvalidKey)
+			return
+		}
+		h.serveObject(w, r, bucket, key, q)
+		return
+	}
+	h.writeError(w, r, errFSNotImplemented)
+}
+
+// fsValidBucket returns whether bucket can be stored as a directory in root.
+func fsValidBucket(bucket string) bool {
+	return bucket != fsMetaDir && !strings.Contains(bucket, "/") && fsValidKey(bucket)
+}
+
+// fsValidKey returns whether key can be stored as a file.
+func fsValidKey(key string) bool {
+	for _, s := range strings.Split(key, "/") {
+		switch s {
+		case "", ".", "..":
+			return false
+		}
+	}
+	return !strings.Contains(key, "\\")
+}
+
+// fsValidPrefix returns whether the directories of a listing prefix are in the bucket.
+// The last element is only matched against names, so it may be empty or incomplete.
+func fsValidPrefix(prefix string) bool {
+	dirs := strings.Split(prefix, "/")
+	last := dirs[len(dirs)-1]
+	return last != "." && last != ".." && (len(dirs) == 1 || fsValidKey(strings.Join(dirs[:len(dirs)-1], "/")))
+}
+
+func (h *fsHandler) bucketPath(bucket string) string {
+	return filepath.Join(h.root, bucket)
+}
+
+func (h *fsHandler) objectPath(bucket, key string) string {
+	return filepath.Join(h.root, bucket, filepath.FromSlash(key))
+}
+
+// checkBucket returns an error if bucket does not exist.
+func (h *fsHandler) checkBucket(bucket string) error {
+	if !fsValidBucket(bucket) {
+		return errFSNoSuchBucket
+	}
+	st, err := os.Stat(h.bucketPath(bucket))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return errFSNoSuchBucket
+		}
+		return err
+	}
+	if !st.IsDir() {
+		return errFSNoSuchBucket
+	}
+	return nil
+}
+
+// fsETag returns an ETag for a file, based on its modification time and size.
+// It does not resemble an MD5, so it will not be used to verify content.
+func fsETag(fi fs.FileInfo) string {
+	return fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size())
+}
+
+func (h *fsHandler) listBuckets(w http.ResponseWriter, r *http.Request) {
+	type bucket struct {
+		Name         string
+		CreationDate string
+	}
+	var res struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}
+	entries, err := os.ReadDir(h.root)
+	if err != nil {
+		h.writeErr(w, r, err, errFSNotImplemented)
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == fsMetaDir {
+			continue
+		}
+		b := bucket{Name: e.Name()}
+		if fi, err := e.Info(); err == nil {
+			b.CreationDate = fi.ModTime().UTC().Format(time.RFC3339)
+		}
+		res.Buckets = append(res.Buckets, b)
+	}
+	h.writeXML(w, res)
+}
+
+func (h *fsHandler) serveBucket(w http.ResponseWriter, r *http.Request, bucket string, q url.Values) {
+	if r.Method == http.MethodPut && len(q) == 0 {
+		if !fsValidBucket(bucket) {
+			h.writeError(w, r, errFSInvalidBucket)
+			return
+		}
+		err := os.Mkdir(h.bucketPath(bucket), 0o755)
+		if errors.Is(err, fs.ErrExist) {
+			err = errFSBucketExists
+		}
+		if err != nil {
+			h.writeErr(w, r, err, errFSNoSuchBucket)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := h.checkBucket(bucket); err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	switch r.Method {
+	case http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+		return
+	case http.MethodDelete:
+		if len(q) > 0 {
+			break
+		}
+		err := os.Remove(h.bucketPath(bucket))
+		if err != nil {
+			if entries, _ := os.ReadDir(h.bucketPath(bucket)); len(entries) > 0 {
+				err = errFSBucketNotEmpty
+			}
+			h.writeErr(w, r, err, errFSNoSuchBucket)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+		if q.Has("delete") {
+			h.deleteObjects(w, r, bucket)
+			return
+		}
+	case http.MethodGet:
+		switch {
+		case q.Has("location"):
+			h.writeXML(w, struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+			}{})
+			return
+		case q.Has("versioning"):
+			h.writeXML(w, struct {
+				XMLName xml.Name `xml:"VersioningConfiguration"`
+			}{})
+			return
+		case q.Has("object-lock"):
+			h.writeError(w, r, errFSNoObjectLock)
+			return
+		case q.Has("versions"):
+			h.listObjects(w, r, bucket, q, true)
+			return
+		case q.Get("list-type") == "2", fsListV1(q):
+			h.listObjects(w, r, bucket, q, false)
+			return
+		}
+	}
+	h.writeError(w, r, errFSNotImplemented)
+}
+
+// fsListV1 returns whether q is a version 1 listing,
+// which has no subresource and only listing parameters.
+func fsListV1(q url.Values) bool {
+	for k := range q {
+		switch k {
+		case "prefix", "delimiter", "marker", "max-keys", "encoding-type":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fsListEntry is an object or common prefix in a listing.
+type fsListEntry struct {
+	key    string
+	fi     fs.FileInfo
+	prefix bool
+}
+
+// listKeys returns up to limit+1 objects in bucket starting with prefix
+// and sorting after the key after, in lexical order.
+// If delimiter is set, keys containing it after the prefix are returned as prefixes.
+// Directories are read in key order, so listing stops when enough keys have been found.
+func (h *fsHandler) listKeys(bucket, prefix, delimiter, after string, limit int) ([]fsListEntry, error) {
+	if !fsValidPrefix(prefix) {
+		return nil, errFSInvalidKey
+	}
+	l := fsLister{prefix: prefix, delimiter: delimiter, after: after, limit: limit}
+	// Only walk the directory containing the prefix.
+	start, rel := h.bucketPath(bucket), ""
+	if i := strings.LastIndexByte(prefix, '/'); i >= 0 {
+		start = filepath.Join(start, filepath.FromSlash(prefix[:i]))
+		rel = prefix[:i+1]
+	}
+	err := l.walk(start, rel)
+	if err == errFSListDone {
+		err = nil
+	}
+	return l.res, err
+}
+
+// errFSListDone stops a listing when enough entries have been found.
+var errFSListDone = errors.New("listing done")
+
+// fsLister collects the entries of a listing.
+type fsLister struct {
+	prefix, delimiter, after string
+	limit                    int
+	res                      []fsListEntry
+	// lastPrefix is the last common prefix found, including those sorting before after.
+	// Keys starting with it are skipped.
+	lastPrefix string
+}
+
+// fsEntryKey returns the name of d as part of a key.
+// Directory names end with '/', so they sort like the keys in them.
+func fsEntryKey(d fs.DirEntry) string {
+	if d.IsDir() {
+		return d.Name() + "/"
+	}
+	return d.Name()
+}
+
+// walk adds the entries in dir in key order. rel is the key prefix of dir.
+func (l *fsLister) walk(dir, rel string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed while listing.
+			return nil
+		}
+		return err
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(fsEntryKey(a), fsEntryKey(b))
+	})
+	for _, d := range entries {
+		if l.lastPrefix != "" && strings.HasPrefix(rel, l.lastPrefix) {
+			// The rest of the directory is part of the same common prefix.
+			return nil
+		}
+		key := rel + fsEntryKey(d)
+		if d.IsDir() {
+			if !strings.HasPrefix(key, l.prefix) && !strings.HasPrefix(l.prefix, key) {
+				continue
+			}
+			if key < l.after && !strings.HasPrefix(l.after, key) {
+				// All keys in the directory sort before after.
+				continue
+			}
+			if err := l.walk(filepath.Join(dir, d.Name()), key); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, l.prefix) {
+			continue
+		}
+		if l.delimiter != "" {
+			if i := strings.Index(key[len(l.prefix):], l.delimiter); i >= 0 {
+				cp := key[:len(l.prefix)+i+len(l.delimiter)]
+				if cp == l.lastPrefix {
+					continue
+				}
+				l.lastPrefix = cp
+				if cp <= l.after {
+					continue
+				}
+				if err := l.add(fsListEntry{key: cp, prefix: true}); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if key <= l.after {
+			continue
+		}
+		fi, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// Deleted while listing.
+				continue
+			}
+			return err
+		}
+		if err := l.add(fsListEntry{key: key, fi: fi}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds e to the listing and returns errFSListDone when more than limit entries have been found.
+func (l *fsLister) add(e fsListEntry) error {
+	l.res = append(l.res, e)
+	if len(l.res) > l.limit {
+		return errFSListDone
+	}
+	return nil
+}
+
+func (h *fsHandler) listObjects(w http.ResponseWriter, r *http.Request, bucket string, q url.Values, versions bool) {
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(q.Get("max-keys")); err == nil && n >= 0 && n < maxKeys {
+		maxKeys = n
+	}
+	v1 := q.Get("list-type") != "2"
+	after := q.Get("start-after")
+	if t := q.Get("continuation-token"); t != "" {
+		after = t
+	}
+	if v1 {
+		after = q.Get("marker")
+	}
+	if versions {
+		after = q.Get("key-marker")
+	}
+	entries, err := h.listKeys(bucket, prefix, delimiter, after, maxKeys)
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	truncated := len(entries) > maxKeys
+	if truncated {
+		entries = entries[:maxKeys]
+	}
+	next, nextVersion := "", ""
+	if truncated && len(entries) > 0 {
+		next = entries[len(entries)-1].key
+		nextVersion = "null"
+	}
+
+	type object struct {
+		Key          string
+		VersionID    string `xml:"VersionId,omitempty"`
+		IsLatest     bool   `xml:",omitempty"`
+		LastModified string
+		ETag         string
+		Size         int64
+		StorageClass string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	var objects []object
+	var cps []commonPrefix
+	for _, e := range entries {
+		if e.prefix {
+			cps = append(cps, commonPrefix{Prefix: e.key})
+			continue
+		}
+		o := object{
+			Key:          e.key,
+			LastModified: e.fi.ModTime().UTC().Format(time.RFC3339Nano),
+			ETag:         `"` + fsETag(e.fi) + `"`,
+			Size:         e.fi.Size(),
+			StorageClass: "STANDARD",
+		}
+		if versions {
+			o.VersionID = "null"
+			o.IsLatest = true
+		}
+		objects = append(objects, o)
+	}
+	if versions {
+		h.writeXML(w, struct {
+			XMLName             xml.Name       `xml:"ListVersionsResult"`
+			Name                string         `xml:"Name"`
+			Prefix              string         `xml:"Prefix"`
+			Delimiter           string         `xml:"Delimiter,omitempty"`
+			MaxKeys             int            `xml:"MaxKeys"`
+			IsTruncated         bool           `xml:"IsTruncated"`
+			NextKeyMarker       string         `xml:"NextKeyMarker,omitempty"`
+			NextVersionIDMarker string         `xml:"NextVersionIdMarker,omitempty"`
+			Versions            []object       `xml:"Version"`
+			CommonPrefixes      []commonPrefix `xml:"CommonPrefixes"`
+		}{
+			Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys, IsTruncated: truncated,
+			NextKeyMarker: next, NextVersionIDMarker: nextVersion,
+			Versions: objects, CommonPrefixes: cps,
+		})
+		return
+	}
+	// Version 1 listings only return the next marker with a delimiter,
+	// otherwise the last key is the marker.
+	marker, nextMarker := "", ""
+	if v1 {
+		marker = after
+		if delimiter != "" {
+			nextMarker = next
+		}
+		next = ""
+	}
+	h.writeXML(w, struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		KeyCount              int            `xml:"KeyCount"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		Marker                string         `xml:"Marker,omitempty"`
+		NextMarker            string         `xml:"NextMarker,omitempty"`
+		Contents              []object       `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys, KeyCount: len(entries),
+		IsTruncated: truncated, ContinuationToken: q.Get("continuation-token"), NextContinuationToken: next,
+		Marker: marker, NextMarker: nextMarker,
+		Contents: objects, CommonPrefixes: cps,
+	})
+}
+
+func (h *fsHandler) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	var req struct {
+		Quiet   bool
+		Objects []struct {
+			Key string
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, s3Error{Code: "MalformedXML", Message: err.Error(), status: http.StatusBadRequest})
+		return
+	}
+	type deleted struct {
+		Key string
+	}
+	type deleteError struct {
+		Key     string
+		Code    string
+		Message string
+	}
+	var res struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Deleted []deleted     `xml:"Deleted"`
+		Errors  []deleteError `xml:"Error"`
+	}
+	for _, o := range req.Objects {
+		err := h.removeObject(bucket, o.Key)
+		if err != nil {
+			res.Errors = append(res.Errors, deleteError{Key: o.Key, Code: "InternalError", Message: err.Error()})
+			continue
+		}
+		if !req.Quiet {
+			res.Deleted = append(res.Deleted, deleted{Key: o.Key})
+		}
+	}
+	h.writeXML(w, res)
+}
+
+// removeObject removes an object and any directories left empty by it.
+// Removing an object that does not exist is not an error.
+func (h *fsHandler) removeObject(bucket, key string) error {
+	if !fsValidKey(key) {
+		return errFSInvalidKey
+	}
+	p := h.objectPath(bucket, key)
+	err := os.Remove(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	bucketDir := h.bucketPath(bucket)
+	for dir := filepath.Dir(p); dir != bucketDir && strings.HasPrefix(dir, bucketDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (h *fsHandler) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string, q url.Values) {
+	if err := h.checkBucket(bucket); err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	uploadID := q.Get("uploadId")
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if len(q) > 0 && !q.Has("versionId") && !q.Has("partNumber") {
+			break
+		}
+		h.getObject(w, r, bucket, key)
+		return
+	case http.MethodPut:
+		switch {
+		case uploadID != "":
+			h.putPart(w, r, uploadID, q)
+			return
+		case len(q) > 0:
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			h.copyObject(w, r, bucket, key)
+			return
+		default:
+			h.putObject(w, r, bucket, key)
+			return
+		}
+	case http.MethodPost:
+		switch {
+		case q.Has("uploads"):
+			h.newUpload(w, r, bucket, key)
+			return
+		case uploadID != "":
+			h.completeUpload(w, r, bucket, key, uploadID)
+			return
+		}
+	case http.MethodDelete:
+		switch {
+		case uploadID != "":
+			if err := os.RemoveAll(h.uploadPath(uploadID)); err != nil {
+				h.writeErr(w, r, err, errFSNoSuchUpload)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case len(q) > 0 && !q.Has("versionId"):
+		default:
+			if err := h.removeObject(bucket, key); err != nil {
+				h.writeErr(w, r, err, errFSNoSuchKey)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	h.writeError(w, r, errFSNotImplemented)
+}
+
+func (h *fsHandler) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	f, err := os.Open(h.objectPath(bucket, key))
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchKey)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchKey)
+		return
+	}
+	if fi.IsDir() {
+		h.writeError(w, r, errFSNoSuchKey)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", `"`+fsETag(fi)+`"`)
+	w.Header().Set("Accept-Ranges", "bytes")
+	// Handles ranges, conditional requests and HEAD.
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
+
+// writeFile writes r to the object bucket/key.
+// Data is written to a temporary file, which is renamed when complete.
+func (h *fsHandler) writeFile(bucket, key string, r io.Reader) (fs.FileInfo, error) {
+	tmpDir := filepath.Join(h.root, fsMetaDir, "tmp")
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(tmpDir, "obj-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if r != nil {
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	dst := h.objectPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(f.Name(), dst); err != nil {
+		return nil, err
+	}
+	return os.Stat(dst)
+}
+
+func (h *fsHandler) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	fi, err := h.writeFile(bucket, key, r.Body)
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	w.Header().Set("ETag", `"`+fsETag(fi)+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *fsHandler) copyObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	src := strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/")
+	if i := strings.Index(src, "?"); i >= 0 {
+		src = src[:i]
+	}
+	srcBucket, srcKey, _ := strings.Cut(src, "/")
+	if unescaped, err := url.PathUnescape(srcKey); err == nil {
+		srcKey = unescaped
+	}
+	if err := h.checkBucket(srcBucket); err != nil || !fsValidKey(srcKey) {
+		h.writeError(w, r, errFSNoSuchKey)
+		return
+	}
+	f, err := os.Open(h.objectPath(srcBucket, srcKey))
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchKey)
+		return
+	}
+	defer f.Close()
+	fi, err := h.writeFile(bucket, key, f)
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	h.writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		LastModified string
+		ETag         string
+	}{LastModified: fi.ModTime().UTC().Format(time.RFC3339Nano), ETag: `"` + fsETag(fi) + `"`})
+}
+
+func (h *fsHandler) uploadPath(uploadID string) string {
+	return filepath.Join(h.root, fsMetaDir, "uploads", filepath.Base(uploadID))
+}
+
+func (h *fsHandler) newUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	var id [16]byte
+	rand.Read(id[:])
+	uploadID := hex.EncodeToString(id[:])
+	if err := os.MkdirAll(h.uploadPath(uploadID), 0o755); err != nil {
+		h.writeErr(w, r, err, errFSNoSuchBucket)
+		return
+	}
+	h.writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadID string `xml:"UploadId"`
+	}{Bucket: bucket, Key: key, UploadID: uploadID})
+}
+
+func (h *fsHandler) putPart(w http.ResponseWriter, r *http.Request, uploadID string, q url.Values) {
+	n, _ := strconv.Atoi(q.Get("partNumber"))
+	if n < 1 || n > 10000 {
+		h.writeError(w, r, s3Error{Code: "InvalidArgument", Message: "Part number must be an integer between 1 and 10000", status: http.StatusBadRequest})
+		return
+	}
+	dir := h.uploadPath(uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	f, err := os.CreateTemp(dir, "part-")
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	_, err = io.Copy(f, r.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	part := filepath.Join(dir, strconv.Itoa(n))
+	if err == nil {
+		err = os.Rename(f.Name(), part)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	fi, err := os.Stat(part)
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	w.Header().Set("ETag", `"`+fsETag(fi)+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *fsHandler) completeUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
+	var req struct {
+		Parts []struct {
+			PartNumber int
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, s3Error{Code: "MalformedXML", Message: err.Error(), status: http.StatusBadRequest})
+		return
+	}
+	dir := h.uploadPath(uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		for _, p := range req.Parts {
+			f, err := os.Open(filepath.Join(dir, strconv.Itoa(p.PartNumber)))
+			if err != nil {
+				pw.CloseWithError(s3Error{Code: "InvalidPart", Message: err.Error(), status: http.StatusBadRequest})
+				return
+			}
+			_, err = io.Copy(pw, f)
+			f.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+	fi, err := h.writeFile(bucket, key, pr)
+	pr.Close()
+	if err != nil {
+		h.writeErr(w, r, err, errFSNoSuchUpload)
+		return
+	}
+	os.RemoveAll(dir)
+	h.writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: bucket, Key: key, ETag: fmt.Sprintf(`"%s-%d"`, fsETag(fi), len(req.Parts))})
+}
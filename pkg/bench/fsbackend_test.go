@@ -0,0 +1,297 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newFSTestClient returns a client serving requests from a temporary directory
+// and the directory. The bucket "bucket" is created.
+func newFSTestClient(t *testing.T) (*minio.Client, string) {
+	t.Helper()
+	root := t.TempDir()
+	cl, err := minio.New("fs", &minio.Options{
+		Creds:        credentials.NewStatic("", "", "", credentials.SignatureAnonymous),
+		BucketLookup: minio.BucketLookupPath,
+		Transport:    FSTransport(root),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.MakeBucket(context.Background(), "bucket", minio.MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return cl, root
+}
+
+func TestFSBackendObject(t *testing.T) {
+	cl, root := newFSTestClient(t)
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("warp"), 1000)
+	info, err := cl.PutObject(ctx, "bucket", "dir/obj", bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(root, "bucket", "dir", "obj")); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("file content mismatch, err: %v", err)
+	}
+
+	st, err := cl.StatObject(ctx, "bucket", "dir/obj", minio.StatObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Size != int64(len(data)) || st.ETag != info.ETag {
+		t.Errorf("stat: got size %d, etag %q, want %d, %q", st.Size, st.ETag, len(data), info.ETag)
+	}
+
+	o, err := cl.GetObject(ctx, "bucket", "dir/obj", minio.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(o)
+	o.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("get: content mismatch, err: %v", err)
+	}
+
+	opts := minio.GetObjectOptions{}
+	opts.SetRange(4, 11)
+	o, err = cl.GetObject(ctx, "bucket", "dir/obj", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = io.ReadAll(o)
+	o.Close()
+	if err != nil || !bytes.Equal(got, data[4:12]) {
+		t.Fatalf("range get: got %q, err: %v", got, err)
+	}
+
+	if err := cl.RemoveObject(ctx, "bucket", "dir/obj", minio.RemoveObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cl.StatObject(ctx, "bucket", "dir/obj", minio.StatObjectOptions{})
+	if code := minio.ToErrorResponse(err).StatusCode; code != http.StatusNotFound {
+		t.Errorf("stat after delete: got status %d, err: %v", code, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "bucket", "dir")); !os.IsNotExist(err) {
+		t.Errorf("empty directory was not removed: %v", err)
+	}
+	_, err = cl.StatObject(ctx, "nobucket", "obj", minio.StatObjectOptions{})
+	if code := minio.ToErrorResponse(err).StatusCode; code != http.StatusNotFound {
+		t.Errorf("stat in missing bucket: got status %d, err: %v", code, err)
+	}
+}
+
+func TestFSBackendMultipart(t *testing.T) {
+	cl, root := newFSTestClient(t)
+	ctx := context.Background()
+	core := minio.Core{Client: cl}
+	id, err := core.NewMultipartUpload(ctx, "bucket", "mp", minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := [][]byte{[]byte("first part,"), []byte("second part,"), []byte("last")}
+	var complete []minio.CompletePart
+	// Upload out of order.
+	for _, n := range []int{3, 1, 2} {
+		p := parts[n-1]
+		res, err := core.PutObjectPart(ctx, "bucket", "mp", id, n, bytes.NewReader(p), int64(len(p)), minio.PutObjectPartOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		complete = append(complete, minio.CompletePart{PartNumber: n, ETag: res.ETag})
+	}
+	complete[0], complete[1], complete[2] = complete[1], complete[2], complete[0]
+	if _, err := core.CompleteMultipartUpload(ctx, "bucket", "mp", id, complete, minio.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "bucket", "mp"))
+	if want := bytes.Join(parts, nil); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q, err: %v", got, want, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, fsMetaDir, "uploads", id)); !os.IsNotExist(err) {
+		t.Errorf("upload directory was not removed: %v", err)
+	}
+
+	// Aborted uploads are removed and can no longer be used.
+	id, err = core.NewMultipartUpload(ctx, "bucket", "aborted", minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := core.AbortMultipartUpload(ctx, "bucket", "aborted", id); err != nil {
+		t.Fatal(err)
+	}
+	_, err = core.PutObjectPart(ctx, "bucket", "aborted", id, 1, strings.NewReader("x"), 1, minio.PutObjectPartOptions{})
+	if code := minio.ToErrorResponse(err).Code; code != "NoSuchUpload" {
+		t.Errorf("part after abort: got code %q, err: %v", code, err)
+	}
+}
+
+func TestFSBackendList(t *testing.T) {
+	cl, _ := newFSTestClient(t)
+	ctx := context.Background()
+	// "a-1" sorts before the keys in directory "a".
+	keys := []string{"a-1", "a/1", "a/2", "a/sub/3", "b/1", "c"}
+	for _, k := range keys {
+		if _, err := cl.PutObject(ctx, "bucket", k, strings.NewReader(k), int64(len(k)), minio.PutObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(opts minio.ListObjectsOptions) []string {
+		t.Helper()
+		var res []string
+		for o := range cl.ListObjects(ctx, "bucket", opts) {
+			if o.Err != nil {
+				t.Fatal(o.Err)
+			}
+			res = append(res, o.Key)
+		}
+		return res
+	}
+	tests := []struct {
+		opts minio.ListObjectsOptions
+		want []string
+	}{
+		{opts: minio.ListObjectsOptions{Recursive: true}, want: keys},
+		{opts: minio.ListObjectsOptions{Prefix: "a/", Recursive: true}, want: []string{"a/1", "a/2", "a/sub/3"}},
+		// Objects are returned before prefixes.
+		{opts: minio.ListObjectsOptions{}, want: []string{"a-1", "c", "a/", "b/"}},
+		{opts: minio.ListObjectsOptions{Prefix: "a/"}, want: []string{"a/1", "a/2", "a/sub/"}},
+		// Paging with a single key per request.
+		{opts: minio.ListObjectsOptions{Recursive: true, MaxKeys: 1}, want: keys},
+		{opts: minio.ListObjectsOptions{MaxKeys: 1}, want: []string{"a-1", "a/", "b/", "c"}},
+		{opts: minio.ListObjectsOptions{Prefix: "a/", MaxKeys: 2}, want: []string{"a/1", "a/2", "a/sub/"}},
+		{opts: minio.ListObjectsOptions{Recursive: true, StartAfter: "a/"}, want: []string{"a/1", "a/2", "a/sub/3", "b/1", "c"}},
+		{opts: minio.ListObjectsOptions{Recursive: true, StartAfter: "a/sub/3"}, want: []string{"b/1", "c"}},
+		{opts: minio.ListObjectsOptions{Recursive: true, WithVersions: true}, want: keys},
+	}
+	for _, test := range tests {
+		if got := list(test.opts); strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%+v: got %v, want %v", test.opts, got, test.want)
+		}
+	}
+}
+
+func TestFSBackendInvalidKeys(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "bucket"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A file outside the bucket that must not be reachable.
+	if err := os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := &fsHandler{root: root}
+	for _, path := range []string{
+		"/bucket/../secret",
+		"/bucket/a/../../secret",
+		"/bucket/a//b",
+		"/bucket/a/",
+		"/bucket/./a",
+		`/bucket/a\b`,
+		"/../secret",
+		"/./secret",
+	} {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			req := httptest.NewRequest(method, "http://fs/", strings.NewReader("data"))
+			req.URL.Path = path
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code < 400 {
+				t.Errorf("%s %s: got status %d", method, path, w.Code)
+			}
+		}
+	}
+	// Listing prefixes must not walk outside the bucket.
+	for _, prefix := range []string{"../", "..", "a/../../", "./", "a//b", `a\b/`} {
+		for _, query := range []string{"list-type=2&prefix=", "versions&prefix=", "prefix="} {
+			req := httptest.NewRequest(http.MethodGet, "http://fs/bucket?"+query+url.QueryEscape(prefix), nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code < 400 {
+				t.Errorf("list %q: got status %d", prefix, w.Code)
+			}
+		}
+	}
+	if got, err := os.ReadFile(filepath.Join(root, "secret")); err != nil || string(got) != "secret" {
+		t.Errorf("file outside bucket was modified: %q, %v", got, err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "bucket"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("objects were created for invalid keys: %v, %v", entries, err)
+	}
+}
+
+func TestFSBackendMetaDir(t *testing.T) {
+	cl, root := newFSTestClient(t)
+	ctx := context.Background()
+	core := minio.Core{Client: cl}
+	// Create the meta directory with temporary files and an upload.
+	if _, err := cl.PutObject(ctx, "bucket", "obj", strings.NewReader("x"), 1, minio.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.NewMultipartUpload(ctx, "bucket", "mp", minio.PutObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, fsMetaDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, err := cl.ListBuckets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || buckets[0].Name != "bucket" {
+		t.Errorf("got buckets %v, want only bucket", buckets)
+	}
+
+	h := &fsHandler{root: root}
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "http://fs/"+fsMetaDir, nil),
+		httptest.NewRequest(http.MethodHead, "http://fs/"+fsMetaDir, nil),
+		httptest.NewRequest(http.MethodGet, "http://fs/"+fsMetaDir+"?list-type=2", nil),
+		httptest.NewRequest(http.MethodDelete, "http://fs/"+fsMetaDir, nil),
+		httptest.NewRequest(http.MethodGet, "http://fs/"+fsMetaDir+"/tmp", nil),
+		httptest.NewRequest(http.MethodPut, "http://fs/"+fsMetaDir+"/obj", strings.NewReader("x")),
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code < 400 {
+			t.Errorf("%s %s: got status %d", req.Method, req.URL, w.Code)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, fsMetaDir)); err != nil {
+		t.Errorf("meta directory was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, fsMetaDir, "obj")); !os.IsNotExist(err) {
+		t.Errorf("object was written to the meta directory: %v", err)
+	}
+}
@@ -0,0 +1,536 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// gcsClient is a native client for the Google Cloud Storage XML API.
+// Objects are uploaded with a single request, regardless of the part size.
+type gcsClient struct {
+	endpoint *url.URL
+	rt       http.RoundTripper
+	project  string
+	token    *gcsToken
+}
+
+func newGCSClient(endpoint *url.URL, project, secret string, rt http.RoundTripper) (*gcsClient, error) {
+	tok, err := gcsTokenFor(secret)
+	if err != nil {
+		return nil, err
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &gcsClient{endpoint: endpoint, rt: rt, project: project, token: tok}, nil
+}
+
+// EndpointURL implements ObjectClient.
+func (c *gcsClient) EndpointURL() *url.URL {
+	return c.endpoint
+}
+
+// do sends an authorized request for path.
+// A size of -1 sends the body with chunked transfer encoding.
+func (c *gcsClient) do(ctx context.Context, method, path string, q url.Values, h http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := *c.endpoint
+	// Keep the path of the endpoint, for example the account of an emulator.
+	u.Path, u.RawPath, u.RawQuery = strings.TrimSuffix(c.endpoint.Path, "/")+path, "", q.Encode()
+	if body == nil || size == 0 {
+		body = http.NoBody
+	}
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if h != nil {
+		r.Header = h
+	}
+	r.ContentLength = size
+	tok, err := c.token.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Authorization", "Bearer "+tok)
+	return c.rt.RoundTrip(r)
+}
+
+// error returns the error of a failed request.
+// The response body is closed.
+func (c *gcsClient) error(resp *http.Response, bucket, object string) error {
+	defer resp.Body.Close()
+	var e struct {
+		Code    string
+		Message string
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&e)
+	return providerError(resp, e.Code, e.Message, "", bucket, object)
+}
+
+// BucketExists implements ObjectClient.
+func (c *gcsClient) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/"+bucket, nil, nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		resp.Body.Close()
+		return true, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return false, nil
+	}
+	return false, c.error(resp, bucket, "")
+}
+
+// MakeBucket implements ObjectClient.
+// Buckets are created in the project given as access key.
+func (c *gcsClient) MakeBucket(ctx context.Context, bucket string, opts minio.MakeBucketOptions) error {
+	h := make(http.Header)
+	if c.project != "" {
+		h.Set("x-goog-project-id", c.project)
+	}
+	var body bytes.Buffer
+	if opts.Region != "" {
+		fmt.Fprintf(&body, "<CreateBucketConfiguration><LocationConstraint>%s</LocationConstraint></CreateBucketConfiguration>", opts.Region)
+	}
+	resp, err := c.do(ctx, http.MethodPut, "/"+bucket, nil, h, &body, int64(body.Len()))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.error(resp, bucket, "")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RemoveBucket implements ObjectClient.
+func (c *gcsClient) RemoveBucket(ctx context.Context, bucket string) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/"+bucket, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return c.error(resp, bucket, "")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PutObject implements ObjectClient.
+// Objects of unknown size are uploaded with chunked transfer encoding.
+// A CRC32C checksum in the user metadata is sent as x-goog-hash.
+func (c *gcsClient) PutObject(ctx context.Context, bucket, object string, r io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	h := make(http.Header)
+	for k, v := range opts.UserMetadata {
+		switch lk := strings.ToLower(k); {
+		case lk == "x-amz-checksum-crc32c":
+			h.Add("x-goog-hash", "crc32c="+v)
+		case strings.HasPrefix(lk, "x-amz-"):
+		default:
+			h.Set("x-goog-meta-"+k, v)
+		}
+	}
+	if opts.ContentType != "" {
+		h.Set("Content-Type", opts.ContentType)
+	}
+	if opts.StorageClass != "" {
+		h.Set("x-goog-storage-class", opts.StorageClass)
+	}
+	cr := &countingReader{r: r}
+	resp, err := c.do(ctx, http.MethodPut, "/"+bucket+"/"+object, nil, h, cr, size)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return minio.UploadInfo{}, c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	mod, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return minio.UploadInfo{
+		Bucket:       bucket,
+		Key:          object,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		Size:         cr.n,
+		LastModified: mod,
+	}, nil
+}
+
+// GetObject implements ObjectClient.
+func (c *gcsClient) GetObject(ctx context.Context, bucket, object string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/"+bucket+"/"+object, nil, providerReadHeaders(opts.Header()), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, c.error(resp, bucket, object)
+	}
+	return resp.Body, nil
+}
+
+// StatObject implements ObjectClient.
+func (c *gcsClient) StatObject(ctx context.Context, bucket, object string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/"+bucket+"/"+object, nil, providerReadHeaders(opts.Header()), nil, 0)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return minio.ObjectInfo{}, c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return providerObjectInfo(resp.Header, object, "X-Goog-Meta-"), nil
+}
+
+// RemoveObject implements ObjectClient.
+// Deleting an object that does not exist succeeds, like on S3.
+func (c *gcsClient) RemoveObject(ctx context.Context, bucket, object string, _ minio.RemoveObjectOptions) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/"+bucket+"/"+object, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return c.error(resp, bucket, object)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RemoveObjects implements ObjectClient.
+// The XML API has no multi-object delete, so objects are deleted one by one.
+func (c *gcsClient) RemoveObjects(ctx context.Context, bucket string, objects <-chan minio.ObjectInfo, _ minio.RemoveObjectsOptions) <-chan minio.RemoveObjectError {
+	return removeEach(objects, func(key string) error {
+		return c.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{})
+	})
+}
+
+// ListObjects implements ObjectClient.
+func (c *gcsClient) ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	q := make(url.Values)
+	if opts.Prefix != "" {
+		q.Set("prefix", opts.Prefix)
+	}
+	if !opts.Recursive {
+		q.Set("delimiter", "/")
+	}
+	if opts.MaxKeys > 0 {
+		q.Set("max-keys", strconv.Itoa(opts.MaxKeys))
+	}
+	return listPages(ctx, func(marker string) ([]minio.ObjectInfo, string, error) {
+		q.Del("marker")
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		resp, err := c.do(ctx, http.MethodGet, "/"+bucket, q, nil, nil, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", c.error(resp, bucket, "")
+		}
+		defer resp.Body.Close()
+		var res struct {
+			Contents []struct {
+				Key          string
+				LastModified time.Time
+				ETag         string
+				Size         int64
+			}
+			CommonPrefixes []struct {
+				Prefix string
+			}
+			IsTruncated bool
+			NextMarker  string
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return nil, "", err
+		}
+		objs := make([]minio.ObjectInfo, 0, len(res.Contents)+len(res.CommonPrefixes))
+		last := ""
+		for _, o := range res.Contents {
+			objs = append(objs, minio.ObjectInfo{
+				Key:          o.Key,
+				Size:         o.Size,
+				ETag:         strings.Trim(o.ETag, `"`),
+				LastModified: o.LastModified,
+			})
+			last = max(last, o.Key)
+		}
+		for _, p := range res.CommonPrefixes {
+			objs = append(objs, minio.ObjectInfo{Key: p.Prefix})
+			last = max(last, p.Prefix)
+		}
+		if !res.IsTruncated {
+			return objs, "", nil
+		}
+		if res.NextMarker == "" {
+			// Only returned with a delimiter, continue after the last entry.
+			return objs, last, nil
+		}
+		return objs, res.NextMarker, nil
+	})
+}
+
+// gcsToken provides OAuth2 access tokens.
+// Either a fixed token is used, or tokens are read from a file or requested
+// for a service account or authorized user and refreshed before they expire.
+type gcsToken struct {
+	// refresh returns a new token and when it expires.
+	// If nil the token is fixed.
+	refresh func(ctx context.Context) (string, time.Time, error)
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// gcsKeyFile is the part of a credentials file used for requesting tokens.
+// Service account key files and authorized user files,
+// as written by "gcloud auth application-default login", are supported.
+type gcsKeyFile struct {
+	Type     string `json:"type"`
+	TokenURI string `json:"token_uri"`
+
+	// Service accounts.
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+
+	// Authorized users.
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+
+	key *rsa.PrivateKey
+}
+
+// gcsTokenFileInterval is how often a token file is read again.
+const gcsTokenFileInterval = time.Minute
+
+// gcsTokenClient sends token requests.
+// It is separate from the benchmark transport, so token requests are not recorded.
+var gcsTokenClient = &http.Client{Timeout: time.Minute}
+
+var (
+	gcsTokensMu sync.Mutex
+	gcsTokens   = make(map[string]*gcsToken)
+)
+
+// gcsTokenFor returns the token source for secret, which is shared by all clients.
+// secret is an access token, a file containing an access token or a JSON credentials file.
+func gcsTokenFor(secret string) (*gcsToken, error) {
+	gcsTokensMu.Lock()
+	defer gcsTokensMu.Unlock()
+	if tok, ok := gcsTokens[secret]; ok {
+		return tok, nil
+	}
+	if secret == "" {
+		return nil, errors.New("gcs: access token or credentials file required as secret key")
+	}
+	tok := &gcsToken{token: secret}
+	if st, err := os.Stat(secret); err == nil && !st.IsDir() {
+		refresh, err := gcsFileToken(secret)
+		if err != nil {
+			return nil, err
+		}
+		tok = &gcsToken{refresh: refresh}
+	}
+	gcsTokens[secret] = tok
+	return tok, nil
+}
+
+// GCSFixedToken returns whether secret is an access token that cannot be refreshed.
+func GCSFixedToken(secret string) bool {
+	st, err := os.Stat(secret)
+	return secret != "" && (err != nil || st.IsDir())
+}
+
+// gcsFileToken returns a function providing tokens for the credentials in file.
+func gcsFileToken(file string) (func(ctx context.Context) (string, time.Time, error), error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		// A file with an access token, which is updated by another process.
+		readToken := func(context.Context) (string, time.Time, error) {
+			b, err := os.ReadFile(file)
+			tok := strings.TrimSpace(string(b))
+			if err == nil && tok == "" {
+				err = fmt.Errorf("gcs: no access token in %s", file)
+			}
+			return tok, time.Now().Add(gcsTokenFileInterval + time.Minute), err
+		}
+		if _, _, err := readToken(context.Background()); err != nil {
+			return nil, err
+		}
+		return readToken, nil
+	}
+	var kf gcsKeyFile
+	if err := json.Unmarshal(b, &kf); err != nil {
+		return nil, fmt.Errorf("gcs: reading credentials file %s: %w", file, err)
+	}
+	if kf.TokenURI == "" {
+		kf.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	switch kf.Type {
+	case "service_account":
+		if kf.ClientEmail == "" {
+			return nil, fmt.Errorf("gcs: no client email in %s", file)
+		}
+		if err := kf.parseKey(); err != nil {
+			return nil, fmt.Errorf("gcs: %s: %w", file, err)
+		}
+		return kf.serviceAccountToken, nil
+	case "authorized_user":
+		if kf.ClientID == "" || kf.RefreshToken == "" {
+			return nil, fmt.Errorf("gcs: no client ID or refresh token in %s", file)
+		}
+		return kf.authorizedUserToken, nil
+	}
+	return nil, fmt.Errorf("gcs: %s is not a service account or authorized user credentials file", file)
+}
+
+// parseKey parses the private key of a service account.
+func (kf *gcsKeyFile) parseKey() error {
+	block, _ := pem.Decode([]byte(kf.PrivateKey))
+	if block == nil {
+		return errors.New("no private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing private key: %w", err)
+	}
+	var ok bool
+	kf.key, ok = key.(*rsa.PrivateKey)
+	if !ok {
+		return errors.New("private key is not an RSA key")
+	}
+	return nil
+}
+
+// get returns a valid access token.
+// Tokens are refreshed when they expire within a minute.
+func (t *gcsToken) get(ctx context.Context) (string, error) {
+	if t.refresh == nil {
+		return t.token, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Until(t.expires) > time.Minute {
+		return t.token, nil
+	}
+	tok, expires, err := t.refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token, t.expires = tok, expires
+	return tok, nil
+}
+
+// serviceAccountToken exchanges a signed JWT for an access token.
+// See https://developers.google.com/identity/protocols/oauth2/service-account#authorizingrequests
+func (kf *gcsKeyFile) serviceAccountToken(ctx context.Context) (string, time.Time, error) {
+	now := time.Now()
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	unsigned := enc(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kf.PrivateKeyID}) + "." + enc(map[string]any{
+		"iss":   kf.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/devstorage.full_control",
+		"aud":   kf.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(nil, kf.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return kf.requestToken(ctx, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	})
+}
+
+// authorizedUserToken exchanges the refresh token of a user for an access token.
+func (kf *gcsKeyFile) authorizedUserToken(ctx context.Context) (string, time.Time, error) {
+	return kf.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {kf.ClientID},
+		"client_secret": {kf.ClientSecret},
+		"refresh_token": {kf.RefreshToken},
+	})
+}
+
+// requestToken requests an access token from the token URI.
+func (kf *gcsKeyFile) requestToken(ctx context.Context, form url.Values) (string, time.Time, error) {
+	now := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, kf.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := gcsTokenClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("gcs: requesting access token: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return "", time.Time{}, fmt.Errorf("gcs: requesting access token: %w", err)
+	}
+	return res.AccessToken, now.Add(time.Duration(res.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,50 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/warp/pkg/generator"
+)
+
+// genTimedReader keeps track of time spent reading generated data.
+type genTimedReader struct {
+	io.ReadSeeker
+	d *atomic.Int64
+}
+
+func (g genTimedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := g.ReadSeeker.Read(p)
+	g.d.Add(int64(time.Since(start)))
+	return n, err
+}
+
+// timeGeneration replaces the reader of obj with one that records
+// the time spent generating data.
+// The returned function returns the time spent so far.
+func timeGeneration(obj *generator.Object) func() time.Duration {
+	var d atomic.Int64
+	obj.Reader = genTimedReader{ReadSeeker: obj.Reader, d: &d}
+	return func() time.Duration {
+		return time.Duration(d.Load())
+	}
+}
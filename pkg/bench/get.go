@@ -21,7 +21,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"sync"
 	"time"
@@ -46,6 +45,22 @@ type Get struct {
 	RangeSize     int64
 	ListExisting  bool
 	ListFlat      bool
+
+	// RandomRangeLen will use random range lengths up to RangeSize, if set.
+	RandomRangeLen bool
+
+	// ReadFraction will abort downloads after this fraction of the object or range has been read.
+	// Values <= 0 or >= 1 will read everything.
+	ReadFraction float64
+
+	// Verify will check the content of full object downloads.
+	Verify bool
+
+	// Access is the distribution of objects read.
+	Access AccessDist
+
+	// Manifest, if set, contains the objects to read instead of uploading.
+	Manifest generator.Objects
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -53,8 +68,21 @@ type Get struct {
 func (g *Get) Prepare(ctx context.Context) error {
 	// prepare the bench by listing object from the bucket
 	g.addCollector()
+	if len(g.Manifest) > 0 {
+		cl, done := g.objectClient()
+		defer done()
+		found, err := cl.BucketExists(ctx, g.Bucket)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("bucket %s does not exist and a manifest has been given", g.Bucket)
+		}
+		g.objects = g.Manifest
+		return nil
+	}
 	if g.ListExisting {
-		cl, done := g.Client()
+		cl, done := g.objectClient()
 
 		// ensure the bucket exist
 		found, err := cl.BucketExists(ctx, g.Bucket)
@@ -86,6 +114,7 @@ func (g *Get) Prepare(ctx context.Context) error {
 			obj := generator.Object{
 				Name: object.Key,
 				Size: object.Size,
+				ETag: object.ETag,
 			}
 
 			if g.Versions > 1 {
@@ -170,10 +199,10 @@ func (g *Get) Prepare(ctx context.Context) error {
 					// New input for each version
 					obj := src.Object()
 					obj.Name = name
-					client, cldone := g.Client()
+					client, cldone := g.objectClient()
 					op := Operation{
 						OpType:   http.MethodPut,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -182,30 +211,29 @@ func (g *Get) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, err := g.prepareUpload(ctx, client, obj, opts)
 					op.End = time.Now()
+					cldone()
 					if err != nil {
-						err := fmt.Errorf("upload error: %w", err)
 						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
+						if err := g.prepareFailed(err, g.CreateObjects*g.Versions); err != nil {
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
 						}
-						mu.Unlock()
-						return
+						continue
 					}
 					obj.VersionID = res.VersionID
-					if res.Size != obj.Size {
-						err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
+					obj.ETag = res.ETag
+					if g.Verify {
+						obj.Checksum, err = objectChecksum(obj.Reader)
+						if err != nil {
+							g.Error(err)
 						}
-						mu.Unlock()
-						return
 					}
-					cldone()
 					mu.Lock()
 					obj.Reader = nil
 					g.objects = append(g.objects, *obj)
@@ -217,6 +245,9 @@ func (g *Get) Prepare(ctx context.Context) error {
 		}(i, obj)
 	}
 	wg.Wait()
+	if groupErr == nil && len(g.objects) == 0 {
+		groupErr = fmt.Errorf("no objects uploaded")
+	}
 	return groupErr
 }
 
@@ -245,15 +276,15 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodGet, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, http.MethodGet, g.autoTermOpts())
 	}
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
+			next := g.Access.picker(rng, len(g.objects), i*len(g.objects)/g.Concurrency)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.GetOpts
@@ -267,16 +298,16 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
 				fbr := firstByteRecorder{}
-				obj := g.objects[rng.Intn(len(g.objects))]
-				client, cldone := g.Client()
+				obj := g.objects[next()]
+				client, cldone := g.threadObjectClient(i)
 				op := Operation{
 					OpType:   http.MethodGet,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -286,26 +317,38 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 					op.File = ""
 				}
 
-				if g.RandomRanges && op.Size > 2 {
+				ranged := g.RandomRanges && op.Size > 2
+				if ranged {
 					var start, end int64
-					if g.RangeSize <= 0 {
+					if g.RangeSize <= 0 || g.RandomRangeLen {
 						// Randomize length similar to --obj.randsize
-						size := generator.GetExpRandSize(rng, 0, op.Size-2)
+						maxSize := op.Size - 2
+						if g.RangeSize > 0 {
+							maxSize = min(maxSize, g.RangeSize-1)
+						}
+						size := min(generator.GetExpRandSize(rng, 0, maxSize), maxSize)
 						start = rng.Int63n(op.Size - size)
 						end = start + size
 					} else {
-						start = rng.Int63n(op.Size - g.RangeSize)
-						end = start + g.RangeSize - 1
+						size := min(g.RangeSize, op.Size)
+						start = rng.Int63n(op.Size - size + 1)
+						end = start + size - 1
 					}
 					op.Size = end - start + 1
+					op.Range = fmt.Sprintf("%d-%d", start, end)
 					opts.SetRange(start, end)
 				}
+				// Number of bytes to read before aborting the download.
+				readSize := op.Size
+				if g.ReadFraction > 0 && g.ReadFraction < 1 {
+					readSize = max(int64(float64(op.Size)*g.ReadFraction), 1)
+				}
 				op.Start = time.Now()
 				var err error
-				if g.Versions > 1 {
+				if g.Versions > 1 || len(g.Manifest) > 0 {
 					opts.VersionID = obj.VersionID
 				}
-				o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, opts)
+				o, err := client.GetObject(opContext(nonTerm, &op), g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
@@ -315,17 +358,47 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 					continue
 				}
 				fbr.r = o
-				n, err := io.Copy(io.Discard, &fbr)
+				var rd io.Reader = &fbr
+				var ct *cryptoTimer
+				if g.Encryption != nil {
+					rd, ct = g.Encryption.decrypt(rd)
+				}
+				var vf *verifier
+				if g.Verify && !ranged {
+					vf = newVerifier(obj, g.GetOpts.ServerSideEncryption == nil)
+				}
+				if vf != nil {
+					rd = io.TeeReader(rd, vf)
+				}
+				if readSize < op.Size {
+					rd = io.LimitReader(rd, readSize)
+				}
+				n, err := io.Copy(io.Discard, rd)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				if ct != nil {
+					op.CryptoTime = ct.Duration()
+				}
+				if readSize < op.Size {
+					// Only the bytes read are transferred.
+					op.Size = readSize
+				}
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
+					op.ErrClass = ErrClassValidation
 					g.Error(op.Err)
 				}
+				if vf != nil && op.Err == "" {
+					if err := vf.check(); err != nil {
+						op.Err = err.Error()
+						op.ErrClass = ErrClassValidation
+						g.Error(obj.Name, ": ", err)
+					}
+				}
 				rcv <- op
 				cldone()
 				o.Close()
@@ -336,9 +409,19 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 	return c.Close(), nil
 }
 
+// Rerun makes the benchmark ready to start again with the given concurrency.
+func (g *Get) Rerun(concurrency int, extra []chan<- Operation) {
+	g.rerun(concurrency, extra)
+}
+
+// PreparedObjects returns the objects that will be used for the benchmark.
+func (g *Get) PreparedObjects() generator.Objects {
+	return g.objects
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Get) Cleanup(ctx context.Context) {
-	if !g.ListExisting {
+	if !g.ListExisting && len(g.Manifest) == 0 {
 		g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
 	}
 }
@@ -0,0 +1,100 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/generator"
+)
+
+func TestGetRerun(t *testing.T) {
+	cl, _ := newFSTestClient(t)
+	src, err := generator.NewFn(generator.WithSize(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := Get{
+		Common: Common{
+			Bucket:      "bucket",
+			Client:      func() (*minio.Client, func()) { return cl, func() {} },
+			Error:       func(data ...interface{}) { t.Log(data...) },
+			Source:      src,
+			Concurrency: 4,
+			Clear:       true,
+		},
+		CreateObjects: 20,
+		Versions:      1,
+	}
+	if err := g.Prepare(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	run := func(concurrency int) {
+		t.Helper()
+		extra := make(chan Operation, 1000)
+		extraN := make(chan int)
+		go func() {
+			n := 0
+			for range extra {
+				n++
+			}
+			extraN <- n
+		}()
+		g.Rerun(concurrency, []chan<- Operation{extra})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		wait := make(chan struct{})
+		close(wait)
+		ops, err := g.Start(ctx, wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) == 0 {
+			t.Fatal("no operations")
+		}
+		threads := make(map[uint32]bool)
+		for _, op := range ops {
+			if op.Err != "" {
+				t.Fatal(op.Err)
+			}
+			threads[op.Thread] = true
+		}
+		if len(threads) != concurrency {
+			t.Errorf("concurrency %d: got %d threads", concurrency, len(threads))
+		}
+		if n := <-extraN; n != len(ops) {
+			t.Errorf("concurrency %d: got %d extra operations, want %d", concurrency, n, len(ops))
+		}
+	}
+	run(2)
+	run(4)
+
+	n := 0
+	for obj := range cl.ListObjects(context.Background(), "bucket", minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			t.Fatal(obj.Err)
+		}
+		n++
+	}
+	if n != g.CreateObjects {
+		t.Errorf("got %d objects after rerun, want %d", n, g.CreateObjects)
+	}
+}
@@ -0,0 +1,517 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+const (
+	// hdrSubBits is the number of bits used for sub-buckets.
+	// This gives a relative precision of 1/1024 for all recorded values.
+	hdrSubBits  = 10
+	hdrSubCount = 1 << hdrSubBits
+	// hdrMaxShift limits the recordable values to less than hdrMaxValue.
+	hdrMaxShift = 31
+	// hdrMaxValue is the lowest value that cannot be recorded exactly, 2^42.
+	// With values in microseconds this is about 51 days.
+	hdrMaxValue = int64(1) << (hdrMaxShift + hdrSubBits + 1)
+	// hdrMaxIndex is the index of the last bucket.
+	hdrMaxIndex = 2*hdrSubCount + hdrMaxShift*hdrSubCount - 1
+)
+
+// Histogram is a high dynamic range histogram with fixed precision.
+// Memory use grows with the highest recorded value and never exceeds 33792 counters, 264KiB.
+// Values of hdrMaxValue or more are saturated: they are counted in the last bucket
+// and reported by Saturated, while min, max and mean use the actual values.
+type Histogram struct {
+	counts    []uint64
+	n         uint64
+	saturated uint64
+	sum       float64
+	sumSq     float64
+	min, max  int64
+}
+
+func hdrIndex(v int64) int {
+	if v < 2*hdrSubCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - hdrSubBits - 1
+	if shift > hdrMaxShift {
+		return hdrMaxIndex
+	}
+	return 2*hdrSubCount + (shift-1)*hdrSubCount + int(v>>shift) - hdrSubCount
+}
+
+// hdrValue returns the mid-point value of the bucket at index idx.
+func hdrValue(idx int) int64 {
+	if idx < 2*hdrSubCount {
+		return int64(idx)
+	}
+	j := idx - 2*hdrSubCount
+	shift := j/hdrSubCount + 1
+	m := int64(j%hdrSubCount + hdrSubCount)
+	return m<<shift + (int64(1)<<shift)/2
+}
+
+// Record a value. Negative values are recorded as 0.
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	if v >= hdrMaxValue {
+		h.saturated++
+	}
+	idx := hdrIndex(v)
+	if idx >= len(h.counts) {
+		counts := make([]uint64, min(idx+1+idx/4, hdrMaxIndex+1))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[idx]++
+	if h.n == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.n++
+	h.sum += float64(v)
+	h.sumSq += float64(v) * float64(v)
+}
+
+// Merge other into h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.n == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]uint64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.n == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.n += other.n
+	h.saturated += other.saturated
+	h.sum += other.sum
+	h.sumSq += other.sumSq
+}
+
+// N returns the number of recorded values.
+func (h *Histogram) N() int {
+	return int(h.n)
+}
+
+// Saturated returns the number of recorded values that were too big for the histogram.
+// Percentiles including these values are capped.
+func (h *Histogram) Saturated() int {
+	return int(h.saturated)
+}
+
+// Mean returns the average of all recorded values.
+func (h *Histogram) Mean() float64 {
+	if h.n == 0 {
+		return 0
+	}
+	return h.sum / float64(h.n)
+}
+
+// StdDev returns the standard deviation of all recorded values.
+func (h *Histogram) StdDev() float64 {
+	if h.n < 2 {
+		return 0
+	}
+	mean := h.Mean()
+	variance := h.sumSq/float64(h.n) - mean*mean
+	if variance <= 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// Percentile returns the value at the given fraction (0->1) of recorded values.
+// 0 returns the smallest and 1 the biggest recorded value.
+func (h *Histogram) Percentile(p float64) int64 {
+	if h.n == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return h.min
+	}
+	if p >= 1 {
+		return h.max
+	}
+	want := uint64(math.Ceil(p * float64(h.n)))
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		if total >= want {
+			v := hdrValue(i)
+			if v < h.min {
+				return h.min
+			}
+			if v > h.max {
+				return h.max
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// HDRSize contains statistics for a range of object sizes.
+type HDRSize struct {
+	// Smallest and Biggest size of the range. Biggest is not included.
+	Smallest, Biggest int64
+	SmallestLog10     int
+	BiggestLog10      int
+
+	Requests   int
+	TotalBytes int64
+	TotalDur   time.Duration
+
+	// Latency in microseconds.
+	Latency Histogram
+	// BPS contains bytes per second for each request.
+	BPS Histogram
+}
+
+// SizesString returns the lower and upper limit as strings.
+func (s HDRSize) SizesString() (lo, hi string) {
+	return SizeSegment{SmallestLog10: s.SmallestLog10, BiggestLog10: s.BiggestLog10, Smallest: s.Smallest, Biggest: s.Biggest}.SizesString()
+}
+
+// HDRHost contains totals for a single endpoint.
+type HDRHost struct {
+	Requests   int
+	Errors     int
+	Objects    float64
+	TotalBytes int64
+}
+
+// hdrSecond contains totals for a single second.
+type hdrSecond struct {
+	ops     int
+	errors  int
+	objects float64
+	bytes   int64
+}
+
+// HDROp contains statistics for a single operation type.
+type HDROp struct {
+	OpType      string
+	Start, End  time.Time
+	Requests    int
+	Errors      int
+	FirstErrors []string
+
+	// ValidationErrors is the number of errors that failed validation.
+	ValidationErrors int
+	ObjPerOp         int
+	TotalBytes       int64
+	Objects          float64
+
+	// Latency and TTFB of successful requests in microseconds.
+	Latency Histogram
+	TTFB    Histogram
+
+	// Sizes contains statistics for each log10 size range.
+	Sizes map[int]*HDRSize
+	Hosts map[string]*HDRHost
+
+	// ErrCategories contains error counts by category and host.
+	ErrCategories map[string]map[string]int
+	// Retries contains response status and retry counts.
+	Retries RetryCounts
+
+	firstSize   int64
+	multiSize   bool
+	threads     map[uint32]struct{}
+	clients     map[string]struct{}
+	perSecond   []hdrSecond
+	secondsFrom time.Time
+}
+
+// HDRStats contains operation statistics collected without storing operations.
+type HDRStats struct {
+	Ops map[string]*HDROp
+	// All contains combined statistics for all operation types.
+	All *HDROp
+}
+
+// NewHDRStats returns empty operation statistics.
+func NewHDRStats() *HDRStats {
+	return &HDRStats{Ops: make(map[string]*HDROp)}
+}
+
+// Add an operation to the statistics.
+func (s *HDRStats) Add(o Operation) {
+	op := s.Ops[o.OpType]
+	if op == nil {
+		op = newHDROp(o.OpType, o)
+		s.Ops[o.OpType] = op
+	}
+	op.add(o)
+	if s.All == nil {
+		s.All = newHDROp("", o)
+	}
+	s.All.add(o)
+}
+
+// IsMixed returns true if different operation types overlap in time.
+func (s *HDRStats) IsMixed() bool {
+	for _, a := range s.Ops {
+		for _, b := range s.Ops {
+			if a != b && a.Start.Before(b.End) && b.Start.Before(a.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newHDROp(typ string, o Operation) *HDROp {
+	return &HDROp{
+		OpType:      typ,
+		Start:       o.Start,
+		End:         o.End,
+		ObjPerOp:    o.ObjPerOp,
+		Sizes:       make(map[int]*HDRSize),
+		Hosts:       make(map[string]*HDRHost),
+		firstSize:   o.Size,
+		threads:     make(map[uint32]struct{}),
+		clients:     make(map[string]struct{}),
+		secondsFrom: o.End.Truncate(time.Second),
+	}
+}
+
+// ErrorCounts returns the number of failed operations and how many of them failed validation.
+func (s *HDRStats) ErrorCounts() (errs, validation int) {
+	for _, op := range s.Ops {
+		errs += op.Errors
+		validation += op.ValidationErrors
+	}
+	return errs, validation
+}
+
+// Saturated returns the number of recorded samples that were too big for the histograms.
+func (s *HDRStats) Saturated() int {
+	var n int
+	for _, op := range s.Ops {
+		n += op.Latency.Saturated() + op.TTFB.Saturated()
+		for _, sz := range op.Sizes {
+			n += sz.Latency.Saturated() + sz.BPS.Saturated()
+		}
+	}
+	return n
+}
+
+// OpTypes returns the recorded operation types, sorted.
+func (s *HDRStats) OpTypes() []string {
+	res := make([]string, 0, len(s.Ops))
+	for typ := range s.Ops {
+		res = append(res, typ)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (op *HDROp) add(o Operation) {
+	if o.Start.Before(op.Start) {
+		op.Start = o.Start
+	}
+	if o.End.After(op.End) {
+		op.End = o.End
+	}
+	op.Requests++
+	op.threads[o.Thread] = struct{}{}
+	op.clients[o.ClientID] = struct{}{}
+	host := op.Hosts[o.Endpoint]
+	if host == nil {
+		host = &HDRHost{}
+		op.Hosts[o.Endpoint] = host
+	}
+	host.Requests++
+	op.Retries.Add(o)
+	sec := op.second(o.End)
+	if o.Err != "" {
+		op.Errors++
+		host.Errors++
+		if o.ValidationErr() {
+			op.ValidationErrors++
+		}
+		if op.ErrCategories == nil {
+			op.ErrCategories = make(map[string]map[string]int)
+		}
+		cat, _ := o.ErrCategory()
+		if op.ErrCategories[cat] == nil {
+			op.ErrCategories[cat] = make(map[string]int)
+		}
+		op.ErrCategories[cat][o.Endpoint]++
+		sec.errors++
+		if len(op.FirstErrors) < 10 {
+			op.FirstErrors = append(op.FirstErrors, fmt.Sprintf("%s, %s: %v", o.Endpoint, o.End.Round(time.Second), o.Err))
+		}
+		return
+	}
+	sec.ops++
+	sec.objects += float64(o.ObjPerOp)
+	sec.bytes += o.Size
+	op.Objects += float64(o.ObjPerOp)
+	op.TotalBytes += o.Size
+	host.Objects += float64(o.ObjPerOp)
+	host.TotalBytes += o.Size
+	if o.Size != op.firstSize {
+		op.multiSize = true
+	}
+
+	dur := o.End.Sub(o.Start)
+	op.Latency.Record(dur.Microseconds())
+	if o.FirstByte != nil {
+		op.TTFB.Record(o.FirstByte.Sub(o.Start).Microseconds())
+	}
+
+	l10 := 0
+	for o.Size >= log10ToLog2Size[l10+1] && l10 < len(log10ToLog2Size)-2 {
+		l10++
+	}
+	sz := op.Sizes[l10]
+	if sz == nil {
+		sz = &HDRSize{
+			Smallest:      log10ToLog2Size[l10],
+			SmallestLog10: l10,
+			Biggest:       log10ToLog2Size[l10+1],
+			BiggestLog10:  l10 + 1,
+		}
+		op.Sizes[l10] = sz
+	}
+	sz.Requests++
+	sz.TotalBytes += o.Size
+	sz.TotalDur += dur
+	sz.Latency.Record(dur.Microseconds())
+	if dur > 0 {
+		sz.BPS.Record(int64(float64(o.Size) / dur.Seconds()))
+	}
+}
+
+// second returns the totals of the second containing t.
+func (op *HDROp) second(t time.Time) *hdrSecond {
+	if t.Before(op.secondsFrom) {
+		// Operations are received roughly in order, so this is rare.
+		shift := int(op.secondsFrom.Sub(t.Truncate(time.Second)) / time.Second)
+		op.perSecond = append(make([]hdrSecond, shift), op.perSecond...)
+		op.secondsFrom = op.secondsFrom.Add(-time.Duration(shift) * time.Second)
+	}
+	idx := int(t.Sub(op.secondsFrom) / time.Second)
+	for len(op.perSecond) <= idx {
+		op.perSecond = append(op.perSecond, hdrSecond{})
+	}
+	return &op.perSecond[idx]
+}
+
+// MultipleSizes returns whether successful operations had different sizes.
+func (op *HDROp) MultipleSizes() bool {
+	return op.multiSize
+}
+
+// Size returns the object size if all operations had the same size.
+func (op *HDROp) Size() int64 {
+	if op.multiSize {
+		return 0
+	}
+	return op.firstSize
+}
+
+// Threads returns the number of threads seen.
+func (op *HDROp) Threads() int {
+	return len(op.threads)
+}
+
+// Clients returns the number of clients seen.
+func (op *HDROp) Clients() int {
+	return len(op.clients)
+}
+
+// Endpoints returns the sorted endpoints seen.
+func (op *HDROp) Endpoints() []string {
+	res := make([]string, 0, len(op.Hosts))
+	for ep := range op.Hosts {
+		res = append(res, ep)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Total returns the total throughput of successful operations as a segment.
+func (op *HDROp) Total() Segment {
+	return Segment{
+		OpType:     op.OpType,
+		Start:      op.Start,
+		EndsBefore: op.End,
+		OpsStarted: op.Requests,
+		FullOps:    op.Requests - op.Errors,
+		OpsEnded:   op.Requests - op.Errors,
+		Objects:    op.Objects,
+		Errors:     op.Errors,
+		TotalBytes: op.TotalBytes,
+		ObjsPerOp:  op.ObjPerOp,
+	}
+}
+
+// Segments returns throughput split into segments of the given duration.
+// Operations are counted in the segment they ended.
+// The duration is rounded up to whole seconds and the first and last
+// partial seconds are not included.
+func (op *HDROp) Segments(segDur time.Duration) Segments {
+	perSeg := int((segDur + time.Second - 1) / time.Second)
+	if perSeg < 1 {
+		perSeg = 1
+	}
+	var res Segments
+	for i := 1; i+perSeg < len(op.perSecond); i += perSeg {
+		start := op.secondsFrom.Add(time.Duration(i) * time.Second)
+		seg := Segment{
+			OpType:     op.OpType,
+			Start:      start,
+			EndsBefore: start.Add(time.Duration(perSeg) * time.Second),
+			ObjsPerOp:  op.ObjPerOp,
+		}
+		for _, s := range op.perSecond[i : i+perSeg] {
+			seg.OpsEnded += s.ops
+			seg.FullOps += s.ops
+			seg.Objects += s.objects
+			seg.TotalBytes += s.bytes
+			seg.Errors += s.errors
+		}
+		res = append(res, seg)
+	}
+	return res
+}
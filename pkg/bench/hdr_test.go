@@ -0,0 +1,55 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogramSaturated(t *testing.T) {
+	var h Histogram
+	for v := int64(1); v < hdrMaxValue; v *= 3 {
+		h.Record(v)
+	}
+	if h.Saturated() != 0 {
+		t.Fatalf("got %d saturated values below the limit", h.Saturated())
+	}
+	h.Record(hdrMaxValue - 1)
+	if got := hdrIndex(hdrMaxValue - 1); got != hdrMaxIndex {
+		t.Errorf("highest value got index %d, want %d", got, hdrMaxIndex)
+	}
+	h.Record(hdrMaxValue)
+	h.Record(math.MaxInt64)
+	if h.Saturated() != 2 {
+		t.Errorf("got %d saturated values, want 2", h.Saturated())
+	}
+	if len(h.counts) > hdrMaxIndex+1 {
+		t.Errorf("got %d counters, want at most %d", len(h.counts), hdrMaxIndex+1)
+	}
+	if h.Percentile(1) != math.MaxInt64 {
+		t.Errorf("got max %d", h.Percentile(1))
+	}
+
+	var merged Histogram
+	merged.Merge(&h)
+	merged.Merge(&h)
+	if merged.Saturated() != 4 || merged.N() != 2*h.N() {
+		t.Errorf("merged got %d saturated of %d values", merged.Saturated(), merged.N())
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// HeatmapOptions describe options used to build a latency heatmap.
+type HeatmapOptions struct {
+	// SliceDuration is the duration of each time slice.
+	SliceDuration time.Duration
+	// Buckets is the number of latency buckets.
+	// Buckets are spaced logarithmically between the fastest and slowest operation.
+	Buckets int
+}
+
+// LatencyHeatmap contains operation counts binned by the time
+// the operation ended and the latency of the operation.
+type LatencyHeatmap struct {
+	OpType string    `json:"op"`
+	Start  time.Time `json:"start"`
+	// Slice is the duration of each time slice.
+	Slice time.Duration `json:"slice_ns"`
+	// Buckets contains the upper bound of each latency bucket.
+	Buckets []time.Duration `json:"buckets_ns"`
+	// Counts contains the number of operations for each [time slice][latency bucket].
+	Counts [][]int `json:"counts"`
+}
+
+// LatencyHeatmap bins successful operations into time slices and latency buckets.
+// Operations are placed in the time slice they ended in.
+func (o Operations) LatencyHeatmap(opts HeatmapOptions) LatencyHeatmap {
+	o = o.FilterSuccessful()
+	res := LatencyHeatmap{OpType: o.FirstOpType(), Slice: opts.SliceDuration}
+	if len(o) == 0 || opts.SliceDuration <= 0 {
+		return res
+	}
+	if o.IsMixed() {
+		res.OpType = ""
+	}
+	nBuckets := max(opts.Buckets, 1)
+	start, end := o.TimeRange()
+	res.Start = start
+	lo, hi := time.Duration(math.MaxInt64), time.Duration(0)
+	for _, op := range o {
+		d := max(op.Duration(), time.Microsecond)
+		lo = min(lo, d)
+		hi = max(hi, d)
+	}
+	if lo == hi {
+		nBuckets = 1
+	}
+	res.Buckets = make([]time.Duration, nBuckets)
+	ratio := float64(hi) / float64(lo)
+	for i := range res.Buckets {
+		res.Buckets[i] = time.Duration(float64(lo) * math.Pow(ratio, float64(i+1)/float64(nBuckets)))
+	}
+	res.Buckets[nBuckets-1] = hi
+
+	nSlices := int(end.Sub(start)/opts.SliceDuration) + 1
+	res.Counts = make([][]int, nSlices)
+	for i := range res.Counts {
+		res.Counts[i] = make([]int, nBuckets)
+	}
+	for _, op := range o {
+		slice := min(int(op.End.Sub(start)/opts.SliceDuration), nSlices-1)
+		d := max(op.Duration(), time.Microsecond)
+		bucket := 0
+		if nBuckets > 1 {
+			bucket = int(math.Ceil(math.Log(float64(d)/float64(lo))/math.Log(ratio)*float64(nBuckets))) - 1
+			bucket = min(max(bucket, 0), nBuckets-1)
+		}
+		res.Counts[slice][bucket]++
+	}
+	return res
+}
+
+// CSV writes the heatmap as tab separated values.
+// Each row is a time slice, each column a latency bucket.
+func (h LatencyHeatmap) CSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	header := []string{"index", "op", "start_time"}
+	for _, b := range h.Buckets {
+		header = append(header, fmt.Sprintf("le_%gms", float64(b)/float64(time.Millisecond)))
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i, counts := range h.Counts {
+		row := []string{fmt.Sprint(i), h.OpType, fmt.Sprint(h.Start.Add(time.Duration(i) * h.Slice))}
+		for _, c := range counts {
+			row = append(row, fmt.Sprint(c))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// JSON writes the heatmap as a single JSON object.
+func (h LatencyHeatmap) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(h)
+}
+
+// heatmapShades are the characters used for rendering, least to most operations.
+const heatmapShades = " .:-=+*#%@"
+
+// ASCII renders the heatmap for a terminal.
+// Latency buckets are rows with the slowest on top, time slices are columns.
+// If there are more slices than width, adjacent slices are combined.
+// Shading is logarithmic, so single outliers remain visible.
+func (h LatencyHeatmap) ASCII(w io.Writer, width int) error {
+	if len(h.Counts) == 0 || len(h.Buckets) == 0 {
+		return nil
+	}
+	perCol := 1
+	if width > 0 && len(h.Counts) > width {
+		perCol = (len(h.Counts) + width - 1) / width
+	}
+	nCols := (len(h.Counts) + perCol - 1) / perCol
+	cols := make([][]int, nCols)
+	maxCount := 0
+	for i := range cols {
+		cols[i] = make([]int, len(h.Buckets))
+		for _, counts := range h.Counts[i*perCol : min((i+1)*perCol, len(h.Counts))] {
+			for b, c := range counts {
+				cols[i][b] += c
+			}
+		}
+		for _, c := range cols[i] {
+			maxCount = max(maxCount, c)
+		}
+	}
+	if maxCount == 0 {
+		return nil
+	}
+	labels := make([]string, len(h.Buckets))
+	labelW := 0
+	for i, b := range h.Buckets {
+		labels[i] = "<" + roundDuration(b).String()
+		labelW = max(labelW, len(labels[i]))
+	}
+	var sb strings.Builder
+	for b := len(h.Buckets) - 1; b >= 0; b-- {
+		fmt.Fprintf(&sb, "%*s |", labelW, labels[b])
+		for _, col := range cols {
+			c := col[b]
+			if c == 0 {
+				sb.WriteByte(heatmapShades[0])
+				continue
+			}
+			level := int(math.Ceil(math.Log1p(float64(c)) / math.Log1p(float64(maxCount)) * float64(len(heatmapShades)-1)))
+			sb.WriteByte(heatmapShades[min(max(level, 1), len(heatmapShades)-1)])
+		}
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "%*s +%s\n", labelW, "", strings.Repeat("-", nCols))
+	fmt.Fprintf(&sb, "%*s  %s, %v per column. Max %d ops per cell ('%c').\n", labelW, "",
+		h.Start.Format("15:04:05 MST"), h.Slice*time.Duration(perCol), maxCount, heatmapShades[len(heatmapShades)-1])
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// roundDuration rounds d for display.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= 10*time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package bench
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ingest benchmarks sustained uploads of very large objects.
+// Each thread uploads a single object at the time as a sequence of parts,
+// with every part and the final completion recorded as separate operations.
+type Ingest struct {
+	Common
+
+	// ObjSize is the total size of each uploaded object.
+	ObjSize int64
+
+	// PartSize is the size of each uploaded part.
+	// The Source must generate objects of this size.
+	PartSize int64
+
+	// PartPace will limit each upload to this many bytes per second,
+	// by delaying part uploads. 0 means no pacing.
+	PartPace int64
+
+	prefixes map[string]struct{}
+}
+
+// ingestPartRetries is the number of times a failed part is retried
+// before the upload is aborted and a new object is started.
+const ingestPartRetries = 5
+
+// Prepare will create an empty bucket or delete any content already there.
+func (u *Ingest) Prepare(ctx context.Context) error {
+	return u.createEmptyBucket(ctx)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (u *Ingest) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(u.Concurrency)
+	u.addCollector()
+	c := u.Collector
+	if u.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, opMultipartPart, u.autoTermOpts())
+	}
+	u.prefixes = make(map[string]struct{}, u.Concurrency)
+
+	parts := int((u.ObjSize + u.PartSize - 1) / u.PartSize)
+
+	for i := 0; i < u.Concurrency; i++ {
+		src := u.Source()
+		u.prefixes[src.Prefix()] = struct{}{}
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := u.threadContext(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			opts := u.PutOpts
+			done := ctx.Done()
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				obj := src.Object()
+				opts.ContentType = obj.ContentType
+				up, err := u.newMultipartUpload(nonTerm, rcv, i, obj.Name, parts, opts)
+				if err != nil {
+					if u.backoff(ctx, 1) != nil {
+						return
+					}
+					continue
+				}
+
+				uploadStart := time.Now()
+				var sent int64
+				failed := false
+				for partN, retries := 1, 0; partN <= parts; {
+					select {
+					case <-done:
+						up.abort(nonTerm)
+						return
+					default:
+					}
+					if u.waitThread(ctx, i) != nil {
+						continue
+					}
+					if u.PartPace > 0 {
+						// Wait until we are allowed to send the next part.
+						due := uploadStart.Add(time.Duration(float64(sent) / float64(u.PartPace) * float64(time.Second)))
+						if wait := time.Until(due); wait > 0 {
+							select {
+							case <-done:
+								continue
+							case <-time.After(wait):
+							}
+						}
+					}
+
+					part := src.Object()
+					size := part.Size
+					if remain := u.ObjSize - sent; size > remain {
+						size = remain
+					}
+					if err := up.uploadPart(nonTerm, i, partN, part, size); err != nil {
+						// Retry the same part number, until retries are exhausted.
+						retries++
+						if retries > ingestPartRetries {
+							u.Error("giving up on ", obj.Name, " after ", retries, " failed part uploads")
+							failed = true
+							break
+						}
+						_ = u.backoff(ctx, retries)
+						continue
+					}
+					sent += size
+					partN++
+					retries = 0
+				}
+				if failed {
+					up.abort(nonTerm)
+					continue
+				}
+				up.complete(nonTerm)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (u *Ingest) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(u.prefixes))
+	for p := range u.prefixes {
+		pf = append(pf, p)
+	}
+	u.deleteAllInBucket(ctx, pf...)
+}
@@ -0,0 +1,261 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Lifecycle benchmarks how fast objects are expired or transitioned by a lifecycle rule.
+// Objects are uploaded with a tag matching the rule, the rule is applied
+// when the benchmark starts and objects are polled until they are processed.
+type Lifecycle struct {
+	Common
+
+	CreateObjects int
+
+	// Days after creation objects are expired or transitioned.
+	// If 0, a date in the past is used, so objects are eligible immediately.
+	Days int
+
+	// Tier is the storage class objects are transitioned to.
+	// If empty, objects are expired.
+	Tier string
+
+	// PollInterval is the delay between listings of each prefix.
+	PollInterval time.Duration
+
+	objects []generator.Objects
+}
+
+// lifecycleTag is set on all uploaded objects and used as rule filter.
+// It is the same for all clients, so they apply identical rules.
+var lifecycleTag = lifecycle.Tag{Key: "warp-lifecycle", Value: "benchmark"}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload a number of tagged objects.
+func (l *Lifecycle) Prepare(ctx context.Context) error {
+	if err := l.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	objPerPrefix := (l.CreateObjects + l.Concurrency - 1) / l.Concurrency
+	console.Eraseline()
+	console.Info("\rUploading ", objPerPrefix*l.Concurrency, " objects in ", l.Concurrency, " prefixes")
+
+	var wg sync.WaitGroup
+	wg.Add(l.Concurrency)
+	l.addCollector()
+	l.objects = make([]generator.Objects, l.Concurrency)
+	var mu sync.Mutex
+	objsCreated := 0
+	var groupErr error
+	for i := 0; i < l.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			src := l.Source()
+			opts := l.PutOpts
+			opts.UserTags = make(map[string]string, len(l.PutOpts.UserTags)+1)
+			for k, v := range l.PutOpts.UserTags {
+				opts.UserTags[k] = v
+			}
+			opts.UserTags[lifecycleTag.Key] = lifecycleTag.Value
+			rcv := l.Collector.Receiver()
+			done := ctx.Done()
+
+			for j := 0; j < objPerPrefix; j++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if l.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				client, cldone := l.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				_, err := l.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					l.Error(err)
+					if err := l.prepareFailed(err, objPerPrefix*l.Concurrency); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					continue
+				}
+				mu.Lock()
+				obj.Reader = nil
+				l.objects[i] = append(l.objects[i], *obj)
+				objsCreated++
+				l.prepareProgress(float64(objsCreated) / float64(objPerPrefix*l.Concurrency))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// rule returns the lifecycle rule to apply.
+func (l *Lifecycle) rule() lifecycle.Rule {
+	r := lifecycle.Rule{
+		ID:         "warp-lifecycle",
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Tag: lifecycleTag},
+	}
+	// Dates must be at midnight UTC.
+	date := lifecycle.ExpirationDate{Time: time.Now().UTC().Truncate(24 * time.Hour)}
+	if l.Tier != "" {
+		r.Transition = lifecycle.Transition{StorageClass: l.Tier, Days: lifecycle.ExpirationDays(l.Days)}
+		if l.Days == 0 {
+			r.Transition.Date = date
+		}
+		return r
+	}
+	r.Expiration = lifecycle.Expiration{Days: lifecycle.ExpirationDays(l.Days)}
+	if l.Days == 0 {
+		r.Expiration.Date = date
+	}
+	return r
+}
+
+// Start will apply the lifecycle rule and record when each object is processed.
+// Operations start when the rule is applied and end when the object is seen as
+// removed or transitioned. Operations should begin executing when the start channel is closed.
+func (l *Lifecycle) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	c := l.Collector
+	opType := "EXPIRE"
+	if l.Tier != "" {
+		opType = "TRANSITION"
+	}
+	<-wait
+	cl, done := l.Client()
+	cfg := lifecycle.NewConfiguration()
+	cfg.Rules = []lifecycle.Rule{l.rule()}
+	err := cl.SetBucketLifecycle(ctx, l.Bucket, cfg)
+	done()
+	if err != nil {
+		return c.Close(), fmt.Errorf("unable to set lifecycle of bucket %q: %w", l.Bucket, err)
+	}
+	applied := time.Now()
+
+	var wg sync.WaitGroup
+	wg.Add(l.Concurrency)
+	for i := 0; i < l.Concurrency; i++ {
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			pending := make(map[string]generator.Object, len(l.objects[i]))
+			for _, obj := range l.objects[i] {
+				pending[obj.Name] = obj
+			}
+			if len(pending) == 0 {
+				return
+			}
+			prefix := l.objects[i][0].Prefix
+			ticker := time.NewTicker(l.PollInterval)
+			defer ticker.Stop()
+			for len(pending) > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				client, cldone := l.threadClient(i)
+				found := make(map[string]struct{}, len(pending))
+				var listErr error
+				listCtx, cancel := context.WithCancel(ctx)
+				for obj := range client.ListObjects(listCtx, l.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+					if obj.Err != nil {
+						listErr = obj.Err
+						break
+					}
+					if l.Tier != "" && obj.StorageClass == l.Tier {
+						continue
+					}
+					found[obj.Key] = struct{}{}
+				}
+				now := time.Now()
+				cancel()
+				cldone()
+				if listErr != nil {
+					if ctx.Err() == nil {
+						l.Error("list error: ", listErr)
+					}
+					continue
+				}
+				for name, obj := range pending {
+					if _, ok := found[name]; ok {
+						continue
+					}
+					delete(pending, name)
+					rcv <- Operation{
+						OpType:   opType,
+						Thread:   uint32(i),
+						Size:     obj.Size,
+						File:     name,
+						ObjPerOp: 1,
+						Endpoint: client.EndpointURL().String(),
+						Start:    applied,
+						End:      now,
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup removes the lifecycle configuration and deletes everything uploaded to the bucket.
+func (l *Lifecycle) Cleanup(ctx context.Context) {
+	cl, done := l.Client()
+	if err := cl.SetBucketLifecycle(ctx, l.Bucket, lifecycle.NewConfiguration()); err != nil {
+		l.Error("unable to remove lifecycle configuration: ", err)
+	}
+	done()
+	l.deleteAllInBucket(ctx, generator.MergeObjectPrefixes(l.objects)...)
+}
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +42,54 @@ type List struct {
 	Versions      int
 	NoPrefix      bool
 	Metadata      bool
+
+	// TreeDepth is the number of directory levels objects are placed under in each prefix.
+	// If 0, objects are placed directly in the prefix.
+	TreeDepth int
+	// Fanout is the number of sub-directories of each directory in the tree.
+	Fanout int
+	// ObjectsPerLeaf is the number of objects in each leaf directory.
+	// Replaces CreateObjects when TreeDepth > 0.
+	ObjectsPerLeaf int
+
+	// Delimiter will list a single directory using "/" as delimiter
+	// instead of listing all objects in the prefix recursively.
+	Delimiter bool
+
+	// VerifySample is the fraction of listed objects that are checked with HEAD
+	// after each listing. Objects that are missing or differ are recorded as
+	// validation errors of LISTVERIFY operations.
+	VerifySample float64
+}
+
+// opListVerify is the operation type of HEAD requests verifying listed objects.
+const opListVerify = "LISTVERIFY"
+
+// treeLeaves returns the number of leaf directories in each prefix.
+func (d *List) treeLeaves() int {
+	n := 1
+	for i := 0; i < d.TreeDepth; i++ {
+		n *= d.Fanout
+	}
+	return n
+}
+
+// treeDir returns the path of a leaf directory relative to the prefix.
+func (d *List) treeDir(leaf int) string {
+	dirs := make([]string, d.TreeDepth)
+	for i := d.TreeDepth - 1; i >= 0; i-- {
+		dirs[i] = fmt.Sprintf("d%d", leaf%d.Fanout)
+		leaf /= d.Fanout
+	}
+	return strings.Join(dirs, "/")
+}
+
+// dirPrefix returns the listing prefix of dir.
+func dirPrefix(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return dir + "/"
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -61,11 +111,17 @@ func (d *List) Prepare(ctx context.Context) error {
 	}
 
 	objPerPrefix := (d.CreateObjects + d.Concurrency - 1) / d.Concurrency
+	if d.TreeDepth > 0 {
+		objPerPrefix = d.treeLeaves() * d.ObjectsPerLeaf
+	}
 	console.Eraseline()
 	x := ""
 	if d.Versions > 1 {
 		x = fmt.Sprintf(" with %d versions each", d.Versions)
 	}
+	if d.TreeDepth > 0 {
+		x += fmt.Sprintf(" in a tree of depth %d with %d leaf directories", d.TreeDepth, d.treeLeaves())
+	}
 	if d.NoPrefix {
 		console.Info("\rUploading ", objPerPrefix*d.Concurrency, " objects", x)
 	} else {
@@ -99,10 +155,16 @@ func (d *List) Prepare(ctx context.Context) error {
 				}
 
 				obj := src.Object()
+				if d.TreeDepth > 0 {
+					obj.Name = d.treeName(obj, j/d.ObjectsPerLeaf)
+				}
 				// Assure we don't have duplicates
 				for {
 					if _, ok := exists[obj.Name]; ok {
 						obj = src.Object()
+						if d.TreeDepth > 0 {
+							obj.Name = d.treeName(obj, j/d.ObjectsPerLeaf)
+						}
 						continue
 					}
 					break
@@ -113,10 +175,10 @@ func (d *List) Prepare(ctx context.Context) error {
 					// New input for each version
 					obj := src.Object()
 					obj.Name = name
-					client, cldone := d.Client()
+					client, cldone := d.objectClient()
 					op := Operation{
 						OpType:   http.MethodPut,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -125,29 +187,22 @@ func (d *List) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, d.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, err := d.prepareUpload(ctx, client, obj, opts)
 					op.End = time.Now()
 					if err != nil {
-						err := fmt.Errorf("upload error: %w", err)
 						d.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
+						cldone()
+						if err := d.prepareFailed(err, objPerPrefix*d.Concurrency*d.Versions); err != nil {
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
 						}
-						mu.Unlock()
-						return
+						continue
 					}
 					obj.VersionID = res.VersionID
-					if res.Size != obj.Size {
-						err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-						d.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
-						}
-						mu.Unlock()
-						return
-					}
 					cldone()
 					mu.Lock()
 					obj.Reader = nil
@@ -171,6 +226,12 @@ func (d *List) Prepare(ctx context.Context) error {
 	return groupErr
 }
 
+// treeName returns the name of obj placed in a leaf directory.
+func (d *List) treeName(obj *generator.Object, leaf int) string {
+	base := strings.TrimPrefix(obj.Name, dirPrefix(obj.Prefix))
+	return path.Join(obj.Prefix, d.treeDir(leaf), base)
+}
+
 // Start will execute the main benchmark.
 // Operations should begin executing when the start channel is closed.
 func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
@@ -178,13 +239,14 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	wg.Add(d.Concurrency)
 	c := d.Collector
 	if d.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "LIST", d.AutoTermScale, autoTermCheck, autoTermSamples, d.AutoTermDur)
+		ctx = c.AutoTerm(ctx, "LIST", d.autoTermOpts())
 	}
-	// Non-terminating context.
-	nonTerm := context.Background()
 
 	for i := 0; i < d.Concurrency; i++ {
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := d.threadContext(i)
+			rng := d.threadRng(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
@@ -202,16 +264,16 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 				default:
 				}
 
-				if d.rpsLimit(ctx) != nil {
+				if d.waitThread(ctx, i) != nil {
 					return
 				}
 
-				prefix := objs[0].Prefix
-				client, cldone := d.Client()
+				prefix, want := d.listPrefix(objs[0].Prefix, wantN, rng)
+				client, cldone := d.threadObjectClient(i)
 				op := Operation{
 					File:     prefix,
 					OpType:   "LIST",
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     0,
 					Endpoint: client.EndpointURL().String(),
 				}
@@ -219,15 +281,17 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 				op.Start = time.Now()
 
 				// List all objects with prefix
-				listCh := client.ListObjects(nonTerm, d.Bucket, minio.ListObjectsOptions{
+				listCtx, wc := withWireCounter(opContext(nonTerm, &op))
+				listCh := client.ListObjects(listCtx, d.Bucket, minio.ListObjectsOptions{
 					WithMetadata: d.Metadata,
-					Prefix:       objs[0].Prefix,
-					Recursive:    true,
+					Prefix:       prefix,
+					Recursive:    !d.Delimiter,
 					WithVersions: d.Versions > 1,
 					MaxKeys:      100,
 				})
 
 				// Wait for errCh to close.
+				var sampled []minio.ObjectInfo
 				for {
 					err, ok := <-listCh
 					if !ok {
@@ -236,6 +300,8 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 					if err.Err != nil {
 						d.Error(err.Err)
 						op.Err = err.Err.Error()
+					} else if d.VerifySample > 0 && !err.IsDeleteMarker && !strings.HasSuffix(err.Key, "/") && rng.Float64() < d.VerifySample {
+						sampled = append(sampled, err)
 					}
 					op.ObjPerOp++
 					if op.FirstByte == nil {
@@ -243,14 +309,19 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 						op.FirstByte = &now
 					}
 				}
-				if op.ObjPerOp != wantN {
+				if op.ObjPerOp != want {
 					if op.Err == "" {
-						op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", wantN, op.ObjPerOp)
+						op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", want, op.ObjPerOp)
 					}
 				}
 				op.End = time.Now()
+				op.WireBytes = wc.bytes.Load()
+				op.Pages = int(wc.responses.Load())
 				cldone()
 				rcv <- op
+				if d.verifyListed(ctx, nonTerm, i, sampled, rcv) != nil {
+					return
+				}
 			}
 		}(i)
 	}
@@ -258,6 +329,68 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	return c.Close(), nil
 }
 
+// verifyListed checks that listed objects exist and match the listing using HEAD requests.
+// Returns an error if ctx is canceled.
+func (d *List) verifyListed(ctx, nonTerm context.Context, thread int, listed []minio.ObjectInfo, rcv chan<- Operation) error {
+	for _, obj := range listed {
+		if err := d.rpsLimit(ctx); err != nil {
+			return err
+		}
+		client, cldone := d.threadObjectClient(thread)
+		op := Operation{
+			OpType:   opListVerify,
+			Thread:   uint32(thread),
+			File:     obj.Key,
+			ObjPerOp: 1,
+			Endpoint: client.EndpointURL().String(),
+		}
+		op.Start = time.Now()
+		st, err := client.StatObject(opContext(nonTerm, &op), d.Bucket, obj.Key, minio.StatObjectOptions{VersionID: obj.VersionID})
+		op.End = time.Now()
+		cldone()
+		switch {
+		case err != nil:
+			op.Err = err.Error()
+			switch minio.ToErrorResponse(err).Code {
+			case "NoSuchKey", "NoSuchVersion", "BlobNotFound":
+				op.Err = fmt.Sprintf("listed object not found: %v", err)
+				op.ErrClass = ErrClassValidation
+			}
+		case st.Size != obj.Size:
+			op.Err = fmt.Sprint("listed size does not match. listed:", obj.Size, ", got:", st.Size)
+			op.ErrClass = ErrClassValidation
+		case obj.ETag != "" && st.ETag != "" && st.ETag != obj.ETag:
+			op.Err = fmt.Sprintf("listed etag does not match. listed: %q, got: %q", obj.ETag, st.ETag)
+			op.ErrClass = ErrClassValidation
+		}
+		if op.Err != "" {
+			d.Error(obj.Key, ": ", op.Err)
+		}
+		rcv <- op
+	}
+	return nil
+}
+
+// listPrefix returns the prefix to list and the expected number of entries.
+// all is the number of objects in the prefix of the thread.
+// With Delimiter set, a random directory of the tree is listed and
+// sub-directories are returned as a single entry each.
+func (d *List) listPrefix(prefix string, all int, rng *rand.Rand) (string, int) {
+	if !d.Delimiter {
+		return prefix, all
+	}
+	if d.TreeDepth == 0 {
+		return dirPrefix(prefix), all
+	}
+	level := rng.Intn(d.TreeDepth + 1)
+	dirs := strings.Split(d.treeDir(rng.Intn(d.treeLeaves())), "/")[:level]
+	dir := dirPrefix(path.Join(append([]string{prefix}, dirs...)...))
+	if level < d.TreeDepth {
+		return dir, d.Fanout
+	}
+	return dir, all / d.treeLeaves()
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (d *List) Cleanup(ctx context.Context) {
 	d.deleteAllInBucket(ctx, generator.MergeObjectPrefixes(d.objects)...)
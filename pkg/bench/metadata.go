@@ -0,0 +1,137 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Metadata describes how benchmark data was created.
+// It is stored as comments at the end of CSV benchmark data.
+type Metadata struct {
+	// Command is the command line of the benchmark.
+	Command string `json:"command,omitempty"`
+	// Version is the warp version that wrote the data.
+	Version string `json:"version,omitempty"`
+	// Values contains custom key=value comments.
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// metaVersionKey is the comment key of the warp version.
+const metaVersionKey = "warp-version"
+
+// String returns the metadata as comment lines.
+// The command is written first, followed by key=value lines.
+func (m Metadata) String() string {
+	lines := make([]string, 0, len(m.Values)+2)
+	if m.Command != "" {
+		lines = append(lines, m.Command)
+	}
+	if m.Version != "" {
+		lines = append(lines, metaVersionKey+"="+m.Version)
+	}
+	keys := make([]string, 0, len(m.Values))
+	for k := range m.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+"="+m.Values[k])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsEmpty returns true if no metadata is set.
+func (m Metadata) IsEmpty() bool {
+	return m.Command == "" && m.Version == "" && len(m.Values) == 0
+}
+
+// ParseMetadata parses comment lines, without the comment prefix.
+// Lines starting with a key without spaces followed by '=' are key=value pairs.
+// Other lines are considered part of the command.
+func ParseMetadata(lines []string) Metadata {
+	var m Metadata
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || k == "" || strings.ContainsAny(k, " \t") {
+			if m.Command != "" {
+				m.Command += "\n"
+			}
+			m.Command += line
+			continue
+		}
+		if k == metaVersionKey {
+			m.Version = v
+			continue
+		}
+		if m.Values == nil {
+			m.Values = make(map[string]string)
+		}
+		m.Values[k] = v
+	}
+	return m
+}
+
+// commentReader removes lines starting with '#' from a stream and keeps them.
+type commentReader struct {
+	br       *bufio.Reader
+	comments []string
+	// line is the remaining part of the current line.
+	line []byte
+	// partial is set when line did not end with a newline.
+	partial bool
+	err     error
+}
+
+func newCommentReader(r io.Reader) *commentReader {
+	return &commentReader{br: bufio.NewReaderSize(r, 64<<10)}
+}
+
+func (c *commentReader) Read(p []byte) (n int, err error) {
+	for len(c.line) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		var line []byte
+		line, c.err = c.br.ReadSlice('\n')
+		continued := c.partial
+		c.partial = c.err == bufio.ErrBufferFull
+		if c.partial {
+			// Long line, return what we have.
+			// A comment longer than the buffer is passed on,
+			// which is fine, since the CSV reader ignores it as well.
+			c.err = nil
+		} else if !continued && bytes.HasPrefix(line, []byte("#")) {
+			txt := strings.TrimRight(string(line[1:]), "\r\n")
+			c.comments = append(c.comments, strings.TrimPrefix(txt, " "))
+			continue
+		}
+		c.line = line
+	}
+	n = copy(p, c.line)
+	c.line = c.line[n:]
+	return n, nil
+}
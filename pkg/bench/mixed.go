@@ -24,6 +24,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -32,14 +33,67 @@ import (
 	"github.com/minio/warp/pkg/generator"
 )
 
+// mixedMultipartOp is the operation type of multipart uploads in mixed benchmarks.
+const mixedMultipartOp = "MULTIPART"
+
 // Mixed benchmarks mixed operations all inclusive.
 type Mixed struct {
 	Common
 	Dist *MixedDistribution
 
+	// PutSource generates objects for PUT operations if set.
+	// Otherwise the Source used for preparing is used.
+	PutSource func() generator.Source
+
+	// MultipartSource generates objects for multipart upload operations.
+	// Must be set if the distribution contains multipart uploads.
+	MultipartSource func() generator.Source
+
+	// MultipartPartSize is the part size of multipart upload operations.
+	MultipartPartSize uint64
+
+	// ListMaxKeys is the number of objects requested per page by LIST operations.
+	// The server default is used if 0.
+	ListMaxKeys int
+
 	GetOpts       minio.GetObjectOptions
 	StatOpts      minio.StatObjectOptions
 	CreateObjects int
+
+	// Verify will check the content of downloads.
+	Verify bool
+
+	// ReadClient is used for GET and STAT operations if set.
+	ReadClient func() (cl *minio.Client, done func())
+
+	// WriteClient is used for uploads and DELETE operations if set.
+	WriteClient func() (cl *minio.Client, done func())
+}
+
+func (g *Mixed) writeClient() (ObjectClient, func()) {
+	if g.WriteClient != nil {
+		cl, done := g.WriteClient()
+		return S3ObjectClient(cl), done
+	}
+	return g.objectClient()
+}
+
+// readThreadClient returns the client for GET and STAT operations on a thread.
+func (g *Mixed) readThreadClient(thread int) (ObjectClient, func()) {
+	if g.ReadClient != nil {
+		cl, done := g.ReadClient()
+		return S3ObjectClient(cl), done
+	}
+	return g.threadObjectClient(thread)
+}
+
+// writeThreadClient returns the client for PUT and DELETE operations on a thread.
+func (g *Mixed) writeThreadClient(thread int) (ObjectClient, func()) {
+	if g.WriteClient != nil {
+		cl, done := g.WriteClient()
+		return S3ObjectClient(cl), done
+	}
+	return g.threadObjectClient(thread)
 }
 
 // MixedDistribution keeps track of operation distribution
@@ -50,6 +104,17 @@ type MixedDistribution struct {
 	objects      map[string]generator.Object
 	rng          *rand.Rand
 
+	// Access is the distribution of objects read.
+	Access AccessDist
+	// names contains object names in the order they were added.
+	// Only kept if Access is not uniform.
+	names []string
+	pos   int
+
+	// prefixes contains the prefixes of all objects added, for listing.
+	prefixes    []string
+	hasPrefixes map[string]struct{}
+
 	ops []string
 
 	current int
@@ -57,10 +122,11 @@ type MixedDistribution struct {
 }
 
 func (m *MixedDistribution) Generate(allocObjs int) error {
-	if m.Distribution[http.MethodDelete] > m.Distribution[http.MethodPut] {
-		return errors.New("DELETE distribution cannot be bigger than PUT")
+	if m.Distribution[http.MethodDelete] > m.Distribution[http.MethodPut]+m.Distribution[mixedMultipartOp] {
+		return errors.New("DELETE distribution cannot be bigger than PUT and MULTIPART combined")
 	}
 	m.objects = make(map[string]generator.Object, allocObjs)
+	m.hasPrefixes = make(map[string]struct{})
 
 	err := m.normalize()
 	if err != nil {
@@ -110,6 +176,9 @@ func (m *MixedDistribution) normalize() error {
 func (m *MixedDistribution) randomObj() (obj generator.Object, done func()) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.Access.uniform() {
+		return m.pickObj()
+	}
 	// Use map randomness to select.
 	for k, o := range m.objects {
 		delete(m.objects, k)
@@ -122,12 +191,37 @@ func (m *MixedDistribution) randomObj() (obj generator.Object, done func()) {
 	panic("ran out of objects")
 }
 
+// pickObj selects an object using the access distribution.
+// Objects that are in use are skipped. m.mu must be held.
+func (m *MixedDistribution) pickObj() (obj generator.Object, done func()) {
+	n := len(m.names)
+	i := m.Access.picker(m.rng, n, m.pos)()
+	m.pos = i + 1
+	for j := 0; j < n; j++ {
+		k := m.names[(i+j)%n]
+		o, ok := m.objects[k]
+		if !ok {
+			continue
+		}
+		delete(m.objects, k)
+		return o, func() {
+			m.mu.Lock()
+			m.objects[k] = obj
+			m.mu.Unlock()
+		}
+	}
+	panic("ran out of objects")
+}
+
 func (m *MixedDistribution) deleteRandomObj() generator.Object {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	// Use map randomness to select.
 	for k, o := range m.objects {
 		delete(m.objects, k)
+		if !m.Access.uniform() {
+			m.names = slices.DeleteFunc(m.names, func(s string) bool { return s == k })
+		}
 		return o
 	}
 	panic("ran out of objects")
@@ -136,9 +230,26 @@ func (m *MixedDistribution) deleteRandomObj() generator.Object {
 func (m *MixedDistribution) addObj(o generator.Object) {
 	m.mu.Lock()
 	m.objects[o.Name] = o
+	if !m.Access.uniform() {
+		m.names = append(m.names, o.Name)
+	}
+	if _, ok := m.hasPrefixes[o.Prefix]; !ok {
+		m.hasPrefixes[o.Prefix] = struct{}{}
+		m.prefixes = append(m.prefixes, o.Prefix)
+	}
 	m.mu.Unlock()
 }
 
+// randomPrefix returns a random prefix of the objects added.
+func (m *MixedDistribution) randomPrefix() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.prefixes) == 0 {
+		return ""
+	}
+	return m.prefixes[m.rng.Intn(len(m.prefixes))]
+}
+
 func (m *MixedDistribution) getOp() string {
 	m.mu.Lock()
 	op := m.ops[m.current]
@@ -153,6 +264,9 @@ func (g *Mixed) Prepare(ctx context.Context) error {
 	if g.CreateObjects <= g.Concurrency {
 		return errors.New("initial number of objects should be at least matching concurrency")
 	}
+	if g.Dist.Distribution[mixedMultipartOp] > 0 && g.MultipartSource == nil {
+		return errors.New("no multipart object source")
+	}
 	if err := g.createEmptyBucket(ctx); err != nil {
 		return err
 	}
@@ -186,31 +300,31 @@ func (g *Mixed) Prepare(ctx context.Context) error {
 				}
 
 				obj := src.Object()
-				client, clDone := g.Client()
+				client, clDone := g.writeClient()
 				opts.ContentType = obj.ContentType
-				res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				res, err := g.prepareUpload(ctx, client, obj, opts)
 				if err != nil {
-					err := fmt.Errorf("upload error: %w", err)
 					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+					clDone()
+					if err := g.prepareFailed(err, g.CreateObjects); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
 					}
-					mu.Unlock()
-					return
+					continue
 				}
 				obj.VersionID = res.VersionID
-				if res.Size != obj.Size {
-					err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+				obj.ETag = res.ETag
+				clDone()
+				if g.Verify {
+					obj.Checksum, err = objectChecksum(obj.Reader)
+					if err != nil {
+						g.Error(err)
 					}
-					mu.Unlock()
-					return
 				}
-				clDone()
 				obj.Reader = nil
 				g.Dist.addObj(*obj)
 				g.prepareProgress(float64(len(g.Dist.objects)) / float64(g.CreateObjects))
@@ -218,6 +332,9 @@ func (g *Mixed) Prepare(ctx context.Context) error {
 		}(obj)
 	}
 	wg.Wait()
+	if groupErr == nil && len(g.Dist.objects) == 0 {
+		groupErr = fmt.Errorf("no objects uploaded")
+	}
 	return groupErr
 }
 
@@ -228,18 +345,28 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "", g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, "", g.autoTermOpts())
 	}
-	// Non-terminating context.
-	nonTerm := context.Background()
 
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
 			src := g.Source()
+			if g.PutSource != nil {
+				src = g.PutSource()
+			}
+			var mpSrc generator.Source
+			if g.MultipartSource != nil {
+				mpSrc = g.MultipartSource()
+			}
 			putOpts := g.PutOpts
+			mpOpts := g.PutOpts
+			mpOpts.PartSize = g.MultipartPartSize
+			mpOpts.DisableMultipart = false
 			statOpts := g.StatOpts
 			getOpts := g.GetOpts
 
@@ -251,7 +378,7 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
@@ -260,10 +387,10 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				case http.MethodGet:
 					fbr := firstByteRecorder{}
 					obj, objDone := g.Dist.randomObj()
-					client, clDone := g.Client()
+					client, clDone := g.readThreadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -273,7 +400,7 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					op.Start = time.Now()
 					var err error
 					getOpts.VersionID = obj.VersionID
-					o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, getOpts)
+					o, err := client.GetObject(opContext(nonTerm, &op), g.Bucket, obj.Name, getOpts)
 					fbr.r = o
 					if err != nil {
 						g.Error("download error:", err)
@@ -284,7 +411,15 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 						objDone()
 						continue
 					}
-					n, err := io.Copy(io.Discard, &fbr)
+					var rd io.Reader = &fbr
+					var vf *verifier
+					if g.Verify {
+						vf = newVerifier(obj, getOpts.ServerSideEncryption == nil)
+					}
+					if vf != nil {
+						rd = io.TeeReader(rd, vf)
+					}
+					n, err := io.Copy(io.Discard, rd)
 					if err != nil {
 						g.Error("download error:", err)
 						op.Err = err.Error()
@@ -293,52 +428,75 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					op.End = time.Now()
 					if n != obj.Size && op.Err == "" {
 						op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+						op.ErrClass = ErrClassValidation
 						g.Error(op.Err)
 					}
+					if vf != nil && op.Err == "" {
+						if err := vf.check(); err != nil {
+							op.Err = err.Error()
+							op.ErrClass = ErrClassValidation
+							g.Error(obj.Name, ": ", err)
+						}
+					}
 					rcv <- op
 					objDone()
 					clDone()
 					o.Close()
 
-				case http.MethodPut:
+				case http.MethodPut, mixedMultipartOp:
 					obj := src.Object()
-					putOpts.ContentType = obj.ContentType
-					client, clDone := g.Client()
+					opts := putOpts
+					if operation == mixedMultipartOp {
+						obj = mpSrc.Object()
+						opts = mpOpts
+					}
+					opts.ContentType = obj.ContentType
+					client, clDone := g.writeThreadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
 						Endpoint: client.EndpointURL().String(),
 					}
+					genTime := timeGeneration(obj)
 					op.Start = time.Now()
-					res, err := client.PutObject(nonTerm, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
+					res, err := g.putObject(opContext(nonTerm, &op), client, obj, obj.Size, opts)
 					op.End = time.Now()
+					op.GenTime = genTime()
 					if err != nil {
 						g.Error("upload error:", err)
 						op.Err = err.Error()
 					}
 					obj.VersionID = res.VersionID
+					obj.ETag = res.ETag
 
 					if res.Size != obj.Size && op.Err == "" {
 						err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
 						if op.Err == "" {
 							op.Err = err
+							op.ErrClass = ErrClassValidation
 						}
 						g.Error(err)
 					}
 					clDone()
+					if g.Verify && op.Err == "" {
+						obj.Checksum, err = objectChecksum(obj.Reader)
+						if err != nil {
+							g.Error(err)
+						}
+					}
 					if op.Err == "" {
 						g.Dist.addObj(*obj)
 					}
 					rcv <- op
 				case http.MethodDelete:
-					client, clDone := g.Client()
+					client, clDone := g.writeThreadClient(i)
 					obj := g.Dist.deleteRandomObj()
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     0,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -346,7 +504,7 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					}
 
 					op.Start = time.Now()
-					err := client.RemoveObject(nonTerm, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
+					err := client.RemoveObject(opContext(nonTerm, &op), g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
 					op.End = time.Now()
 					clDone()
 					if err != nil {
@@ -354,12 +512,43 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 						op.Err = err.Error()
 					}
 					rcv <- op
+				case "LIST":
+					prefix := g.Dist.randomPrefix()
+					client, clDone := g.readThreadClient(i)
+					op := Operation{
+						OpType:   operation,
+						Thread:   uint32(i),
+						Size:     0,
+						File:     prefix,
+						Endpoint: client.EndpointURL().String(),
+					}
+					op.Start = time.Now()
+					listCh := client.ListObjects(opContext(nonTerm, &op), g.Bucket, minio.ListObjectsOptions{
+						Prefix:    prefix,
+						Recursive: true,
+						MaxKeys:   g.ListMaxKeys,
+					})
+					for obj := range listCh {
+						if obj.Err != nil {
+							g.Error("list error: ", obj.Err)
+							op.Err = obj.Err.Error()
+							continue
+						}
+						op.ObjPerOp++
+						if op.FirstByte == nil {
+							now := time.Now()
+							op.FirstByte = &now
+						}
+					}
+					op.End = time.Now()
+					rcv <- op
+					clDone()
 				case "STAT":
 					obj, objDone := g.Dist.randomObj()
-					client, clDone := g.Client()
+					client, clDone := g.readThreadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     0,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -367,7 +556,7 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					}
 					op.Start = time.Now()
 					var err error
-					objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, statOpts)
+					objI, err := client.StatObject(opContext(nonTerm, &op), g.Bucket, obj.Name, statOpts)
 					if err != nil {
 						g.Error("stat error: ", err)
 						op.Err = err.Error()
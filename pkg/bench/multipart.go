@@ -22,7 +22,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"strconv"
 	"sync"
@@ -117,7 +116,7 @@ func (g *Multipart) Prepare(ctx context.Context) error {
 				core := minio.Core{Client: client}
 				op := Operation{
 					OpType:   http.MethodPut,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -198,15 +197,14 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodGet, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, http.MethodGet, g.autoTermOpts())
 	}
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.GetOpts
@@ -220,7 +218,7 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
@@ -228,10 +226,10 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 				part := rng.Intn(len(g.objects))
 				obj := g.objects[part]
 				part += g.PartStart
-				client, cldone := g.Client()
+				client, cldone := g.threadClient(i)
 				op := Operation{
 					OpType:   http.MethodGet,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -240,7 +238,7 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 
 				op.Start = time.Now()
 				opts.PartNumber = part
-				o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, opts)
+				o, err := client.GetObject(opContext(nonTerm, &op), g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
@@ -259,6 +257,7 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 				op.End = time.Now()
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
+					op.ErrClass = ErrClassValidation
 					g.Error(op.Err)
 				}
 				rcv <- op
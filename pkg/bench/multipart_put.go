@@ -0,0 +1,150 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/warp/pkg/generator"
+)
+
+// MultipartPut benchmarks multipart uploads.
+// Each part upload and the completion of the upload are recorded as separate operations.
+type MultipartPut struct {
+	Common
+
+	// Parts is the number of parts in each object.
+	Parts int
+
+	// PartConcurrency is the number of parts uploaded concurrently for each object.
+	PartConcurrency int
+
+	prefixes map[string]struct{}
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (u *MultipartPut) Prepare(ctx context.Context) error {
+	return u.createEmptyBucket(ctx)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (u *MultipartPut) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(u.Concurrency)
+	u.addCollector()
+	c := u.Collector
+	if u.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, opMultipartPart, u.autoTermOpts())
+	}
+	u.prefixes = make(map[string]struct{}, u.Concurrency)
+
+	for i := 0; i < u.Concurrency; i++ {
+		srcs := make([]generator.Source, u.PartConcurrency)
+		for j := range srcs {
+			srcs[j] = u.Source()
+		}
+		u.prefixes[srcs[0].Prefix()] = struct{}{}
+		go func(i int) {
+			// Non-terminating contexts of the part workers.
+			partCtx := make([]context.Context, u.PartConcurrency)
+			for j := range partCtx {
+				partCtx[j] = u.threadContext(i*u.PartConcurrency + j)
+			}
+			nonTerm := partCtx[0]
+			rcv := c.Receiver()
+			defer wg.Done()
+			opts := u.PutOpts
+			done := ctx.Done()
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if u.waitThread(ctx, i) != nil {
+					return
+				}
+				obj := srcs[0].Object()
+				opts.ContentType = obj.ContentType
+				up, err := u.newMultipartUpload(nonTerm, rcv, i*u.PartConcurrency, obj.Name, u.Parts, opts)
+				if err != nil {
+					if u.backoff(ctx, 1) != nil {
+						return
+					}
+					continue
+				}
+
+				// Upload parts using PartConcurrency workers.
+				partCh := make(chan int, u.Parts)
+				for p := 1; p <= u.Parts; p++ {
+					partCh <- p
+				}
+				close(partCh)
+				var failed atomic.Bool
+				var pwg sync.WaitGroup
+				pwg.Add(u.PartConcurrency)
+				for j := 0; j < u.PartConcurrency; j++ {
+					go func(j int) {
+						defer pwg.Done()
+						src := srcs[j]
+						for partN := range partCh {
+							select {
+							case <-done:
+								return
+							default:
+							}
+							part := src.Object()
+							if up.uploadPart(partCtx[j], i*u.PartConcurrency+j, partN, part, part.Size) != nil {
+								failed.Store(true)
+								return
+							}
+						}
+					}(j)
+				}
+				pwg.Wait()
+
+				select {
+				case <-done:
+					failed.Store(true)
+				default:
+				}
+				if failed.Load() {
+					up.abort(nonTerm)
+					continue
+				}
+				up.complete(nonTerm)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (u *MultipartPut) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(u.prefixes))
+	for p := range u.prefixes {
+		pf = append(pf, p)
+	}
+	u.deleteAllInBucket(ctx, pf...)
+}
@@ -0,0 +1,173 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Operation types recorded by multipart uploads.
+const (
+	opMultipartCreate   = "CREATE"
+	opMultipartPart     = "PUTPART"
+	opMultipartComplete = "COMPLETE"
+)
+
+// multipartUpload is a single object being uploaded in parts.
+// Parts may be uploaded concurrently, as long as each part number is only uploaded by one goroutine at the time.
+type multipartUpload struct {
+	c         *Common
+	rcv       chan<- Operation
+	name      string
+	uploadID  string
+	opts      minio.PutObjectOptions
+	thread    int
+	completed []minio.CompletePart
+}
+
+// backoff waits before retry number n of a failed upload, or until ctx is canceled.
+func (c *Common) backoff(ctx context.Context, n int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(min(n, 6)) * 500 * time.Millisecond):
+		return nil
+	}
+}
+
+// newMultipartUpload creates a multipart upload of object name with the given number of parts.
+// The creation is recorded as a CREATE operation on thread.
+func (c *Common) newMultipartUpload(ctx context.Context, rcv chan<- Operation, thread int, name string, parts int, opts minio.PutObjectOptions) (*multipartUpload, error) {
+	client, cldone := c.threadClient(thread)
+	defer cldone()
+	core := minio.Core{Client: client}
+	op := Operation{
+		OpType:   opMultipartCreate,
+		Thread:   uint32(thread),
+		File:     name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	if c.DiscardOutput {
+		op.File = ""
+	}
+	op.Start = time.Now()
+	uploadID, err := core.NewMultipartUpload(opContext(ctx, &op), c.Bucket, name, opts)
+	op.End = time.Now()
+	if err != nil {
+		c.Error("new multipart upload error: ", err)
+		op.Err = err.Error()
+	}
+	rcv <- op
+	if err != nil {
+		return nil, err
+	}
+	return &multipartUpload{
+		c:         c,
+		rcv:       rcv,
+		name:      name,
+		uploadID:  uploadID,
+		opts:      opts,
+		thread:    thread,
+		completed: make([]minio.CompletePart, parts),
+	}, nil
+}
+
+// uploadPart uploads size bytes of part as part number partN on thread.
+// The upload is recorded as a PUTPART operation.
+func (m *multipartUpload) uploadPart(ctx context.Context, thread, partN int, part *generator.Object, size int64) error {
+	c := m.c
+	client, cldone := c.threadClient(thread)
+	defer cldone()
+	core := minio.Core{Client: client}
+	op := Operation{
+		OpType:   opMultipartPart,
+		Thread:   uint32(thread),
+		Size:     size,
+		File:     m.name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	if c.DiscardOutput {
+		op.File = ""
+	}
+	genTime := timeGeneration(part)
+	op.Start = time.Now()
+	res, err := core.PutObjectPart(opContext(ctx, &op), c.Bucket, m.name, m.uploadID, partN, part.Reader, size, minio.PutObjectPartOptions{
+		SSE:                  c.PutOpts.ServerSideEncryption,
+		DisableContentSha256: c.PutOpts.DisableContentSha256,
+	})
+	op.End = time.Now()
+	op.GenTime = genTime()
+	if err != nil {
+		c.Error("upload part error: ", err)
+		op.Err = err.Error()
+	} else if res.Size != size {
+		err = fmt.Errorf("short upload. want: %d, got: %d", size, res.Size)
+		op.Err = err.Error()
+		op.ErrClass = ErrClassValidation
+		c.Error(op.Err)
+	}
+	m.rcv <- op
+	if err != nil {
+		return err
+	}
+	m.completed[partN-1] = minio.CompletePart{PartNumber: partN, ETag: res.ETag}
+	return nil
+}
+
+// complete completes the upload with all uploaded parts.
+// The completion is recorded as a COMPLETE operation.
+func (m *multipartUpload) complete(ctx context.Context) error {
+	c := m.c
+	client, cldone := c.threadClient(m.thread)
+	defer cldone()
+	core := minio.Core{Client: client}
+	op := Operation{
+		OpType:   opMultipartComplete,
+		Thread:   uint32(m.thread),
+		File:     m.name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	if c.DiscardOutput {
+		op.File = ""
+	}
+	op.Start = time.Now()
+	_, err := core.CompleteMultipartUpload(opContext(ctx, &op), c.Bucket, m.name, m.uploadID, m.completed, m.opts)
+	op.End = time.Now()
+	if err != nil {
+		c.Error("complete multipart upload error: ", err)
+		op.Err = err.Error()
+	}
+	m.rcv <- op
+	return err
+}
+
+// abort aborts the upload. Failures are ignored.
+func (m *multipartUpload) abort(ctx context.Context) {
+	client, cldone := m.c.threadClient(m.thread)
+	defer cldone()
+	core := minio.Core{Client: client}
+	_ = core.AbortMultipartUpload(ctx, m.c.Bucket, m.name, m.uploadID)
+}
@@ -0,0 +1,360 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Notify benchmarks the latency of bucket event notifications.
+// Objects are uploaded continuously and the time from each upload
+// completing until its notification arrives is recorded.
+type Notify struct {
+	Common
+
+	// Target is where notifications are received from.
+	Target NotifyTarget
+
+	// Addr is the address the webhook receiver listens on.
+	Addr string
+
+	// Timeout is how long to wait for outstanding notifications
+	// after uploads have stopped.
+	Timeout time.Duration
+
+	prefixes map[string]struct{}
+
+	mu sync.Mutex
+	// pending contains uploaded objects waiting for a notification.
+	pending map[string]notifyPending
+	// early contains arrival times of notifications received
+	// before the upload returned.
+	early map[string]time.Time
+	rcv   chan<- Operation
+}
+
+// NotifyTarget selects how notifications are received.
+type NotifyTarget string
+
+// Notification targets.
+const (
+	// NotifyWebhook receives notifications with an embedded webhook receiver.
+	// The server must be configured to send bucket events to it.
+	NotifyWebhook NotifyTarget = "webhook"
+	// NotifyListen receives notifications with the MinIO ListenBucketNotification API.
+	NotifyListen NotifyTarget = "listen"
+)
+
+// notifyOp is the operation type of received notifications.
+const notifyOp = "NOTIFY"
+
+// notifyPending is an upload waiting for its notification.
+type notifyPending struct {
+	thread   uint32
+	size     int64
+	endpoint string
+	putEnd   time.Time
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (n *Notify) Prepare(ctx context.Context) error {
+	return n.createEmptyBucket(ctx)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (n *Notify) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	n.addCollector()
+	c := n.Collector
+	if n.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, notifyOp, n.autoTermOpts())
+	}
+	n.pending = make(map[string]notifyPending)
+	n.early = make(map[string]time.Time)
+	n.rcv = c.Receiver()
+	n.prefixes = make(map[string]struct{}, n.Concurrency)
+	srcs := make([]generator.Source, n.Concurrency)
+	for i := range srcs {
+		srcs[i] = n.Source()
+		n.prefixes[srcs[i].Prefix()] = struct{}{}
+	}
+
+	// Receive until outstanding notifications are done.
+	recvCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recvDone, err := n.receive(recvCtx)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n.Concurrency)
+	for i, src := range srcs {
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := n.threadContext(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			opts := n.PutOpts
+			done := ctx.Done()
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if n.waitThread(ctx, i) != nil {
+					return
+				}
+
+				obj := src.Object()
+				opts.ContentType = obj.ContentType
+				client, cldone := n.threadClient(i)
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					ObjPerOp: 1,
+					File:     obj.Name,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				res, err := n.putObject(opContext(nonTerm, &op), S3ObjectClient(client), obj, obj.Size, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					n.Error("upload error: ", err)
+					op.Err = err.Error()
+				}
+				op.Size = res.Size
+				rcv <- op
+				if err == nil {
+					n.uploaded(obj.Name, notifyPending{
+						thread:   op.Thread,
+						size:     op.Size,
+						endpoint: op.Endpoint,
+						putEnd:   op.End,
+					})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	n.waitPending(recvDone)
+	cancel()
+	<-recvDone
+	return c.Close(), nil
+}
+
+// uploaded registers an uploaded object as waiting for its notification.
+func (n *Notify) uploaded(key string, p notifyPending) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if at, ok := n.early[key]; ok {
+		delete(n.early, key)
+		n.rcv <- p.operation(key, at)
+		return
+	}
+	n.pending[key] = p
+}
+
+// received records the arrival of a notification for key.
+// Notifications for objects not uploaded by this client are ignored.
+func (n *Notify) received(key string, at time.Time) {
+	if !n.ownKey(key) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.rcv == nil {
+		// Benchmark has ended.
+		return
+	}
+	p, ok := n.pending[key]
+	if !ok {
+		// Upload is still in progress.
+		n.early[key] = at
+		return
+	}
+	delete(n.pending, key)
+	n.rcv <- p.operation(key, at)
+}
+
+// ownKey returns whether key is in a prefix uploaded to by this client.
+func (n *Notify) ownKey(key string) bool {
+	for p := range n.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// operation returns the notification operation of key arriving at.
+func (p notifyPending) operation(key string, at time.Time) Operation {
+	op := Operation{
+		OpType:   notifyOp,
+		Thread:   p.thread,
+		Size:     p.size,
+		ObjPerOp: 1,
+		File:     key,
+		Endpoint: p.endpoint,
+		Start:    p.putEnd,
+		End:      at,
+	}
+	// The notification may arrive before the upload response.
+	if op.End.Before(op.Start) {
+		op.End = op.Start
+	}
+	return op
+}
+
+// waitPending waits up to n.Timeout for outstanding notifications.
+// Uploads that did not receive a notification are recorded as errors.
+func (n *Notify) waitPending(recvDone <-chan struct{}) {
+	deadline := time.NewTimer(n.Timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		n.mu.Lock()
+		left := len(n.pending)
+		n.mu.Unlock()
+		if left == 0 {
+			return
+		}
+		select {
+		case <-tick.C:
+			continue
+		case <-recvDone:
+		case <-deadline.C:
+		}
+		break
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) > 0 {
+		n.Error(fmt.Sprintf("%d notifications not received within %v", len(n.pending), n.Timeout))
+	}
+	end := time.Now()
+	for key, p := range n.pending {
+		op := p.operation(key, end)
+		op.Err = fmt.Sprintf("notification not received within %v", n.Timeout)
+		n.rcv <- op
+	}
+	clear(n.pending)
+	n.rcv = nil
+}
+
+// receive starts receiving notifications until ctx is canceled.
+// The returned channel is closed when receiving has stopped.
+func (n *Notify) receive(ctx context.Context) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	switch n.Target {
+	case NotifyListen:
+		client, cldone := n.Client()
+		events := client.ListenBucketNotification(ctx, n.Bucket, "", "", []string{"s3:ObjectCreated:*"})
+		go func() {
+			defer close(done)
+			defer cldone()
+			for info := range events {
+				if info.Err != nil {
+					if ctx.Err() == nil {
+						n.Error("listen notification error: ", info.Err)
+					}
+					continue
+				}
+				n.receivedRecords(info.Records, time.Now())
+			}
+		}()
+	case NotifyWebhook:
+		ln, err := net.Listen("tcp", n.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to start webhook receiver: %w", err)
+		}
+		console.Eraseline()
+		console.Info("\rReceiving notifications on ", ln.Addr().String())
+		srv := &http.Server{Handler: http.HandlerFunc(n.webhook)}
+		go func() {
+			defer close(done)
+			err := srv.Serve(ln)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				n.Error("webhook receiver error: ", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+	default:
+		return nil, fmt.Errorf("unknown notification target %q", n.Target)
+	}
+	return done, nil
+}
+
+// webhook handles a notification sent to the webhook receiver.
+// Requests that are not notifications, like the connectivity check
+// done when the target is configured, are accepted and ignored.
+func (n *Notify) webhook(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	var info notification.Info
+	if r.Method == http.MethodPost && json.NewDecoder(r.Body).Decode(&info) == nil {
+		n.receivedRecords(info.Records, at)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// receivedRecords records notifications of created objects in the benchmark bucket.
+func (n *Notify) receivedRecords(records []notification.Event, at time.Time) {
+	for _, rec := range records {
+		if rec.S3.Bucket.Name != n.Bucket || !strings.HasPrefix(rec.EventName, "s3:ObjectCreated:") {
+			continue
+		}
+		key, err := url.QueryUnescape(rec.S3.Object.Key)
+		if err != nil {
+			key = rec.S3.Object.Key
+		}
+		n.received(key, at)
+	}
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (n *Notify) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(n.prefixes))
+	for p := range n.prefixes {
+		pf = append(pf, p)
+	}
+	n.deleteAllInBucket(ctx, pf...)
+}
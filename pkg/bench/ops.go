@@ -19,10 +19,12 @@ package bench
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"math"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -45,7 +47,67 @@ type Operation struct {
 	Endpoint  string     `json:"endpoint"`
 	ObjPerOp  int        `json:"ops"`
 	Size      int64      `json:"size"`
-	Thread    uint16     `json:"thread"`
+	Thread    uint32     `json:"thread"`
+	// Concurrency is the number of active threads when the operation started.
+	// Only set when the concurrency changes during the benchmark.
+	Concurrency uint32 `json:"concurrency,omitempty"`
+	// CryptoTime is the time spent on client-side encryption or decryption.
+	CryptoTime time.Duration `json:"crypto_ns,omitempty"`
+	// GenTime is the time spent generating uploaded data.
+	GenTime time.Duration `json:"gen_ns,omitempty"`
+	// BytesScanned and BytesReturned are reported by S3 Select.
+	BytesScanned  int64 `json:"bytes_scanned,omitempty"`
+	BytesReturned int64 `json:"bytes_returned,omitempty"`
+	// ErrClass is the class of Err. Empty for infrastructure errors.
+	ErrClass string `json:"err_class,omitempty"`
+
+	// Conn is the local address of the connection used, if recorded.
+	Conn string `json:"conn,omitempty"`
+	// Remote is the IP of the server the operation was sent to, if recorded.
+	Remote string `json:"remote,omitempty"`
+	// WireBytes is the size of the responses received and Pages the number of responses.
+	// Only recorded for listings.
+	WireBytes int64 `json:"wire_bytes,omitempty"`
+	Pages     int   `json:"pages,omitempty"`
+	// Phase is the name of the scheduled phase when the operation started, if any.
+	Phase string `json:"phase,omitempty"`
+	// Status is the HTTP status code of the last response received for the operation, if recorded.
+	Status int `json:"status,omitempty"`
+	// Retries is the number of failed requests that were retried during the operation.
+	Retries int `json:"retries,omitempty"`
+	// RequestID and DeploymentID are returned by the server for the last request of the operation.
+	// Only recorded if enabled.
+	RequestID    string `json:"request_id,omitempty"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+	// Range is the inclusive byte range requested as "start-end", if any.
+	Range string `json:"range,omitempty"`
+
+	// status collects the responses to the requests of the operation until it is annotated.
+	status *opStatus
+}
+
+// ErrClassValidation is the error class of operations that succeeded,
+// but returned unexpected results, like wrong sizes or content.
+const ErrClassValidation = "validation"
+
+// ErrClassNotFound is the error class of reads that did not find an object that was written.
+// These are also validation errors.
+const ErrClassNotFound = "not_found"
+
+// ErrClassStale is the error class of reads that returned another version than the one written.
+// These are also validation errors.
+const ErrClassStale = "stale"
+
+// ValidationErr returns whether the operation failed validation.
+func (o Operation) ValidationErr() bool {
+	if o.Err == "" {
+		return false
+	}
+	switch o.ErrClass {
+	case ErrClassValidation, ErrClassNotFound, ErrClassStale:
+		return true
+	}
+	return false
 }
 
 // Duration returns the duration o.End-o.Start
@@ -671,6 +733,19 @@ var log10ToLog2Size = map[int]int64{
 	12: 1 << 40,
 }
 
+// SizeBucket returns the log10 size bucket of size as the lower bound of the bucket.
+// For example 1MiB is returned for sizes from 1MiB up to, but not including, 10MiB.
+func SizeBucket(size int64) string {
+	if size < 10 {
+		return "0B"
+	}
+	l := 1
+	for l < len(log10ToSize)-1 && size >= log10ToLog2Size[l+1] {
+		l++
+	}
+	return log10ToSize[l]
+}
+
 func (o Operations) SingleSizeSegment() SizeSegment {
 	minSize, maxSize := o.MinMaxSize()
 	var minL10, maxL10 int
@@ -794,8 +869,8 @@ func (o Operations) ActiveTimeRange(allThreads bool) (start, end time.Time) {
 		return
 	}
 	threads := o.Threads()
-	firstEnded := make(map[uint16]time.Time, threads)
-	lastStarted := make(map[uint16]time.Time, threads)
+	firstEnded := make(map[uint32]time.Time, threads)
+	lastStarted := make(map[uint32]time.Time, threads)
 	for _, op := range o {
 		ended, ok := firstEnded[op.Thread]
 		if !ok || ended.After(op.End) {
@@ -831,7 +906,7 @@ func (o Operations) Threads() int {
 	if len(o) == 0 {
 		return 0
 	}
-	maxT := uint16(0)
+	maxT := uint32(0)
 	for _, op := range o {
 		if op.Thread > maxT {
 			maxT = op.Thread
@@ -842,11 +917,15 @@ func (o Operations) Threads() int {
 
 // OffsetThreads adds an offset to all thread ids and
 // returns the next thread number.
-func (o Operations) OffsetThreads(n uint16) uint16 {
+// An error is returned if thread ids would overflow.
+func (o Operations) OffsetThreads(n uint32) (uint32, error) {
 	if len(o) == 0 {
-		return 0
+		return n, nil
 	}
-	maxT := uint16(0)
+	if uint64(n)+uint64(o.Threads()) > math.MaxUint32 {
+		return 0, fmt.Errorf("too many threads: offset %d + %d threads exceeds %d", n, o.Threads(), uint32(math.MaxUint32))
+	}
+	maxT := uint32(0)
 	for i, op := range o {
 		op.Thread += n
 		if op.Thread > maxT {
@@ -854,7 +933,7 @@ func (o Operations) OffsetThreads(n uint16) uint16 {
 		}
 		o[i] = op
 	}
-	return maxT + 1
+	return maxT + 1, nil
 }
 
 // Hosts returns the number of servers.
@@ -1026,40 +1105,165 @@ func (o Operations) FilterErrors() Operations {
 	return errs
 }
 
+// FilterValidationErrors returns all operations that failed validation.
+func (o Operations) FilterValidationErrors() Operations {
+	if len(o) == 0 {
+		return nil
+	}
+	errs := Operations{}
+	for _, op := range o {
+		if op.ValidationErr() {
+			errs = append(errs, op)
+		}
+	}
+	return errs
+}
+
 // CSV will write the operations to w as CSV.
 // The comment, if any, is written at the end of the file, each line prefixed with '# '.
 func (o Operations) CSV(w io.Writer, comment string) error {
 	bw := bufio.NewWriter(w)
-	_, err := bw.WriteString("idx\tthread\top\tclient_id\tn_objects\tbytes\tendpoint\tfile\terror\tstart\tfirst_byte\tend\tduration_ns\n")
+	_, err := bw.WriteString(csvHeader)
 	if err != nil {
 		return err
 	}
+	if err := o.csvRows(bw, 0); err != nil {
+		return err
+	}
+	if err := csvComment(bw, comment); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
 
+// csvChunkOps is the number of operations in each chunk written by CSVParallel.
+const csvChunkOps = 100000
+
+// CSVParallel will write the operations to w as CSV, like CSV.
+// Chunks of operations are formatted concurrently and passed to encode,
+// which must return the bytes to write. Chunks are written to w in order.
+// encode is called concurrently.
+func (o Operations) CSVParallel(w io.Writer, comment string, encode func(b []byte) []byte) error {
+	chunks := max((len(o)+csvChunkOps-1)/csvChunkOps, 1)
+	results := make([]chan []byte, chunks)
+	for i := range results {
+		results[i] = make(chan []byte, 1)
+	}
+	// Limit the number of chunks kept in memory.
+	sem := make(chan struct{}, 2*runtime.GOMAXPROCS(0))
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := range results {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func(i int) {
+				var buf bytes.Buffer
+				if i == 0 {
+					buf.WriteString(csvHeader)
+				}
+				start := i * csvChunkOps
+				o[start:min(start+csvChunkOps, len(o))].csvRows(&buf, start)
+				if i == chunks-1 {
+					csvComment(&buf, comment)
+				}
+				results[i] <- encode(buf.Bytes())
+			}(i)
+		}
+	}()
+	for _, res := range results {
+		_, err := w.Write(<-res)
+		<-sem
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvHeader is the header of CSV operations.
+const csvHeader = "idx\tthread\top\tclient_id\tn_objects\tbytes\tendpoint\tfile\terror\tstart\tfirst_byte\tend\tduration_ns\tconcurrency\tcrypto_ns\tbytes_scanned\tbytes_returned\terr_class\tconn\twire_bytes\tpages\tremote\tgen_ns\tphase\tstatus\tretries\trequest_id\tdeployment_id\trange\n"
+
+// csvRows writes the operations as CSV rows.
+// The index of the first operation is first.
+func (o Operations) csvRows(w io.Writer, first int) error {
 	for i, op := range o {
 		var ttfb string
 		if op.FirstByte != nil {
 			ttfb = op.FirstByte.Format(time.RFC3339Nano)
 		}
-		_, err := fmt.Fprintf(bw, "%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", i, op.Thread, op.OpType, op.ClientID, op.ObjPerOp, op.Size, csvEscapeString(op.Endpoint), op.File, csvEscapeString(op.Err), op.Start.Format(time.RFC3339Nano), ttfb, op.End.Format(time.RFC3339Nano), op.End.Sub(op.Start)/time.Nanosecond)
+		_, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%s\t%d\t%s\t%d\t%d\t%s\t%s\t%s\n", first+i, op.Thread, op.OpType, op.ClientID, op.ObjPerOp, op.Size, csvEscapeString(op.Endpoint), op.File, csvEscapeString(op.Err), op.Start.Format(time.RFC3339Nano), ttfb, op.End.Format(time.RFC3339Nano), op.End.Sub(op.Start)/time.Nanosecond, op.Concurrency, op.CryptoTime/time.Nanosecond, op.BytesScanned, op.BytesReturned, op.ErrClass, op.Conn, op.WireBytes, op.Pages, op.Remote, op.GenTime/time.Nanosecond, op.Phase, op.Status, op.Retries, op.RequestID, op.DeploymentID, op.Range)
 		if err != nil {
 			return err
 		}
 	}
-	if len(comment) > 0 {
-		lines := strings.Split(comment, "\n")
-		for _, txt := range lines {
-			_, err := bw.WriteString("# " + txt + "\n")
-			if err != nil {
-				return err
-			}
+	return nil
+}
+
+// csvComment writes the comment, if any, with each line prefixed with '# '.
+func csvComment(w io.Writer, comment string) error {
+	if len(comment) == 0 {
+		return nil
+	}
+	for _, txt := range strings.Split(comment, "\n") {
+		_, err := io.WriteString(w, "# "+txt+"\n")
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return bw.Flush()
+// opsMappers returns functions for mapping client IDs and file names of loaded operations.
+// When only analyzing, values are replaced by shorter ones to use less RAM.
+func opsMappers(analyzeOnly bool) (client, file func(string) string) {
+	if !analyzeOnly {
+		same := func(s string) string { return s }
+		return same, same
+	}
+	clientMap := make(map[string]string, 16)
+	cb := byte('a')
+	client = func(c string) string {
+		if v, ok := clientMap[c]; ok {
+			return v
+		}
+		clientMap[c] = string([]byte{cb})
+		cb++
+		return clientMap[c]
+	}
+	var i int
+	m := make(map[string]int)
+	file = func(s string) string {
+		if v, ok := m[s]; ok {
+			return strconv.Itoa(v)
+		}
+		i++
+		m[s] = i
+		return strconv.Itoa(i)
+	}
+	return client, file
 }
 
 // OperationsFromCSV will load operations from CSV.
 func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, error) {
+	ops, _, err := OperationsFromCSVMeta(r, analyzeOnly, offset, limit, log)
+	return ops, err
+}
+
+// OperationsFromCSVMeta will load operations from CSV.
+// Metadata is parsed from the comments in the CSV.
+// Comments are read when the end of the data is reached, so if limit is set,
+// metadata may be missing.
+func OperationsFromCSVMeta(r io.Reader, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, Metadata, error) {
+	comments := newCommentReader(r)
+	ops, err := operationsFromCSV(comments, analyzeOnly, offset, limit, log)
+	return ops, ParseMetadata(comments.comments), err
+}
+
+func operationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, error) {
 	var ops Operations
 	cr := csv.NewReader(r)
 	cr.Comma = '\t'
@@ -1073,34 +1277,15 @@ func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log fun
 	for i, s := range header {
 		fieldIdx[s] = i
 	}
-	clientMap := make(map[string]string, 16)
-	cb := byte('a')
-	getClient := func(c string) string {
-		if !analyzeOnly {
-			return c
-		}
-		if v, ok := clientMap[c]; ok {
+	getClient, fileMap := opsMappers(analyzeOnly)
+	// Deployment IDs are shared by many operations.
+	deployments := make(map[string]string)
+	getDeployment := func(id string) string {
+		if v, ok := deployments[id]; ok {
 			return v
 		}
-		clientMap[c] = string([]byte{cb})
-		cb++
-		return clientMap[c]
-	}
-	fileMap := func(s string) string {
-		return s
-	}
-	if analyzeOnly {
-		// When analyzing map file names to a number for less RAM.
-		var i int
-		m := make(map[string]int)
-		fileMap = func(s string) string {
-			if v, ok := m[s]; ok {
-				return strconv.Itoa(v)
-			}
-			i++
-			m[s] = i
-			return strconv.Itoa(i)
-		}
+		deployments[id] = id
+		return id
 	}
 	for {
 		values, err := cr.Read()
@@ -1137,7 +1322,7 @@ func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log fun
 		if err != nil {
 			return nil, err
 		}
-		thread, err := strconv.ParseUint(values[fieldIdx["thread"]], 10, 16)
+		thread, err := strconv.ParseUint(values[fieldIdx["thread"]], 10, 32)
 		if err != nil {
 			return nil, err
 		}
@@ -1152,20 +1337,127 @@ func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log fun
 		if idx, ok := fieldIdx["client_id"]; ok {
 			clientID = values[idx]
 		}
-		file := fileMap(values[fieldIdx["file"]])
+		var concurrency uint64
+		if idx, ok := fieldIdx["concurrency"]; ok {
+			concurrency, err = strconv.ParseUint(values[idx], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var cryptoTime int64
+		if idx, ok := fieldIdx["crypto_ns"]; ok {
+			cryptoTime, err = strconv.ParseInt(values[idx], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var scanned, returned int64
+		if idx, ok := fieldIdx["bytes_scanned"]; ok {
+			scanned, err = strconv.ParseInt(values[idx], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if idx, ok := fieldIdx["bytes_returned"]; ok {
+			returned, err = strconv.ParseInt(values[idx], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var errClass string
+		if idx, ok := fieldIdx["err_class"]; ok {
+			errClass = values[idx]
+		}
+		var conn string
+		if idx, ok := fieldIdx["conn"]; ok {
+			conn = values[idx]
+		}
+		var remote string
+		if idx, ok := fieldIdx["remote"]; ok {
+			remote = values[idx]
+		}
+		var wireBytes, pages int64
+		if idx, ok := fieldIdx["wire_bytes"]; ok {
+			wireBytes, err = strconv.ParseInt(values[idx], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if idx, ok := fieldIdx["pages"]; ok {
+			pages, err = strconv.ParseInt(values[idx], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var genTime int64
+		if idx, ok := fieldIdx["gen_ns"]; ok {
+			genTime, err = strconv.ParseInt(values[idx], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var phase string
+		if idx, ok := fieldIdx["phase"]; ok {
+			phase = values[idx]
+		}
+		var status, retries int64
+		if idx, ok := fieldIdx["status"]; ok {
+			status, err = strconv.ParseInt(values[idx], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if idx, ok := fieldIdx["retries"]; ok {
+			retries, err = strconv.ParseInt(values[idx], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var requestID, deploymentID string
+		if idx, ok := fieldIdx["request_id"]; ok {
+			requestID = values[idx]
+		}
+		if idx, ok := fieldIdx["deployment_id"]; ok {
+			deploymentID = getDeployment(values[idx])
+		}
+		var byteRange string
+		if idx, ok := fieldIdx["range"]; ok {
+			byteRange = values[idx]
+		}
+		file := values[fieldIdx["file"]]
+		if values[fieldIdx["op"]] != OpAnnotation {
+			file = fileMap(file)
+		}
 
 		ops = append(ops, Operation{
-			OpType:    values[fieldIdx["op"]],
-			ObjPerOp:  int(objs),
-			Start:     start,
-			FirstByte: ttfb,
-			End:       end,
-			Err:       values[fieldIdx["error"]],
-			Size:      size,
-			File:      file,
-			Thread:    uint16(thread),
-			Endpoint:  endpoint,
-			ClientID:  getClient(clientID),
+			OpType:      values[fieldIdx["op"]],
+			ObjPerOp:    int(objs),
+			Start:       start,
+			FirstByte:   ttfb,
+			End:         end,
+			Err:         values[fieldIdx["error"]],
+			Size:        size,
+			File:        file,
+			Thread:      uint32(thread),
+			Endpoint:    endpoint,
+			ClientID:    getClient(clientID),
+			Concurrency: uint32(concurrency),
+			CryptoTime:  time.Duration(cryptoTime),
+			GenTime:     time.Duration(genTime),
+
+			BytesScanned:  scanned,
+			BytesReturned: returned,
+			ErrClass:      errClass,
+			Conn:          conn,
+			WireBytes:     wireBytes,
+			Pages:         int(pages),
+			Remote:        remote,
+			Phase:         phase,
+			Status:        int(status),
+			Retries:       int(retries),
+			RequestID:     requestID,
+			DeploymentID:  deploymentID,
+			Range:         byteRange,
 		})
 		if log != nil && len(ops)%1000000 == 0 {
 			console.Eraseline()
@@ -0,0 +1,64 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// threadPacing spreads the operations of threads over time.
+type threadPacing struct {
+	// stagger is the time over which thread starts are spread.
+	stagger time.Duration
+	// jitter is the maximum random delay before each operation.
+	jitter  time.Duration
+	threads int
+
+	once  sync.Once
+	start time.Time
+}
+
+// wait until the thread may run its next operation.
+// The first operation of thread n is delayed by n/threads of the stagger duration,
+// counted from the first call of any thread.
+func (p *threadPacing) wait(ctx context.Context, thread int) error {
+	p.once.Do(func() {
+		p.start = time.Now()
+	})
+	var delay time.Duration
+	if p.stagger > 0 && p.threads > 0 {
+		delay = time.Until(p.start.Add(p.stagger * time.Duration(thread%p.threads) / time.Duration(p.threads)))
+	}
+	if p.jitter > 0 {
+		delay = max(delay, 0) + time.Duration(rand.Int63n(int64(p.jitter)))
+	}
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
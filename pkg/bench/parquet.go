@@ -0,0 +1,713 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Parquet physical types, encodings and codecs used.
+const (
+	pqInt32     = 1
+	pqInt64     = 2
+	pqByteArray = 6
+
+	pqEncPlain = 0
+	pqEncRLE   = 3
+
+	pqCodecNone   = 0
+	pqCodecSnappy = 1
+	pqCodecZstd   = 6
+
+	pqRowGroupSize = 1 << 17
+
+	// pqMaxRowGroupSize is the largest row group read.
+	// Larger sizes are treated as corrupt metadata.
+	pqMaxRowGroupSize = 1 << 24
+)
+
+var pqMagic = []byte("PAR1")
+
+// Keys of the metadata stored in the Parquet key/value metadata.
+// Custom values are stored with pqMetaValuePrefix followed by their key.
+const (
+	pqMetaCommand     = "warp.command"
+	pqMetaVersion     = "warp.version"
+	pqMetaValuePrefix = "warp.meta."
+)
+
+// pqColumn describes a column in the Parquet output.
+// Integer columns use get/set, string columns use getStr/setStr.
+type pqColumn struct {
+	name      string
+	typ       int32
+	optional  bool
+	timestamp bool
+	// unsigned integer columns are annotated, so readers don't return negative values.
+	unsigned bool
+	get      func(idx int, op *Operation) (v int64, ok bool)
+	set      func(op *Operation, v int64)
+	getStr   func(op *Operation) string
+	setStr   func(op *Operation, s string)
+}
+
+// pqColumns matches the columns of the CSV output.
+var pqColumns = []pqColumn{
+	{
+		name: "idx", typ: pqInt64,
+		get: func(idx int, _ *Operation) (int64, bool) { return int64(idx), true },
+		set: func(*Operation, int64) {},
+	},
+	{
+		name: "thread", typ: pqInt32, unsigned: true,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.Thread), true },
+		set: func(op *Operation, v int64) { op.Thread = uint32(v) },
+	},
+	{
+		name: "op", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.OpType },
+		setStr: func(op *Operation, s string) { op.OpType = s },
+	},
+	{
+		name: "client_id", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.ClientID },
+		setStr: func(op *Operation, s string) { op.ClientID = s },
+	},
+	{
+		name: "n_objects", typ: pqInt32,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.ObjPerOp), true },
+		set: func(op *Operation, v int64) { op.ObjPerOp = int(v) },
+	},
+	{
+		name: "bytes", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return op.Size, true },
+		set: func(op *Operation, v int64) { op.Size = v },
+	},
+	{
+		name: "endpoint", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Endpoint },
+		setStr: func(op *Operation, s string) { op.Endpoint = s },
+	},
+	{
+		name: "file", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.File },
+		setStr: func(op *Operation, s string) { op.File = s },
+	},
+	{
+		name: "error", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Err },
+		setStr: func(op *Operation, s string) { op.Err = s },
+	},
+	{
+		name: "start", typ: pqInt64, timestamp: true,
+		get: func(_ int, op *Operation) (int64, bool) { return op.Start.UnixNano(), true },
+		set: func(op *Operation, v int64) { op.Start = time.Unix(0, v) },
+	},
+	{
+		name: "first_byte", typ: pqInt64, timestamp: true, optional: true,
+		get: func(_ int, op *Operation) (int64, bool) {
+			if op.FirstByte == nil {
+				return 0, false
+			}
+			return op.FirstByte.UnixNano(), true
+		},
+		set: func(op *Operation, v int64) {
+			t := time.Unix(0, v)
+			op.FirstByte = &t
+		},
+	},
+	{
+		name: "end", typ: pqInt64, timestamp: true,
+		get: func(_ int, op *Operation) (int64, bool) { return op.End.UnixNano(), true },
+		set: func(op *Operation, v int64) { op.End = time.Unix(0, v) },
+	},
+	{
+		name: "duration_ns", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.End.Sub(op.Start)), true },
+		set: func(*Operation, int64) {},
+	},
+	{
+		name: "concurrency", typ: pqInt32, unsigned: true,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.Concurrency), true },
+		set: func(op *Operation, v int64) { op.Concurrency = uint32(v) },
+	},
+	{
+		name: "crypto_ns", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.CryptoTime), true },
+		set: func(op *Operation, v int64) { op.CryptoTime = time.Duration(v) },
+	},
+	{
+		name: "bytes_scanned", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return op.BytesScanned, true },
+		set: func(op *Operation, v int64) { op.BytesScanned = v },
+	},
+	{
+		name: "bytes_returned", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return op.BytesReturned, true },
+		set: func(op *Operation, v int64) { op.BytesReturned = v },
+	},
+	{
+		name: "err_class", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.ErrClass },
+		setStr: func(op *Operation, s string) { op.ErrClass = s },
+	},
+	{
+		name: "conn", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Conn },
+		setStr: func(op *Operation, s string) { op.Conn = s },
+	},
+	{
+		name: "wire_bytes", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return op.WireBytes, true },
+		set: func(op *Operation, v int64) { op.WireBytes = v },
+	},
+	{
+		name: "pages", typ: pqInt32,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.Pages), true },
+		set: func(op *Operation, v int64) { op.Pages = int(v) },
+	},
+	{
+		name: "remote", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Remote },
+		setStr: func(op *Operation, s string) { op.Remote = s },
+	},
+	{
+		name: "gen_ns", typ: pqInt64,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.GenTime), true },
+		set: func(op *Operation, v int64) { op.GenTime = time.Duration(v) },
+	},
+	{
+		name: "phase", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Phase },
+		setStr: func(op *Operation, s string) { op.Phase = s },
+	},
+	{
+		name: "status", typ: pqInt32,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.Status), true },
+		set: func(op *Operation, v int64) { op.Status = int(v) },
+	},
+	{
+		name: "retries", typ: pqInt32,
+		get: func(_ int, op *Operation) (int64, bool) { return int64(op.Retries), true },
+		set: func(op *Operation, v int64) { op.Retries = int(v) },
+	},
+	{
+		name: "request_id", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.RequestID },
+		setStr: func(op *Operation, s string) { op.RequestID = s },
+	},
+	{
+		name: "deployment_id", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.DeploymentID },
+		setStr: func(op *Operation, s string) { op.DeploymentID = s },
+	},
+	{
+		name: "range", typ: pqByteArray,
+		getStr: func(op *Operation) string { return op.Range },
+		setStr: func(op *Operation, s string) { op.Range = s },
+	},
+}
+
+// Parquet will write the operations to w in Parquet format.
+// Columns match the CSV output. Timestamps are stored as nanoseconds since epoch in UTC.
+// The metadata is stored in the key/value metadata of the file.
+func (o Operations) Parquet(w io.Writer, m Metadata) error {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return err
+	}
+	defer enc.Close()
+
+	type chunk struct {
+		offset, uncompressed, compressed, values int64
+	}
+	type rowGroup struct {
+		chunks []chunk
+		rows   int64
+	}
+	var groups []rowGroup
+	offset := int64(len(pqMagic))
+	if _, err := w.Write(pqMagic); err != nil {
+		return err
+	}
+	var page bytes.Buffer
+	for start := 0; start < len(o); start += pqRowGroupSize {
+		ops := o[start:min(start+pqRowGroupSize, len(o))]
+		rg := rowGroup{rows: int64(len(ops))}
+		for _, col := range pqColumns {
+			page.Reset()
+			if col.optional {
+				levels := make([]bool, len(ops))
+				for i := range ops {
+					_, levels[i] = col.get(start+i, &ops[i])
+				}
+				pqWriteLevels(&page, levels)
+			}
+			var tmp [8]byte
+			for i := range ops {
+				op := &ops[i]
+				switch col.typ {
+				case pqByteArray:
+					s := col.getStr(op)
+					binary.LittleEndian.PutUint32(tmp[:], uint32(len(s)))
+					page.Write(tmp[:4])
+					page.WriteString(s)
+				case pqInt32:
+					if v, ok := col.get(start+i, op); ok {
+						binary.LittleEndian.PutUint32(tmp[:], uint32(v))
+						page.Write(tmp[:4])
+					}
+				case pqInt64:
+					if v, ok := col.get(start+i, op); ok {
+						binary.LittleEndian.PutUint64(tmp[:], uint64(v))
+						page.Write(tmp[:])
+					}
+				}
+			}
+			data := enc.EncodeAll(page.Bytes(), nil)
+			hdr := newThriftWriter()
+			hdr.i32(1, 0) // DATA_PAGE
+			hdr.i32(2, int32(page.Len()))
+			hdr.i32(3, int32(len(data)))
+			hdr.structBegin(5)
+			hdr.i32(1, int32(len(ops)))
+			hdr.i32(2, pqEncPlain)
+			hdr.i32(3, pqEncRLE)
+			hdr.i32(4, pqEncRLE)
+			hdr.structEnd()
+			h := hdr.finish()
+			if _, err := w.Write(h); err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			rg.chunks = append(rg.chunks, chunk{
+				offset:       offset,
+				uncompressed: int64(len(h) + page.Len()),
+				compressed:   int64(len(h) + len(data)),
+				values:       int64(len(ops)),
+			})
+			offset += int64(len(h) + len(data))
+		}
+		groups = append(groups, rg)
+	}
+
+	// File metadata.
+	meta := newThriftWriter()
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStructTyp, len(pqColumns)+1)
+	meta.elemBegin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(pqColumns)))
+	meta.structEnd()
+	for _, col := range pqColumns {
+		meta.elemBegin()
+		meta.i32(1, col.typ)
+		if col.optional {
+			meta.i32(3, 1)
+		} else {
+			meta.i32(3, 0)
+		}
+		meta.str(4, col.name)
+		switch {
+		case col.typ == pqByteArray:
+			meta.i32(6, 0) // UTF8
+			meta.structBegin(10)
+			meta.structBegin(1) // STRING
+			meta.structEnd()
+			meta.structEnd()
+		case col.timestamp:
+			meta.structBegin(10)
+			meta.structBegin(8) // TIMESTAMP
+			meta.boolean(1, true)
+			meta.structBegin(2)
+			meta.structBegin(3) // NANOS
+			meta.structEnd()
+			meta.structEnd()
+			meta.structEnd()
+			meta.structEnd()
+		case col.unsigned:
+			meta.i32(6, 13) // UINT_32
+			meta.structBegin(10)
+			meta.structBegin(10) // INTEGER
+			meta.i8(1, 32)
+			meta.boolean(2, false)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.structEnd()
+	}
+	meta.i64(3, int64(len(o)))
+	meta.listBegin(4, thriftStructTyp, len(groups))
+	for _, rg := range groups {
+		meta.elemBegin()
+		meta.listBegin(1, thriftStructTyp, len(rg.chunks))
+		var total int64
+		for i, c := range rg.chunks {
+			col := pqColumns[i]
+			total += c.uncompressed
+			meta.elemBegin()
+			meta.i64(2, c.offset)
+			meta.structBegin(3)
+			meta.i32(1, col.typ)
+			meta.listBegin(2, thriftI32, 2)
+			meta.i32Elem(pqEncPlain)
+			meta.i32Elem(pqEncRLE)
+			meta.listBegin(3, thriftBinary, 1)
+			meta.strElem(col.name)
+			meta.i32(4, pqCodecZstd)
+			meta.i64(5, c.values)
+			meta.i64(6, c.uncompressed)
+			meta.i64(7, c.compressed)
+			meta.i64(9, c.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64(2, total)
+		meta.i64(3, rg.rows)
+		meta.structEnd()
+	}
+	if kv := pqMetaKeyValues(m); len(kv) > 0 {
+		meta.listBegin(5, thriftStructTyp, len(kv)/2)
+		for i := 0; i < len(kv); i += 2 {
+			meta.elemBegin()
+			meta.str(1, kv[i])
+			meta.str(2, kv[i+1])
+			meta.structEnd()
+		}
+	}
+	meta.str(6, "warp")
+	footer := meta.finish()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, pqMagic...)
+	_, err = w.Write(footer)
+	return err
+}
+
+// pqMetaKeyValues returns the metadata as consecutive key and value pairs.
+func pqMetaKeyValues(m Metadata) []string {
+	var kv []string
+	if m.Command != "" {
+		kv = append(kv, pqMetaCommand, m.Command)
+	}
+	if m.Version != "" {
+		kv = append(kv, pqMetaVersion, m.Version)
+	}
+	keys := make([]string, 0, len(m.Values))
+	for k := range m.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kv = append(kv, pqMetaValuePrefix+k, m.Values[k])
+	}
+	return kv
+}
+
+// pqReadMeta returns the metadata stored in the key/value metadata of the file.
+// Keys not written by warp are ignored.
+func pqReadMeta(meta thriftStruct) Metadata {
+	var m Metadata
+	for _, e := range meta.list(5) {
+		kv, ok := e.(thriftStruct)
+		if !ok {
+			continue
+		}
+		k, v := kv.str(1), kv.str(2)
+		switch {
+		case k == pqMetaCommand:
+			m.Command = v
+		case k == pqMetaVersion:
+			m.Version = v
+		case strings.HasPrefix(k, pqMetaValuePrefix):
+			if m.Values == nil {
+				m.Values = make(map[string]string)
+			}
+			m.Values[strings.TrimPrefix(k, pqMetaValuePrefix)] = v
+		}
+	}
+	return m
+}
+
+// pqWriteLevels writes definition levels with bit width 1
+// as a length prefixed RLE/bit-packed hybrid run.
+func pqWriteLevels(w *bytes.Buffer, levels []bool) {
+	groups := (len(levels) + 7) / 8
+	run := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, v := range levels {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(run)+len(packed)))
+	w.Write(tmp[:])
+	w.Write(run)
+	w.Write(packed)
+}
+
+// pqReadLevels reads n definition levels with bit width 1.
+// The number of bytes consumed is returned.
+func pqReadLevels(b []byte, n int) ([]bool, int, error) {
+	if len(b) < 4 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	size := int(binary.LittleEndian.Uint32(b))
+	if size > len(b)-4 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	src := b[4 : 4+size]
+	levels := make([]bool, 0, n)
+	for len(levels) < n {
+		h, k := binary.Uvarint(src)
+		if k <= 0 {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		src = src[k:]
+		if h&1 == 1 {
+			// Bit-packed groups of 8.
+			if h>>1 > uint64(len(src)) {
+				return nil, 0, io.ErrUnexpectedEOF
+			}
+			cnt := int(h >> 1)
+			for i := 0; i < cnt*8 && len(levels) < n; i++ {
+				levels = append(levels, src[i/8]&(1<<(i%8)) != 0)
+			}
+			src = src[cnt:]
+			continue
+		}
+		// RLE run, value is one byte.
+		if len(src) < 1 {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		v := src[0] != 0
+		src = src[1:]
+		for i := uint64(0); i < h>>1 && len(levels) < n; i++ {
+			levels = append(levels, v)
+		}
+	}
+	return levels, 4 + size, nil
+}
+
+// OperationsFromParquet will load operations written by Parquet.
+// Only uncompressed, snappy or zstd compressed columns with plain encoding are supported.
+func OperationsFromParquet(r io.ReaderAt, size int64, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, error) {
+	ops, _, err := OperationsFromParquetMeta(r, size, analyzeOnly, offset, limit, log)
+	return ops, err
+}
+
+// OperationsFromParquetMeta will load operations written by Parquet.
+// Metadata is read from the key/value metadata of the file.
+func OperationsFromParquetMeta(r io.ReaderAt, size int64, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, Metadata, error) {
+	if size < 12 {
+		return nil, Metadata{}, errors.New("parquet: file too small")
+	}
+	var tail [8]byte
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, Metadata{}, err
+	}
+	if !bytes.Equal(tail[4:], pqMagic) {
+		return nil, Metadata{}, errors.New("parquet: invalid file")
+	}
+	metaLen := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if metaLen > size-12 {
+		return nil, Metadata{}, errors.New("parquet: invalid metadata size")
+	}
+	metaBuf := make([]byte, metaLen)
+	if _, err := r.ReadAt(metaBuf, size-8-metaLen); err != nil {
+		return nil, Metadata{}, err
+	}
+	tr := thriftReader{b: metaBuf}
+	meta, err := tr.readStruct()
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	cols := make(map[string]pqColumn, len(pqColumns))
+	for _, col := range pqColumns {
+		cols[col.name] = col
+	}
+	mapClient, mapFile := opsMappers(analyzeOnly)
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer dec.Close()
+
+	var ops Operations
+	for _, g := range meta.list(4) {
+		rg, ok := g.(thriftStruct)
+		if !ok {
+			return nil, Metadata{}, errors.New("parquet: invalid row group")
+		}
+		if n := rg.i64(3); n < 0 || n > pqMaxRowGroupSize {
+			return nil, Metadata{}, fmt.Errorf("parquet: invalid row group size %d", n)
+		}
+		rows := int(rg.i64(3))
+		if offset >= rows {
+			offset -= rows
+			continue
+		}
+		group := make(Operations, rows)
+		for _, c := range rg.list(1) {
+			cc, ok := c.(thriftStruct)
+			if !ok {
+				return nil, Metadata{}, errors.New("parquet: invalid column chunk")
+			}
+			cm := cc.strct(3)
+			path := cm.list(3)
+			if len(path) != 1 {
+				continue
+			}
+			name, _ := path[0].([]byte)
+			col, ok := cols[string(name)]
+			if !ok || cm.i64(1) != int64(col.typ) {
+				continue
+			}
+			chunkSize, chunkOffset := cm.i64(7), cm.i64(9)
+			if chunkSize < 0 || chunkOffset < 0 || chunkSize > size-chunkOffset {
+				return nil, Metadata{}, fmt.Errorf("parquet: column %s: invalid chunk", col.name)
+			}
+			buf := make([]byte, chunkSize)
+			if _, err := r.ReadAt(buf, chunkOffset); err != nil {
+				return nil, Metadata{}, err
+			}
+			if err := pqReadColumn(buf, col, group, cm.i64(4), dec); err != nil {
+				return nil, Metadata{}, fmt.Errorf("parquet: column %s: %w", col.name, err)
+			}
+		}
+		group = group[offset:]
+		offset = 0
+		for i := range group {
+			group[i].ClientID = mapClient(group[i].ClientID)
+			if group[i].OpType != OpAnnotation {
+				group[i].File = mapFile(group[i].File)
+			}
+		}
+		ops = append(ops, group...)
+		if log != nil {
+			console.Eraseline()
+			log("\r%d operations loaded...", len(ops))
+		}
+		if limit > 0 && len(ops) >= limit {
+			ops = ops[:limit]
+			break
+		}
+	}
+	if log != nil {
+		console.Eraseline()
+		log("\r%d operations loaded... Done!\n", len(ops))
+	}
+	return ops, pqReadMeta(meta), nil
+}
+
+// pqReadColumn reads the pages in buf into the operations.
+func pqReadColumn(buf []byte, col pqColumn, ops Operations, codec int64, dec *zstd.Decoder) error {
+	row := 0
+	for len(buf) > 0 && row < len(ops) {
+		tr := thriftReader{b: buf}
+		hdr, err := tr.readStruct()
+		if err != nil {
+			return err
+		}
+		buf = buf[tr.pos:]
+		compressed := hdr.i64(3)
+		if compressed < 0 || compressed > int64(len(buf)) {
+			return io.ErrUnexpectedEOF
+		}
+		data := buf[:compressed]
+		buf = buf[compressed:]
+		if hdr.i64(1) != 0 {
+			// Skip everything but data pages.
+			continue
+		}
+		dph := hdr.strct(5)
+		if dph.i64(2) != pqEncPlain {
+			return fmt.Errorf("unsupported encoding %d", dph.i64(2))
+		}
+		switch codec {
+		case pqCodecNone:
+		case pqCodecSnappy:
+			data, err = snappy.Decode(nil, data)
+		case pqCodecZstd:
+			data, err = dec.DecodeAll(data, nil)
+		default:
+			return fmt.Errorf("unsupported compression codec %d", codec)
+		}
+		if err != nil {
+			return err
+		}
+		if n := dph.i64(1); n < 0 || n > int64(len(ops)-row) {
+			return fmt.Errorf("invalid value count %d", n)
+		}
+		n := int(dph.i64(1))
+		var levels []bool
+		if col.optional {
+			var used int
+			levels, used, err = pqReadLevels(data, n)
+			if err != nil {
+				return err
+			}
+			data = data[used:]
+		}
+		for i := 0; i < n; i++ {
+			op := &ops[row+i]
+			if levels != nil && !levels[i] {
+				continue
+			}
+			switch col.typ {
+			case pqByteArray:
+				if len(data) < 4 {
+					return io.ErrUnexpectedEOF
+				}
+				l := int(binary.LittleEndian.Uint32(data))
+				if l > len(data)-4 {
+					return io.ErrUnexpectedEOF
+				}
+				col.setStr(op, string(data[4:4+l]))
+				data = data[4+l:]
+			case pqInt32:
+				if len(data) < 4 {
+					return io.ErrUnexpectedEOF
+				}
+				col.set(op, int64(int32(binary.LittleEndian.Uint32(data))))
+				data = data[4:]
+			case pqInt64:
+				if len(data) < 8 {
+					return io.ErrUnexpectedEOF
+				}
+				col.set(op, int64(binary.LittleEndian.Uint64(data)))
+				data = data[8:]
+			}
+		}
+		row += n
+	}
+	return nil
+}
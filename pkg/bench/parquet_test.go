@@ -0,0 +1,313 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parquetTestOps returns n operations with all columns set
+// and optional or empty values on some of them.
+func parquetTestOps(n int) Operations {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	ops := make(Operations, n)
+	for i := range ops {
+		s := start.Add(time.Duration(i) * time.Millisecond)
+		op := Operation{
+			OpType:        []string{"GET", "PUT", "DELETE"}[i%3],
+			Thread:        uint32(i) * 40503, // Exceeds 16 bits.
+			Size:          int64(i) << 20,
+			ObjPerOp:      1 + i%4,
+			Endpoint:      fmt.Sprintf("http://host%d:9000", i%7),
+			ClientID:      fmt.Sprintf("client-%d", i%3),
+			File:          fmt.Sprintf("prefix/obj-%d", i),
+			Start:         s,
+			End:           s.Add(time.Duration(i%1000) * time.Microsecond),
+			Concurrency:   uint32(i % 100000),
+			CryptoTime:    time.Duration(i),
+			GenTime:       time.Duration(i * 2),
+			BytesScanned:  int64(i * 3),
+			BytesReturned: int64(i * 4),
+			WireBytes:     int64(i * 5),
+			Pages:         i % 9,
+			Status:        200,
+			Retries:       i % 2,
+			Phase:         "main",
+			Range:         "",
+		}
+		if i%5 != 0 {
+			fb := s.Add(time.Microsecond)
+			op.FirstByte = &fb
+		}
+		if i%11 == 0 {
+			op.Err = "some error"
+			op.ErrClass = ErrClassValidation
+			op.Status = 500
+			op.RequestID = fmt.Sprintf("req-%d", i)
+		}
+		if i%13 == 0 {
+			op.Conn = "127.0.0.1:1234"
+			op.Remote = "10.0.0.1"
+			op.DeploymentID = "deployment"
+			op.Range = "bytes=0-99"
+		}
+		ops[i] = op
+	}
+	return ops
+}
+
+// parquetEqual returns an error describing the first difference.
+func parquetEqual(got, want Operations) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("got %d operations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if (g.FirstByte == nil) != (w.FirstByte == nil) || (g.FirstByte != nil && !g.FirstByte.Equal(*w.FirstByte)) {
+			return fmt.Errorf("op %d: first byte got %v, want %v", i, g.FirstByte, w.FirstByte)
+		}
+		if !g.Start.Equal(w.Start) || !g.End.Equal(w.End) {
+			return fmt.Errorf("op %d: got %v-%v, want %v-%v", i, g.Start, g.End, w.Start, w.End)
+		}
+		g.FirstByte, w.FirstByte = nil, nil
+		g.Start, g.End, w.Start, w.End = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+		if !reflect.DeepEqual(g, w) {
+			return fmt.Errorf("op %d:\ngot  %+v\nwant %+v", i, g, w)
+		}
+	}
+	return nil
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 100, pqRowGroupSize + 17} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			want := parquetTestOps(n)
+			wantMeta := Metadata{
+				Command: "warp get --duration=1m",
+				Version: "1.0.0",
+				Values:  map[string]string{"server-version": "RELEASE.2024-08-03T04-33-23Z", "note": "a=b"},
+			}
+			var buf bytes.Buffer
+			if err := want.Parquet(&buf, wantMeta); err != nil {
+				t.Fatal(err)
+			}
+			got, gotMeta, err := OperationsFromParquetMeta(bytes.NewReader(buf.Bytes()), int64(buf.Len()), false, 0, 0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parquetEqual(got, want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotMeta, wantMeta) {
+				t.Fatalf("metadata: got %+v, want %+v", gotMeta, wantMeta)
+			}
+			if n < 100 {
+				return
+			}
+			// Offset and limit spanning row groups.
+			offset, limit := n/2+1, n/3
+			got, err = OperationsFromParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), false, offset, limit, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parquetEqual(got, want[offset:offset+limit]); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParquetCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := parquetTestOps(1000).Parquet(&buf, Metadata{Command: "warp get"}); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+	metaLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	metaStart := len(file) - 8 - metaLen
+	read := func(b []byte) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				t.Fatal(err)
+			}
+		}()
+		_, err = OperationsFromParquet(bytes.NewReader(b), int64(len(b)), false, 0, 0, nil)
+		return err
+	}
+
+	// Truncated files must fail.
+	for _, n := range []int{0, 4, 12, metaStart, len(file) / 2, len(file) - 9, len(file) - 1} {
+		if err := read(file[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: expected error", n)
+		}
+	}
+	// Truncated metadata, with a valid length and magic.
+	for _, n := range []int{1, 2, metaLen / 3, metaLen / 2, metaLen - 1} {
+		b := append([]byte{}, file[:metaStart]...)
+		b = append(b, file[metaStart:metaStart+n]...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(n))
+		b = append(b, pqMagic...)
+		if err := read(b); err == nil {
+			t.Errorf("metadata truncated to %d bytes: expected error", n)
+		}
+	}
+	// Corrupt metadata and pages must not panic.
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		b := append([]byte{}, file...)
+		pos := metaStart + rng.Intn(metaLen)
+		if i%4 == 0 {
+			pos = rng.Intn(metaStart)
+		}
+		for j := 0; j < 1+rng.Intn(3); j++ {
+			b[pos] = byte(rng.Intn(256))
+			pos = min(pos+1, len(b)-9)
+		}
+		read(b)
+	}
+	// Deeply nested metadata.
+	meta := bytes.Repeat([]byte{0x1c}, 1<<20)
+	b := append(append([]byte{}, pqMagic...), meta...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(meta)))
+	b = append(b, pqMagic...)
+	if err := read(b); err == nil {
+		t.Error("nested metadata: expected error")
+	}
+}
+
+// parquetTestSchema returns the schema elements of a Parquet file, keyed by column name.
+func parquetTestSchema(t *testing.T, file []byte) map[string]thriftStruct {
+	t.Helper()
+	metaLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	r := thriftReader{b: file[len(file)-8-metaLen : len(file)-8]}
+	meta, err := r.readStruct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[string]thriftStruct)
+	for i, e := range meta.list(2) {
+		if i == 0 {
+			continue // root
+		}
+		el := e.(thriftStruct)
+		res[el.str(4)] = el
+	}
+	return res
+}
+
+func TestParquetSchema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := parquetTestOps(10).Parquet(&buf, Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	schema := parquetTestSchema(t, buf.Bytes())
+	if len(schema) != len(pqColumns) {
+		t.Fatalf("got %d columns, want %d", len(schema), len(pqColumns))
+	}
+	// Field ids and values from parquet.thrift.
+	const (
+		typeInt32, typeInt64, typeByteArray = 1, 2, 6
+		convUTF8, convUint32                = 0, 13
+		logicalString, logicalTimestamp     = 1, 8
+		logicalInteger                      = 10
+	)
+	check := func(name string, typ int64, conv int64, logical int16) thriftStruct {
+		t.Helper()
+		el := schema[name]
+		if el == nil {
+			t.Fatalf("column %s missing", name)
+		}
+		if el.i64(1) != typ {
+			t.Errorf("%s: got type %d, want %d", name, el.i64(1), typ)
+		}
+		if c, ok := el[6].(int64); ok != (conv >= 0) || (ok && c != conv) {
+			t.Errorf("%s: got converted type %v, want %d", name, el[6], conv)
+		}
+		lt := el.strct(10)
+		if _, ok := lt[logical]; logical != 0 && !ok {
+			t.Errorf("%s: got logical type %v, want %d", name, lt, logical)
+		}
+		return lt.strct(logical)
+	}
+	for _, name := range []string{"thread", "concurrency"} {
+		it := check(name, typeInt32, convUint32, logicalInteger)
+		if it.i64(1) != 32 || it[2] != false {
+			t.Errorf("%s: got integer type %v, want unsigned 32 bits", name, it)
+		}
+	}
+	check("n_objects", typeInt32, -1, 0)
+	check("bytes", typeInt64, -1, 0)
+	check("op", typeByteArray, convUTF8, logicalString)
+	ts := check("start", typeInt64, -1, logicalTimestamp)
+	if ts[1] != true || ts.strct(2)[3] == nil {
+		t.Errorf("start: got timestamp type %v, want UTC nanoseconds", ts)
+	}
+	if schema["first_byte"].i64(3) != 1 || schema["start"].i64(3) != 0 {
+		t.Error("first_byte must be optional and start required")
+	}
+}
+
+// TestParquetDuckDB checks that DuckDB reads the written files, if it is installed.
+func TestParquetDuckDB(t *testing.T) {
+	duckdb, err := exec.LookPath("duckdb")
+	if err != nil {
+		t.Skip("duckdb not found")
+	}
+	ops := parquetTestOps(1000)
+	ops[0].Thread = math.MaxUint32
+	ops[1].Concurrency = 1 << 31
+	var buf bytes.Buffer
+	if err := ops.Parquet(&buf, Metadata{Command: "warp get"}); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "ops.parquet")
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	query := fmt.Sprintf(`SELECT count(*), max(thread), max(concurrency), sum(bytes), count(first_byte),
+		min(epoch_ns("start")), max(length(file)), count(DISTINCT op) FROM read_parquet('%s')`, file)
+	out, err := exec.Command(duckdb, "-csv", "-noheader", "-c", query).CombinedOutput()
+	if err != nil {
+		t.Fatalf("duckdb: %v: %s", err, out)
+	}
+	var bytesSum int64
+	var firstByte, maxFile int
+	for _, op := range ops {
+		bytesSum += op.Size
+		if op.FirstByte != nil {
+			firstByte++
+		}
+		maxFile = max(maxFile, len(op.File))
+	}
+	want := fmt.Sprintf("%d,%d,%d,%d,%d,%d,%d,3", len(ops), uint32(math.MaxUint32), 1<<31, bytesSum, firstByte, ops[0].Start.UnixNano(), maxFile)
+	if got := strings.TrimSpace(string(out)); got != want {
+		t.Errorf("duckdb: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// PhaseIdle is the name of phases that run no operations by default.
+const PhaseIdle = "idle"
+
+// PhaseStep is a single phase of a phase schedule.
+type PhaseStep struct {
+	Name     string
+	Duration time.Duration
+	// Load is the percentage of threads that are active during the phase.
+	Load int
+}
+
+// Phases alternates between phases with different load.
+// Threads with an index at or above the active threads of the current phase will wait.
+// The schedule is repeated until the benchmark ends.
+type Phases struct {
+	Steps []PhaseStep
+
+	// threads is the total number of threads.
+	threads int
+	// start is the start time of the schedule as unix nanoseconds.
+	start atomic.Int64
+}
+
+// ParsePhases parses a phase schedule like "burst:2m,idle:5m,warm:5m:25%".
+// Each phase is name:duration with an optional percentage of threads to run.
+// Phases named "idle" run no threads, all other phases run all threads by default.
+func ParsePhases(s string, threads int) (*Phases, error) {
+	p := Phases{threads: threads}
+	for _, step := range strings.Split(s, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		fields := strings.Split(step, ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid phase %q, expected name:duration[:load%%]", step)
+		}
+		if strings.ContainsAny(fields[0], " \t") {
+			return nil, fmt.Errorf("invalid phase %q: name cannot contain spaces", step)
+		}
+		dur, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid phase %q: %w", step, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("invalid phase %q: duration must be positive", step)
+		}
+		load := 100
+		if fields[0] == PhaseIdle {
+			load = 0
+		}
+		if len(fields) == 3 {
+			load, err = strconv.Atoi(strings.TrimSuffix(fields[2], "%"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid phase %q: %w", step, err)
+			}
+			if load < 0 || load > 100 {
+				return nil, fmt.Errorf("invalid phase %q: load must be between 0 and 100%%", step)
+			}
+		}
+		p.Steps = append(p.Steps, PhaseStep{Name: fields[0], Duration: dur, Load: load})
+	}
+	if len(p.Steps) == 0 {
+		return nil, errors.New("no phases specified")
+	}
+	return &p, nil
+}
+
+// begin sets the start time of the schedule, if not already set.
+func (p *Phases) begin() {
+	p.start.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// at returns the phase at time t and when it ends.
+func (p *Phases) at(t time.Time) (PhaseStep, time.Time) {
+	var cycle time.Duration
+	for _, s := range p.Steps {
+		cycle += s.Duration
+	}
+	start := time.Unix(0, p.start.Load())
+	elapsed := t.Sub(start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	// Start of the current cycle.
+	end := start.Add(elapsed - elapsed%cycle)
+	for _, s := range p.Steps {
+		end = end.Add(s.Duration)
+		if t.Before(end) {
+			return s, end
+		}
+	}
+	// Only reached on rounding, return the first step of the next cycle.
+	return p.Steps[0], end.Add(p.Steps[0].Duration)
+}
+
+// active returns the number of active threads in the phase.
+func (p *Phases) active(s PhaseStep) int {
+	return (p.threads*s.Load + 99) / 100
+}
+
+// wait until thread is active.
+func (p *Phases) wait(ctx context.Context, thread int) error {
+	p.begin()
+	for {
+		s, next := p.at(time.Now())
+		if thread < p.active(s) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// nameAt returns the name of the phase at time t.
+// Returns an empty string if the schedule has not started.
+func (p *Phases) nameAt(t time.Time) string {
+	if start := p.start.Load(); start == 0 || t.UnixNano() < start {
+		return ""
+	}
+	s, _ := p.at(t)
+	return s.Name
+}
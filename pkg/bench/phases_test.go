@@ -0,0 +1,95 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePhases(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []PhaseStep
+		wantErr bool
+	}{
+		{in: "burst:2m", want: []PhaseStep{{"burst", 2 * time.Minute, 100}}},
+		{
+			in:   "burst:2m, idle:5m,warm:5m:25%,low:1m:10,idle:1s:50",
+			want: []PhaseStep{{"burst", 2 * time.Minute, 100}, {PhaseIdle, 5 * time.Minute, 0}, {"warm", 5 * time.Minute, 25}, {"low", time.Minute, 10}, {PhaseIdle, time.Second, 50}},
+		},
+		{in: "", wantErr: true},
+		{in: "burst", wantErr: true},
+		{in: ":1m", wantErr: true},
+		{in: "my phase:1m", wantErr: true},
+		{in: "burst:1m:50%:x", wantErr: true},
+		{in: "burst:x", wantErr: true},
+		{in: "burst:0s", wantErr: true},
+		{in: "burst:1m:x%", wantErr: true},
+		{in: "burst:1m:101%", wantErr: true},
+		{in: "burst:1m:-1%", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParsePhases(test.in, 10)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: got %+v, want error", test.in, got.Steps)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Steps, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.in, got.Steps, test.want)
+		}
+	}
+}
+
+func TestPhasesAt(t *testing.T) {
+	p, err := ParsePhases("burst:2m,idle:1m,warm:1m:25%", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1000, 0)
+	p.start.Store(start.UnixNano())
+	tests := []struct {
+		at     time.Duration
+		name   string
+		active int
+		end    time.Duration
+	}{
+		{at: 0, name: "burst", active: 10, end: 2 * time.Minute},
+		{at: 2 * time.Minute, name: PhaseIdle, active: 0, end: 3 * time.Minute},
+		{at: 3*time.Minute + time.Second, name: "warm", active: 3, end: 4 * time.Minute},
+		// The schedule repeats.
+		{at: 4 * time.Minute, name: "burst", active: 10, end: 6 * time.Minute},
+		{at: 10 * time.Minute, name: PhaseIdle, active: 0, end: 11 * time.Minute},
+	}
+	for _, test := range tests {
+		s, end := p.at(start.Add(test.at))
+		if s.Name != test.name || p.active(s) != test.active {
+			t.Errorf("at %v: got phase %s with %d threads, want %s with %d", test.at, s.Name, p.active(s), test.name, test.active)
+		}
+		if !end.Equal(start.Add(test.end)) {
+			t.Errorf("at %v: got end %v, want %v", test.at, end.Sub(start), test.end)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Presigned benchmarks requests using presigned URLs.
+// URLs are signed by the client and requested using plain HTTP requests.
+// Signing is recorded as separate PRESIGN operations.
+type Presigned struct {
+	Common
+
+	CreateObjects int
+
+	// Put will upload new objects instead of downloading prepared objects.
+	Put bool
+
+	// Expiry is the validity of presigned URLs.
+	Expiry time.Duration
+
+	objects  generator.Objects
+	prefixes map[string]struct{}
+	cl       *http.Client
+}
+
+// opPresign is the operation type of URL signing.
+const opPresign = "PRESIGN"
+
+// Prepare will create an empty bucket or delete any content already there.
+// If downloads are benchmarked, a number of objects are uploaded.
+func (p *Presigned) Prepare(ctx context.Context) error {
+	p.cl = &http.Client{Transport: p.Transport}
+	if err := p.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	p.addCollector()
+	if p.Put {
+		return nil
+	}
+	console.Eraseline()
+	console.Info("\rUploading ", p.CreateObjects, " objects")
+
+	var wg sync.WaitGroup
+	wg.Add(p.Concurrency)
+	objs := splitObjs(p.CreateObjects, p.Concurrency)
+	rcv := p.Collector.rcv
+	var groupErr error
+	var mu sync.Mutex
+
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := p.Source()
+			opts := p.PutOpts
+
+			for range obj {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if p.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				client, cldone := p.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				_, err := p.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					p.Error(err)
+					if err := p.prepareFailed(err, p.CreateObjects); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					continue
+				}
+				mu.Lock()
+				obj.Reader = nil
+				p.objects = append(p.objects, *obj)
+				p.prepareProgress(float64(len(p.objects)) / float64(p.CreateObjects))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	if groupErr == nil && len(p.objects) == 0 {
+		groupErr = fmt.Errorf("no objects uploaded")
+	}
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (p *Presigned) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(p.Concurrency)
+	c := p.Collector
+	method := http.MethodGet
+	if p.Put {
+		method = http.MethodPut
+	}
+	if p.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, method, p.autoTermOpts())
+	}
+	p.prefixes = make(map[string]struct{}, p.Concurrency)
+
+	for i := 0; i < p.Concurrency; i++ {
+		src := p.Source()
+		if p.Put {
+			p.prefixes[src.Prefix()] = struct{}{}
+		}
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := p.threadContext(i)
+			rng := p.threadRng(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if p.waitThread(ctx, i) != nil {
+					return
+				}
+
+				var obj *generator.Object
+				if p.Put {
+					obj = src.Object()
+				} else {
+					o := p.objects[rng.Intn(len(p.objects))]
+					obj = &o
+				}
+				client, cldone := p.threadClient(i)
+				sign := Operation{
+					OpType:   opPresign,
+					Thread:   uint32(i),
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				sign.Start = time.Now()
+				var u *url.URL
+				var err error
+				if p.Put {
+					u, err = client.PresignedPutObject(opContext(nonTerm, &sign), p.Bucket, obj.Name, p.Expiry)
+				} else {
+					u, err = client.PresignedGetObject(opContext(nonTerm, &sign), p.Bucket, obj.Name, p.Expiry, nil)
+				}
+				sign.End = time.Now()
+				cldone()
+				if err != nil {
+					p.Error("presign error: ", err)
+					sign.Err = err.Error()
+				}
+				rcv <- sign
+				if err != nil {
+					continue
+				}
+
+				op := Operation{
+					OpType:   method,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: sign.Endpoint,
+				}
+				if p.DiscardOutput {
+					op.File = ""
+				}
+				var body io.Reader
+				if p.Put {
+					body = obj.Reader
+				}
+				req, err := http.NewRequestWithContext(opContext(nonTerm, &op), method, u.String(), body)
+				if err != nil {
+					p.Error("request error: ", err)
+					op.Err = err.Error()
+					op.Start = time.Now()
+					op.End = op.Start
+					rcv <- op
+					continue
+				}
+				if p.Put {
+					req.ContentLength = obj.Size
+					req.Header.Set("Content-Type", obj.ContentType)
+				}
+				op.Start = time.Now()
+				n, err := p.do(req, &op)
+				op.End = time.Now()
+				if err != nil {
+					p.Error(method, " error: ", err)
+					op.Err = err.Error()
+				}
+				if !p.Put && n != obj.Size && op.Err == "" {
+					op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+					op.ErrClass = ErrClassValidation
+					p.Error(op.Err)
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// do executes the request and reads the response body.
+// The time of the first response byte is recorded in op.
+// Returns the number of body bytes read.
+func (p *Presigned) do(req *http.Request, op *Operation) (int64, error) {
+	resp, err := p.cl.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	fbr := firstByteRecorder{r: resp.Body}
+	n, err := io.Copy(io.Discard, &fbr)
+	op.FirstByte = fbr.t
+	if resp.StatusCode != http.StatusOK {
+		return n, fmt.Errorf("unexpected status code: (%d) %s", resp.StatusCode, resp.Status)
+	}
+	return n, err
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (p *Presigned) Cleanup(ctx context.Context) {
+	if !p.Put {
+		p.deleteAllInBucket(ctx, p.objects.Prefixes()...)
+		return
+	}
+	pf := make([]string, 0, len(p.prefixes))
+	for k := range p.prefixes {
+		pf = append(pf, k)
+	}
+	p.deleteAllInBucket(ctx, pf...)
+}
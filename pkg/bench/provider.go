@@ -0,0 +1,213 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Provider is the storage API benchmarks are run against.
+type Provider string
+
+const (
+	ProviderS3    Provider = "s3"
+	ProviderAzure Provider = "azure"
+	ProviderGCS   Provider = "gcs"
+)
+
+// ObjectClient is the storage client used by benchmarks that support other providers than S3.
+// Options and results use the minio-go types, so operations are recorded the same way for all providers.
+// Native clients ignore options that have no equivalent in the API of the provider.
+type ObjectClient interface {
+	EndpointURL() *url.URL
+	BucketExists(ctx context.Context, bucket string) (bool, error)
+	MakeBucket(ctx context.Context, bucket string, opts minio.MakeBucketOptions) error
+	RemoveBucket(ctx context.Context, bucket string) error
+	PutObject(ctx context.Context, bucket, object string, r io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	GetObject(ctx context.Context, bucket, object string, opts minio.GetObjectOptions) (io.ReadCloser, error)
+	StatObject(ctx context.Context, bucket, object string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+	RemoveObject(ctx context.Context, bucket, object string, opts minio.RemoveObjectOptions) error
+	RemoveObjects(ctx context.Context, bucket string, objects <-chan minio.ObjectInfo, opts minio.RemoveObjectsOptions) <-chan minio.RemoveObjectError
+	ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+}
+
+// NewObjectClient returns a native client for the provider.
+// Requests are sent to endpoint using rt.
+//
+// For Azure Blob Storage accessKey is the storage account name and secretKey the account key.
+// For Google Cloud Storage secretKey is an OAuth2 access token or the path
+// of a service account key file and accessKey is the project ID used when creating buckets.
+func NewObjectClient(p Provider, endpoint *url.URL, accessKey, secretKey string, rt http.RoundTripper) (ObjectClient, error) {
+	switch p {
+	case ProviderAzure:
+		return newAzureClient(endpoint, accessKey, secretKey, rt)
+	case ProviderGCS:
+		return newGCSClient(endpoint, accessKey, secretKey, rt)
+	case ProviderS3, "":
+		return nil, fmt.Errorf("provider %q uses the S3 client", p)
+	}
+	return nil, fmt.Errorf("unknown provider %q", p)
+}
+
+// S3ObjectClient returns cl as an ObjectClient.
+func S3ObjectClient(cl *minio.Client) ObjectClient {
+	return s3ObjectClient{Client: cl}
+}
+
+type s3ObjectClient struct {
+	*minio.Client
+}
+
+// GetObject implements ObjectClient.
+func (c s3ObjectClient) GetObject(ctx context.Context, bucket, object string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	o, err := c.Client.GetObject(ctx, bucket, object, opts)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// s3Client returns the S3 client of cl.
+// Returns false if cl is a native client of another provider.
+func s3Client(cl ObjectClient) (*minio.Client, bool) {
+	s3, ok := cl.(s3ObjectClient)
+	return s3.Client, ok
+}
+
+// providerError returns the error of a failed request to a native API.
+// The error is returned as a minio.ErrorResponse, so it is recorded like S3 errors.
+func providerError(resp *http.Response, code, msg, requestID, bucket, object string) error {
+	if code == "" {
+		code = strings.ReplaceAll(http.StatusText(resp.StatusCode), " ", "")
+	}
+	return minio.ErrorResponse{
+		Code:       code,
+		Message:    msg,
+		StatusCode: resp.StatusCode,
+		RequestID:  requestID,
+		BucketName: bucket,
+		Key:        object,
+	}
+}
+
+// providerObjectInfo returns the object info of a HEAD response.
+// User metadata is read from headers with metaPrefix.
+func providerObjectInfo(h http.Header, object, metaPrefix string) minio.ObjectInfo {
+	size, _ := strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	mod, _ := http.ParseTime(h.Get("Last-Modified"))
+	meta := make(minio.StringMap)
+	for k, v := range h {
+		if name, ok := strings.CutPrefix(k, metaPrefix); ok && len(v) > 0 {
+			meta[name] = v[0]
+		}
+	}
+	return minio.ObjectInfo{
+		Key:          object,
+		Size:         size,
+		ETag:         strings.Trim(h.Get("ETag"), `"`),
+		LastModified: mod,
+		ContentType:  h.Get("Content-Type"),
+		Metadata:     h,
+		UserMetadata: meta,
+	}
+}
+
+// providerReadHeaders returns the range and conditional headers of a read.
+func providerReadHeaders(h http.Header) http.Header {
+	res := make(http.Header)
+	for _, k := range []string{"Range", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+		if v := h.Get(k); v != "" {
+			res.Set(k, v)
+		}
+	}
+	return res
+}
+
+// listPages returns the entries of a listing.
+// page is called with the marker returned by the previous call until it returns an empty marker.
+// Errors are returned as the last entry.
+func listPages(ctx context.Context, page func(marker string) ([]minio.ObjectInfo, string, error)) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo, 1)
+	go func() {
+		defer close(ch)
+		marker := ""
+		for {
+			objs, next, err := page(marker)
+			if err != nil {
+				objs = []minio.ObjectInfo{{Err: err}}
+			}
+			for _, o := range objs {
+				select {
+				case ch <- o:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil || next == "" {
+				return
+			}
+			marker = next
+		}
+	}()
+	return ch
+}
+
+// removeEach deletes objects one by one using del, since the provider has no multi-object delete.
+// Deletes are sent concurrently, similar to the batches of an S3 multi-object delete.
+func removeEach(objects <-chan minio.ObjectInfo, del func(key string) error) <-chan minio.RemoveObjectError {
+	const workers = 16
+	errCh := make(chan minio.RemoveObjectError, workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for o := range objects {
+				if err := del(o.Key); err != nil {
+					errCh <- minio.RemoveObjectError{ObjectName: o.Key, VersionID: o.VersionID, Err: err}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(errCh)
+	}()
+	return errCh
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,163 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// testProviderRoundTrip runs the operations used by benchmarks against cl
+// in a new bucket, which is removed afterwards.
+func testProviderRoundTrip(t *testing.T, cl ObjectClient) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	bucket := fmt.Sprintf("warp-test-%d", rand.Int63())
+	if err := cl.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cl.RemoveBucket(ctx, bucket); err != nil {
+			t.Error(err)
+		}
+	}()
+	if ok, err := cl.BucketExists(ctx, bucket); !ok || err != nil {
+		t.Fatalf("bucket exists: got %v, %v", ok, err)
+	}
+
+	// A small object and one uploaded in several parts, if the provider supports it.
+	small := []byte("warp provider test")
+	large := bytes.Repeat([]byte("0123456789abcdef"), (minPartSize+minPartSize/2)/16)
+	objs := map[string][]byte{"dir/small": small, "dir/sub/large": large}
+	for key, data := range objs {
+		_, err := cl.PutObject(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+			PartSize:     minPartSize,
+			UserMetadata: map[string]string{"Warp": "test"},
+		})
+		if err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+		st, err := cl.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+		if err != nil {
+			t.Fatalf("stat %s: %v", key, err)
+		}
+		if st.Size != int64(len(data)) || st.UserMetadata["Warp"] != "test" {
+			t.Errorf("stat %s: got size %d, metadata %v", key, st.Size, st.UserMetadata)
+		}
+		opts := minio.GetObjectOptions{}
+		opts.SetRange(5, 12)
+		o, err := cl.GetObject(ctx, bucket, key, opts)
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		got, err := io.ReadAll(o)
+		o.Close()
+		if err != nil || !bytes.Equal(got, data[5:13]) {
+			t.Errorf("range get %s: got %q, %v", key, got, err)
+		}
+	}
+	_, err := cl.StatObject(ctx, bucket, "missing", minio.StatObjectOptions{})
+	if code := minio.ToErrorResponse(err).StatusCode; code != http.StatusNotFound {
+		t.Errorf("missing object: got status %d, %v", code, err)
+	}
+
+	var listed []string
+	for o := range cl.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: "dir/"}) {
+		if o.Err != nil {
+			t.Fatal(o.Err)
+		}
+		listed = append(listed, o.Key)
+	}
+	if got := strings.Join(listed, ","); got != "dir/small,dir/sub/" {
+		t.Errorf("list: got %s", got)
+	}
+
+	ch := make(chan minio.ObjectInfo, len(objs))
+	for key := range objs {
+		ch <- minio.ObjectInfo{Key: key}
+	}
+	close(ch)
+	for e := range cl.RemoveObjects(ctx, bucket, ch, minio.RemoveObjectsOptions{}) {
+		t.Errorf("delete %s: %v", e.ObjectName, e.Err)
+	}
+}
+
+func TestProviderRoundTrip(t *testing.T) {
+	t.Run("azure", func(t *testing.T) {
+		f, srv := newFakeAzure(t)
+		testProviderRoundTrip(t, newAzureTestClient(t, srv, f.key))
+	})
+	t.Run("gcs", func(t *testing.T) {
+		secret := "gcs-test-token-" + t.Name()
+		_, srv := newFakeGCS(t, secret)
+		testProviderRoundTrip(t, newGCSTestClient(t, srv, secret))
+	})
+}
+
+// TestAzureEmulator runs against the endpoint in WARP_TEST_AZURE_ENDPOINT,
+// for example Azurite at http://127.0.0.1:10000/devstoreaccount1.
+// The account and key default to the well-known Azurite account.
+func TestAzureEmulator(t *testing.T) {
+	endpoint := os.Getenv("WARP_TEST_AZURE_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("WARP_TEST_AZURE_ENDPOINT not set")
+	}
+	account, key := os.Getenv("WARP_TEST_AZURE_ACCOUNT"), os.Getenv("WARP_TEST_AZURE_KEY")
+	if account == "" {
+		account = "devstoreaccount1"
+		key = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	}
+	testProviderRoundTrip(t, newEndpointClient(t, ProviderAzure, endpoint, account, key))
+}
+
+// TestGCSEndpoint runs against the endpoint in WARP_TEST_GCS_ENDPOINT,
+// for example https://storage.googleapis.com, with the credentials in WARP_TEST_GCS_SECRET
+// and buckets created in the project WARP_TEST_GCS_PROJECT.
+func TestGCSEndpoint(t *testing.T) {
+	endpoint := os.Getenv("WARP_TEST_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("WARP_TEST_GCS_ENDPOINT not set")
+	}
+	cl := newEndpointClient(t, ProviderGCS, endpoint, os.Getenv("WARP_TEST_GCS_PROJECT"), os.Getenv("WARP_TEST_GCS_SECRET"))
+	testProviderRoundTrip(t, cl)
+}
+
+func newEndpointClient(t *testing.T, p Provider, endpoint, accessKey, secretKey string) ObjectClient {
+	t.Helper()
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, err := NewObjectClient(p, u, accessKey, secretKey, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cl
+}
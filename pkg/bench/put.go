@@ -24,6 +24,8 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -34,9 +36,20 @@ import (
 // Put benchmarks upload speed.
 type Put struct {
 	Common
+
+	// PostObject will upload using browser style POST form uploads.
+	// Signing the policy is recorded as a separate PRESIGN operation.
 	PostObject bool
-	prefixes   map[string]struct{}
-	cl         *http.Client
+	// PostStatus is the success_action_status requested for POST uploads.
+	// If 0 the default of the server is used.
+	PostStatus int
+
+	// Stream will upload objects without giving the size to the client,
+	// so they are sent as multipart uploads of unknown length.
+	Stream bool
+
+	prefixes map[string]struct{}
+	cl       *http.Client
 }
 
 // Prepare will create an empty bucket ot delete any content already there.
@@ -57,17 +70,20 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 	u.addCollector()
 	c := u.Collector
 	if u.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodPut, u.AutoTermScale, autoTermCheck, autoTermSamples, u.AutoTermDur)
+		opType := http.MethodPut
+		if u.PostObject {
+			opType = http.MethodPost
+		}
+		ctx = c.AutoTerm(ctx, opType, u.autoTermOpts())
 	}
 	u.prefixes = make(map[string]struct{}, u.Concurrency)
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < u.Concurrency; i++ {
 		src := u.Source()
 		u.prefixes[src.Prefix()] = struct{}{}
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := u.threadContext(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 
@@ -92,51 +108,94 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 				default:
 				}
 
-				if u.rpsLimit(ctx) != nil {
+				if u.waitThread(ctx, i) != nil {
 					return
 				}
 
 				obj := src.Object()
 				opts.ContentType = obj.ContentType
-				client, cldone := u.Client()
+				client, cldone := u.threadObjectClient(i)
 				op := Operation{
 					OpType:   http.MethodPut,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					ObjPerOp: 1,
 					File:     obj.Name,
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				var post *postForm
+				if u.PostObject {
+					sign := op
+					sign.OpType = opPresign
+					sign.Size = 0
+					sign.Start = time.Now()
+					var err error
+					post, err = u.signPostPolicy(opContext(nonTerm, &sign), client, obj, opts)
+					sign.End = time.Now()
+					if err != nil {
+						u.Error("presign error: ", err)
+						sign.Err = err.Error()
+					}
+					rcv <- sign
+					if err != nil {
+						cldone()
+						continue
+					}
+				}
+
+				genTime := timeGeneration(obj)
 				op.Start = time.Now()
 				var err error
 				var res minio.UploadInfo
-				if !u.PostObject {
-					res, err = client.PutObject(nonTerm, u.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				var ct *cryptoTimer
+				size := obj.Size
+				putSize := size
+				if u.Stream {
+					putSize = -1
+				}
+				if u.Encryption != nil {
+					var r io.Reader
+					r, ct = u.Encryption.encrypt(obj.Reader)
+					size = u.Encryption.EncryptedSize(obj.Size)
+					if !u.Stream {
+						putSize = size
+					}
+					res, err = client.PutObject(opContext(nonTerm, &op), u.Bucket, obj.Name, r, putSize, opts)
+				} else if !u.PostObject {
+					res, err = u.putObject(opContext(nonTerm, &op), client, obj, putSize, opts)
 				} else {
 					op.OpType = http.MethodPost
 					var verID string
-					verID, err = u.postPolicy(ctx, client, u.Bucket, obj)
+					verID, err = u.postObject(opContext(nonTerm, &op), post, obj)
 					if err == nil {
 						res.Size = obj.Size
 						res.VersionID = verID
 					}
 				}
 				op.End = time.Now()
+				op.GenTime = genTime()
+				if ct != nil {
+					op.CryptoTime = ct.Duration()
+				}
 				if err != nil {
 					u.Error("upload error: ", err)
 					op.Err = err.Error()
 				}
 				obj.VersionID = res.VersionID
 
-				if res.Size != obj.Size && op.Err == "" {
-					err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+				if res.Size != size && op.Err == "" {
+					err := fmt.Sprint("short upload. want:", size, ", got:", res.Size)
 					if op.Err == "" {
 						op.Err = err
+						op.ErrClass = ErrClassValidation
 					}
 					u.Error(err)
 				}
 				op.Size = res.Size
+				if u.Encryption != nil && op.Err == "" {
+					op.Size = obj.Size
+				}
 				cldone()
 				rcv <- op
 			}
@@ -155,24 +214,50 @@ func (u *Put) Cleanup(ctx context.Context) {
 	u.deleteAllInBucket(ctx, pf...)
 }
 
-// postPolicy will upload using https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html API.
-func (u *Put) postPolicy(ctx context.Context, c *minio.Client, bucket string, obj *generator.Object) (versionID string, err error) {
+// postForm is a signed POST policy for a single upload.
+type postForm struct {
+	url    *url.URL
+	fields map[string]string
+}
+
+// signPostPolicy signs a POST policy allowing obj to be uploaded.
+func (u *Put) signPostPolicy(ctx context.Context, cl ObjectClient, obj *generator.Object, opts minio.PutObjectOptions) (*postForm, error) {
+	c, ok := s3Client(cl)
+	if !ok {
+		return nil, errors.New("POST uploads require S3")
+	}
 	pp := minio.NewPostPolicy()
 	pp.SetEncryption(u.PutOpts.ServerSideEncryption)
-	err = errors.Join(
+	err := errors.Join(
 		pp.SetContentType(obj.ContentType),
-		pp.SetBucket(bucket),
+		pp.SetBucket(u.Bucket),
 		pp.SetKey(obj.Name),
 		pp.SetContentLengthRange(obj.Size, obj.Size),
 		pp.SetExpires(time.Now().Add(24*time.Hour)),
 	)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if u.PostStatus != 0 {
+		if err := pp.SetSuccessStatusAction(strconv.Itoa(u.PostStatus)); err != nil {
+			return nil, err
+		}
 	}
-	url, form, err := c.PresignedPostPolicy(ctx, pp)
+	for k, v := range opts.UserMetadata {
+		if err := pp.SetUserMetadata(k, v); err != nil {
+			return nil, err
+		}
+	}
+	pu, form, err := c.PresignedPostPolicy(ctx, pp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return &postForm{url: pu, fields: form}, nil
+}
+
+// postObject will upload using https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html API.
+func (u *Put) postObject(ctx context.Context, post *postForm, obj *generator.Object) (versionID string, err error) {
+	form := post.fields
 	pr, pw := io.Pipe()
 	defer pr.Close()
 	writer := multipart.NewWriter(pw)
@@ -196,7 +281,7 @@ func (u *Put) postPolicy(ctx context.Context, c *minio.Client, bucket string, ob
 		pw.CloseWithError(writer.Close())
 	}()
 
-	req, err := http.NewRequest(http.MethodPost, url.String(), pr)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, post.url.String(), pr)
 	if err != nil {
 		return "", err
 	}
@@ -210,9 +295,15 @@ func (u *Put) postPolicy(ctx context.Context, c *minio.Client, bucket string, ob
 	if resp.Body != nil {
 		defer resp.Body.Close()
 	}
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+	want := resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK
+	if u.PostStatus != 0 {
+		want = resp.StatusCode == u.PostStatus
+	}
+	if !want {
 		return "", fmt.Errorf("unexpected status code: (%d) %s", resp.StatusCode, resp.Status)
 	}
+	// A 201 response has an XML body, which must be read like a browser would.
+	io.Copy(io.Discard, resp.Body)
 
 	return resp.Header.Get("x-amz-version-id"), nil
 }
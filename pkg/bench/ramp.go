@@ -0,0 +1,147 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RampStep is a single step of a concurrency ramp.
+type RampStep struct {
+	Concurrency int
+	Duration    time.Duration
+}
+
+// Ramp changes the number of active threads over time.
+// Threads with an index at or above the current concurrency will wait.
+// The last step is kept after the ramp has ended.
+type Ramp struct {
+	Steps []RampStep
+
+	// start is the start time of the ramp as unix nanoseconds.
+	start atomic.Int64
+}
+
+// ParseRamp parses a ramp specification like "10:1m,50:5m,100:5m".
+func ParseRamp(s string) (*Ramp, error) {
+	var r Ramp
+	for _, step := range strings.Split(s, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		c, d, ok := strings.Cut(step, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ramp step %q, expected concurrency:duration", step)
+		}
+		n, err := strconv.Atoi(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ramp step %q: %w", step, err)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid ramp step %q: concurrency must be at least 1", step)
+		}
+		dur, err := time.ParseDuration(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ramp step %q: %w", step, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("invalid ramp step %q: duration must be positive", step)
+		}
+		r.Steps = append(r.Steps, RampStep{Concurrency: n, Duration: dur})
+	}
+	if len(r.Steps) == 0 {
+		return nil, errors.New("no ramp steps specified")
+	}
+	return &r, nil
+}
+
+// MaxConcurrency returns the highest concurrency of all steps.
+func (r *Ramp) MaxConcurrency() int {
+	var n int
+	for _, s := range r.Steps {
+		if s.Concurrency > n {
+			n = s.Concurrency
+		}
+	}
+	return n
+}
+
+// Duration returns the total duration of all steps.
+func (r *Ramp) Duration() time.Duration {
+	var d time.Duration
+	for _, s := range r.Steps {
+		d += s.Duration
+	}
+	return d
+}
+
+// begin sets the start time of the ramp, if not already set.
+func (r *Ramp) begin() {
+	r.start.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// at returns the concurrency at time t and when it will change next.
+// If the concurrency will not change, the returned time is zero.
+func (r *Ramp) at(t time.Time) (int, time.Time) {
+	end := time.Unix(0, r.start.Load())
+	for _, s := range r.Steps {
+		end = end.Add(s.Duration)
+		if t.Before(end) {
+			return s.Concurrency, end
+		}
+	}
+	return r.Steps[len(r.Steps)-1].Concurrency, time.Time{}
+}
+
+// wait until thread is active.
+func (r *Ramp) wait(ctx context.Context, thread int) error {
+	r.begin()
+	for {
+		n, next := r.at(time.Now())
+		if thread < n {
+			return nil
+		}
+		if next.IsZero() {
+			// Never becomes active.
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// concurrencyAt returns the concurrency at time t.
+// Returns 0 if the ramp has not started.
+func (r *Ramp) concurrencyAt(t time.Time) uint32 {
+	if start := r.start.Load(); start == 0 || t.UnixNano() < start {
+		return 0
+	}
+	n, _ := r.at(t)
+	return uint32(n)
+}
@@ -0,0 +1,100 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []RampStep
+		wantErr bool
+	}{
+		{in: "10:1m", want: []RampStep{{10, time.Minute}}},
+		{in: "10:1m, 50:5m,,100:30s", want: []RampStep{{10, time.Minute}, {50, 5 * time.Minute}, {100, 30 * time.Second}}},
+		{in: "", wantErr: true},
+		{in: ",", wantErr: true},
+		{in: "10", wantErr: true},
+		{in: "x:1m", wantErr: true},
+		{in: "0:1m", wantErr: true},
+		{in: "-1:1m", wantErr: true},
+		{in: "10:1", wantErr: true},
+		{in: "10:0s", wantErr: true},
+		{in: "10:-1m", wantErr: true},
+		{in: "10:1m:2", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseRamp(test.in)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: got %+v, want error", test.in, got.Steps)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Steps, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.in, got.Steps, test.want)
+		}
+	}
+}
+
+func TestRampAt(t *testing.T) {
+	r, err := ParseRamp("10:1m,50:2m,20:1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.MaxConcurrency(); got != 50 {
+		t.Errorf("got max concurrency %d, want 50", got)
+	}
+	if got := r.Duration(); got != 4*time.Minute {
+		t.Errorf("got duration %v, want 4m", got)
+	}
+	start := time.Unix(1000, 0)
+	r.start.Store(start.UnixNano())
+	tests := []struct {
+		at   time.Duration
+		want int
+		next time.Duration
+	}{
+		{at: 0, want: 10, next: time.Minute},
+		{at: time.Minute - 1, want: 10, next: time.Minute},
+		{at: time.Minute, want: 50, next: 3 * time.Minute},
+		{at: 3*time.Minute + time.Second, want: 20, next: 4 * time.Minute},
+		{at: time.Hour, want: 20},
+	}
+	for _, test := range tests {
+		got, next := r.at(start.Add(test.at))
+		if got != test.want {
+			t.Errorf("at %v: got concurrency %d, want %d", test.at, got, test.want)
+		}
+		wantNext := time.Time{}
+		if test.next > 0 {
+			wantNext = start.Add(test.next)
+		}
+		if !next.Equal(wantNext) {
+			t.Errorf("at %v: got next change %v, want %v", test.at, next, wantNext)
+		}
+	}
+}
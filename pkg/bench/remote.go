@@ -0,0 +1,110 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// remoteEvent is a connection obtained for a request.
+type remoteEvent struct {
+	t  time.Time
+	ip string
+	// local is the local address of the connection.
+	local string
+}
+
+// remoteTrace keeps the latest connections obtained by a thread.
+// Operations are annotated by the collector after they have been sent,
+// so a few events are kept to find the connection that was used.
+type remoteTrace struct {
+	mu     sync.Mutex
+	events [8]remoteEvent
+	n      int
+}
+
+func (r *remoteTrace) add(ip, local string) {
+	r.mu.Lock()
+	r.events[r.n%len(r.events)] = remoteEvent{t: time.Now(), ip: ip, local: local}
+	r.n++
+	r.mu.Unlock()
+}
+
+// at returns the latest connection obtained before t.
+func (r *remoteTrace) at(t time.Time) remoteEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var best remoteEvent
+	for _, e := range r.events {
+		if e.ip != "" && !e.t.After(t) && e.t.After(best.t) {
+			best = e
+		}
+	}
+	return best
+}
+
+// threadRemotes contains the traces by thread.
+type threadRemotes struct {
+	mu     sync.Mutex
+	traces map[uint32]*remoteTrace
+}
+
+// threadContext returns the context to use for operations on the thread.
+// Requests of operations made with opContext record the status of their responses.
+// If RecordRemote or NewThreadClient is set, the connections used by the thread are recorded.
+func (c *Common) threadContext(thread int) context.Context {
+	ctx := context.Background()
+	if c.statuses != nil {
+		ctx = c.withStatusTrace(ctx)
+	}
+	if c.remotes == nil {
+		return ctx
+	}
+	tr := &remoteTrace{}
+	c.remotes.mu.Lock()
+	c.remotes.traces[uint32(thread)] = tr
+	c.remotes.mu.Unlock()
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn == nil {
+				return
+			}
+			addr := info.Conn.RemoteAddr().String()
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			tr.add(addr, info.Conn.LocalAddr().String())
+		},
+	})
+}
+
+// threadConnAt returns the connection used by the thread when op ended.
+// Returns false if connections are not traced for the thread.
+func (c *Common) threadConnAt(op *Operation) (remoteEvent, bool) {
+	c.remotes.mu.Lock()
+	tr := c.remotes.traces[op.Thread]
+	c.remotes.mu.Unlock()
+	if tr == nil {
+		return remoteEvent{}, false
+	}
+	return tr.at(op.End), true
+}
@@ -0,0 +1,334 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Replay benchmarks a recorded sequence of operations.
+// Operations of each recorded thread are issued in order on a separate thread,
+// with the recorded timing between operations scaled by Speed.
+type Replay struct {
+	Common
+
+	// Ops are the recorded operations.
+	Ops Operations
+
+	// Speed is the replay speed. 2 replays twice as fast as recorded.
+	// If 0, operations are issued as fast as possible.
+	Speed float64
+
+	threads  []Operations
+	first    time.Time
+	prefixes []string
+}
+
+// replayKey identifies a recorded thread.
+type replayKey struct {
+	client string
+	thread uint32
+}
+
+// replayable returns whether the operation type can be replayed.
+func replayable(opType string) bool {
+	switch opType {
+	case http.MethodPut, http.MethodGet, http.MethodDelete, "STAT", "LIST":
+		return true
+	}
+	return false
+}
+
+// Prepare will create an empty bucket and upload objects that are
+// read by the recorded operations before being written.
+func (r *Replay) Prepare(ctx context.Context) error {
+	ops := make(Operations, 0, len(r.Ops))
+	skipped := make(map[string]int)
+	for _, op := range r.Ops {
+		if !replayable(op.OpType) || op.File == "" {
+			skipped[op.OpType]++
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if len(skipped) > 0 {
+		types := make([]string, 0, len(skipped))
+		for k, v := range skipped {
+			types = append(types, fmt.Sprintf("%s: %d", k, v))
+		}
+		sort.Strings(types)
+		console.Eraseline()
+		console.Infoln("\rSkipping operations of unsupported types or without object names:", strings.Join(types, ", "))
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("no operations to replay")
+	}
+	ops.SortByStartTime()
+	r.first = ops[0].Start
+
+	// Group by recorded thread and find objects to upload.
+	byThread := make(map[replayKey]int)
+	seen := make(map[string]bool)
+	sizes := make(map[string]int64)
+	prefixes := make(map[string]struct{})
+	var upload []string
+	for _, op := range ops {
+		k := replayKey{client: op.ClientID, thread: op.Thread}
+		idx, ok := byThread[k]
+		if !ok {
+			idx = len(r.threads)
+			byThread[k] = idx
+			r.threads = append(r.threads, nil)
+		}
+		r.threads[idx] = append(r.threads[idx], op)
+		prefix, _, _ := strings.Cut(op.File, "/")
+		prefixes[prefix] = struct{}{}
+		if op.OpType == "LIST" {
+			continue
+		}
+		sizes[op.File] = max(sizes[op.File], op.Size)
+		if !seen[op.File] && op.OpType != http.MethodPut {
+			upload = append(upload, op.File)
+		}
+		seen[op.File] = true
+	}
+	for p := range prefixes {
+		r.prefixes = append(r.prefixes, p)
+	}
+	r.Concurrency = len(r.threads)
+
+	if err := r.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	r.addCollector()
+	if len(upload) == 0 {
+		return nil
+	}
+	console.Eraseline()
+	console.Info("\rUploading ", len(upload), " objects")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var groupErr error
+	uploaded := 0
+	rcv := r.Collector.rcv
+	names := make(chan string, len(upload))
+	for _, name := range upload {
+		names <- name
+	}
+	close(names)
+	for i := 0; i < min(r.Concurrency, len(upload)); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := r.Source()
+			opts := r.PutOpts
+			for name := range names {
+				if ctx.Err() != nil || r.rpsLimit(ctx) != nil {
+					return
+				}
+				obj := replayObject(src, name, sizes[name])
+				client, cldone := r.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				_, err := r.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					r.Error(err)
+					if err := r.prepareFailed(err, len(upload)); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					continue
+				}
+				mu.Lock()
+				uploaded++
+				r.prepareProgress(float64(uploaded) / float64(len(upload)))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// replayObject returns a generated object with the name and size given.
+// The source must generate objects of at least size bytes.
+func replayObject(src generator.Source, name string, size int64) *generator.Object {
+	obj := src.Object()
+	obj.Name = name
+	obj.Size = size
+	obj.Reader = &sizedReader{ReadSeeker: obj.Reader, n: size}
+	return obj
+}
+
+// Start will replay the recorded operations.
+// Operations should begin executing when the start channel is closed.
+func (r *Replay) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(len(r.threads))
+	c := r.Collector
+	// Recorded times are relative to when the benchmark began.
+	var began time.Time
+	ready := make(chan struct{})
+	go func() {
+		<-wait
+		began = time.Now()
+		close(ready)
+	}()
+
+	for i, ops := range r.threads {
+		go func(i int, ops Operations) {
+			// Non-terminating context.
+			nonTerm := r.threadContext(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			src := r.Source()
+			opts := r.PutOpts
+
+			<-ready
+			for _, rec := range ops {
+				if r.Speed > 0 {
+					at := began.Add(time.Duration(float64(rec.Start.Sub(r.first)) / r.Speed))
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(time.Until(at)):
+					}
+				}
+				if ctx.Err() != nil || r.waitThread(ctx, i) != nil {
+					return
+				}
+				client, cldone := r.threadClient(i)
+				op := Operation{
+					OpType:   rec.OpType,
+					Thread:   uint32(i),
+					Size:     rec.Size,
+					File:     rec.File,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				var err error
+				switch rec.OpType {
+				case http.MethodPut:
+					obj := replayObject(src, rec.File, rec.Size)
+					opts.ContentType = obj.ContentType
+					op.Start = time.Now()
+					_, err = client.PutObject(opContext(nonTerm, &op), r.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				case http.MethodGet:
+					op.Start = time.Now()
+					var o *minio.Object
+					o, err = client.GetObject(opContext(nonTerm, &op), r.Bucket, rec.File, minio.GetObjectOptions{})
+					if err == nil {
+						fbr := firstByteRecorder{r: o}
+						op.Size, err = io.Copy(io.Discard, &fbr)
+						op.FirstByte = fbr.t
+						o.Close()
+					}
+				case "STAT":
+					op.Start = time.Now()
+					_, err = client.StatObject(opContext(nonTerm, &op), r.Bucket, rec.File, minio.StatObjectOptions{})
+				case http.MethodDelete:
+					op.Start = time.Now()
+					err = client.RemoveObject(opContext(nonTerm, &op), r.Bucket, rec.File, minio.RemoveObjectOptions{})
+				case "LIST":
+					op.ObjPerOp = 0
+					op.Start = time.Now()
+					listCtx, cancel := context.WithCancel(opContext(nonTerm, &op))
+					for obj := range client.ListObjects(listCtx, r.Bucket, minio.ListObjectsOptions{Prefix: rec.File, Recursive: true}) {
+						if obj.Err != nil {
+							err = obj.Err
+							break
+						}
+						op.ObjPerOp++
+					}
+					cancel()
+				}
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					r.Error(rec.OpType, " error: ", err)
+					op.Err = err.Error()
+				}
+				rcv <- op
+			}
+		}(i, ops)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (r *Replay) Cleanup(ctx context.Context) {
+	r.deleteAllInBucket(ctx, r.prefixes...)
+}
+
+// sizedReader returns the first n bytes of a generated object.
+type sizedReader struct {
+	io.ReadSeeker
+	n, pos int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.pos >= s.n {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.n-s.pos {
+		p = p[:s.n-s.pos]
+	}
+	n, err := s.ReadSeeker.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+func (s *sizedReader) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekEnd {
+		offset += s.n
+		whence = io.SeekStart
+	}
+	pos, err := s.ReadSeeker.Seek(offset, whence)
+	if err == nil {
+		s.pos = pos
+	}
+	return pos, err
+}
@@ -0,0 +1,205 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Replication benchmarks bucket replication lag.
+// Objects are uploaded to the source bucket and the destination
+// is polled until the uploaded version appears with the same ETag.
+type Replication struct {
+	Common
+
+	// DstClient returns clients for the destination.
+	DstClient func() (cl *minio.Client, done func())
+
+	// DstBucket is the destination bucket.
+	DstBucket string
+
+	// PollInterval is the delay between destination checks.
+	PollInterval time.Duration
+
+	// MaxLag is the longest time to wait for an object to be replicated.
+	MaxLag time.Duration
+
+	prefixes map[string]struct{}
+}
+
+// opReplicate is the operation type of replication lag measurements.
+const opReplicate = "REPLICATE"
+
+// Prepare will create an empty source bucket and check that replication is configured.
+func (r *Replication) Prepare(ctx context.Context) error {
+	if err := r.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	cl, done := r.Client()
+	cfg, err := cl.GetBucketReplication(ctx, r.Bucket)
+	done()
+	if err != nil {
+		return fmt.Errorf("unable to get replication config of bucket %q: %w", r.Bucket, err)
+	}
+	if len(cfg.Rules) == 0 {
+		return fmt.Errorf("bucket %q has no replication rules", r.Bucket)
+	}
+
+	dst, done := r.DstClient()
+	defer done()
+	x, err := dst.BucketExists(ctx, r.DstBucket)
+	if err != nil {
+		return fmt.Errorf("unable to check destination bucket %q: %w", r.DstBucket, err)
+	}
+	if !x {
+		return fmt.Errorf("destination bucket %q does not exist", r.DstBucket)
+	}
+	r.addCollector()
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (r *Replication) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(r.Concurrency)
+	c := r.Collector
+	if r.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodPut, r.autoTermOpts())
+	}
+	r.prefixes = make(map[string]struct{}, r.Concurrency)
+
+	for i := 0; i < r.Concurrency; i++ {
+		src := r.Source()
+		r.prefixes[src.Prefix()] = struct{}{}
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := r.threadContext(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			opts := r.PutOpts
+			done := ctx.Done()
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if r.waitThread(ctx, i) != nil {
+					return
+				}
+
+				obj := src.Object()
+				opts.ContentType = obj.ContentType
+				client, cldone := r.threadClient(i)
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				res, err := r.putObject(opContext(nonTerm, &op), S3ObjectClient(client), obj, obj.Size, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					r.Error("upload error: ", err)
+					op.Err = err.Error()
+				}
+				if res.Size != obj.Size && op.Err == "" {
+					err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+					r.Error(err)
+					op.Err = err
+					op.ErrClass = ErrClassValidation
+				}
+				rcv <- op
+				if op.Err != "" {
+					continue
+				}
+
+				dst, dstDone := r.DstClient()
+				rop := Operation{
+					OpType:   opReplicate,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: dst.EndpointURL().String(),
+					Start:    op.End,
+				}
+				err = r.waitReplicated(opContext(nonTerm, &rop), dst, obj.Name, res)
+				rop.End = time.Now()
+				dstDone()
+				if err != nil {
+					r.Error(fmt.Sprintf("replication %s: %v", obj.Name, err))
+					rop.Err = err.Error()
+				}
+				rcv <- rop
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// waitReplicated polls the destination until the uploaded version is present with the same ETag.
+func (r *Replication) waitReplicated(ctx context.Context, dst *minio.Client, object string, res minio.UploadInfo) error {
+	deadline := time.Now().Add(r.MaxLag)
+	opts := minio.StatObjectOptions{VersionID: res.VersionID}
+	for {
+		st, err := dst.StatObject(ctx, r.DstBucket, object, opts)
+		switch {
+		case err == nil && strings.Trim(st.ETag, `"`) == strings.Trim(res.ETag, `"`):
+			return nil
+		case err == nil:
+			err = fmt.Errorf("etag mismatch. want: %s, got: %s", res.ETag, st.ETag)
+		case minio.ToErrorResponse(err).StatusCode != http.StatusNotFound:
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not replicated within %v: %w", r.MaxLag, err)
+		}
+		time.Sleep(r.PollInterval)
+	}
+}
+
+// Cleanup deletes everything uploaded to the source and destination buckets.
+func (r *Replication) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(r.prefixes))
+	for p := range r.prefixes {
+		pf = append(pf, p)
+	}
+	r.deleteAllInBucket(ctx, pf...)
+
+	dst := r.Common
+	dst.Client = r.DstClient
+	dst.Bucket = r.DstBucket
+	dst.deleteAllInBucket(ctx, pf...)
+}
@@ -19,9 +19,8 @@ package bench
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,8 +36,28 @@ type Retention struct {
 
 	CreateObjects int
 	Versions      int
+
+	// RetainOnPut applies governance retention when uploading objects.
+	RetainOnPut bool
+
+	// LegalHold uploads objects with legal hold enabled
+	// and adds PutObjectLegalHold operations to the benchmark.
+	LegalHold bool
+
+	// GetRatio is the fraction of operations that read
+	// retention or legal hold status instead of setting it.
+	GetRatio float64
 }
 
+// Operation types for retention operations.
+const (
+	retentionPut    = "RETENTION"
+	retentionGet    = "GET-RETENTION"
+	legalHoldPut    = "LEGALHOLD"
+	legalHoldGet    = "GET-LEGALHOLD"
+	retentionPeriod = 24 * time.Hour
+)
+
 // Prepare will create an empty bucket or delete any content already there
 // and upload a number of objects.
 func (g *Retention) Prepare(ctx context.Context) error {
@@ -55,6 +74,7 @@ func (g *Retention) Prepare(ctx context.Context) error {
 		done()
 	}
 
+	retainUntil := time.Now().Add(retentionPeriod).Truncate(time.Second).UTC()
 	src := g.Source()
 	console.Eraseline()
 	console.Info("\rUploading ", g.CreateObjects, " objects with ", g.Versions, " versions each of ", src.String())
@@ -72,6 +92,13 @@ func (g *Retention) Prepare(ctx context.Context) error {
 
 			for range obj {
 				opts := g.PutOpts
+				if g.RetainOnPut {
+					opts.Mode = minio.Governance
+					opts.RetainUntilDate = retainUntil
+				}
+				if g.LegalHold {
+					opts.LegalHold = minio.LegalHoldEnabled
+				}
 				rcv := g.Collector.Receiver()
 				done := ctx.Done()
 
@@ -94,7 +121,7 @@ func (g *Retention) Prepare(ctx context.Context) error {
 					client, cldone := g.Client()
 					op := Operation{
 						OpType:   http.MethodPut,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -103,29 +130,22 @@ func (g *Retention) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, err := g.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
 					op.End = time.Now()
 					if err != nil {
-						err := fmt.Errorf("upload error: %w", err)
 						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
+						cldone()
+						if err := g.prepareFailed(err, g.CreateObjects*g.Versions); err != nil {
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
 						}
-						mu.Unlock()
-						return
+						continue
 					}
 					obj.VersionID = res.VersionID
-					if res.Size != obj.Size {
-						err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
-						}
-						mu.Unlock()
-						return
-					}
 					cldone()
 					mu.Lock()
 					obj.Reader = nil
@@ -148,22 +168,19 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodGet, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, retentionPut, g.autoTermOpts())
 	}
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
-			var opts minio.PutObjectRetentionOptions
 
 			<-wait
-			mode := minio.Governance
 			for {
 				select {
 				case <-done:
@@ -171,15 +188,27 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
 				obj := g.objects[rng.Intn(len(g.objects))]
-				client, cldone := g.Client()
+				opType := retentionPut
+				if g.LegalHold && rng.Intn(2) == 0 {
+					opType = legalHoldPut
+				}
+				if g.GetRatio > 0 && rng.Float64() < g.GetRatio {
+					switch opType {
+					case retentionPut:
+						opType = retentionGet
+					case legalHoldPut:
+						opType = legalHoldGet
+					}
+				}
+				client, cldone := g.threadClient(i)
 				op := Operation{
-					OpType:   "RETENTION",
-					Thread:   uint16(i),
+					OpType:   opType,
+					Thread:   uint32(i),
 					Size:     0,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -187,21 +216,12 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 				}
 
 				op.Start = time.Now()
-				opts.VersionID = obj.VersionID
-				t := op.Start.Add(24 * time.Hour)
-				opts.RetainUntilDate = &t
-				opts.Mode = &mode
-				opts.GovernanceBypass = true
-				err := client.PutObjectRetention(nonTerm, g.Bucket, obj.Name, opts)
+				err := g.retentionOp(opContext(nonTerm, &op), client, obj, opType, op.Start)
+				op.End = time.Now()
 				if err != nil {
-					g.Error("put retention error:", err)
+					g.Error(strings.ToLower(opType), " error:", err)
 					op.Err = err.Error()
-					op.End = time.Now()
-					rcv <- op
-					cldone()
-					continue
 				}
-				op.End = time.Now()
 				rcv <- op
 				cldone()
 			}
@@ -211,7 +231,70 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 	return c.Close(), nil
 }
 
+// retentionOp executes a single operation of type opType on obj.
+func (g *Retention) retentionOp(ctx context.Context, client *minio.Client, obj generator.Object, opType string, now time.Time) error {
+	switch opType {
+	case retentionGet:
+		_, _, err := client.GetObjectRetention(ctx, g.Bucket, obj.Name, obj.VersionID)
+		return err
+	case legalHoldPut:
+		status := minio.LegalHoldEnabled
+		return client.PutObjectLegalHold(ctx, g.Bucket, obj.Name, minio.PutObjectLegalHoldOptions{
+			VersionID: obj.VersionID,
+			Status:    &status,
+		})
+	case legalHoldGet:
+		_, err := client.GetObjectLegalHold(ctx, g.Bucket, obj.Name, minio.GetObjectLegalHoldOptions{
+			VersionID: obj.VersionID,
+		})
+		return err
+	}
+	mode := minio.Governance
+	t := now.Add(retentionPeriod)
+	return client.PutObjectRetention(ctx, g.Bucket, obj.Name, minio.PutObjectRetentionOptions{
+		GovernanceBypass: true,
+		Mode:             &mode,
+		RetainUntilDate:  &t,
+		VersionID:        obj.VersionID,
+	})
+}
+
+// releaseLegalHolds disables legal hold on all uploaded objects,
+// since it cannot be bypassed when deleting.
+func (g *Retention) releaseLegalHolds(ctx context.Context) {
+	console.Eraseline()
+	console.Info("\rReleasing legal holds...")
+	var wg sync.WaitGroup
+	objs := make(chan generator.Object)
+	for i := 0; i < g.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, done := g.Client()
+			defer done()
+			status := minio.LegalHoldDisabled
+			for obj := range objs {
+				err := client.PutObjectLegalHold(ctx, g.Bucket, obj.Name, minio.PutObjectLegalHoldOptions{
+					VersionID: obj.VersionID,
+					Status:    &status,
+				})
+				if err != nil {
+					g.Error("release legal hold error:", err)
+				}
+			}
+		}()
+	}
+	for _, obj := range g.objects {
+		objs <- obj
+	}
+	close(objs)
+	wg.Wait()
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Retention) Cleanup(ctx context.Context) {
+	if g.LegalHold {
+		g.releaseLegalHolds(ctx)
+	}
 	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
 }
@@ -22,7 +22,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"path"
 	"sync"
@@ -130,15 +129,14 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodGet, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, http.MethodGet, g.autoTermOpts())
 	}
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
@@ -152,16 +150,16 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
 				fbr := firstByteRecorder{}
 				obj := g.objects[rng.Intn(len(g.objects))]
-				client, cldone := g.Client()
+				client, cldone := g.threadClient(i)
 				op := Operation{
 					OpType:   "GET",
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     path.Join(g.ZipObjName, obj.Name),
 					ObjPerOp: 1,
@@ -171,7 +169,7 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				op.Start = time.Now()
 				opts.Set("x-minio-extract", "true")
 
-				o, err := client.GetObject(nonTerm, g.Bucket, op.File, opts)
+				o, err := client.GetObject(opContext(nonTerm, &op), g.Bucket, op.File, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
@@ -190,6 +188,7 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				op.End = time.Now()
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
+					op.ErrClass = ErrClassValidation
 					g.Error(op.Err)
 				}
 				rcv <- op
@@ -18,11 +18,11 @@
 package bench
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +40,13 @@ type Select struct {
 	objects    generator.Objects
 
 	CreateObjects int
+
+	// InputFormat is the format objects are uploaded as.
+	// Generated CSV data is converted to JSON lines or Parquet if requested.
+	InputFormat string
+
+	// Compression is applied to uploaded CSV and JSON objects.
+	Compression minio.SelectCompressionType
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -78,10 +85,27 @@ func (g *Select) Prepare(ctx context.Context) error {
 				}
 
 				obj := src.Object()
+				if g.InputFormat != SelectCSV || g.Compression != minio.SelectCompressionNONE {
+					b, err := selectEncode(obj.Reader, g.InputFormat, g.Compression)
+					if err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					obj.Reader = bytes.NewReader(b)
+					obj.Size = int64(len(b))
+					obj.Name = strings.TrimSuffix(obj.Name, ".csv") + selectExt(g.InputFormat, g.Compression)
+					if g.InputFormat != SelectCSV {
+						obj.ContentType = "application/octet-stream"
+					}
+				}
 				client, cldone := g.Client()
 				op := Operation{
 					OpType:   http.MethodPut,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -90,29 +114,22 @@ func (g *Select) Prepare(ctx context.Context) error {
 
 				opts.ContentType = obj.ContentType
 				op.Start = time.Now()
-				res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				res, err := g.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
 				op.End = time.Now()
 				if err != nil {
-					err := fmt.Errorf("upload error: %w", err)
 					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+					cldone()
+					if err := g.prepareFailed(err, g.CreateObjects); err != nil {
+						mu.Lock()
+						if groupErr == nil {
+							groupErr = err
+						}
+						mu.Unlock()
+						return
 					}
-					mu.Unlock()
-					return
+					continue
 				}
 				obj.VersionID = res.VersionID
-				if res.Size != obj.Size {
-					err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
-					}
-					mu.Unlock()
-					return
-				}
 				cldone()
 				mu.Lock()
 				obj.Reader = nil
@@ -134,15 +151,14 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "SELECT", g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, "SELECT", g.autoTermOpts())
 	}
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.SelectOpts
@@ -156,16 +172,16 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
 				fbr := firstByteRecorder{}
 				obj := g.objects[rng.Intn(len(g.objects))]
-				client, cldone := g.Client()
+				client, cldone := g.threadClient(i)
 				op := Operation{
 					OpType:   "SELECT",
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     obj.Size,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -174,7 +190,7 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 
 				op.Start = time.Now()
 				var err error
-				o, err := client.SelectObjectContent(nonTerm, g.Bucket, obj.Name, opts)
+				o, err := client.SelectObjectContent(opContext(nonTerm, &op), g.Bucket, obj.Name, opts)
 				fbr.r = o
 				if err != nil {
 					g.Error("download error: ", err)
@@ -191,6 +207,10 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				if st := o.Stats(); st != nil {
+					op.BytesScanned = st.BytesScanned
+					op.BytesReturned = st.BytesReturned
+				}
 				rcv <- op
 				cldone()
 				o.Close()
@@ -0,0 +1,215 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-go/v7"
+)
+
+// Select input formats.
+const (
+	SelectCSV     = "csv"
+	SelectJSON    = "json"
+	SelectParquet = "parquet"
+)
+
+// selectEncode converts CSV input with a header row to the requested format
+// and applies compression. Incomplete trailing rows are dropped.
+func selectEncode(r io.Reader, format string, comp minio.SelectCompressionType) ([]byte, error) {
+	var header []string
+	var rows [][]string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		fields := strings.Split(sc.Text(), ",")
+		if header == nil {
+			header = fields
+			continue
+		}
+		if len(fields) != len(header) {
+			continue
+		}
+		rows = append(rows, fields)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(header) == 0 {
+		return nil, fmt.Errorf("select: no csv header")
+	}
+
+	var raw bytes.Buffer
+	switch format {
+	case SelectCSV, "":
+		raw.WriteString(strings.Join(header, ","))
+		raw.WriteByte('\n')
+		for _, row := range rows {
+			raw.WriteString(strings.Join(row, ","))
+			raw.WriteByte('\n')
+		}
+	case SelectJSON:
+		for _, row := range rows {
+			raw.WriteByte('{')
+			for i, v := range row {
+				if i > 0 {
+					raw.WriteByte(',')
+				}
+				k, _ := json.Marshal(header[i])
+				val, _ := json.Marshal(v)
+				raw.Write(k)
+				raw.WriteByte(':')
+				raw.Write(val)
+			}
+			raw.WriteString("}\n")
+		}
+	case SelectParquet:
+		if err := pqWriteStrings(&raw, header, rows); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("select: unknown format %q", format)
+	}
+
+	switch comp {
+	case minio.SelectCompressionNONE, "":
+		return raw.Bytes(), nil
+	case minio.SelectCompressionGZIP:
+		var out bytes.Buffer
+		gw := gzip.NewWriter(&out)
+		if _, err := gw.Write(raw.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	case minio.SelectCompressionZSTD:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		return enc.EncodeAll(raw.Bytes(), nil), nil
+	}
+	return nil, fmt.Errorf("select: unsupported compression %q", comp)
+}
+
+// selectExt returns the object name extension for the format and compression.
+func selectExt(format string, comp minio.SelectCompressionType) string {
+	ext := "." + format
+	switch comp {
+	case minio.SelectCompressionGZIP:
+		ext += ".gz"
+	case minio.SelectCompressionZSTD:
+		ext += ".zst"
+	}
+	return ext
+}
+
+// pqWriteStrings writes a Parquet file with a single uncompressed row group
+// where all columns are required strings.
+func pqWriteStrings(w *bytes.Buffer, names []string, rows [][]string) error {
+	w.Write(pqMagic)
+	offsets := make([]int64, len(names))
+	sizes := make([]int64, len(names))
+	var page bytes.Buffer
+	var tmp [4]byte
+	for col := range names {
+		page.Reset()
+		for _, row := range rows {
+			binary.LittleEndian.PutUint32(tmp[:], uint32(len(row[col])))
+			page.Write(tmp[:])
+			page.WriteString(row[col])
+		}
+		hdr := newThriftWriter()
+		hdr.i32(1, 0) // DATA_PAGE
+		hdr.i32(2, int32(page.Len()))
+		hdr.i32(3, int32(page.Len()))
+		hdr.structBegin(5)
+		hdr.i32(1, int32(len(rows)))
+		hdr.i32(2, pqEncPlain)
+		hdr.i32(3, pqEncRLE)
+		hdr.i32(4, pqEncRLE)
+		hdr.structEnd()
+		h := hdr.finish()
+		offsets[col] = int64(w.Len())
+		sizes[col] = int64(len(h) + page.Len())
+		w.Write(h)
+		w.Write(page.Bytes())
+	}
+
+	meta := newThriftWriter()
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStructTyp, len(names)+1)
+	meta.elemBegin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(names)))
+	meta.structEnd()
+	for _, name := range names {
+		meta.elemBegin()
+		meta.i32(1, pqByteArray)
+		meta.i32(3, 0)
+		meta.str(4, name)
+		meta.i32(6, 0) // UTF8
+		meta.structEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.listBegin(4, thriftStructTyp, 1)
+	meta.elemBegin()
+	meta.listBegin(1, thriftStructTyp, len(names))
+	var total int64
+	for col, name := range names {
+		total += sizes[col]
+		meta.elemBegin()
+		meta.i64(2, offsets[col])
+		meta.structBegin(3)
+		meta.i32(1, pqByteArray)
+		meta.listBegin(2, thriftI32, 2)
+		meta.i32Elem(pqEncPlain)
+		meta.i32Elem(pqEncRLE)
+		meta.listBegin(3, thriftBinary, 1)
+		meta.strElem(name)
+		meta.i32(4, pqCodecNone)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, sizes[col])
+		meta.i64(7, sizes[col])
+		meta.i64(9, offsets[col])
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(rows)))
+	meta.structEnd()
+	meta.str(6, "warp")
+	footer := meta.finish()
+	w.Write(footer)
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(footer)))
+	w.Write(tmp[:])
+	w.Write(pqMagic)
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/klauspost/compress/zstd"
+	"github.com/minio/warp/pkg/generator"
 )
 
 // Snowball benchmarks snowball upload speed.
@@ -42,8 +43,12 @@ type Snowball struct {
 	WindowSize int
 	Duplicate  bool // Duplicate object content.
 	Compress   bool // Zstandard compress snowball.
+	Individual bool // Upload objects with individual PUTs on every second thread.
 }
 
+// snowballOp is the operation type of snowball uploads when compared to individual PUTs.
+const snowballOp = "SNOWBALL"
+
 // Prepare will create an empty bucket or delete any content already there
 // and upload a number of objects.
 func (s *Snowball) Prepare(ctx context.Context) error {
@@ -69,17 +74,16 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 	wg.Add(s.Concurrency)
 	c := s.Collector
 	if s.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, http.MethodPut, s.AutoTermScale, autoTermCheck, autoTermSamples, s.AutoTermDur)
+		ctx = c.AutoTerm(ctx, http.MethodPut, s.autoTermOpts())
 	}
 	s.prefixes = make(map[string]struct{}, s.Concurrency)
 
-	// Non-terminating context.
-	nonTerm := context.Background()
-
 	for i := 0; i < s.Concurrency; i++ {
 		src := s.Source()
 		s.prefixes[src.Prefix()] = struct{}{}
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := s.threadContext(i)
 			var buf bytes.Buffer
 			rcv := c.Receiver()
 			defer wg.Done()
@@ -88,6 +92,10 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 			done := ctx.Done()
 
 			<-wait
+			if s.Individual && i%2 == 1 {
+				s.putObjects(ctx, nonTerm, i, src, rcv)
+				return
+			}
 			for {
 				select {
 				case <-done:
@@ -95,7 +103,7 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 				default:
 				}
 
-				if s.rpsLimit(ctx) != nil {
+				if s.waitThread(ctx, i) != nil {
 					return
 				}
 
@@ -108,10 +116,13 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 				obj := src.Object()
 				op := Operation{
 					OpType:   http.MethodPut,
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					File:     path.Join(obj.Prefix, "snowball.tar"),
 					ObjPerOp: s.NumObjs,
 				}
+				if s.Individual {
+					op.OpType = snowballOp
+				}
 
 				{
 					tw := tar.NewWriter(w)
@@ -160,12 +171,12 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 				opts.ContentType = obj.ContentType
 				opts.DisableMultipart = true
 
-				client, cldone := s.Client()
+				client, cldone := s.threadClient(i)
 				op.Endpoint = client.EndpointURL().String()
 				op.Start = time.Now()
 				tarLength := int64(buf.Len())
 				// fmt.Println(op.Size, "->", tarLength, math.Round(100*float64(tarLength)/float64(op.Size)), "%")
-				res, err := client.PutObject(nonTerm, s.Bucket, obj.Name+".tar", &buf, tarLength, opts)
+				res, err := client.PutObject(opContext(nonTerm, &op), s.Bucket, obj.Name+".tar", &buf, tarLength, opts)
 				op.End = time.Now()
 				if err != nil {
 					s.Error("upload error: ", err)
@@ -177,6 +188,7 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 					err := fmt.Sprint("short upload. want:", tarLength, ", got:", res.Size)
 					if op.Err == "" {
 						op.Err = err
+						op.ErrClass = ErrClassValidation
 					}
 					s.Error(err)
 				}
@@ -189,6 +201,49 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 	return c.Close(), nil
 }
 
+// putObjects uploads objects from src with individual PUTs until ctx is canceled,
+// so the effective ingest rate can be compared to snowball uploads.
+func (s *Snowball) putObjects(ctx, nonTerm context.Context, thread int, src generator.Source, rcv chan<- Operation) {
+	opts := s.PutOpts
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if s.waitThread(ctx, thread) != nil {
+			return
+		}
+		obj := src.Object()
+		opts.ContentType = obj.ContentType
+		client, cldone := s.threadClient(thread)
+		op := Operation{
+			OpType:   http.MethodPut,
+			Thread:   uint32(thread),
+			Size:     obj.Size,
+			ObjPerOp: 1,
+			File:     obj.Name,
+			Endpoint: client.EndpointURL().String(),
+		}
+		op.Start = time.Now()
+		res, err := client.PutObject(opContext(nonTerm, &op), s.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+		op.End = time.Now()
+		if err != nil {
+			s.Error("upload error: ", err)
+			op.Err = err.Error()
+		}
+		if res.Size != obj.Size && op.Err == "" {
+			err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+			op.Err = err
+			op.ErrClass = ErrClassValidation
+			s.Error(err)
+		}
+		cldone()
+		rcv <- op
+	}
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (s *Snowball) Cleanup(ctx context.Context) {
 	if s.Compress {
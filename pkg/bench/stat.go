@@ -20,7 +20,6 @@ package bench
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"sync"
 	"time"
@@ -44,6 +43,19 @@ type Stat struct {
 
 	ListExisting bool
 	ListFlat     bool
+
+	// Scan will list the bucket and stat every listed object,
+	// instead of stating the prepared objects.
+	Scan bool
+
+	// ScanPageSize is the number of keys requested for each listing when scanning.
+	ScanPageSize int
+
+	// Access is the distribution of objects read.
+	Access AccessDist
+
+	// Manifest, if set, contains the objects to read instead of uploading.
+	Manifest generator.Objects
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -51,8 +63,21 @@ type Stat struct {
 func (g *Stat) Prepare(ctx context.Context) error {
 	// prepare the bench by listing object from the bucket
 	g.addCollector()
+	if len(g.Manifest) > 0 {
+		cl, done := g.objectClient()
+		defer done()
+		found, err := cl.BucketExists(ctx, g.Bucket)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("bucket %s does not exist and a manifest has been given", g.Bucket)
+		}
+		g.objects = g.Manifest
+		return nil
+	}
 	if g.ListExisting {
-		cl, done := g.Client()
+		cl, done := g.objectClient()
 
 		// ensure the bucket exist
 		found, err := cl.BucketExists(ctx, g.Bucket)
@@ -84,6 +109,7 @@ func (g *Stat) Prepare(ctx context.Context) error {
 			obj := generator.Object{
 				Name: object.Key,
 				Size: object.Size,
+				ETag: object.ETag,
 			}
 
 			if g.Versions > 1 {
@@ -167,10 +193,10 @@ func (g *Stat) Prepare(ctx context.Context) error {
 					// New input for each version
 					obj := src.Object()
 					obj.Name = name
-					client, cldone := g.Client()
+					client, cldone := g.objectClient()
 					op := Operation{
 						OpType:   http.MethodPut,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -179,29 +205,23 @@ func (g *Stat) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, err := g.prepareUpload(ctx, client, obj, opts)
 					op.End = time.Now()
 					if err != nil {
-						err := fmt.Errorf("upload error: %w", err)
 						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
+						cldone()
+						if err := g.prepareFailed(err, g.CreateObjects*g.Versions); err != nil {
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
 						}
-						mu.Unlock()
-						return
+						continue
 					}
 					obj.VersionID = res.VersionID
-					if res.Size != obj.Size {
-						err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-						g.Error(err)
-						mu.Lock()
-						if groupErr == nil {
-							groupErr = err
-						}
-						mu.Unlock()
-						return
-					}
+					obj.ETag = res.ETag
 					cldone()
 					mu.Lock()
 					obj.Reader = nil
@@ -214,24 +234,30 @@ func (g *Stat) Prepare(ctx context.Context) error {
 		}(i, obj)
 	}
 	wg.Wait()
+	if groupErr == nil && len(g.objects) == 0 {
+		groupErr = fmt.Errorf("no objects uploaded")
+	}
 	return groupErr
 }
 
 // Start will execute the main benchmark.
 // Operations should begin executing when the start channel is closed.
 func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
-	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "STAT", g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, "STAT", g.autoTermOpts())
 	}
-	// Non-terminating context.
-	nonTerm := context.Background()
+	if g.Scan {
+		return g.startScan(ctx, wait)
+	}
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
 
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
-			rng := rand.New(rand.NewSource(int64(i)))
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			next := g.Access.picker(g.threadRng(i), len(g.objects), i*len(g.objects)/g.Concurrency)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.StatOpts
@@ -245,15 +271,15 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
-				obj := g.objects[rng.Intn(len(g.objects))]
-				client, cldone := g.Client()
+				obj := g.objects[next()]
+				client, cldone := g.threadObjectClient(i)
 				op := Operation{
 					OpType:   "STAT",
-					Thread:   uint16(i),
+					Thread:   uint32(i),
 					Size:     0,
 					File:     obj.Name,
 					ObjPerOp: 1,
@@ -262,10 +288,10 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 
 				op.Start = time.Now()
 				var err error
-				if g.Versions > 1 {
+				if g.Versions > 1 || len(g.Manifest) > 0 {
 					opts.VersionID = obj.VersionID
 				}
-				objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, opts)
+				objI, err := client.StatObject(opContext(nonTerm, &op), g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("StatObject error: ", err)
 					op.Err = err.Error()
@@ -288,7 +314,138 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	return c.Close(), nil
 }
 
+// startScan lists the bucket and stats each listed object.
+// A single thread lists the bucket, one page at a time, and starts over when the end is reached.
+// The listed keys are stated by the other threads, so listing is paused if stats cannot keep up.
+func (g *Stat) startScan(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency + 1)
+	c := g.Collector
+	done := ctx.Done()
+	pageSize := g.ScanPageSize
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	keys := make(chan minio.ObjectInfo, pageSize)
+	delimiter := ""
+	if g.ListFlat {
+		delimiter = "/"
+	}
+
+	// Lister. Not paced, since it is limited by the stat threads.
+	go func() {
+		defer wg.Done()
+		defer close(keys)
+		thread := g.Concurrency
+		rcv := c.Receiver()
+		<-wait
+		token := ""
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			client, cldone := g.threadClient(thread)
+			op := Operation{
+				OpType:   "LIST",
+				Thread:   uint32(thread),
+				File:     g.ListPrefix,
+				Endpoint: client.EndpointURL().String(),
+			}
+			core := minio.Core{Client: client}
+			op.Start = time.Now()
+			res, err := core.ListObjectsV2(g.Bucket, g.ListPrefix, "", token, delimiter, pageSize)
+			op.End = time.Now()
+			cldone()
+			if err != nil {
+				g.Error("ListObjectsV2 error: ", err)
+				op.Err = err.Error()
+				rcv <- op
+				// Start over.
+				token = ""
+				continue
+			}
+			op.ObjPerOp = len(res.Contents)
+			rcv <- op
+			if token == "" && !res.IsTruncated && len(res.Contents) == 0 {
+				g.Error("no objects found to scan in bucket ", g.Bucket)
+				return
+			}
+			token = res.NextContinuationToken
+			if !res.IsTruncated {
+				token = ""
+			}
+			for _, obj := range res.Contents {
+				select {
+				case <-done:
+					return
+				case keys <- obj:
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rcv := c.Receiver()
+			opts := g.StatOpts
+
+			<-wait
+			for obj := range keys {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.waitThread(ctx, i) != nil {
+					return
+				}
+
+				client, cldone := g.threadObjectClient(i)
+				op := Operation{
+					OpType:   "STAT",
+					Thread:   uint32(i),
+					File:     obj.Key,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				objI, err := client.StatObject(opContext(nonTerm, &op), g.Bucket, obj.Key, opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					g.Error("StatObject error: ", err)
+					op.Err = err.Error()
+				} else if objI.Size != obj.Size {
+					op.Err = fmt.Sprint("unexpected file size. want:", obj.Size, ", got:", objI.Size)
+					op.ErrClass = ErrClassValidation
+					g.Error(op.Err)
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Rerun makes the benchmark ready to start again with the given concurrency.
+func (g *Stat) Rerun(concurrency int, extra []chan<- Operation) {
+	g.rerun(concurrency, extra)
+}
+
+// PreparedObjects returns the objects that will be used for the benchmark.
+func (g *Stat) PreparedObjects() generator.Objects {
+	return g.objects
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Stat) Cleanup(ctx context.Context) {
-	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
+	if !g.ListExisting && len(g.Manifest) == 0 {
+		g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
+	}
 }
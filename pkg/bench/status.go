@@ -0,0 +1,204 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// statusTrace is the response recording state of a thread.
+type statusTrace struct {
+	// requestIDs is set if request IDs should be recorded.
+	requestIDs bool
+
+	mu sync.Mutex
+	// deploymentID is the last deployment ID received.
+	// Kept so the same string can be shared by all operations.
+	deploymentID string
+}
+
+type statusTraceKey struct{}
+
+// opStatus contains the responses received for the requests of a single operation.
+type opStatus struct {
+	trace *statusTrace
+
+	mu sync.Mutex
+	// n is the number of responses received.
+	n int
+	// status is the HTTP status code of the last response, or 0 if no response was received.
+	status  int
+	retries int
+	// requestID and deploymentID are the IDs returned by the server for the last response, if recorded.
+	requestID, deploymentID string
+}
+
+type opStatusKey struct{}
+
+// opContext returns the context to use for the requests of op.
+// Responses to requests made with the returned context are recorded in op
+// when it is annotated by the collector.
+// All contexts returned for the same op record to the same status.
+// If ctx is not from a thread recording responses, it is returned as is.
+func opContext(ctx context.Context, op *Operation) context.Context {
+	st, ok := ctx.Value(statusTraceKey{}).(*statusTrace)
+	if !ok {
+		return ctx
+	}
+	if op.status == nil {
+		op.status = &opStatus{trace: st}
+	}
+	return context.WithValue(ctx, opStatusKey{}, op.status)
+}
+
+// add a response. A failed request that is followed by another request is counted as a retry.
+func (s *opStatus) add(status int, hdr http.Header) {
+	var requestID, deploymentID string
+	if s.trace.requestIDs && hdr != nil {
+		requestID = hdr.Get("X-Amz-Request-Id")
+		if requestID == "" {
+			// Azure Blob Storage.
+			requestID = hdr.Get("X-Ms-Request-Id")
+		}
+		deploymentID = s.trace.deployment(hdr.Get("X-Minio-Deployment-Id"))
+	}
+	s.mu.Lock()
+	if s.n > 0 && retryableStatus(s.status) {
+		s.retries++
+	}
+	s.n++
+	s.status = status
+	s.requestID, s.deploymentID = requestID, deploymentID
+	s.mu.Unlock()
+}
+
+// deployment returns id, sharing the string with earlier responses of the thread if it is unchanged.
+func (s *statusTrace) deployment(id string) string {
+	if id == "" {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id != s.deploymentID {
+		s.deploymentID = id
+	}
+	return s.deploymentID
+}
+
+// annotate sets the status and request IDs of the last response received for op
+// and the number of failed requests that were followed by another request.
+func (s *opStatus) annotate(op *Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == 0 {
+		return
+	}
+	op.Status = s.status
+	op.Retries += s.retries
+	op.RequestID, op.DeploymentID = s.requestID, s.deploymentID
+}
+
+// retryableStatus returns whether the client retries requests with this response status.
+// 0 is used for requests that did not receive a response.
+func retryableStatus(status int) bool {
+	switch status {
+	case 0, http.StatusRequestTimeout, http.StatusTooManyRequests, 499, 520:
+		return true
+	}
+	return status >= http.StatusInternalServerError && status <= http.StatusGatewayTimeout
+}
+
+// StatusRecorder returns a transport that records the status of responses
+// to requests made with the context of a benchmark operation.
+// Other requests are passed through unmodified.
+func StatusRecorder(rt http.RoundTripper) http.RoundTripper {
+	return &statusTransport{rt: rt}
+}
+
+type statusTransport struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (s *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	st, ok := req.Context().Value(opStatusKey{}).(*opStatus)
+	if !ok {
+		return s.rt.RoundTrip(req)
+	}
+	resp, err := s.rt.RoundTrip(req)
+	if err != nil || resp == nil {
+		if req.Context().Err() == nil {
+			st.add(0, nil)
+		}
+		return resp, err
+	}
+	st.add(resp.StatusCode, resp.Header)
+	return resp, nil
+}
+
+// threadStatuses contains the settings for recording responses of threads.
+type threadStatuses struct {
+	// requestIDs is set if request IDs should be recorded.
+	requestIDs bool
+}
+
+// withStatusTrace returns a context of a thread recording responses.
+// Operations on the thread must use opContext for their requests to record them.
+func (c *Common) withStatusTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, statusTraceKey{}, &statusTrace{requestIDs: c.statuses.requestIDs})
+}
+
+// RetryCounts contains response status and retry counts of operations.
+type RetryCounts struct {
+	// Ops is the number of operations with a recorded status.
+	Ops int
+	// FirstTry is the number of successful operations without retries.
+	FirstTry int
+	// RetriedOK is the number of successful operations with retries.
+	RetriedOK int
+	// RetriedFailed is the number of failed operations with retries.
+	RetriedFailed int
+	// Retries is the total number of retried requests.
+	Retries int
+	// Statuses contains the number of operations by final status code.
+	Statuses map[int]int
+}
+
+// Add the status and retries of op.
+// Operations without a recorded status are ignored.
+func (r *RetryCounts) Add(op Operation) {
+	if op.Status == 0 && op.Retries == 0 {
+		return
+	}
+	r.Ops++
+	r.Retries += op.Retries
+	switch {
+	case op.Retries == 0 && op.Err == "":
+		r.FirstTry++
+	case op.Retries > 0 && op.Err == "":
+		r.RetriedOK++
+	case op.Retries > 0:
+		r.RetriedFailed++
+	}
+	if r.Statuses == nil {
+		r.Statuses = make(map[int]int)
+	}
+	r.Statuses[op.Status]++
+}
@@ -0,0 +1,116 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// statusResponses is a transport returning the queued status codes in order.
+type statusResponses struct {
+	mu       sync.Mutex
+	statuses []int
+	n        int
+}
+
+func (s *statusResponses) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	status := s.statuses[0]
+	s.statuses = s.statuses[1:]
+	s.n++
+	id := strings.Repeat("x", s.n)
+	s.mu.Unlock()
+	hdr := http.Header{}
+	hdr.Set("X-Amz-Request-Id", id)
+	hdr.Set("X-Minio-Deployment-Id", "deployment")
+	hdr.Set("Last-Modified", "Mon, 2 Jan 2006 15:04:05 GMT")
+	return &http.Response{
+		StatusCode: status,
+		Header:     hdr,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestStatusRecorder(t *testing.T) {
+	c := Common{statuses: &threadStatuses{requestIDs: true}}
+	thread := c.withStatusTrace(context.Background())
+	rt := StatusRecorder(&statusResponses{statuses: []int{503, 200, 500, 200, 404}})
+	var op1, op2 Operation
+	ctx1, ctx2 := opContext(thread, &op1), opContext(thread, &op2)
+	// The requests of the operations are interleaved.
+	for _, ctx := range []context.Context{ctx1, ctx2, ctx1, opContext(thread, &op1), thread} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Operations are annotated independently of the order they completed.
+	c.annotate(&op2)
+	c.annotate(&op1)
+	if op1.Status != 200 || op1.Retries != 2 || op1.RequestID != "xxxx" || op1.DeploymentID != "deployment" {
+		t.Errorf("op1: got status %d, %d retries, request id %q, deployment %q", op1.Status, op1.Retries, op1.RequestID, op1.DeploymentID)
+	}
+	if op2.Status != 200 || op2.Retries != 0 || op2.RequestID != "xx" {
+		t.Errorf("op2: got status %d, %d retries, request id %q", op2.Status, op2.Retries, op2.RequestID)
+	}
+	if op1.status != nil || op2.status != nil {
+		t.Error("status not released after annotation")
+	}
+
+	// Operations without requests and requests without an operation are not recorded.
+	var op3 Operation
+	opContext(thread, &op3)
+	c.annotate(&op3)
+	if op3.Status != 0 || op3.Retries != 0 {
+		t.Errorf("op3: got status %d, %d retries", op3.Status, op3.Retries)
+	}
+}
+
+func TestStatusRecorderClientRetries(t *testing.T) {
+	cl, err := minio.New("localhost", &minio.Options{
+		Creds:     credentials.NewStaticV4("access", "secret", ""),
+		Region:    "us-east-1",
+		Transport: StatusRecorder(&statusResponses{statuses: []int{503, 503, 200}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Common{statuses: &threadStatuses{}}
+	var op Operation
+	_, err = cl.StatObject(opContext(c.withStatusTrace(context.Background()), &op), "bucket", "object", minio.StatObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.annotate(&op)
+	if op.Status != 200 || op.Retries != 2 || op.RequestID != "" {
+		t.Errorf("got status %d, %d retries, request id %q", op.Status, op.Retries, op.RequestID)
+	}
+}
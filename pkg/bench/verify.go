@@ -0,0 +1,94 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/minio/warp/pkg/generator"
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// objectChecksum returns the base64 encoded CRC32-C of the content of r.
+// r is read from the start and rewound afterwards.
+func objectChecksum(r io.ReadSeeker) (string, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := crc32.New(crc32c)
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifier checksums downloaded data of an object.
+// Data is written to the verifier as it is read.
+type verifier struct {
+	h    hash.Hash
+	want string
+	name string
+}
+
+// newVerifier returns a verifier for the complete content of obj.
+// The checksum recorded when uploading is used if known.
+// Otherwise the ETag is used if etag is true and it is a plain MD5.
+// Returns nil if the object content cannot be verified.
+func newVerifier(obj generator.Object, etag bool) *verifier {
+	if obj.Checksum != "" {
+		return &verifier{h: crc32.New(crc32c), want: obj.Checksum, name: "crc32c"}
+	}
+	tag := strings.Trim(obj.ETag, `"`)
+	if !etag || len(tag) != 2*md5.Size {
+		return nil
+	}
+	if _, err := hex.DecodeString(tag); err != nil {
+		return nil
+	}
+	return &verifier{h: md5.New(), want: strings.ToLower(tag), name: "etag"}
+}
+
+// Write adds p to the checksum.
+func (v *verifier) Write(p []byte) (int, error) {
+	return v.h.Write(p)
+}
+
+// check returns an error if the checksum of the written data doesn't match.
+func (v *verifier) check() error {
+	var got string
+	if v.name == "etag" {
+		got = hex.EncodeToString(v.h.Sum(nil))
+	} else {
+		got = base64.StdEncoding.EncodeToString(v.h.Sum(nil))
+	}
+	if got != v.want {
+		return fmt.Errorf("corruption: %s mismatch. want: %s, got: %s", v.name, v.want, got)
+	}
+	return nil
+}
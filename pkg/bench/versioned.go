@@ -25,6 +25,7 @@ import (
 	"math/rand"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -40,6 +41,9 @@ type Versioned struct {
 	GetOpts       minio.GetObjectOptions
 	StatOpts      minio.StatObjectOptions
 	CreateObjects int
+
+	// VersionsPerObject is the number of versions uploaded for each object during prepare.
+	VersionsPerObject int
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -60,9 +64,17 @@ func (g *Versioned) Prepare(ctx context.Context) error {
 		}
 		g.Versioned = true
 	}
+	versions := g.VersionsPerObject
+	if versions < 1 {
+		versions = 1
+	}
 	src := g.Source()
 	console.Eraseline()
-	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
+	x := ""
+	if versions > 1 {
+		x = fmt.Sprintf(" with %d versions each", versions)
+	}
+	console.Info("\rUploading ", g.CreateObjects, " objects", x, " of ", src.String())
 	var wg sync.WaitGroup
 	wg.Add(g.Concurrency)
 	g.addCollector()
@@ -70,6 +82,7 @@ func (g *Versioned) Prepare(ctx context.Context) error {
 
 	var groupErr error
 	var mu sync.Mutex
+	var prepared atomic.Int64
 	for _, obj := range objs {
 		go func(obj []struct{}) {
 			defer wg.Done()
@@ -90,34 +103,36 @@ func (g *Versioned) Prepare(ctx context.Context) error {
 				}
 
 				obj := src.Object()
-				client, clDone := g.Client()
-				opts.ContentType = obj.ContentType
-				res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
-				if err != nil {
-					err := fmt.Errorf("upload error: %w", err)
-					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+				name, prefix := obj.Name, obj.Prefix
+				for ver := 0; ver < versions; ver++ {
+					if ver > 0 {
+						// New input for each version
+						obj = src.Object()
+						obj.Name, obj.Prefix = name, prefix
 					}
-					mu.Unlock()
-					return
-				}
-				obj.VersionID = res.VersionID
-				if res.Size != obj.Size {
-					err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
-					g.Error(err)
-					mu.Lock()
-					if groupErr == nil {
-						groupErr = err
+					client, clDone := g.Client()
+					opts.ContentType = obj.ContentType
+					res, err := g.prepareUpload(ctx, S3ObjectClient(client), obj, opts)
+					if err != nil {
+						g.Error(err)
+						clDone()
+						if err := g.prepareFailed(err, g.CreateObjects*versions); err != nil {
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
+						}
+						continue
 					}
-					mu.Unlock()
-					return
+					obj.VersionID = res.VersionID
+					clDone()
+					obj.Reader = nil
+					g.Dist.addObj(*obj)
+					prepared.Add(1)
+					g.prepareProgress(float64(prepared.Load()) / float64(g.CreateObjects*versions))
 				}
-				clDone()
-				obj.Reader = nil
-				g.Dist.addObj(*obj)
-				g.prepareProgress(float64(len(g.Dist.objects)) / float64(g.CreateObjects))
 			}
 		}(obj)
 	}
@@ -132,12 +147,12 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 	wg.Add(g.Concurrency)
 	c := g.Collector
 	if g.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "", g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+		ctx = c.AutoTerm(ctx, "", g.autoTermOpts())
 	}
-	// Non-terminating context.
-	nonTerm := context.Background()
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
 			rcv := c.Receiver()
 			defer wg.Done()
 			done := ctx.Done()
@@ -154,7 +169,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 				default:
 				}
 
-				if g.rpsLimit(ctx) != nil {
+				if g.waitThread(ctx, i) != nil {
 					return
 				}
 
@@ -163,10 +178,10 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 				case http.MethodGet:
 					fbr := firstByteRecorder{}
 					obj, objDone := g.Dist.randomObjRead()
-					client, clDone := g.Client()
+					client, clDone := g.threadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -176,7 +191,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					op.Start = time.Now()
 					var err error
 					getOpts.VersionID = obj.VersionID
-					fbr.r, err = client.GetObject(nonTerm, g.Bucket, obj.Name, getOpts)
+					fbr.r, err = client.GetObject(opContext(nonTerm, &op), g.Bucket, obj.Name, getOpts)
 					if err != nil {
 						g.Error("download error: ", err)
 						op.Err = err.Error()
@@ -195,6 +210,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					op.End = time.Now()
 					if n != obj.Size && op.Err == "" {
 						op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+						op.ErrClass = ErrClassValidation
 						g.Error(op.Err)
 					}
 					rcv <- op
@@ -203,10 +219,10 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 				case http.MethodPut:
 					obj, objDone := g.Dist.newVersion(src.Object())
 					putOpts.ContentType = obj.ContentType
-					client, clDone := g.Client()
+					client, clDone := g.threadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     obj.Size,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -214,7 +230,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					}
 
 					op.Start = time.Now()
-					res, err := client.PutObject(nonTerm, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
+					res, err := g.putObject(opContext(nonTerm, &op), S3ObjectClient(client), &obj, obj.Size, putOpts)
 					op.End = time.Now()
 					if err != nil {
 						g.Error("upload error: ", err)
@@ -226,6 +242,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 						err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
 						if op.Err == "" {
 							op.Err = err
+							op.ErrClass = ErrClassValidation
 						}
 						g.Error(err)
 					}
@@ -238,18 +255,18 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					objDone(res.VersionID)
 					rcv <- op
 				case http.MethodDelete:
-					client, clDone := g.Client()
+					client, clDone := g.threadClient(i)
 					obj := g.Dist.deleteRandomObj()
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     0,
 						File:     obj.Name,
 						ObjPerOp: 1,
 						Endpoint: client.EndpointURL().String(),
 					}
 					op.Start = time.Now()
-					err := client.RemoveObject(nonTerm, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
+					err := client.RemoveObject(opContext(nonTerm, &op), g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
 					op.End = time.Now()
 					clDone()
 					if err != nil {
@@ -259,10 +276,10 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					rcv <- op
 				case "STAT":
 					obj, objDone := g.Dist.randomObjRead()
-					client, clDone := g.Client()
+					client, clDone := g.threadClient(i)
 					op := Operation{
 						OpType:   operation,
-						Thread:   uint16(i),
+						Thread:   uint32(i),
 						Size:     0,
 						File:     obj.Name,
 						ObjPerOp: 1,
@@ -271,7 +288,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					op.Start = time.Now()
 					var err error
 					statOpts.VersionID = obj.VersionID
-					objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, statOpts)
+					objI, err := client.StatObject(opContext(nonTerm, &op), g.Bucket, obj.Name, statOpts)
 					if err != nil {
 						g.Error("stat error:", err)
 						op.Err = err.Error()
@@ -0,0 +1,92 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// wireCounter counts responses and their size.
+type wireCounter struct {
+	bytes     atomic.Int64
+	responses atomic.Int64
+}
+
+type wireCounterKey struct{}
+
+// withWireCounter returns a context that will count responses
+// received by clients using a transport returned by WireCounter.
+func withWireCounter(ctx context.Context) (context.Context, *wireCounter) {
+	wc := &wireCounter{}
+	return context.WithValue(ctx, wireCounterKey{}, wc), wc
+}
+
+// WireCounter returns a transport that records the size of responses
+// to requests made with a context from withWireCounter.
+// Other requests are passed through unmodified.
+func WireCounter(rt http.RoundTripper) http.RoundTripper {
+	return &wireCountTransport{rt: rt}
+}
+
+type wireCountTransport struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (w *wireCountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wc, ok := req.Context().Value(wireCounterKey{}).(*wireCounter)
+	if !ok {
+		return w.rt.RoundTrip(req)
+	}
+	if _, ok := req.URL.Query()["location"]; ok {
+		// Bucket location lookups are not part of the response.
+		return w.rt.RoundTrip(req)
+	}
+	resp, err := w.rt.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	wc.responses.Add(1)
+	// Status line, headers and the empty line separating them from the body.
+	n := len(resp.Proto) + len(resp.Status) + 3
+	for k, v := range resp.Header {
+		for _, s := range v {
+			n += len(k) + len(s) + 4
+		}
+	}
+	wc.bytes.Add(int64(n + 2))
+	if resp.Body != nil {
+		resp.Body = &wireCountBody{ReadCloser: resp.Body, n: &wc.bytes}
+	}
+	return resp, nil
+}
+
+// wireCountBody counts the bytes read from a response body.
+type wireCountBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (w *wireCountBody) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	w.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,226 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Worm benchmarks object lock compliance mode.
+// Objects are uploaded with compliance retention and attempts are made
+// to delete them and shorten their retention, which must be rejected.
+type Worm struct {
+	Common
+
+	// Retain is how long objects are retained after the benchmark deadline.
+	Retain time.Duration
+
+	// Attempts is the number of rejected operations attempted per upload.
+	Attempts int
+
+	mu          sync.Mutex
+	objects     generator.Objects
+	retainUntil time.Time
+}
+
+// Operation types for rejected operations.
+// Operations that are not rejected are recorded as errors.
+const (
+	wormDelete  = "WORM-DELETE"
+	wormShorten = "WORM-SHORTEN"
+)
+
+// Prepare will create an empty bucket with object locking enabled.
+func (g *Worm) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	// Buckets with object locking are always versioned.
+	g.Versioned = true
+	g.addCollector()
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Worm) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodPut, g.autoTermOpts())
+	}
+	// Keep objects locked for the entire benchmark.
+	retainUntil := time.Now().Add(g.Retain)
+	if dl, ok := ctx.Deadline(); ok {
+		retainUntil = dl.Add(g.Retain)
+	}
+	retainUntil = retainUntil.Truncate(time.Second).Add(time.Second).UTC()
+	g.retainUntil = retainUntil
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			// Non-terminating context.
+			nonTerm := g.threadContext(i)
+			rng := g.threadRng(i)
+			rcv := c.Receiver()
+			defer wg.Done()
+			src := g.Source()
+			done := ctx.Done()
+			mode := minio.Compliance
+			putOpts := g.PutOpts
+			putOpts.Mode = mode
+			putOpts.RetainUntilDate = retainUntil
+
+			<-wait
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.waitThread(ctx, i) != nil {
+					return
+				}
+
+				obj := src.Object()
+				putOpts.ContentType = obj.ContentType
+				client, cldone := g.threadClient(i)
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint32(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				res, err := g.putObject(opContext(nonTerm, &op), S3ObjectClient(client), obj, obj.Size, putOpts)
+				op.End = time.Now()
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					g.Error(err)
+					op.Err = err.Error()
+				}
+				obj.VersionID = res.VersionID
+				if res.Size != obj.Size && op.Err == "" {
+					err := fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+					g.Error(err)
+					op.Err = err.Error()
+					op.ErrClass = ErrClassValidation
+				}
+				cldone()
+				rcv <- op
+				if op.Err == "" {
+					obj.Reader = nil
+					g.mu.Lock()
+					g.objects = append(g.objects, *obj)
+					g.mu.Unlock()
+				}
+
+				for n := 0; n < g.Attempts; n++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					if g.waitThread(ctx, i) != nil {
+						return
+					}
+					g.mu.Lock()
+					if len(g.objects) == 0 {
+						g.mu.Unlock()
+						break
+					}
+					obj := g.objects[rng.Intn(len(g.objects))]
+					g.mu.Unlock()
+
+					client, cldone := g.threadClient(i)
+					op := Operation{
+						Thread:   uint32(i),
+						File:     obj.Name,
+						ObjPerOp: 1,
+						Endpoint: client.EndpointURL().String(),
+					}
+					if rng.Intn(2) == 0 {
+						op.OpType = wormDelete
+						op.Start = time.Now()
+						err = client.RemoveObject(opContext(nonTerm, &op), g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
+					} else {
+						op.OpType = wormShorten
+						until := time.Now().Add(time.Second)
+						op.Start = time.Now()
+						err = client.PutObjectRetention(opContext(nonTerm, &op), g.Bucket, obj.Name, minio.PutObjectRetentionOptions{
+							Mode:            &mode,
+							RetainUntilDate: &until,
+							VersionID:       obj.VersionID,
+						})
+					}
+					op.End = time.Now()
+					cldone()
+					if rerr := wormRejected(err); rerr != nil {
+						g.Error(fmt.Sprintf("%s %s: %v", op.OpType, obj.Name, rerr))
+						op.Err = rerr.Error()
+						if err == nil {
+							// The operation was allowed.
+							op.ErrClass = ErrClassValidation
+						}
+					}
+					rcv <- op
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// wormRejected returns nil if err is the expected rejection of a locked object.
+func wormRejected(err error) error {
+	if err == nil {
+		return errors.New("object lock not enforced, operation was allowed")
+	}
+	if minio.ToErrorResponse(err).StatusCode == http.StatusForbidden {
+		return nil
+	}
+	return err
+}
+
+// Cleanup waits for retention to expire and deletes everything uploaded to the bucket.
+func (g *Worm) Cleanup(ctx context.Context) {
+	if wait := time.Until(g.retainUntil); wait > 0 {
+		console.Eraseline()
+		console.Infof("\rWaiting %v for retention to expire...", wait.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
+}
@@ -0,0 +1,126 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package generator
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// blockReader returns data generated one block at a time.
+// The content of each block only depends on the seed and the block index,
+// so the same content is returned after seeking.
+type blockReader struct {
+	gen func(seed uint64, idx int64, dst []byte)
+	buf []byte
+	// idx is the index of the block in buf, -1 if none.
+	idx  int64
+	seed uint64
+
+	// The total number of bytes to return
+	want int64
+	read int64
+}
+
+func newBlockReader(blockSize int, gen func(seed uint64, idx int64, dst []byte)) *blockReader {
+	return &blockReader{
+		gen: gen,
+		buf: make([]byte, blockSize),
+		idx: -1,
+	}
+}
+
+// Reset will reset the reader to return want bytes of content generated from seed.
+func (b *blockReader) Reset(want int64, seed uint64) io.ReadSeeker {
+	b.want = want
+	b.read = 0
+	b.seed = seed
+	b.idx = -1
+	return b
+}
+
+// Seek implements io.Seeker.
+func (b *blockReader) Seek(offset int64, whence int) (n int64, err error) {
+	switch whence {
+	default:
+		return 0, errors.New("blockReader.Seek: invalid whence")
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.read
+	case io.SeekEnd:
+		offset += b.want
+	}
+	if offset < 0 {
+		return 0, errors.New("blockReader.Seek: negative position")
+	}
+	if offset > b.want {
+		return 0, io.EOF
+	}
+	b.read = offset
+	return b.read, nil
+}
+
+func (b *blockReader) Read(p []byte) (n int, err error) {
+	bs := int64(len(b.buf))
+	for len(p) > 0 {
+		remain := b.want - b.read
+		if remain <= 0 {
+			return n, io.EOF
+		}
+		if idx := b.read / bs; idx != b.idx {
+			b.gen(b.seed, idx, b.buf)
+			b.idx = idx
+		}
+		src := b.buf[b.read%bs:]
+		if int64(len(src)) > remain {
+			src = src[:remain]
+		}
+		copied := copy(p, src)
+		p = p[copied:]
+		b.read += int64(copied)
+		n += copied
+	}
+	if b.read == b.want {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// splitMix is a fast pseudorandom generator used for filling blocks.
+type splitMix uint64
+
+func (s *splitMix) next() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	z := uint64(*s)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// fill dst with pseudorandom data.
+func (s *splitMix) fill(dst []byte) {
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, s.next())
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var tmp [8]byte
+		binary.LittleEndian.PutUint64(tmp[:], s.next())
+		copy(dst, tmp[:])
+	}
+}
@@ -125,7 +125,7 @@ func newCsv(o Options) (Source, error) {
 	c.rng = rand.New(rndSrc)
 	c.obj.ContentType = "text/csv"
 	c.obj.Size = 0
-	c.obj.setPrefix(o)
+	c.obj.setPrefix(o, c.rng)
 
 	return &c, nil
 }
@@ -0,0 +1,155 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// WithDedupe returns default options for data with duplicate blocks.
+func WithDedupe() DedupeOpts {
+	return dedupeOptsDefaults()
+}
+
+// Apply dedupe data options.
+func (o DedupeOpts) Apply() Option {
+	return func(opts *Options) error {
+		if err := o.validate(); err != nil {
+			return err
+		}
+		opts.dedupe = o
+		opts.src = newDedupe
+		return nil
+	}
+}
+
+func (o DedupeOpts) validate() error {
+	if o.dupes < 0 || o.dupes > 1 {
+		return fmt.Errorf("dedupe: duplicate fraction must be between 0 and 1, got %v", o.dupes)
+	}
+	if o.blockSize <= 0 {
+		return errors.New("dedupe: block size <= 0")
+	}
+	if o.poolBlocks <= 0 {
+		return errors.New("dedupe: pool blocks <= 0")
+	}
+	return nil
+}
+
+// Dupes sets the fraction of blocks that are duplicates, between 0 and 1.
+// For example 0.75 will give a deduplication ratio of 4:1.
+func (o DedupeOpts) Dupes(f float64) DedupeOpts {
+	o.dupes = f
+	return o
+}
+
+// BlockSize sets the size of each block.
+// Storage using fixed size blocks of this size, or a divisor, will be able to deduplicate.
+func (o DedupeOpts) BlockSize(n int) DedupeOpts {
+	o.blockSize = n
+	return o
+}
+
+// RngSeed will which to a fixed RNG seed to make usage predictable.
+func (o DedupeOpts) RngSeed(s int64) DedupeOpts {
+	o.seed = &s
+	return o
+}
+
+// DedupeOpts are the options for the dedupe data source.
+type DedupeOpts struct {
+	seed       *int64
+	dupes      float64
+	blockSize  int
+	poolBlocks int
+}
+
+func dedupeOptsDefaults() DedupeOpts {
+	return DedupeOpts{
+		dupes:     0.5,
+		blockSize: 4 << 10,
+		// Duplicates are chosen from 256 blocks.
+		poolBlocks: 256,
+	}
+}
+
+type dedupeSrc struct {
+	buf     *blockReader
+	rng     *rand.Rand
+	obj     Object
+	o       Options
+	pool    [][]byte
+	counter uint64
+}
+
+func newDedupe(o Options) (Source, error) {
+	rndSrc := rand.NewSource(int64(rand.Uint64()))
+	if o.dedupe.seed != nil {
+		rndSrc = rand.NewSource(*o.dedupe.seed)
+	}
+	rng := rand.New(rndSrc)
+
+	// The pool is derived from a fixed seed, so duplicates are shared between all sources.
+	pool := make([][]byte, o.dedupe.poolBlocks)
+	for i := range pool {
+		pool[i] = make([]byte, o.dedupe.blockSize)
+		s := splitMix(DeriveSeed(0x6465647570, uint64(i)))
+		s.fill(pool[i])
+	}
+	d := dedupeSrc{
+		o:    o,
+		rng:  rng,
+		pool: pool,
+		obj: Object{
+			ContentType: "application/octet-stream",
+		},
+	}
+	// Fraction of duplicates in 1/2^32 units.
+	limit := uint64(o.dedupe.dupes * (1 << 32))
+	d.buf = newBlockReader(o.dedupe.blockSize, func(seed uint64, idx int64, dst []byte) {
+		s := splitMix(DeriveSeed(int64(seed), uint64(idx)))
+		v := s.next()
+		if v>>32 < limit {
+			copy(dst, d.pool[(v&0xffffffff)%uint64(len(d.pool))])
+			return
+		}
+		s.fill(dst)
+	})
+	d.obj.setPrefix(o, rng)
+	return &d, nil
+}
+
+func (d *dedupeSrc) Object() *Object {
+	atomic.AddUint64(&d.counter, 1)
+	var nBuf [16]byte
+	randASCIIBytes(nBuf[:], d.rng)
+	d.obj.Size = d.o.getSize(d.rng)
+	d.obj.setName(fmt.Sprintf("%d.%s.bin", atomic.LoadUint64(&d.counter), string(nBuf[:])))
+	d.obj.Reader = d.buf.Reset(d.obj.Size, d.rng.Uint64())
+	return &d.obj
+}
+
+func (d *dedupeSrc) String() string {
+	return fmt.Sprintf("Block dedupe data; %.0f%% duplicate %d byte blocks", d.o.dedupe.dupes*100, d.o.dedupe.blockSize)
+}
+
+func (d *dedupeSrc) Prefix() string {
+	return d.obj.Prefix
+}
@@ -0,0 +1,67 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestDedupeRatio(t *testing.T) {
+	const (
+		blockSize = 4 << 10
+		objects   = 64
+		objSize   = 1 << 20
+	)
+	for _, dupes := range []float64{0, 0.5, 0.75, 0.9} {
+		src, err := New(WithDedupe().Dupes(dupes).BlockSize(blockSize).RngSeed(1).Apply(), WithSize(objSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[[sha256.Size]byte]struct{})
+		var blocks int
+		buf := make([]byte, blockSize)
+		for i := 0; i < objects; i++ {
+			obj := src.Object()
+			for {
+				_, err := io.ReadFull(obj.Reader, buf)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				seen[sha256.Sum256(buf)] = struct{}{}
+				blocks++
+			}
+		}
+		if blocks != objects*objSize/blockSize {
+			t.Fatalf("dupes %v: got %d blocks, want %d", dupes, blocks, objects*objSize/blockSize)
+		}
+		// Unique blocks are stored once, plus each block of the duplicate pool.
+		got := float64(blocks) / float64(len(seen))
+		want := float64(blocks) / (float64(blocks)*(1-dupes) + float64(dedupeOptsDefaults().poolBlocks))
+		if dupes == 0 {
+			want = 1
+		}
+		if got < want*0.97 || got > want*1.03 {
+			t.Errorf("dupes %v: got dedupe ratio %.3f, want %.3f", dupes, got, want)
+		}
+	}
+}
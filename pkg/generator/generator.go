@@ -24,6 +24,7 @@ import (
 	"math/rand"
 	"path"
 	"runtime"
+	"sync/atomic"
 )
 
 // Option provides options for data generation.
@@ -58,6 +59,12 @@ type Object struct {
 
 	VersionID string
 
+	// ETag of the uploaded object, if known.
+	ETag string
+
+	// Checksum is the base64 encoded CRC32-C of the content, if known.
+	Checksum string
+
 	// Size of the object to expect.
 	Size int64
 }
@@ -93,13 +100,12 @@ func MergeObjectPrefixes(o []Objects) []string {
 	return res
 }
 
-func (o *Object) setPrefix(opts Options) {
+func (o *Object) setPrefix(opts Options, rng *rand.Rand) {
 	if opts.randomPrefix <= 0 {
 		o.Prefix = opts.customPrefix
 		return
 	}
 	b := make([]byte, opts.randomPrefix)
-	rng := rand.New(rand.NewSource(int64(rand.Uint64())))
 	randASCIIBytes(b, rng)
 	o.Prefix = path.Join(opts.customPrefix, string(b))
 }
@@ -140,7 +146,16 @@ func NewFn(opts ...Option) (func() Source, error) {
 		return nil, errors.New("internal error: generator Source was nil")
 	}
 
+	var n uint64
 	return func() Source {
+		options := options
+		if options.seed != nil {
+			seed := DeriveSeed(*options.seed, atomic.AddUint64(&n, 1)-1)
+			options.random.seed = &seed
+			options.csv.seed = &seed
+			options.dedupe.seed = &seed
+			options.text.seed = &seed
+		}
 		s, err := options.src(options)
 		if err != nil {
 			panic(err)
@@ -149,6 +164,16 @@ func NewFn(opts ...Option) (func() Source, error) {
 	}, nil
 }
 
+// DeriveSeed returns a seed for stream n derived from seed.
+// Streams with different n, or different seeds, are independent.
+func DeriveSeed(seed int64, n uint64) int64 {
+	// splitmix64
+	z := uint64(seed) + (n+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
 const asciiLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890()"
 
 var asciiLetterBytes [len(asciiLetters)]byte
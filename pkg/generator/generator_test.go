@@ -49,6 +49,30 @@ func TestNew(t *testing.T) {
 			wantErr:  false,
 			wantSize: 1 << 20,
 		},
+		{
+			name: "Dedupe",
+			args: args{
+				opts: []Option{WithDedupe().Dupes(0.75).Apply(), WithSize(1<<20 + 17)},
+			},
+			wantErr:  false,
+			wantSize: 1<<20 + 17,
+		},
+		{
+			name: "Text",
+			args: args{
+				opts: []Option{WithText().Words(100).Apply()},
+			},
+			wantErr:  false,
+			wantSize: 1 << 20,
+		},
+		{
+			name: "SizeDist",
+			args: args{
+				opts: []Option{WithSizeDist(&SizeDist{sizes: []int64{4 << 10}, cum: []float64{1}})},
+			},
+			wantErr:  false,
+			wantSize: 4 << 10,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,88 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package generator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+)
+
+// ManifestEntry is a single object in a dataset manifest.
+// Manifests are stored as JSON lines, one entry per line.
+type ManifestEntry struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	ETag      string `json:"etag,omitempty"`
+	VersionID string `json:"version,omitempty"`
+}
+
+// WriteManifest writes the objects as JSON lines to w.
+func (o Objects) WriteManifest(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, obj := range o {
+		err := enc.Encode(ManifestEntry{
+			Key:       obj.Name,
+			Size:      obj.Size,
+			ETag:      obj.ETag,
+			VersionID: obj.VersionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadManifest reads objects from a manifest written as JSON lines.
+// Empty lines are ignored.
+// The prefix of each object is set to the directory of the key.
+func ReadManifest(r io.Reader) (Objects, error) {
+	var res Objects
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		b := sc.Bytes()
+		if len(b) == 0 {
+			continue
+		}
+		var e ManifestEntry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, fmt.Errorf("manifest line %d: %w", line, err)
+		}
+		if e.Key == "" {
+			return nil, fmt.Errorf("manifest line %d: no key", line)
+		}
+		prefix := path.Dir(e.Key)
+		if prefix == "." {
+			prefix = ""
+		}
+		res = append(res, Object{
+			Name:      e.Key,
+			Size:      e.Size,
+			ETag:      e.ETag,
+			VersionID: e.VersionID,
+			Prefix:    prefix,
+		})
+	}
+	return res, sc.Err()
+}
@@ -0,0 +1,61 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	objs := Objects{
+		{Name: "obj", Size: 0},
+		{Name: "prefix/obj-1", Size: 1 << 20, ETag: "d41d8cd98f00b204e9800998ecf8427e", Prefix: "prefix"},
+		{Name: "prefix/sub/obj-2", Size: 12345, VersionID: "3f2a0e6b-6c1d-4f0e-9b1a-7c2d8e9f0a1b", Prefix: "prefix/sub"},
+		{Name: "prefix/sub/obj-2", Size: 54321, VersionID: "null", Prefix: "prefix/sub"},
+	}
+	var buf bytes.Buffer
+	if err := objs.WriteManifest(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, objs) {
+		t.Errorf("got %+v, want %+v", got, objs)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	got, err := ReadManifest(strings.NewReader("\n{\"key\":\"a/b\",\"size\":3}\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Objects{{Name: "a/b", Size: 3, Prefix: "a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for _, in := range []string{"{", `{"size":1}`, `{"key":"a"}` + "\n" + "not json"} {
+		if _, err := ReadManifest(strings.NewReader(in)); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
@@ -31,14 +31,20 @@ type Options struct {
 	customPrefix string
 	random       RandomOpts
 	csv          CsvOpts
+	dedupe       DedupeOpts
+	text         TextOpts
 	minSize      int64
 	totalSize    int64
 	randomPrefix int
 	randSize     bool
+	seed         *int64
 
 	// Activates the use of a distribution of sizes
 	flagSizesDistribution bool
 	sizesDistribution     hist.Int64Distribution
+
+	// sizeDist is a weighted distribution of sizes, if set.
+	sizeDist *SizeDist
 }
 
 // OptionApplier allows to abstract generator options.
@@ -48,6 +54,9 @@ type OptionApplier interface {
 
 // getSize will return a size for an object.
 func (o Options) getSize(rng *rand.Rand) int64 {
+	if o.sizeDist != nil {
+		return o.sizeDist.Poll(rng)
+	}
 	if o.flagSizesDistribution {
 		return o.sizesDistribution.Poll(rng)
 	}
@@ -62,6 +71,8 @@ func defaultOptions() Options {
 		src:          newRandom,
 		totalSize:    1 << 20,
 		csv:          csvOptsDefaults(),
+		dedupe:       dedupeOptsDefaults(),
+		text:         textOptsDefaults(),
 		random:       randomOptsDefaults(),
 		randomPrefix: 0,
 	}
@@ -80,6 +91,19 @@ func WithSizeHistograms(encoded string) Option {
 	}
 }
 
+// WithSizeDist will select object sizes from the distribution.
+// This overrides any other size options.
+func WithSizeDist(d *SizeDist) Option {
+	return func(o *Options) error {
+		if d == nil {
+			return errors.New("WithSizeDist: no distribution")
+		}
+		o.sizeDist = d
+		o.totalSize = d.Max()
+		return nil
+	}
+}
+
 // WithMinMaxSize sets the min and max size of the generated data.
 func WithMinMaxSize(minSize, maxSize int64) Option {
 	return func(o *Options) error {
@@ -136,6 +160,16 @@ func WithCustomPrefix(prefix string) Option {
 	}
 }
 
+// WithSeed makes the generated names, sizes and data predictable.
+// Each source returned by NewFn derives its own seed from s,
+// based on the order in which the sources are created.
+func WithSeed(s int64) Option {
+	return func(o *Options) error {
+		o.seed = &s
+		return nil
+	}
+}
+
 // WithPrefixSize sets prefix size.
 func WithPrefixSize(n int) Option {
 	return func(o *Options) error {
@@ -115,7 +115,7 @@ func newRandom(o Options) (Source, error) {
 			Size:        0,
 		},
 	}
-	r.obj.setPrefix(o)
+	r.obj.setPrefix(o, rng)
 	return &r, nil
 }
 
@@ -132,6 +132,9 @@ func (r *randomSrc) Object() *Object {
 }
 
 func (r *randomSrc) String() string {
+	if r.o.sizeDist != nil {
+		return fmt.Sprintf("Random data; sizes %s", r.o.sizeDist)
+	}
 	if r.o.randSize {
 		return fmt.Sprintf("Random data; random size up to %d bytes", r.o.totalSize)
 	}
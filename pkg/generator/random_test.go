@@ -0,0 +1,76 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+// TestRandomSeekReread checks that random data is the same when read again after seeking,
+// so checksums calculated from the generator match what was uploaded.
+func TestRandomSeekReread(t *testing.T) {
+	crc32c := crc32.MakeTable(crc32.Castagnoli)
+	for _, size := range []int64{1, 15, 17, 1000, 1 << 20, 10<<20 + 3} {
+		src, err := New(WithRandomData().Apply(), WithSize(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			obj := src.Object()
+			first, err := io.ReadAll(obj.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(first)) != size {
+				t.Fatalf("size %d: got %d bytes", size, len(first))
+			}
+			if _, err := obj.Reader.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			second, err := io.ReadAll(obj.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatalf("size %d: data changed after seeking to start", size)
+			}
+			if a, b := crc32.Checksum(first, crc32c), crc32.Checksum(second, crc32c); a != b {
+				t.Fatalf("size %d: checksum mismatch %x != %x", size, a, b)
+			}
+			// Seeking into the middle must return the same data as the full read.
+			for _, off := range []int64{size / 3, size / 2, size - 1} {
+				if off < 0 {
+					continue
+				}
+				if _, err := obj.Reader.Seek(off, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+				rest, err := io.ReadAll(obj.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(rest, first[off:]) {
+					t.Fatalf("size %d: data at offset %d does not match", size, off)
+				}
+			}
+		}
+	}
+}
@@ -20,17 +20,22 @@ package generator
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"encoding/binary"
 	"errors"
 	"io"
-	"math"
 	"math/rand"
-
-	"github.com/secure-io/sio-go"
 )
 
 type scrambler struct {
-	// Data source
-	stream *sio.EncReader
+	// Data to scramble, repeated as needed.
+	data []byte
+	// Cipher keyed once per scrambler.
+	block cipher.Block
+	// Per object IV, so the same object can be reproduced after seeking.
+	iv  [aes.BlockSize]byte
+	rng *rand.Rand
+	// Key stream positioned at read. nil if it must be recreated.
+	stream cipher.Stream
 	// The total number of bytes to return
 	want int64
 	// Number of bytes read
@@ -40,17 +45,22 @@ type scrambler struct {
 // Reset will reset the scrambler.
 // The number of bytes to return can be specified.
 // If the number of bytes wanted is <= 0 the value will not be updated.
+// A new object is started, so the output will differ from the previous.
 func (c *scrambler) Reset(want int64) io.ReadSeeker {
 	if want > 0 {
 		c.want = want
 	}
 	c.read = 0
+	c.rng.Read(c.iv[:])
+	c.stream = nil
 	return c
 }
 
 // Implement seeker compatible circular buffer,
 // implemented for minio-go to allow retries.
+// Seeking will replay the same data as was returned on the previous read.
 func (c *scrambler) Seek(offset int64, whence int) (n int64, err error) {
+	prev := c.read
 	// Switch through whence.
 	switch whence {
 	default:
@@ -77,26 +87,47 @@ func (c *scrambler) Seek(offset int64, whence int) (n int64, err error) {
 	if c.read < 0 {
 		return 0, errors.New("circularBuffer.Seek: negative position")
 	}
+	if c.read != prev {
+		c.stream = nil
+	}
 	return c.read, nil
 }
 
-// newCircularBuffer a reader that will produce (virtually) infinitely amounts of random data.
+// newScrambler a reader that will produce random data from the supplied data.
 func newScrambler(data []byte, size int64, rng *rand.Rand) *scrambler {
-	var randSrc [16]byte
-
-	_, err := io.ReadFull(rng, randSrc[:])
+	var key [16]byte
+	_, err := io.ReadFull(rng, key[:])
 	if err != nil {
 		panic(err)
 	}
-	rand.New(rng).Read(randSrc[:])
-	block, _ := aes.NewCipher(randSrc[:])
-	gcm, _ := cipher.NewGCM(block)
-	stream := sio.NewStream(gcm, sio.BufSize)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	c := &scrambler{
+		data:  data,
+		block: block,
+		rng:   rng,
+	}
+	c.Reset(size)
+	return c
+}
 
-	return &scrambler{
-		want:   size,
-		read:   0,
-		stream: stream.EncryptReader(newCircularBuffer(data, math.MaxInt64), randSrc[:stream.NonceSize()], nil),
+// seekStream creates a key stream positioned at the current read offset.
+func (c *scrambler) seekStream() {
+	iv := c.iv
+	blocks := uint64(c.read / aes.BlockSize)
+	lo := binary.BigEndian.Uint64(iv[8:])
+	hi := binary.BigEndian.Uint64(iv[:8])
+	if lo+blocks < lo {
+		hi++
+	}
+	binary.BigEndian.PutUint64(iv[8:], lo+blocks)
+	binary.BigEndian.PutUint64(iv[:8], hi)
+	c.stream = cipher.NewCTR(c.block, iv[:])
+	if skip := c.read % aes.BlockSize; skip > 0 {
+		var tmp [aes.BlockSize]byte
+		c.stream.XORKeyStream(tmp[:skip], tmp[:skip])
 	}
 }
 
@@ -113,14 +144,19 @@ func (c *scrambler) Read(p []byte) (n int, err error) {
 	if int64(toDo) > remain {
 		p = p[:remain]
 	}
-	copied, err := io.ReadFull(c.stream, p)
-	if err != nil {
-		return copied, err
+	if c.stream == nil {
+		c.seekStream()
+	}
+	for len(p) > 0 {
+		copied := copy(p, c.data[(c.read+int64(n))%int64(len(c.data)):])
+		c.stream.XORKeyStream(p[:copied], p[:copied])
+		p = p[copied:]
+		n += copied
 	}
 	// Assign remaining back to c.left
-	c.read += int64(copied)
+	c.read += int64(n)
 	if c.read == c.want {
-		return copied, io.EOF
+		return n, io.EOF
 	}
-	return copied, nil
+	return n, nil
 }
@@ -0,0 +1,189 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// SizeDist is a weighted distribution of object sizes.
+// Each size is returned with a probability proportional to its weight.
+type SizeDist struct {
+	sizes []int64
+	// cum is the cumulative weight up to and including each size.
+	cum []float64
+}
+
+// ParseSizeDist parses a comma separated list of size:weight pairs,
+// for example "4KiB:40,1MiB:50,128MiB:10".
+func ParseSizeDist(s string) (*SizeDist, error) {
+	var b sizeDistBuilder
+	for _, pair := range strings.Split(s, ",") {
+		size, weight, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("size distribution: expected size:weight, got %q", pair)
+		}
+		if err := b.add(size, weight); err != nil {
+			return nil, err
+		}
+	}
+	return b.dist()
+}
+
+// ReadSizeDist reads a size distribution.
+// JSON input can be an array of sizes or an object with sizes as keys and weights as values.
+// Otherwise each line must contain a size, optionally followed by a comma and a weight.
+// Sizes without weights have a weight of 1, so a list of object sizes gives their distribution.
+// Sizes can be numbers of bytes or have a unit, like "4KiB".
+func ReadSizeDist(r io.Reader) (*SizeDist, error) {
+	br := bufio.NewReader(r)
+	var b sizeDistBuilder
+	for {
+		c, err := br.Peek(1)
+		if err != nil || !bytes.ContainsAny(c, " \t\r\n") {
+			break
+		}
+		br.ReadByte()
+	}
+	if c, _ := br.Peek(1); len(c) == 1 && (c[0] == '[' || c[0] == '{') {
+		var v interface{}
+		dec := json.NewDecoder(br)
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("size distribution: %w", err)
+		}
+		switch v := v.(type) {
+		case []interface{}:
+			for _, size := range v {
+				if err := b.add(fmt.Sprint(size), ""); err != nil {
+					return nil, err
+				}
+			}
+		case map[string]interface{}:
+			for size, weight := range v {
+				if err := b.add(size, fmt.Sprint(weight)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return b.dist()
+	}
+	sc := bufio.NewScanner(br)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		size, weight, _ := strings.Cut(line, ",")
+		if err := b.add(size, weight); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return b.dist()
+}
+
+// Poll returns a random size.
+func (s *SizeDist) Poll(rng *rand.Rand) int64 {
+	v := rng.Float64() * s.cum[len(s.cum)-1]
+	i := sort.SearchFloat64s(s.cum, v)
+	// v may equal the total weight when rounding.
+	return s.sizes[min(i, len(s.sizes)-1)]
+}
+
+// Max returns the biggest size of the distribution.
+func (s *SizeDist) Max() int64 {
+	return s.sizes[len(s.sizes)-1]
+}
+
+// String returns the distribution as size:percentage pairs.
+func (s *SizeDist) String() string {
+	var sb strings.Builder
+	var prev float64
+	total := s.cum[len(s.cum)-1]
+	for i, size := range s.sizes {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s:%.4g%%", strings.ReplaceAll(humanize.IBytes(uint64(size)), " ", ""), 100*(s.cum[i]-prev)/total)
+		prev = s.cum[i]
+	}
+	return sb.String()
+}
+
+// sizeDistBuilder collects sizes and weights.
+type sizeDistBuilder struct {
+	weights map[int64]float64
+}
+
+// add a size with a weight. An empty weight is 1.
+func (b *sizeDistBuilder) add(size, weight string) error {
+	sz, err := humanize.ParseBytes(strings.TrimSpace(size))
+	if err != nil {
+		return fmt.Errorf("size distribution: invalid size %q: %w", size, err)
+	}
+	if sz == 0 {
+		return errors.New("size distribution: sizes must be > 0")
+	}
+	w := 1.0
+	if weight = strings.TrimSpace(weight); weight != "" {
+		w, err = strconv.ParseFloat(weight, 64)
+		if err != nil || !(w >= 0) || math.IsInf(w, 1) {
+			return fmt.Errorf("size distribution: invalid weight %q for size %s", weight, size)
+		}
+	}
+	if b.weights == nil {
+		b.weights = make(map[int64]float64)
+	}
+	b.weights[int64(sz)] += w
+	return nil
+}
+
+func (b *sizeDistBuilder) dist() (*SizeDist, error) {
+	var d SizeDist
+	for size, w := range b.weights {
+		if w > 0 {
+			d.sizes = append(d.sizes, size)
+		}
+	}
+	if len(d.sizes) == 0 {
+		return nil, errors.New("size distribution: no sizes with a weight above 0")
+	}
+	sort.Slice(d.sizes, func(i, j int) bool { return d.sizes[i] < d.sizes[j] })
+	d.cum = make([]float64, len(d.sizes))
+	var total float64
+	for i, size := range d.sizes {
+		total += b.weights[size]
+		d.cum[i] = total
+	}
+	return &d, nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseSizeDist(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "4KiB:40,1MiB:50,128MiB:10", want: "4.0KiB:40%,1.0MiB:50%,128MiB:10%"},
+		{in: " 1MiB:1 , 4KiB:3", want: "4.0KiB:75%,1.0MiB:25%"},
+		{in: "4KiB:1,4096:1,1MiB:0", want: "4.0KiB:100%"},
+		{in: "1000:2.5", want: "1000B:100%"},
+		{in: "", wantErr: true},
+		{in: "4KiB", wantErr: true},
+		{in: "0:1", wantErr: true},
+		{in: "abc:1", wantErr: true},
+		{in: "4KiB:x", wantErr: true},
+		{in: "4KiB:-1", wantErr: true},
+		{in: "4KiB:nan", wantErr: true},
+		{in: "4KiB:inf,1MiB:1", wantErr: true},
+		{in: "4KiB:0,1MiB:0", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseSizeDist(test.in)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: got %v, want error", test.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("%q: got %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestReadSizeDist(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "[4096, 4096, 1048576, \"4KiB\"]", want: "4.0KiB:75%,1.0MiB:25%"},
+		{in: "\n {\"4KiB\": 1, \"1MiB\": 3}", want: "4.0KiB:25%,1.0MiB:75%"},
+		{in: "# sizes\n4KiB\n\n1MiB, 3\n4096\n", want: "4.0KiB:40%,1.0MiB:60%"},
+	}
+	for _, test := range tests {
+		got, err := ReadSizeDist(strings.NewReader(test.in))
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("%q: got %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestSizeDistPoll(t *testing.T) {
+	d, err := ParseSizeDist("4KiB:40,1MiB:50,128MiB:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Max(); got != 128<<20 {
+		t.Errorf("got max %d, want %d", got, 128<<20)
+	}
+	const n = 100000
+	counts := map[int64]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		counts[d.Poll(rng)]++
+	}
+	want := map[int64]int{4 << 10: 40, 1 << 20: 50, 128 << 20: 10}
+	if len(counts) != len(want) {
+		t.Fatalf("got sizes %v", counts)
+	}
+	for size, pct := range want {
+		if got := counts[size] * 100; got < (pct-2)*n || got > (pct+2)*n {
+			t.Errorf("size %d: got %d of %d, want about %d%%", size, counts[size], n, pct)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// WithText returns default options for text data.
+func WithText() TextOpts {
+	return textOptsDefaults()
+}
+
+// Apply text data options.
+func (o TextOpts) Apply() Option {
+	return func(opts *Options) error {
+		if err := o.validate(); err != nil {
+			return err
+		}
+		opts.text = o
+		opts.src = newText
+		return nil
+	}
+}
+
+func (o TextOpts) validate() error {
+	if o.words < 1 || o.words > textMaxWords {
+		return fmt.Errorf("text: number of words must be between 1 and %d, got %d", textMaxWords, o.words)
+	}
+	return nil
+}
+
+// Words sets the number of distinct words.
+// Fewer words will make the text more compressible.
+func (o TextOpts) Words(n int) TextOpts {
+	o.words = n
+	return o
+}
+
+// RngSeed will which to a fixed RNG seed to make usage predictable.
+func (o TextOpts) RngSeed(s int64) TextOpts {
+	o.seed = &s
+	return o
+}
+
+// TextOpts are the options for the text data source.
+type TextOpts struct {
+	seed  *int64
+	words int
+}
+
+func textOptsDefaults() TextOpts {
+	return TextOpts{
+		words: 5000,
+	}
+}
+
+const (
+	// textMaxWords is the maximum number of distinct words.
+	textMaxWords = 1 << 16
+	// textBlockSize is the size of each generated block of text.
+	textBlockSize = 64 << 10
+)
+
+type textSrc struct {
+	buf     *blockReader
+	rng     *rand.Rand
+	obj     Object
+	o       Options
+	counter uint64
+
+	words [][]byte
+	// lookup maps 16 random bits to a word index,
+	// so words are used with a Zipfian distribution as in natural language.
+	lookup [1 << 16]uint16
+}
+
+func newText(o Options) (Source, error) {
+	if o.text.words <= 0 {
+		return nil, errors.New("text: no words")
+	}
+	rndSrc := rand.NewSource(int64(rand.Uint64()))
+	if o.text.seed != nil {
+		rndSrc = rand.NewSource(*o.text.seed)
+	}
+	rng := rand.New(rndSrc)
+
+	t := &textSrc{
+		o:   o,
+		rng: rng,
+		obj: Object{
+			ContentType: "text/plain",
+		},
+	}
+	// The vocabulary is derived from a fixed seed, so it is the same for all sources.
+	vocab := rand.New(rand.NewSource(0x74657874))
+	t.words = make([][]byte, o.text.words)
+	for i := range t.words {
+		w := make([]byte, 2+vocab.Intn(9))
+		for j := range w {
+			w[j] = 'a' + byte(vocab.Intn(26))
+		}
+		t.words[i] = w
+	}
+	var total float64
+	for i := range t.words {
+		total += 1 / math.Pow(float64(i+1), 1.1)
+	}
+	var cum float64
+	idx := 0
+	for i := range t.words {
+		cum += 1 / math.Pow(float64(i+1), 1.1) / total
+		end := int(math.Round(cum * float64(len(t.lookup))))
+		for ; idx < end && idx < len(t.lookup); idx++ {
+			t.lookup[idx] = uint16(i)
+		}
+	}
+	for ; idx < len(t.lookup); idx++ {
+		t.lookup[idx] = uint16(len(t.words) - 1)
+	}
+	t.buf = newBlockReader(textBlockSize, t.gen)
+	t.obj.setPrefix(o, rng)
+	return t, nil
+}
+
+// gen fills dst with lines of words.
+func (t *textSrc) gen(seed uint64, idx int64, dst []byte) {
+	s := splitMix(DeriveSeed(int64(seed), uint64(idx)))
+	for n := 0; n < len(dst); {
+		v := s.next()
+		// Each random value selects 3 words and separators.
+		for i := 0; i < 3 && n < len(dst); i++ {
+			n += copy(dst[n:], t.words[t.lookup[uint16(v)]])
+			v >>= 16
+			if n < len(dst) {
+				dst[n] = ' '
+				if v&15 == 0 {
+					dst[n] = '\n'
+				}
+				n++
+			}
+			v >>= 4
+		}
+	}
+}
+
+func (t *textSrc) Object() *Object {
+	atomic.AddUint64(&t.counter, 1)
+	var nBuf [16]byte
+	randASCIIBytes(nBuf[:], t.rng)
+	t.obj.Size = t.o.getSize(t.rng)
+	t.obj.setName(fmt.Sprintf("%d.%s.txt", atomic.LoadUint64(&t.counter), string(nBuf[:])))
+	t.obj.Reader = t.buf.Reset(t.obj.Size, t.rng.Uint64())
+	return &t.obj
+}
+
+func (t *textSrc) String() string {
+	return fmt.Sprintf("Text data; %d distinct words", t.o.text.words)
+}
+
+func (t *textSrc) Prefix() string {
+	return t.obj.Prefix
+}